@@ -0,0 +1,103 @@
+package bytespool
+
+import (
+	"io"
+	"math/bits"
+	"sync"
+)
+
+const (
+	minShift = 6  //最小分级64B
+	maxShift = 20 //最大分级1MB，更大的缓冲不复用
+
+	// CopyBufferSize Copy使用的缓冲大小，与io.Copy默认一致
+	CopyBufferSize = 32 << 10
+)
+
+var pools [maxShift - minShift + 1]sync.Pool
+
+// Buffer 可复用的字节缓冲，B可直接读写，使用完毕后调用Put归还
+type Buffer struct {
+	B []byte
+}
+
+// Get 获取容量不小于sizeHint的空缓冲，按2的幂分级复用，超过1MB时直接分配
+func Get(sizeHint int) *Buffer {
+	i := class(sizeHint)
+	if i < 0 {
+		return &Buffer{B: make([]byte, 0, sizeHint)}
+	}
+	if b, ok := pools[i].Get().(*Buffer); ok {
+		return b
+	}
+	return &Buffer{B: make([]byte, 0, 1<<(minShift+i))}
+}
+
+// Put 归还缓冲，按容量放回对应分级，归还后不能再使用b及b.B
+func Put(b *Buffer) {
+	if b == nil {
+		return
+	}
+	c := cap(b.B)
+	if c < 1<<minShift || c > 1<<maxShift {
+		return
+	}
+	//容量可能因append增长而不是2的幂，向下取整放入分级，保证取出时容量足够
+	i := bits.Len(uint(c)) - 1 - minShift
+	b.B = b.B[:0]
+	pools[i].Put(b)
+}
+
+// class 返回sizeHint所属分级，超出最大分级时返回-1
+func class(sizeHint int) int {
+	if sizeHint <= 1<<minShift {
+		return 0
+	}
+	if sizeHint > 1<<maxShift {
+		return -1
+	}
+	return bits.Len(uint(sizeHint-1)) - minShift
+}
+
+// Copy 与io.Copy相同，使用池中的缓冲避免每次分配32KB
+func Copy(dst io.Writer, src io.Reader) (int64, error) {
+	b := Get(CopyBufferSize)
+	defer Put(b)
+	return io.CopyBuffer(dst, src, b.B[:cap(b.B)])
+}
+
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.B = append(b.B, p...)
+	return len(p), nil
+}
+
+func (b *Buffer) WriteString(s string) (int, error) {
+	b.B = append(b.B, s...)
+	return len(s), nil
+}
+
+func (b *Buffer) WriteByte(c byte) error {
+	b.B = append(b.B, c)
+	return nil
+}
+
+// Bytes 返回缓冲内容，Put之后不再有效
+func (b *Buffer) Bytes() []byte {
+	return b.B
+}
+
+func (b *Buffer) String() string {
+	return string(b.B)
+}
+
+func (b *Buffer) Len() int {
+	return len(b.B)
+}
+
+func (b *Buffer) Cap() int {
+	return cap(b.B)
+}
+
+func (b *Buffer) Reset() {
+	b.B = b.B[:0]
+}
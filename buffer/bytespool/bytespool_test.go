@@ -0,0 +1,108 @@
+package bytespool
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestGetCapacity(t *testing.T) {
+	cases := []struct {
+		hint, cap int
+	}{
+		{0, 64},
+		{1, 64},
+		{64, 64},
+		{65, 128},
+		{1000, 1024},
+		{1024, 1024},
+		{32 << 10, 32 << 10},
+		{1 << 20, 1 << 20},
+		{1<<20 + 1, 1<<20 + 1},
+	}
+	for _, c := range cases {
+		b := Get(c.hint)
+		if b.Len() != 0 || b.Cap() != c.cap {
+			t.Errorf("Get(%d): len=%d cap=%d, want len=0 cap=%d", c.hint, b.Len(), b.Cap(), c.cap)
+		}
+		Put(b)
+	}
+}
+
+func TestPutReuse(t *testing.T) {
+	b := Get(100)
+	b.WriteString("hello")
+	Put(b)
+	for i := 0; i < 10; i++ {
+		b := Get(100)
+		if b.Len() != 0 || b.Cap() < 100 {
+			t.Fatalf("reused buffer len=%d cap=%d", b.Len(), b.Cap())
+		}
+		Put(b)
+	}
+}
+
+func TestPutGrown(t *testing.T) {
+	//append增长后容量不是2的幂，放回较小的分级
+	b := Get(64)
+	b.Write(bytes.Repeat([]byte("x"), 100))
+	c := b.Cap()
+	Put(b)
+	if class(c) < 0 {
+		t.Fatal("unexpected class")
+	}
+	for i := 0; i < 10; i++ {
+		b := Get(128)
+		if b.Cap() < 128 {
+			t.Fatalf("Get(128) cap=%d", b.Cap())
+		}
+		Put(b)
+	}
+	Put(nil)
+	Put(&Buffer{B: make([]byte, 0, 10)})
+	Put(&Buffer{B: make([]byte, 0, 2<<20)})
+}
+
+func TestBufferWrite(t *testing.T) {
+	b := Get(0)
+	defer Put(b)
+	b.WriteString("a")
+	b.WriteByte('b')
+	b.Write([]byte("c"))
+	if b.String() != "abc" || string(b.Bytes()) != "abc" {
+		t.Fatalf("got %q", b.String())
+	}
+	b.Reset()
+	if b.Len() != 0 {
+		t.Fatalf("len after reset: %d", b.Len())
+	}
+}
+
+func TestCopy(t *testing.T) {
+	src := strings.Repeat("0123456789", 10000)
+	var dst bytes.Buffer
+	n, err := Copy(&dst, strings.NewReader(src))
+	if err != nil || n != int64(len(src)) || dst.String() != src {
+		t.Fatalf("Copy: n=%d err=%v", n, err)
+	}
+}
+
+func BenchmarkGetPut(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf := Get(4096)
+			buf.WriteString("benchmark")
+			Put(buf)
+		}
+	})
+}
+
+func BenchmarkCopy(b *testing.B) {
+	data := bytes.Repeat([]byte("x"), 64<<10)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = Copy(io.Discard, bytes.NewReader(data))
+	}
+}
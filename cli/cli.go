@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/liuxy92/golib/zaplog"
+	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+const defaultShutdownTimeout = 10 * time.Second
+
+// App 命令行应用骨架：--config加载配置、--log-*初始化zaplog、version子命令、收到SIGINT/SIGTERM后优雅退出
+type App struct {
+	Name            string
+	Short           string
+	Version         string          //版本号，通常通过 -ldflags "-X main.version=..." 注入
+	Commit          string          //代码提交号
+	BuildTime       string          //构建时间
+	Config          interface{}     //配置结构体指针，指定--config时按文件扩展名加载json/yaml
+	ShutdownTimeout time.Duration   //退出时执行OnShutdown及每个分组的默认超时时间，默认10s
+	ShutdownGroups  []ShutdownGroup //OnShutdownGroup分组的停止顺序及超时，未列出的分组按注册顺序排在后面
+
+	// Run 根命令的主逻辑，ctx在收到退出信号时取消，为nil时根命令只打印帮助
+	Run func(ctx context.Context) error
+
+	cfgFile  string
+	logOpts  *zaplog.Options
+	shutdown []func(ctx context.Context) error
+	groups   []*stopGroup
+	commands []*cobra.Command
+	wrapped  map[*cobra.Command]bool //已包装退出流程的子命令，Command多次调用时不重复包装
+}
+
+// OnShutdown 注册退出时执行的清理函数，在所有分组停止后按注册的倒序执行
+func (a *App) OnShutdown(fn func(ctx context.Context) error) {
+	a.shutdown = append(a.shutdown, fn)
+}
+
+// AddCommand 添加子命令，子命令同样会加载配置并初始化日志，可通过cmd.Context()获取退出信号，
+// 返回后与根命令相同地停止各分组、执行清理函数并刷新日志
+func (a *App) AddCommand(cmds ...*cobra.Command) {
+	a.commands = append(a.commands, cmds...)
+}
+
+// Command 构建根命令
+func (a *App) Command() *cobra.Command {
+	root := &cobra.Command{
+		Use:           a.Name,
+		Short:         a.Short,
+		Version:       a.Version,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return a.setup()
+		},
+	}
+	root.PersistentFlags().StringVarP(&a.cfgFile, "config", "c", "", "配置文件路径(json/yaml)")
+	a.logOpts = zaplog.BindPFlags(root.PersistentFlags())
+	if a.Run != nil {
+		root.RunE = func(cmd *cobra.Command, args []string) error {
+			return a.run(cmd.Context())
+		}
+	}
+	root.AddCommand(a.versionCommand())
+	for _, cmd := range a.commands {
+		a.wrapCommand(cmd)
+	}
+	root.AddCommand(a.commands...)
+	return root
+}
+
+// Execute 执行命令行，ctx在收到SIGINT/SIGTERM时取消
+func (a *App) Execute() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return a.Command().ExecuteContext(ctx)
+}
+
+// Main 执行命令行，出错时打印错误并以状态码1退出
+func (a *App) Main() {
+	if err := a.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", a.Name, err)
+		os.Exit(1)
+	}
+}
+
+func (a *App) setup() error {
+	if a.cfgFile != "" && a.Config != nil {
+		if err := LoadConfig(a.cfgFile, a.Config); err != nil {
+			return err
+		}
+	}
+	if a.logOpts.AppName == "" {
+		a.logOpts.AppName = a.Name
+	}
+	return zaplog.Reconfigure(a.logOpts)
+}
+
+// run 执行主逻辑，返回后执行退出流程
+func (a *App) run(ctx context.Context) error {
+	return a.finish(a.Run(ctx))
+}
+
+// wrapCommand 子命令及其下级命令的Run/RunE返回后执行退出流程
+func (a *App) wrapCommand(cmd *cobra.Command) {
+	if a.wrapped == nil {
+		a.wrapped = make(map[*cobra.Command]bool)
+	}
+	if !a.wrapped[cmd] {
+		a.wrapped[cmd] = true
+		switch run, runE := cmd.Run, cmd.RunE; {
+		case runE != nil:
+			cmd.RunE = func(cmd *cobra.Command, args []string) error {
+				return a.finish(runE(cmd, args))
+			}
+		case run != nil:
+			cmd.Run = nil
+			cmd.RunE = func(cmd *cobra.Command, args []string) error {
+				run(cmd, args)
+				return a.finish(nil)
+			}
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		a.wrapCommand(sub)
+	}
+}
+
+// finish 依次停止各分组、按倒序执行清理函数并刷新日志，err为主逻辑的返回值
+func (a *App) finish(err error) error {
+	timeout := a.shutdownTimeout()
+	err = multierr.Append(err, a.stopGroups(timeout))
+	sctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for i := len(a.shutdown) - 1; i >= 0; i-- {
+		err = multierr.Append(err, a.shutdown[i](sctx))
+	}
+	_ = zaplog.GetLogger().Sync()
+	return err
+}
+
+func (a *App) shutdownTimeout() time.Duration {
+	if a.ShutdownTimeout > 0 {
+		return a.ShutdownTimeout
+	}
+	return defaultShutdownTimeout
+}
+
+func (a *App) versionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "打印版本信息",
+		Args:  cobra.NoArgs,
+		//不加载配置与初始化日志
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Fprintln(cmd.OutOrStdout(), a.VersionString())
+		},
+	}
+}
+
+// VersionString 版本信息，如 "app v1.2.0 (commit abc123, built 2024-05-01, go1.21.0 linux/amd64)"
+func (a *App) VersionString() string {
+	version := a.Version
+	if version == "" {
+		version = "dev"
+	}
+	commit, built := a.Commit, a.BuildTime
+	if commit == "" {
+		commit = "unknown"
+	}
+	if built == "" {
+		built = "unknown"
+	}
+	return fmt.Sprintf("%s %s (commit %s, built %s, %s %s/%s)", a.Name, version, commit, built, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/liuxy92/golib/zaplog"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testConfig struct {
+	Addr string `json:"addr" yaml:"addr"`
+	Port int    `json:"port" yaml:"port"`
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CLI_TEST_ADDR", "10.0.0.1")
+	files := map[string]string{
+		"c.json": `{"addr": "${CLI_TEST_ADDR}", "port": 80}`,
+		"c.yaml": "addr: ${CLI_TEST_ADDR}\nport: 80\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var cfg testConfig
+		if err := LoadConfig(path, &cfg); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Addr != "10.0.0.1" || cfg.Port != 80 {
+			t.Errorf("%s: %+v", name, cfg)
+		}
+	}
+	//$VAR形式及单独的$不展开
+	path := filepath.Join(dir, "literal.yaml")
+	if err := os.WriteFile(path, []byte("addr: p$ss$CLI_TEST_ADDR\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var cfg testConfig
+	if err := LoadConfig(path, &cfg); err != nil || cfg.Addr != "p$ss$CLI_TEST_ADDR" {
+		t.Errorf("literal $: %+v %v", cfg, err)
+	}
+	if err := LoadConfig(filepath.Join(dir, "c.json"), &struct{ Port string }{}); err == nil {
+		t.Error("type mismatch should fail")
+	}
+	if err := LoadConfig("c.toml", &testConfig{}); err == nil {
+		t.Error("unsupported format should fail")
+	}
+}
+
+func TestVersionCommand(t *testing.T) {
+	app := &App{Name: "demo", Version: "v1.2.0", Commit: "abc123"}
+	cmd := app.Command()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"version"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "demo v1.2.0 (commit abc123") {
+		t.Errorf("version output = %q", out.String())
+	}
+}
+
+func TestRunAndShutdown(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile := filepath.Join(dir, "app.yaml")
+	_ = os.WriteFile(cfgFile, []byte("addr: localhost\nport: 8080\n"), 0644)
+
+	var cfg testConfig
+	var order []string
+	app := &App{Name: "demo", Config: &cfg}
+	app.Run = func(ctx context.Context) error {
+		order = append(order, "run")
+		return nil
+	}
+	app.OnShutdown(func(context.Context) error { order = append(order, "first"); return nil })
+	app.OnShutdown(func(context.Context) error { order = append(order, "second"); return errors.New("close failed") })
+
+	cmd := app.Command()
+	cmd.SetArgs([]string{"--config", cfgFile, "--log-dir", dir, "--log-level", "warn"})
+	err := cmd.Execute()
+	if err == nil || err.Error() != "close failed" {
+		t.Errorf("err = %v", err)
+	}
+	if cfg.Port != 8080 {
+		t.Errorf("config not loaded: %+v", cfg)
+	}
+	if strings.Join(order, ",") != "run,second,first" {
+		t.Errorf("order = %v", order)
+	}
+	zaplog.GetLogger().Warn("after run")
+	if _, err := os.Stat(filepath.Join(dir, "demo-warn.log")); err != nil {
+		t.Errorf("log files not initialized: %v", err)
+	}
+}
+
+func TestSubCommandContext(t *testing.T) {
+	app := &App{Name: "demo"}
+	var got context.Context
+	app.AddCommand(&cobra.Command{
+		Use: "migrate",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			got = cmd.Context()
+			return nil
+		},
+	})
+	cmd := app.Command()
+	cmd.SetArgs([]string{"migrate", "--log-dir", t.TempDir()})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := cmd.ExecuteContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Err() == nil {
+		t.Error("sub command should receive the cancellable context")
+	}
+}
+
+func TestSubCommandShutdown(t *testing.T) {
+	app := &App{Name: "demo"}
+	var order []string
+	app.OnShutdown(func(context.Context) error { order = append(order, "cleanup"); return nil })
+	serve := &cobra.Command{
+		Use: "serve",
+		Run: func(cmd *cobra.Command, args []string) { order = append(order, "serve") },
+	}
+	app.AddCommand(serve)
+
+	//多次构建根命令时不重复执行清理
+	_ = app.Command()
+	cmd := app.Command()
+	cmd.SetArgs([]string{"serve", "--log-dir", t.TempDir()})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, ",") != "serve,cleanup" {
+		t.Errorf("order = %v", order)
+	}
+}
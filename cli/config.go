@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/liuxy92/golib/jsonx"
+	"github.com/liuxy92/golib/zaplog"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadConfig 按扩展名读取json/yaml配置文件到out，文件内容中的${VAR}替换为环境变量
+func LoadConfig(path string, out interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cli: read config: %w", err)
+	}
+	data = []byte(zaplog.ExpandEnv(string(data))) //与zaplog.LoadOptions相同，只展开${VAR}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = jsonx.Unmarshal(data, out)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, out)
+	default:
+		return fmt.Errorf("cli: unsupported config format %q", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("cli: parse config %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/liuxy92/golib/zaplog"
+	"go.uber.org/multierr"
+	"time"
+)
+
+// ShutdownGroup 退出时按顺序停止的一组清理函数，如 servers → consumers → flush → db
+type ShutdownGroup struct {
+	Name    string
+	Timeout time.Duration //该组的超时时间，默认ShutdownTimeout
+}
+
+type stopFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+type stopGroup struct {
+	name  string
+	funcs []stopFunc
+}
+
+type stopResult struct {
+	index int
+	err   error
+}
+
+// OnShutdownGroup 注册属于group分组的清理函数，name用于日志。
+// 各分组按ShutdownGroups的顺序依次停止，同一分组内的函数并发执行；
+// 分组超时后记录仍未返回的函数并继续停止下一组。根命令及AddCommand添加的子命令返回后均会执行
+func (a *App) OnShutdownGroup(group, name string, fn func(ctx context.Context) error) {
+	for _, g := range a.groups {
+		if g.name == group {
+			g.funcs = append(g.funcs, stopFunc{name, fn})
+			return
+		}
+	}
+	a.groups = append(a.groups, &stopGroup{name: group, funcs: []stopFunc{{name, fn}}})
+}
+
+// orderedGroups ShutdownGroups中列出的分组在前，其余按注册顺序
+func (a *App) orderedGroups(timeout time.Duration) ([]*stopGroup, []time.Duration) {
+	var groups []*stopGroup
+	var timeouts []time.Duration
+	listed := make(map[string]bool)
+	for _, sg := range a.ShutdownGroups {
+		listed[sg.Name] = true
+		for _, g := range a.groups {
+			if g.name == sg.Name {
+				groups = append(groups, g)
+				if sg.Timeout > 0 {
+					timeouts = append(timeouts, sg.Timeout)
+				} else {
+					timeouts = append(timeouts, timeout)
+				}
+			}
+		}
+	}
+	for _, g := range a.groups {
+		if !listed[g.name] {
+			groups = append(groups, g)
+			timeouts = append(timeouts, timeout)
+		}
+	}
+	return groups, timeouts
+}
+
+// stopGroups 依次停止各分组并记录进度
+func (a *App) stopGroups(timeout time.Duration) error {
+	if len(a.groups) == 0 {
+		return nil
+	}
+	lg := zaplog.GetLogger()
+	groups, timeouts := a.orderedGroups(timeout)
+	start := time.Now()
+	var errs error
+	for i, g := range groups {
+		errs = multierr.Append(errs, stopGroupFuncs(lg, g, timeouts[i]))
+	}
+	lg.Infow("cli: shutdown completed", "groups", len(groups), "duration", time.Since(start))
+	return errs
+}
+
+func stopGroupFuncs(lg *zaplog.Logger, g *stopGroup, timeout time.Duration) error {
+	lg.Infow("cli: stopping group", "group", g.name, "funcs", len(g.funcs), "timeout", timeout)
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	results := make(chan stopResult, len(g.funcs))
+	for i, f := range g.funcs {
+		go func(i int, f stopFunc) {
+			results <- stopResult{i, f.fn(ctx)}
+		}(i, f)
+	}
+	done := make([]bool, len(g.funcs))
+	var errs error
+	for n := 0; n < len(g.funcs); n++ {
+		select {
+		case r := <-results:
+			done[r.index] = true
+			name := g.funcs[r.index].name
+			if r.err != nil {
+				lg.Warnw("cli: shutdown func failed", "group", g.name, "func", name, "error", r.err)
+				errs = multierr.Append(errs, fmt.Errorf("%s/%s: %w", g.name, name, r.err))
+				continue
+			}
+			lg.Debugw("cli: shutdown func stopped", "group", g.name, "func", name, "duration", time.Since(start))
+		case <-ctx.Done():
+			var pending []string
+			for i, f := range g.funcs {
+				if !done[i] {
+					pending = append(pending, f.name)
+				}
+			}
+			lg.Warnw("cli: shutdown group timed out", "group", g.name, "timeout", timeout, "pending", pending)
+			return multierr.Append(errs, fmt.Errorf("cli: shutdown group %s timed out waiting for %v", g.name, pending))
+		}
+	}
+	lg.Infow("cli: group stopped", "group", g.name, "duration", time.Since(start))
+	return errs
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownGroups(t *testing.T) {
+	dir := t.TempDir()
+	var mu sync.Mutex
+	var order []string
+	record := func(s string) {
+		mu.Lock()
+		order = append(order, s)
+		mu.Unlock()
+	}
+
+	app := &App{
+		Name: "demo",
+		ShutdownGroups: []ShutdownGroup{
+			{Name: "servers"},
+			{Name: "consumers", Timeout: 50 * time.Millisecond},
+			{Name: "db"},
+		},
+		Run: func(ctx context.Context) error { return nil },
+	}
+	//注册顺序与停止顺序无关
+	app.OnShutdownGroup("db", "mysql", func(context.Context) error { record("db"); return nil })
+	app.OnShutdownGroup("extra", "cache", func(context.Context) error { record("extra"); return nil })
+	app.OnShutdownGroup("servers", "http", func(context.Context) error { record("servers"); return nil })
+	app.OnShutdownGroup("servers", "grpc", func(context.Context) error { record("servers"); return errors.New("grpc stop failed") })
+	app.OnShutdownGroup("consumers", "kafka", func(ctx context.Context) error {
+		//卡住不返回，超时后继续停止后续分组
+		<-ctx.Done()
+		time.Sleep(time.Second)
+		record("kafka")
+		return nil
+	})
+	app.OnShutdown(func(context.Context) error { record("legacy"); return nil })
+
+	cmd := app.Command()
+	cmd.SetArgs([]string{"--log-dir", dir, "--log-level", "debug"})
+	start := time.Now()
+	err := cmd.Execute()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("hung group was waited for: %v", elapsed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "servers/grpc: grpc stop failed") || !strings.Contains(err.Error(), "consumers timed out waiting for [kafka]") {
+		t.Errorf("err = %v", err)
+	}
+	mu.Lock()
+	got := strings.Join(order, ",")
+	mu.Unlock()
+	if got != "servers,servers,db,extra,legacy" {
+		t.Errorf("order = %s", got)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "demo-warn.log"))
+	if !strings.Contains(string(data), "cli: shutdown group timed out") || !strings.Contains(string(data), `"pending":["kafka"]`) {
+		t.Errorf("warn log: %s", data)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "demo-info.log"))
+	if !strings.Contains(string(data), `"group":"db"`) || !strings.Contains(string(data), "cli: shutdown completed") {
+		t.Errorf("info log: %s", data)
+	}
+}
+
+func TestShutdownGroupsSubCommand(t *testing.T) {
+	dir := t.TempDir()
+	app := &App{Name: "demo"}
+	var order []string
+	app.AddCommand(&cobra.Command{
+		Use: "serve",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			//子命令运行时注册的分组同样在返回后停止
+			app.OnShutdownGroup("servers", "http", func(context.Context) error { order = append(order, "servers"); return nil })
+			order = append(order, "serve")
+			return nil
+		},
+	})
+	cmd := app.Command()
+	cmd.SetArgs([]string{"serve", "--log-dir", dir})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, ",") != "serve,servers" {
+		t.Errorf("order = %v", order)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "demo-info.log"))
+	if !strings.Contains(string(data), "cli: shutdown completed") {
+		t.Errorf("info log not synced: %s", data)
+	}
+}
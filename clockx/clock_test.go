@@ -0,0 +1,89 @@
+package clockx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRealClock(t *testing.T) {
+	c := Or(nil)
+	start := c.Now()
+	c.Sleep(time.Millisecond)
+	if c.Since(start) < time.Millisecond {
+		t.Error("Sleep returned early")
+	}
+	tk := c.NewTicker(time.Millisecond)
+	defer tk.Stop()
+	<-tk.C()
+	<-c.After(time.Millisecond)
+}
+
+func TestMockAfterAndTimer(t *testing.T) {
+	m := NewMock(time.Time{})
+	start := m.Now()
+	after := m.After(time.Second)
+	timer := m.NewTimer(2 * time.Second)
+	m.Add(500 * time.Millisecond)
+	select {
+	case <-after:
+		t.Fatal("fired too early")
+	default:
+	}
+	m.Add(time.Second)
+	if got := <-after; !got.Equal(start.Add(time.Second)) {
+		t.Errorf("After fired at %v", got)
+	}
+	if !timer.Stop() {
+		t.Error("timer should still be active")
+	}
+	m.Add(time.Hour)
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+	if m.Waiters() != 0 {
+		t.Errorf("waiters = %d", m.Waiters())
+	}
+}
+
+func TestMockTicker(t *testing.T) {
+	m := NewMock(time.Time{})
+	tk := m.NewTicker(time.Minute)
+	var ticks int
+	for i := 0; i < 3; i++ {
+		m.Add(time.Minute)
+		select {
+		case <-tk.C():
+			ticks++
+		default:
+		}
+	}
+	tk.Reset(time.Hour)
+	m.Add(time.Minute)
+	select {
+	case <-tk.C():
+		t.Fatal("reset ticker fired early")
+	default:
+	}
+	tk.Stop()
+	if ticks != 3 {
+		t.Errorf("ticks = %d, want 3", ticks)
+	}
+}
+
+func TestMockSleep(t *testing.T) {
+	m := NewMock(time.Time{})
+	done := make(chan struct{})
+	go func() {
+		m.Sleep(time.Second)
+		close(done)
+	}()
+	m.BlockUntil(1)
+	m.Add(time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Sleep not released")
+	}
+}
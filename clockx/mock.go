@@ -0,0 +1,220 @@
+package clockx
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Mock 手动控制的Clock，时间只在调用Add/Set时前进，到期的After、Sleep、Ticker、Timer随之触发
+type Mock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+	changed chan struct{} //waiters变化时关闭并重建，用于BlockUntil
+}
+
+type waiter struct {
+	at     time.Time
+	period time.Duration //大于0表示Ticker
+	ch     chan time.Time
+	sleep  bool //Sleep使用，触发时关闭ch
+}
+
+// NewMock 创建从t开始的Mock，t为零值时从2000-01-01 00:00:00 UTC开始
+func NewMock(t time.Time) *Mock {
+	if t.IsZero() {
+		t = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return &Mock{now: t, changed: make(chan struct{})}
+}
+
+func (m *Mock) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+func (m *Mock) Since(t time.Time) time.Duration {
+	return m.Now().Sub(t)
+}
+
+func (m *Mock) After(d time.Duration) <-chan time.Time {
+	return m.add(d, 0, false).ch
+}
+
+// Sleep 阻塞到时间被Add/Set推进d之后
+func (m *Mock) Sleep(d time.Duration) {
+	<-m.add(d, 0, true).ch
+}
+
+func (m *Mock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clockx: non-positive interval for NewTicker")
+	}
+	return &mockTicker{m: m, w: m.add(d, d, false)}
+}
+
+func (m *Mock) NewTimer(d time.Duration) Timer {
+	return &mockTimer{m: m, w: m.add(d, 0, false)}
+}
+
+// Add 时间前进d，依次触发期间到期的定时器
+func (m *Mock) Add(d time.Duration) {
+	m.Set(m.Now().Add(d))
+}
+
+// Set 将时间设置为t(不能早于当前时间)，依次触发期间到期的定时器
+func (m *Mock) Set(t time.Time) {
+	for {
+		m.mu.Lock()
+		w := m.next(t)
+		if w == nil {
+			if t.After(m.now) {
+				m.now = t
+			}
+			m.mu.Unlock()
+			return
+		}
+		m.now = w.at
+		m.fire(w)
+		m.mu.Unlock()
+	}
+}
+
+// BlockUntil 阻塞直到有n个等待中的After、Sleep、Ticker、Timer，用于确认被测goroutine已开始等待再推进时间
+func (m *Mock) BlockUntil(n int) {
+	for {
+		m.mu.Lock()
+		if len(m.waiters) >= n {
+			m.mu.Unlock()
+			return
+		}
+		ch := m.changed
+		m.mu.Unlock()
+		<-ch
+	}
+}
+
+// Waiters 当前等待中的定时器个数
+func (m *Mock) Waiters() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.waiters)
+}
+
+func (m *Mock) add(d, period time.Duration, sleep bool) *waiter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w := &waiter{at: m.now.Add(d), period: period, ch: make(chan time.Time, 1), sleep: sleep}
+	if d <= 0 && period == 0 {
+		m.fireOnce(w)
+		return w
+	}
+	m.waiters = append(m.waiters, w)
+	m.notify()
+	return w
+}
+
+// next 返回最早在t之前到期的定时器，调用方需持有锁
+func (m *Mock) next(t time.Time) *waiter {
+	sort.SliceStable(m.waiters, func(i, j int) bool { return m.waiters[i].at.Before(m.waiters[j].at) })
+	if len(m.waiters) == 0 || m.waiters[0].at.After(t) {
+		return nil
+	}
+	return m.waiters[0]
+}
+
+// fire 触发定时器，Ticker重新排期，其余移除，调用方需持有锁
+func (m *Mock) fire(w *waiter) {
+	if w.period > 0 {
+		select {
+		case w.ch <- w.at: //与time.Ticker一致，接收方来不及处理时丢弃
+		default:
+		}
+		w.at = w.at.Add(w.period)
+		return
+	}
+	m.remove(w)
+	m.fireOnce(w)
+}
+
+func (m *Mock) fireOnce(w *waiter) {
+	if w.sleep {
+		close(w.ch)
+		return
+	}
+	select {
+	case w.ch <- m.now:
+	default:
+	}
+}
+
+// remove 移除定时器，返回是否仍在等待，调用方需持有锁
+func (m *Mock) remove(w *waiter) bool {
+	for i, x := range m.waiters {
+		if x == w {
+			m.waiters = append(m.waiters[:i], m.waiters[i+1:]...)
+			m.notify()
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Mock) notify() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+type mockTicker struct {
+	m *Mock
+	w *waiter
+}
+
+func (t *mockTicker) C() <-chan time.Time { return t.w.ch }
+
+func (t *mockTicker) Stop() {
+	t.m.mu.Lock()
+	defer t.m.mu.Unlock()
+	t.m.remove(t.w)
+}
+
+func (t *mockTicker) Reset(d time.Duration) {
+	t.m.mu.Lock()
+	defer t.m.mu.Unlock()
+	t.m.remove(t.w)
+	t.w.period, t.w.at = d, t.m.now.Add(d)
+	t.m.waiters = append(t.m.waiters, t.w)
+	t.m.notify()
+}
+
+type mockTimer struct {
+	m *Mock
+	w *waiter
+}
+
+func (t *mockTimer) C() <-chan time.Time { return t.w.ch }
+
+func (t *mockTimer) Stop() bool {
+	t.m.mu.Lock()
+	defer t.m.mu.Unlock()
+	return t.m.remove(t.w)
+}
+
+func (t *mockTimer) Reset(d time.Duration) bool {
+	t.m.mu.Lock()
+	defer t.m.mu.Unlock()
+	active := t.m.remove(t.w)
+	t.w.at = t.m.now.Add(d)
+	if d <= 0 {
+		select {
+		case t.w.ch <- t.m.now:
+		default:
+		}
+		return active
+	}
+	t.m.waiters = append(t.m.waiters, t.w)
+	t.m.notify()
+	return active
+}
@@ -0,0 +1,304 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 常用时间格式，ToTime按顺序尝试解析
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"20060102150405",
+	"20060102",
+}
+
+// ToInt64E 转换为int64，失败返回error
+func ToInt64E(v interface{}) (int64, error) {
+	v = indirect(v)
+	switch n := v.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint:
+		if uint64(n) > math.MaxInt64 {
+			return 0, fmt.Errorf("convert: %d overflows int64", n)
+		}
+		return int64(n), nil
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		if n > math.MaxInt64 {
+			return 0, fmt.Errorf("convert: %d overflows int64", n)
+		}
+		return int64(n), nil
+	case float32:
+		return floatToInt64(float64(n))
+	case float64:
+		return floatToInt64(n)
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	case json.Number:
+		return parseInt64(string(n))
+	case string:
+		return parseInt64(n)
+	case []byte:
+		return parseInt64(string(n))
+	case time.Duration:
+		return int64(n), nil
+	}
+	return 0, fmt.Errorf("convert: unable to cast %#v of type %T to int64", v, v)
+}
+
+// ToInt64 转换为int64，失败时返回def（默认0）
+func ToInt64(v interface{}, def ...int64) int64 {
+	n, err := ToInt64E(v)
+	if err != nil && len(def) > 0 {
+		return def[0]
+	}
+	return n
+}
+
+// ToInt 转换为int，失败时返回def（默认0）
+func ToInt(v interface{}, def ...int) int {
+	n, err := ToInt64E(v)
+	if err != nil || n > math.MaxInt || n < math.MinInt {
+		if len(def) > 0 {
+			return def[0]
+		}
+		return 0
+	}
+	return int(n)
+}
+
+// ToFloatE 转换为float64，失败返回error
+func ToFloatE(v interface{}) (float64, error) {
+	v = indirect(v)
+	switch n := v.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return reflect.ValueOf(n).Convert(reflect.TypeOf(float64(0))).Float(), nil
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	case json.Number:
+		return strconv.ParseFloat(string(n), 64)
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(n), 64)
+	case []byte:
+		return strconv.ParseFloat(strings.TrimSpace(string(n)), 64)
+	}
+	return 0, fmt.Errorf("convert: unable to cast %#v of type %T to float64", v, v)
+}
+
+// ToFloat 转换为float64，失败时返回def（默认0）
+func ToFloat(v interface{}, def ...float64) float64 {
+	f, err := ToFloatE(v)
+	if err != nil && len(def) > 0 {
+		return def[0]
+	}
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+// ToBoolE 转换为bool，字符串支持1/0、true/false、yes/no、on/off、y/n
+func ToBoolE(v interface{}) (bool, error) {
+	v = indirect(v)
+	switch b := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return b, nil
+	case string:
+		return parseBool(b)
+	case []byte:
+		return parseBool(string(b))
+	case json.Number:
+		return parseBool(string(b))
+	}
+	if n, err := ToFloatE(v); err == nil {
+		return n != 0, nil
+	}
+	return false, fmt.Errorf("convert: unable to cast %#v of type %T to bool", v, v)
+}
+
+// ToBool 转换为bool，失败时返回def（默认false）
+func ToBool(v interface{}, def ...bool) bool {
+	b, err := ToBoolE(v)
+	if err != nil && len(def) > 0 {
+		return def[0]
+	}
+	return b
+}
+
+// ToTimeE 转换为time.Time，数字按秒级（>1e12按毫秒级）时间戳处理，字符串按本地时区解析
+func ToTimeE(v interface{}) (time.Time, error) {
+	v = indirect(v)
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		return parseTime(t)
+	case []byte:
+		return parseTime(string(t))
+	case nil:
+		return time.Time{}, fmt.Errorf("convert: unable to cast nil to time.Time")
+	}
+	n, err := ToInt64E(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("convert: unable to cast %#v of type %T to time.Time", v, v)
+	}
+	return unixToTime(n), nil
+}
+
+// ToTime 转换为time.Time，失败时返回def（默认零值）
+func ToTime(v interface{}, def ...time.Time) time.Time {
+	t, err := ToTimeE(v)
+	if err != nil && len(def) > 0 {
+		return def[0]
+	}
+	return t
+}
+
+// ToString 转换为string
+func ToString(v interface{}) string {
+	if s, ok := v.(fmt.Stringer); ok {
+		rv := reflect.ValueOf(v)
+		_, isTime := indirect(v).(time.Time)
+		if !isTime && (rv.Kind() != reflect.Ptr || !rv.IsNil()) {
+			return s.String()
+		}
+	}
+	v = indirect(v)
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case bool:
+		return strconv.FormatBool(s)
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(s), 'f', -1, 32)
+	case int:
+		return strconv.Itoa(s)
+	case int64:
+		return strconv.FormatInt(s, 10)
+	case uint64:
+		return strconv.FormatUint(s, 10)
+	case time.Time:
+		return s.Format("2006-01-02 15:04:05")
+	case error:
+		return s.Error()
+	}
+	return fmt.Sprint(v)
+}
+
+// indirect 解引用指针
+func indirect(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	return rv.Interface()
+}
+
+func parseInt64(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("convert: empty string")
+	}
+	if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("convert: unable to cast %q to int64", s)
+	}
+	return floatToInt64(f)
+}
+
+func floatToInt64(f float64) (int64, error) {
+	//MaxInt64转为float64后为2^63，本身已超出int64范围
+	if math.IsNaN(f) || math.IsInf(f, 0) || f >= 9223372036854775808.0 || f < math.MinInt64 {
+		return 0, fmt.Errorf("convert: %v overflows int64", f)
+	}
+	return int64(f), nil
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "t", "true", "y", "yes", "on":
+		return true, nil
+	case "0", "f", "false", "n", "no", "off", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("convert: unable to cast %q to bool", s)
+}
+
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("convert: empty string")
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return unixToTime(n), nil
+	}
+	return time.Time{}, fmt.Errorf("convert: unable to parse %q as time", s)
+}
+
+func unixToTime(n int64) time.Time {
+	if n > 1e12 || n < -1e12 {
+		return time.UnixMilli(n)
+	}
+	return time.Unix(n, 0)
+}
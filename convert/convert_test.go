@@ -0,0 +1,166 @@
+package convert
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestToInt64(t *testing.T) {
+	cases := []struct {
+		in   interface{}
+		want int64
+	}{
+		{"123", 123},
+		{" 42 ", 42},
+		{"12.9", 12},
+		{"0x10", 16},
+		{3.7, 3},
+		{true, 1},
+		{uint8(7), 7},
+		{nil, 0},
+	}
+	for _, c := range cases {
+		if got := ToInt64(c.in); got != c.want {
+			t.Errorf("ToInt64(%#v) = %d, want %d", c.in, got, c.want)
+		}
+	}
+	if got := ToInt64("abc", -1); got != -1 {
+		t.Errorf("ToInt64 default = %d, want -1", got)
+	}
+	for _, f := range []float64{9223372036854775808.0, math.Inf(1), math.NaN(), -9223372036854777856.0} {
+		if _, err := ToInt64E(f); err == nil {
+			t.Errorf("ToInt64E(%v) should overflow", f)
+		}
+	}
+	if got, err := ToInt64E(-9223372036854775808.0); err != nil || got != math.MinInt64 {
+		t.Errorf("ToInt64E(-2^63) = %d, %v", got, err)
+	}
+	if got, err := ToInt64E(9223372036854774784.0); err != nil || got != 9223372036854774784 {
+		t.Errorf("ToInt64E(largest float below 2^63) = %d, %v", got, err)
+	}
+	n := 5
+	if got := ToInt64(&n); got != 5 {
+		t.Errorf("ToInt64(ptr) = %d, want 5", got)
+	}
+}
+
+func TestToBoolFloat(t *testing.T) {
+	for _, s := range []string{"1", "true", "YES", "on", "y"} {
+		if !ToBool(s) {
+			t.Errorf("ToBool(%q) = false", s)
+		}
+	}
+	if ToBool("maybe", true) != true {
+		t.Error("ToBool default not used")
+	}
+	if got := ToFloat("3.25"); got != 3.25 {
+		t.Errorf("ToFloat = %v", got)
+	}
+	if got := ToFloat("x", 1.5); got != 1.5 {
+		t.Errorf("ToFloat default = %v", got)
+	}
+}
+
+func TestToTime(t *testing.T) {
+	want := time.Date(2024, 5, 1, 10, 20, 30, 0, time.Local)
+	for _, in := range []interface{}{"2024-05-01 10:20:30", want.Unix(), want.UnixMilli(), "20240501102030"} {
+		if got := ToTime(in); !got.Equal(want) {
+			t.Errorf("ToTime(%#v) = %v, want %v", in, got, want)
+		}
+	}
+	def := time.Unix(1, 0)
+	if got := ToTime("bad", def); !got.Equal(def) {
+		t.Errorf("ToTime default = %v", got)
+	}
+}
+
+type base struct {
+	ID int64 `json:"id"`
+}
+
+type user struct {
+	base
+	Name    string    `json:"name"`
+	Age     int       `json:"age,omitempty"`
+	Secret  string    `json:"-"`
+	Created time.Time `json:"created"`
+	Tags    []string  `json:"tags"`
+	Addr    *addr     `json:"addr"`
+}
+
+type addr struct {
+	City string `json:"city"`
+}
+
+func TestStructMap(t *testing.T) {
+	now := time.Now()
+	u := user{base: base{ID: 9}, Name: "tom", Secret: "x", Created: now, Addr: &addr{City: "sh"}}
+	m := StructToMap(&u)
+	if m["id"] != int64(9) || m["name"] != "tom" {
+		t.Fatalf("StructToMap = %#v", m)
+	}
+	if _, ok := m["age"]; ok {
+		t.Error("omitempty field present")
+	}
+	if _, ok := m["Secret"]; ok {
+		t.Error("ignored field present")
+	}
+	if sub, ok := m["addr"].(map[string]interface{}); !ok || sub["city"] != "sh" {
+		t.Errorf("nested struct = %#v", m["addr"])
+	}
+
+	var out user
+	err := MapToStruct(map[string]interface{}{
+		"id":      "10",
+		"name":    "jerry",
+		"age":     "18",
+		"created": "2024-05-01",
+		"tags":    []interface{}{"a", 1},
+		"addr":    map[string]interface{}{"city": "bj"},
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.ID != 10 || out.Name != "jerry" || out.Age != 18 || out.Created.Year() != 2024 {
+		t.Errorf("MapToStruct = %+v", out)
+	}
+	if len(out.Tags) != 2 || out.Tags[1] != "1" || out.Addr == nil || out.Addr.City != "bj" {
+		t.Errorf("MapToStruct nested = %+v", out)
+	}
+	if err := MapToStruct(map[string]interface{}{"age": "old"}, &out); err == nil {
+		t.Error("expected error for bad int")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	if s := FormatThousands(-1234567); s != "-1,234,567" {
+		t.Errorf("FormatThousands = %s", s)
+	}
+	if s := FormatFloatThousands(1234.5, 2); s != "1,234.50" {
+		t.Errorf("FormatFloatThousands = %s", s)
+	}
+	if s := FenToYuan(-5); s != "-0.05" {
+		t.Errorf("FenToYuan = %s", s)
+	}
+	if s := FenToYuanThousands(123456789); s != "1,234,567.89" {
+		t.Errorf("FenToYuanThousands = %s", s)
+	}
+	for in, want := range map[string]int64{"1,234.5": 123450, "0.07": 7, "-3": -300, ".1": 10} {
+		got, err := YuanToFen(in)
+		if err != nil || got != want {
+			t.Errorf("YuanToFen(%q) = %d, %v", in, got, err)
+		}
+	}
+	if _, err := YuanToFen("1.234"); err == nil {
+		t.Error("expected precision error")
+	}
+	for _, in := range []string{"-", "+", ".", "-.", "+.", ",", "1.-5"} {
+		if got, err := YuanToFen(in); err == nil {
+			t.Errorf("YuanToFen(%q) = %d, want error", in, got)
+		}
+	}
+	if got := YuanFloatToFen(19.99); got != 1999 {
+		t.Errorf("YuanFloatToFen = %d", got)
+	}
+}
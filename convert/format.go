@@ -0,0 +1,150 @@
+package convert
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FormatThousands 整数千分位格式化，如 1234567 -> "1,234,567"
+func FormatThousands(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	neg := false
+	if n < 0 {
+		neg = true
+		s = s[1:]
+	}
+	s = groupThousands(s)
+	if neg {
+		return "-" + s
+	}
+	return s
+}
+
+// FormatFloatThousands 浮点数千分位格式化，prec为小数位数，如 (1234.5, 2) -> "1,234.50"
+func FormatFloatThousands(f float64, prec int) string {
+	s := strconv.FormatFloat(f, 'f', prec, 64)
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i:]
+	}
+	s = groupThousands(intPart) + fracPart
+	if neg {
+		return "-" + s
+	}
+	return s
+}
+
+func groupThousands(s string) string {
+	if len(s) <= 3 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s) + len(s)/3)
+	pre := len(s) % 3
+	if pre > 0 {
+		b.WriteString(s[:pre])
+	}
+	for i := pre; i < len(s); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(s[i : i+3])
+	}
+	return b.String()
+}
+
+// FenToYuan 分转元字符串，固定两位小数，如 12345 -> "123.45"
+func FenToYuan(fen int64) string {
+	neg := fen < 0
+	u := uint64(fen)
+	if neg {
+		u = uint64(-fen)
+	}
+	s := strconv.FormatUint(u/100, 10) + "." + leftPad2(u%100)
+	if neg {
+		return "-" + s
+	}
+	return s
+}
+
+// FenToYuanThousands 分转带千分位的元字符串，如 123456789 -> "1,234,567.89"
+func FenToYuanThousands(fen int64) string {
+	s := FenToYuan(fen)
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	i := strings.IndexByte(s, '.')
+	s = groupThousands(s[:i]) + s[i:]
+	if neg {
+		return "-" + s
+	}
+	return s
+}
+
+// YuanToFen 元字符串转分，最多两位小数，不经过浮点运算避免精度丢失
+func YuanToFen(yuan string) (int64, error) {
+	s := strings.ReplaceAll(strings.TrimSpace(yuan), ",", "")
+	if s == "" {
+		return 0, errors.New("convert: empty amount")
+	}
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		//"-"、"."等没有数字的输入
+		return 0, errors.New("convert: invalid amount: " + yuan)
+	}
+	if len(fracPart) > 2 {
+		return 0, errors.New("convert: amount has more than 2 decimal places: " + yuan)
+	}
+	for len(fracPart) < 2 {
+		fracPart += "0"
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+	i, err := strconv.ParseUint(intPart, 10, 64)
+	if err != nil {
+		return 0, errors.New("convert: invalid amount: " + yuan)
+	}
+	f, err := strconv.ParseUint(fracPart, 10, 64)
+	if err != nil {
+		return 0, errors.New("convert: invalid amount: " + yuan)
+	}
+	if i > (math.MaxInt64-f)/100 {
+		return 0, errors.New("convert: amount overflows: " + yuan)
+	}
+	fen := int64(i*100 + f)
+	if neg {
+		fen = -fen
+	}
+	return fen, nil
+}
+
+// YuanFloatToFen 元(float64)转分，四舍五入
+func YuanFloatToFen(yuan float64) int64 {
+	return int64(math.Round(yuan * 100))
+}
+
+func leftPad2(n uint64) string {
+	if n < 10 {
+		return "0" + strconv.FormatUint(n, 10)
+	}
+	return strconv.FormatUint(n, 10)
+}
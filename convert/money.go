@@ -0,0 +1,436 @@
+package convert
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultCurrency Currency为空的Money使用的币种
+var DefaultCurrency = "CNY"
+
+// 币种的小数位数(ISO 4217)，未登记的币种按2位处理，可通过RegisterCurrency补充
+var (
+	currencyMu     sync.RWMutex
+	currencyDigits = map[string]int{
+		"CNY": 2, "USD": 2, "EUR": 2, "GBP": 2, "HKD": 2, "MOP": 2, "TWD": 2, "SGD": 2,
+		"AUD": 2, "CAD": 2, "CHF": 2, "THB": 2, "MYR": 2, "PHP": 2, "INR": 2, "RUB": 2,
+		"JPY": 0, "KRW": 0, "VND": 0, "IDR": 2, "CLP": 0, "ISK": 0,
+		"BHD": 3, "KWD": 3, "OMR": 3, "JOD": 3, "TND": 3,
+	}
+)
+
+// RegisterCurrency 登记币种及其小数位数
+func RegisterCurrency(code string, digits int) {
+	currencyMu.Lock()
+	currencyDigits[strings.ToUpper(code)] = digits
+	currencyMu.Unlock()
+}
+
+// CurrencyDigits 币种的小数位数，未登记的币种返回2
+func CurrencyDigits(code string) int {
+	currencyMu.RLock()
+	defer currencyMu.RUnlock()
+	if d, ok := currencyDigits[strings.ToUpper(code)]; ok {
+		return d
+	}
+	return 2
+}
+
+// RoundingMode 舍入方式
+type RoundingMode int
+
+const (
+	RoundHalfUp   RoundingMode = iota //四舍五入，.5远离0
+	RoundHalfEven                     //银行家舍入，.5取偶数
+	RoundHalfDown                     //.5向0舍去
+	RoundDown                         //向0截断
+	RoundUp                           //远离0进位
+	RoundFloor                        //向负无穷
+	RoundCeil                         //向正无穷
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("convert: currency mismatch")
+	ErrMoneyOverflow    = errors.New("convert: money overflows int64")
+)
+
+// Money 金额，以最小货币单位(如分)的整数存储，避免浮点误差
+type Money struct {
+	Amount   int64  //最小货币单位的数量，如 CNY 12.34 为 1234
+	Currency string //ISO 4217币种代码，如CNY，为空时视为DefaultCurrency
+}
+
+// NewMoney 以最小货币单位创建
+func NewMoney(minor int64, currency string) Money {
+	return Money{Amount: minor, Currency: strings.ToUpper(currency)}
+}
+
+// ParseMoney 解析十进制金额，如 "1,234.56"、"-0.5"，小数位数超出币种精度时返回错误
+func ParseMoney(s, currency string) (Money, error) {
+	return parseMoney(s, currency, -1)
+}
+
+// ParseMoneyRound 解析十进制金额，超出币种精度的部分按mode舍入
+func ParseMoneyRound(s, currency string, mode RoundingMode) (Money, error) {
+	return parseMoney(s, currency, mode)
+}
+
+func parseMoney(s, currency string, mode RoundingMode) (Money, error) {
+	m := Money{Currency: strings.ToUpper(currency)}
+	digits := CurrencyDigits(m.currency())
+	t := strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	neg := false
+	if t != "" && (t[0] == '-' || t[0] == '+') {
+		neg = t[0] == '-'
+		t = t[1:]
+	}
+	intPart, fracPart := t, ""
+	if i := strings.IndexByte(t, '.'); i >= 0 {
+		intPart, fracPart = t[:i], t[i+1:]
+	}
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return m, fmt.Errorf("convert: invalid amount %q", s)
+	}
+	if len(fracPart) > digits && mode < 0 {
+		return m, fmt.Errorf("convert: amount %q has more than %d decimal places", s, digits)
+	}
+	n, _ := new(big.Int).SetString(intPart+fracPart, 10)
+	if neg {
+		n.Neg(n)
+	}
+	if extra := len(fracPart) - digits; extra > 0 {
+		n = divRound(n, pow10(extra), mode)
+	} else if extra < 0 {
+		n.Mul(n, pow10(-extra))
+	}
+	if !n.IsInt64() {
+		return m, ErrMoneyOverflow
+	}
+	m.Amount = n.Int64()
+	return m, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// divRound n/d按mode舍入，d大于0
+func divRound(n, d *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	sign := int64(n.Sign())
+	half := new(big.Int).Abs(r)
+	half.Lsh(half, 1)
+	cmp := half.Cmp(d)
+	away := false
+	switch mode {
+	case RoundHalfUp:
+		away = cmp >= 0
+	case RoundHalfDown:
+		away = cmp > 0
+	case RoundHalfEven:
+		away = cmp > 0 || cmp == 0 && q.Bit(0) == 1
+	case RoundUp:
+		away = true
+	case RoundFloor:
+		away = sign < 0
+	case RoundCeil:
+		away = sign > 0
+	}
+	if away {
+		q.Add(q, big.NewInt(sign))
+	}
+	return q
+}
+
+// MoneyFromFloat 由浮点数创建，按mode舍入到币种精度。仅用于兼容已有的浮点数据
+func MoneyFromFloat(f float64, currency string, mode RoundingMode) (Money, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Money{}, fmt.Errorf("convert: invalid amount %v", f)
+	}
+	//使用最短的十进制表示，19.99不会变成19.989999...
+	return ParseMoneyRound(strconv.FormatFloat(f, 'f', -1, 64), currency, mode)
+}
+
+func (m Money) currency() string {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+	return strings.ToUpper(m.Currency)
+}
+
+// Digits 币种的小数位数
+func (m Money) Digits() int {
+	return CurrencyDigits(m.currency())
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+
+// Decimal 十进制字符串，如 "-1234.50"
+func (m Money) Decimal() string {
+	return formatMinor(m.Amount, m.Digits())
+}
+
+// Format 带千分位的十进制字符串，如 "1,234.50"
+func (m Money) Format() string {
+	s := m.Decimal()
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i:]
+	}
+	s = groupThousands(intPart) + frac
+	if neg {
+		return "-" + s
+	}
+	return s
+}
+
+// String 如 "1234.50 CNY"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.currency()
+}
+
+func formatMinor(n int64, digits int) string {
+	neg := n < 0
+	u := uint64(n)
+	if neg {
+		u = -u
+	}
+	s := strconv.FormatUint(u, 10)
+	if digits > 0 {
+		if len(s) <= digits {
+			s = strings.Repeat("0", digits-len(s)+1) + s
+		}
+		s = s[:len(s)-digits] + "." + s[len(s)-digits:]
+	}
+	if neg {
+		return "-" + s
+	}
+	return s
+}
+
+func (m Money) check(o Money) error {
+	if m.currency() != o.currency() {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency(), o.currency())
+	}
+	return nil
+}
+
+// Add 相加，币种不同或溢出时返回错误
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.check(o); err != nil {
+		return Money{}, err
+	}
+	sum := m.Amount + o.Amount
+	if (sum > m.Amount) != (o.Amount > 0) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Sub 相减，币种不同或溢出时返回错误
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Amount == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+	return m.Add(Money{Amount: -o.Amount, Currency: o.Currency})
+}
+
+func (m Money) Neg() Money {
+	m.Amount = -m.Amount
+	return m
+}
+
+func (m Money) Abs() Money {
+	if m.Amount < 0 {
+		m.Amount = -m.Amount
+	}
+	return m
+}
+
+// Cmp 比较大小，返回-1、0、1，币种不同时返回错误
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.check(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Mul 乘以整数
+func (m Money) Mul(n int64) (Money, error) {
+	return m.MulDiv(n, 1, RoundHalfUp)
+}
+
+// MulDiv 乘以 num/den 并按mode舍入，用于按比例计算，如税率6%为 MulDiv(6, 100, RoundHalfUp)
+func (m Money) MulDiv(num, den int64, mode RoundingMode) (Money, error) {
+	if den == 0 {
+		return Money{}, errors.New("convert: division by zero")
+	}
+	if den < 0 {
+		num, den = -num, -den
+	}
+	n := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(num))
+	n = divRound(n, big.NewInt(den), mode)
+	if !n.IsInt64() {
+		return Money{}, ErrMoneyOverflow
+	}
+	m.Amount = n.Int64()
+	return m, nil
+}
+
+// MulRate 乘以十进制比率，如 MulRate("0.065", RoundHalfEven)
+func (m Money) MulRate(rate string, mode RoundingMode) (Money, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok {
+		return Money{}, fmt.Errorf("convert: invalid rate %q", rate)
+	}
+	n := new(big.Int).Mul(big.NewInt(m.Amount), r.Num())
+	n = divRound(n, r.Denom(), mode)
+	if !n.IsInt64() {
+		return Money{}, ErrMoneyOverflow
+	}
+	m.Amount = n.Int64()
+	return m, nil
+}
+
+// Allocate 按比例分配，各份之和等于原金额，舍入产生的余数从第一份开始逐个分配最小单位，
+// 如 100分按 1:1:1 分为 34、33、33
+func (m Money) Allocate(ratios ...int) ([]Money, error) {
+	var total int64
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, errors.New("convert: negative ratio")
+		}
+		total += int64(r)
+	}
+	if total == 0 {
+		return nil, errors.New("convert: ratios sum to zero")
+	}
+	out := make([]Money, len(ratios))
+	remainder := m.Amount
+	for i, r := range ratios {
+		part, err := m.MulDiv(int64(r), total, RoundDown)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = part
+		remainder -= part.Amount
+	}
+	step := int64(1)
+	if remainder < 0 {
+		step = -1
+	}
+	for i := 0; remainder != 0; i = (i + 1) % len(out) {
+		if ratios[i] == 0 {
+			continue
+		}
+		out[i].Amount += step
+		remainder -= step
+	}
+	return out, nil
+}
+
+// Split 平均分为n份
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, errors.New("convert: split into non-positive parts")
+	}
+	ratios := make([]int, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
+type moneyJSON struct {
+	Amount   json.Number `json:"amount"` //可接受数字及"12.34"形式的字符串
+	Currency string      `json:"currency"`
+}
+
+// MarshalJSON 输出为 {"amount":"12.34","currency":"CNY"}，金额为字符串避免前端浮点误差
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.Decimal(), m.currency()})
+}
+
+// UnmarshalJSON amount可以为字符串或数字，小数位数超出币种精度时返回错误
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("convert: invalid money json: %w", err)
+	}
+	parsed, err := ParseMoney(string(v.Amount), v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value 存入数据库时为最小货币单位的整数，币种需另存一列
+func (m Money) Value() (driver.Value, error) {
+	return m.Amount, nil
+}
+
+// Scan 从整数列读取最小货币单位，字符串及DECIMAL列按十进制金额解析，币种保持不变
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		m.Amount = 0
+		return nil
+	case int64:
+		m.Amount = v
+		return nil
+	case []byte:
+		return m.scanDecimal(string(v))
+	case string:
+		return m.scanDecimal(v)
+	}
+	return fmt.Errorf("convert: cannot scan %T into Money", src)
+}
+
+func (m *Money) scanDecimal(s string) error {
+	//整数字符串视为最小货币单位，与Value对应
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		m.Amount = n
+		return nil
+	}
+	parsed, err := ParseMoney(s, m.currency())
+	if err != nil {
+		return err
+	}
+	m.Amount = parsed.Amount
+	return nil
+}
@@ -0,0 +1,175 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		in       string
+		currency string
+		want     int64
+	}{
+		{"1,234.56", "CNY", 123456},
+		{"-0.5", "usd", -50},
+		{".07", "CNY", 7},
+		{"+3", "CNY", 300},
+		{"1500", "JPY", 1500},
+		{"1.234", "KWD", 1234},
+	}
+	for _, c := range cases {
+		m, err := ParseMoney(c.in, c.currency)
+		if err != nil || m.Amount != c.want {
+			t.Errorf("ParseMoney(%q, %s) = %v, %v", c.in, c.currency, m, err)
+		}
+	}
+	for _, in := range []string{"", "-", ".", "1.2.3", "abc", "1.234", "1e3", "99999999999999999999"} {
+		if _, err := ParseMoney(in, "CNY"); err == nil {
+			t.Errorf("ParseMoney(%q): expected error", in)
+		}
+	}
+	if _, err := ParseMoney("1.5", "JPY"); err == nil {
+		t.Error("JPY has no decimals")
+	}
+}
+
+func TestRoundingModes(t *testing.T) {
+	cases := []struct {
+		in   string
+		mode RoundingMode
+		want int64
+	}{
+		{"1.005", RoundHalfUp, 101},
+		{"-1.005", RoundHalfUp, -101},
+		{"1.005", RoundHalfDown, 100},
+		{"1.0051", RoundHalfDown, 101},
+		{"1.005", RoundHalfEven, 100},
+		{"1.015", RoundHalfEven, 102},
+		{"-1.015", RoundHalfEven, -102},
+		{"1.009", RoundDown, 100},
+		{"-1.009", RoundDown, -100},
+		{"1.001", RoundUp, 101},
+		{"-1.001", RoundFloor, -101},
+		{"1.001", RoundFloor, 100},
+		{"1.001", RoundCeil, 101},
+		{"-1.009", RoundCeil, -100},
+	}
+	for _, c := range cases {
+		m, err := ParseMoneyRound(c.in, "CNY", c.mode)
+		if err != nil || m.Amount != c.want {
+			t.Errorf("ParseMoneyRound(%q, %d) = %d, %v, want %d", c.in, c.mode, m.Amount, err, c.want)
+		}
+	}
+	if m, _ := MoneyFromFloat(19.99, "CNY", RoundHalfUp); m.Amount != 1999 {
+		t.Errorf("MoneyFromFloat = %d", m.Amount)
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	cases := []struct {
+		m                    Money
+		decimal, format, str string
+	}{
+		{NewMoney(123456789, "CNY"), "1234567.89", "1,234,567.89", "1234567.89 CNY"},
+		{NewMoney(-5, ""), "-0.05", "-0.05", "-0.05 CNY"},
+		{NewMoney(1500000, "JPY"), "1500000", "1,500,000", "1500000 JPY"},
+		{NewMoney(1, "KWD"), "0.001", "0.001", "0.001 KWD"},
+	}
+	for _, c := range cases {
+		if c.m.Decimal() != c.decimal || c.m.Format() != c.format || c.m.String() != c.str {
+			t.Errorf("%d %s: %s %s %s", c.m.Amount, c.m.Currency, c.m.Decimal(), c.m.Format(), c.m.String())
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a, b := NewMoney(1050, "CNY"), NewMoney(-300, "cny")
+	if sum, err := a.Add(b); err != nil || sum.Amount != 750 {
+		t.Errorf("Add = %v, %v", sum, err)
+	}
+	if diff, err := a.Sub(b); err != nil || diff.Amount != 1350 {
+		t.Errorf("Sub = %v, %v", diff, err)
+	}
+	if _, err := a.Add(NewMoney(1, "USD")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("mismatch: %v", err)
+	}
+	if _, err := NewMoney(1<<62, "CNY").Mul(4); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("Mul overflow: %v", err)
+	}
+	if _, err := NewMoney(1<<62, "CNY").Add(NewMoney(1<<62, "CNY")); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("Add overflow: %v", err)
+	}
+	if c, err := a.Cmp(b); err != nil || c != 1 {
+		t.Errorf("Cmp = %d, %v", c, err)
+	}
+	if b.Abs().Amount != 300 || a.Neg().Amount != -1050 || !b.IsNegative() || a.IsZero() {
+		t.Error("Abs/Neg/sign")
+	}
+	//6%税率：10.50 * 0.06 = 0.63
+	if tax, err := a.MulDiv(6, 100, RoundHalfUp); err != nil || tax.Amount != 63 {
+		t.Errorf("MulDiv = %v, %v", tax, err)
+	}
+	//10.50 * 0.065 = 0.6825
+	if tax, err := a.MulRate("0.065", RoundHalfEven); err != nil || tax.Amount != 68 {
+		t.Errorf("MulRate = %v, %v", tax, err)
+	}
+	if _, err := a.MulDiv(1, 0, RoundHalfUp); err == nil {
+		t.Error("expected division by zero")
+	}
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	parts, err := NewMoney(100, "CNY").Split(3)
+	if err != nil || parts[0].Amount != 34 || parts[1].Amount != 33 || parts[2].Amount != 33 {
+		t.Errorf("Split = %v, %v", parts, err)
+	}
+	parts, err = NewMoney(-1001, "CNY").Allocate(7, 0, 3)
+	if err != nil || parts[0].Amount != -701 || parts[1].Amount != 0 || parts[2].Amount != -300 {
+		t.Errorf("Allocate = %v, %v", parts, err)
+	}
+	if _, err := NewMoney(1, "CNY").Allocate(0, 0); err == nil {
+		t.Error("expected zero ratio error")
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(-1234, "USD"))
+	if err != nil || string(data) != `{"amount":"-12.34","currency":"USD"}` {
+		t.Errorf("Marshal = %s, %v", data, err)
+	}
+	for _, in := range []string{`{"amount":"12.30","currency":"USD"}`, `{"amount":12.3,"currency":"USD"}`} {
+		var m Money
+		if err := json.Unmarshal([]byte(in), &m); err != nil || m.Amount != 1230 || m.Currency != "USD" {
+			t.Errorf("Unmarshal(%s) = %v, %v", in, m, err)
+		}
+	}
+	var m Money
+	if err := json.Unmarshal([]byte(`{"amount":"1.001","currency":"USD"}`), &m); err == nil {
+		t.Error("expected precision error")
+	}
+	if err := json.Unmarshal([]byte(`{"amount":true}`), &m); err == nil {
+		t.Error("expected type error")
+	}
+}
+
+func TestMoneySQL(t *testing.T) {
+	v, err := NewMoney(1234, "CNY").Value()
+	if err != nil || v != int64(1234) {
+		t.Errorf("Value = %v, %v", v, err)
+	}
+	for src, want := range map[interface{}]int64{int64(99): 99, "12.50": 1250, "1250": 1250, nil: 0} {
+		m := Money{Amount: 7, Currency: "CNY"}
+		if err := m.Scan(src); err != nil || m.Amount != want || m.Currency != "CNY" {
+			t.Errorf("Scan(%v) = %v, %v", src, m, err)
+		}
+	}
+	m := Money{Currency: "JPY"}
+	if err := m.Scan([]byte("1.5")); err == nil {
+		t.Error("expected precision error for JPY")
+	}
+	if err := m.Scan(1.5); err == nil {
+		t.Error("expected type error")
+	}
+}
@@ -0,0 +1,238 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const defaultTag = "json"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// StructToMap 结构体转map，key取tag名（默认json），支持"-"和omitempty，匿名嵌入字段展开
+func StructToMap(v interface{}, tag ...string) map[string]interface{} {
+	tagName := defaultTag
+	if len(tag) > 0 && tag[0] != "" {
+		tagName = tag[0]
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	m := make(map[string]interface{}, rv.NumField())
+	structToMap(rv, tagName, m)
+	return m
+}
+
+func structToMap(rv reflect.Value, tagName string, m map[string]interface{}) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		fv := rv.Field(i)
+		name, omitempty, skip := parseTag(sf, tagName)
+		if skip {
+			continue
+		}
+		if sf.Anonymous && sf.Tag.Get(tagName) == "" {
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				structToMap(fv, tagName, m)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if omitempty && fv.IsZero() {
+			continue
+		}
+		m[name] = fieldValue(fv, tagName)
+	}
+}
+
+func fieldValue(fv reflect.Value, tagName string) interface{} {
+	v := fv
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return fv.Interface()
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Struct && v.Type() != timeType {
+		sub := make(map[string]interface{}, v.NumField())
+		structToMap(v, tagName, sub)
+		return sub
+	}
+	return fv.Interface()
+}
+
+// MapToStruct map转结构体，out必须为结构体指针，字段按tag名（默认json）匹配，值类型不一致时自动转换
+func MapToStruct(m map[string]interface{}, out interface{}, tag ...string) error {
+	tagName := defaultTag
+	if len(tag) > 0 && tag[0] != "" {
+		tagName = tag[0]
+	}
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("convert: out must be a non-nil pointer to struct")
+	}
+	return mapToStruct(m, rv.Elem(), tagName)
+}
+
+func mapToStruct(m map[string]interface{}, rv reflect.Value, tagName string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		fv := rv.Field(i)
+		name, _, skip := parseTag(sf, tagName)
+		if skip {
+			continue
+		}
+		if sf.Anonymous && sf.Tag.Get(tagName) == "" && fv.Kind() == reflect.Struct {
+			if err := mapToStruct(m, fv, tagName); err != nil {
+				return err
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		val, ok := m[name]
+		if !ok {
+			continue
+		}
+		if err := setValue(fv, val, tagName); err != nil {
+			return fmt.Errorf("convert: field %s: %w", sf.Name, err)
+		}
+	}
+	return nil
+}
+
+func setValue(fv reflect.Value, val interface{}, tagName string) error {
+	if val == nil {
+		fv.Set(reflect.Zero(fv.Type()))
+		return nil
+	}
+	vv := reflect.ValueOf(val)
+	if vv.Type().AssignableTo(fv.Type()) {
+		fv.Set(vv)
+		return nil
+	}
+	if fv.Kind() == reflect.Ptr {
+		elem := reflect.New(fv.Type().Elem())
+		if err := setValue(elem.Elem(), val, tagName); err != nil {
+			return err
+		}
+		fv.Set(elem)
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(ToString(val))
+	case reflect.Bool:
+		b, err := ToBoolE(val)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+			if s, ok := val.(string); ok {
+				d, err := time.ParseDuration(s)
+				if err != nil {
+					return err
+				}
+				fv.SetInt(int64(d))
+				return nil
+			}
+		}
+		n, err := ToInt64E(val)
+		if err != nil {
+			return err
+		}
+		if fv.OverflowInt(n) {
+			return fmt.Errorf("%d overflows %s", n, fv.Type())
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := ToInt64E(val)
+		if err != nil {
+			return err
+		}
+		if n < 0 || fv.OverflowUint(uint64(n)) {
+			return fmt.Errorf("%d overflows %s", n, fv.Type())
+		}
+		fv.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := ToFloatE(val)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Struct:
+		if fv.Type() == timeType {
+			t, err := ToTimeE(val)
+			if err != nil {
+				return err
+			}
+			fv.Set(reflect.ValueOf(t))
+			return nil
+		}
+		sub, ok := val.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cannot assign %T to %s", val, fv.Type())
+		}
+		return mapToStruct(sub, fv, tagName)
+	case reflect.Slice:
+		if vv.Kind() != reflect.Slice && vv.Kind() != reflect.Array {
+			return fmt.Errorf("cannot assign %T to %s", val, fv.Type())
+		}
+		s := reflect.MakeSlice(fv.Type(), vv.Len(), vv.Len())
+		for i := 0; i < vv.Len(); i++ {
+			if err := setValue(s.Index(i), vv.Index(i).Interface(), tagName); err != nil {
+				return err
+			}
+		}
+		fv.Set(s)
+	default:
+		if vv.Type().ConvertibleTo(fv.Type()) {
+			fv.Set(vv.Convert(fv.Type()))
+			return nil
+		}
+		return fmt.Errorf("cannot assign %T to %s", val, fv.Type())
+	}
+	return nil
+}
+
+// parseTag 解析字段tag，返回名称、是否omitempty、是否忽略
+func parseTag(sf reflect.StructField, tagName string) (name string, omitempty, skip bool) {
+	tag := sf.Tag.Get(tagName)
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = sf.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
@@ -0,0 +1,172 @@
+package filex
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"github.com/liuxy92/golib/zaplog"
+	"io"
+	"os"
+	"time"
+)
+
+const defaultPollInterval = 250 * time.Millisecond
+
+// TailOptions TailFollow的配置
+type TailOptions struct {
+	FromStart    bool          //从文件开头读取，默认只读取开始跟踪后写入的内容
+	PollInterval time.Duration //检查新内容及文件轮转的间隔，默认250ms
+}
+
+// TailFollow 类似 tail -F，逐行输出文件新写入的内容，ctx结束后关闭channel。
+// 文件被改名轮转或删除重建后(通过inode判断)读完旧文件剩余内容再从头读取新文件，
+// 文件被截断时从头读取；文件不存在时等待其创建。行不包含末尾的换行符，
+// 消费方处理不过来时暂停读取
+func TailFollow(ctx context.Context, path string, o *TailOptions) <-chan string {
+	if o == nil {
+		o = &TailOptions{}
+	}
+	t := &tailer{path: path, interval: o.PollInterval, fromStart: o.FromStart, out: make(chan string)}
+	if t.interval <= 0 {
+		t.interval = defaultPollInterval
+	}
+	go t.run(ctx)
+	return t.out
+}
+
+type tailer struct {
+	path      string
+	interval  time.Duration
+	fromStart bool
+	out       chan string
+
+	f       *os.File
+	fi      os.FileInfo
+	r       *bufio.Reader
+	offset  int64
+	partial []byte
+}
+
+func (t *tailer) run(ctx context.Context) {
+	defer close(t.out)
+	defer t.close()
+	lg := zaplog.FromContext(ctx)
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	var lastErr string
+	for {
+		if t.f == nil {
+			if err := t.open(); os.IsNotExist(err) {
+				//开始跟踪后才创建的文件从头读取
+				t.fromStart = true
+			} else if err != nil && err.Error() != lastErr {
+				//同一错误只记录一次
+				lastErr = err.Error()
+				lg.Warnw("filex: tail open failed", "path", t.path, "error", err)
+			}
+		}
+		if t.f != nil {
+			if !t.read(ctx) {
+				return
+			}
+			if t.rotated(ctx) {
+				//立即读取新文件
+				continue
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *tailer) open() error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	t.f, t.fi, t.offset = f, fi, 0
+	//只有第一次打开时跳过已有内容，轮转后的新文件从头读取
+	if !t.fromStart {
+		if t.offset, err = f.Seek(0, io.SeekEnd); err != nil {
+			_ = f.Close()
+			t.f = nil
+			return err
+		}
+	}
+	t.fromStart = true
+	t.r = bufio.NewReader(f)
+	return nil
+}
+
+func (t *tailer) close() {
+	if t.f != nil {
+		_ = t.f.Close()
+		t.f = nil
+	}
+}
+
+// read 读取到文件末尾，ctx结束时返回false
+func (t *tailer) read(ctx context.Context) bool {
+	for {
+		line, err := t.r.ReadBytes('\n')
+		t.offset += int64(len(line))
+		if err != nil {
+			//不完整的行等待后续写入
+			t.partial = append(t.partial, line...)
+			return true
+		}
+		if len(t.partial) > 0 {
+			line = append(t.partial, line...)
+			t.partial = nil
+		}
+		if !t.send(ctx, line) {
+			return false
+		}
+	}
+}
+
+func (t *tailer) send(ctx context.Context, line []byte) bool {
+	line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+	select {
+	case t.out <- string(line):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// rotated 检查文件是否被轮转或截断。轮转时输出旧文件剩余的不完整行并关闭，返回true
+func (t *tailer) rotated(ctx context.Context) bool {
+	fi, err := os.Stat(t.path)
+	if err != nil {
+		//轮转过程中文件暂时不存在，继续读旧文件
+		return false
+	}
+	if !os.SameFile(t.fi, fi) {
+		if !t.read(ctx) {
+			return false
+		}
+		if len(t.partial) > 0 {
+			t.send(ctx, t.partial)
+			t.partial = nil
+		}
+		t.close()
+		return true
+	}
+	if fi.Size() < t.offset {
+		if _, err := t.f.Seek(0, io.SeekStart); err == nil {
+			t.r.Reset(t.f)
+			t.offset = 0
+			t.partial = nil
+		}
+	}
+	return false
+}
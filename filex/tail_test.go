@@ -0,0 +1,97 @@
+package filex
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func appendFile(t *testing.T, path, s string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectLines(t *testing.T, ch <-chan string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		select {
+		case got := <-ch:
+			if got != w {
+				t.Fatalf("got %q, want %q", got, w)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timeout waiting for %q", w)
+		}
+	}
+}
+
+func tail(t *testing.T, path string, o *TailOptions) <-chan string {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	o.PollInterval = 10 * time.Millisecond
+	return TailFollow(ctx, path, o)
+}
+
+func TestTailFromEnd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "old\n")
+	ch := tail(t, path, &TailOptions{})
+	time.Sleep(50 * time.Millisecond)
+
+	appendFile(t, path, "a\nb")
+	expectLines(t, ch, "a")
+	//不完整的行等待换行后输出
+	appendFile(t, path, "c\r\n")
+	expectLines(t, ch, "bc")
+}
+
+func TestTailRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	ch := tail(t, path, &TailOptions{})
+	//文件不存在时等待创建，新文件从头读取
+	time.Sleep(30 * time.Millisecond)
+	appendFile(t, path, "1\n")
+	expectLines(t, ch, "1")
+
+	appendFile(t, path, "2\n3")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "4\n")
+	expectLines(t, ch, "2", "3", "4")
+}
+
+func TestTailTruncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "a\nb\n")
+	ch := tail(t, path, &TailOptions{FromStart: true})
+	expectLines(t, ch, "a", "b")
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	appendFile(t, path, "c\n")
+	expectLines(t, ch, "c")
+}
+
+func TestTailCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "a\nb\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := TailFollow(ctx, path, &TailOptions{FromStart: true, PollInterval: 10 * time.Millisecond})
+	expectLines(t, ch, "a")
+	//未消费的行不阻塞退出
+	cancel()
+	for range ch {
+	}
+}
@@ -0,0 +1,190 @@
+package filex
+
+import (
+	"context"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/liuxy92/golib/zaplog"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultDebounce = 100 * time.Millisecond
+
+// Event 合并后的文件变化，Op为防抖期间该文件所有操作的并集
+type Event struct {
+	Path string
+	Op   fsnotify.Op
+}
+
+// Watch 监听匹配glob的文件变化，防抖时间内的变化合并后调用一次handler，ctx结束后停止。
+// pattern使用filepath.Match语法，并支持**匹配任意层目录，如 conf/**/*.yaml；
+// 监听的是文件所在目录而不是文件本身，文件被替换、改名轮转或删除后重建仍能收到事件；
+// 只修改权限的事件被忽略。debounce小于等于0时为100ms，监听错误使用zaplog.FromContext(ctx)记录
+func Watch(ctx context.Context, pattern string, debounce time.Duration, handler func([]Event)) error {
+	m, err := newMatcher(pattern)
+	if err != nil {
+		return err
+	}
+	if debounce <= 0 {
+		debounce = defaultDebounce
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("filex: create watcher: %w", err)
+	}
+	if err := m.addDirs(w, m.root); err != nil {
+		_ = w.Close()
+		return err
+	}
+	go func() {
+		defer w.Close()
+		lg := zaplog.FromContext(ctx)
+		pending := make(map[string]fsnotify.Op)
+		timer := time.NewTimer(debounce)
+		timer.Stop()
+		add := func(path string, op fsnotify.Op) {
+			if m.match(path) {
+				pending[path] |= op
+				timer.Reset(debounce)
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if ev.Op == fsnotify.Chmod {
+					continue
+				}
+				if ev.Has(fsnotify.Create) && m.recursive {
+					if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+						//新目录加入监听前已创建的文件补发Create事件
+						if err := m.addDirs(w, ev.Name); err != nil {
+							lg.Warnw("filex: watch new directory failed", "dir", ev.Name, "error", err)
+						}
+						_ = filepath.WalkDir(ev.Name, func(path string, d fs.DirEntry, err error) error {
+							if err == nil && !d.IsDir() {
+								add(path, fsnotify.Create)
+							}
+							return nil
+						})
+						continue
+					}
+				}
+				add(ev.Name, ev.Op)
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				lg.Warnw("filex: watch error", "pattern", pattern, "error", err)
+			case <-timer.C:
+				events := make([]Event, 0, len(pending))
+				for path, op := range pending {
+					events = append(events, Event{Path: path, Op: op})
+				}
+				sort.Slice(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+				pending = make(map[string]fsnotify.Op)
+				handler(events)
+			}
+		}
+	}()
+	return nil
+}
+
+// matcher 将pattern拆为不含通配符的根目录与相对根目录的各段
+type matcher struct {
+	root      string
+	segments  []string
+	recursive bool //通配符不只出现在最后一段时需要监听子目录
+}
+
+func newMatcher(pattern string) (*matcher, error) {
+	pattern = filepath.Clean(pattern)
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	i := 0
+	for i < len(parts)-1 && !hasMeta(parts[i]) {
+		i++
+	}
+	root := filepath.FromSlash(strings.Join(parts[:i], "/"))
+	if root == "" {
+		root = "."
+		if strings.HasPrefix(pattern, string(filepath.Separator)) {
+			root = string(filepath.Separator)
+		}
+	}
+	m := &matcher{root: root, segments: parts[i:], recursive: len(parts)-i > 1}
+	for _, s := range m.segments {
+		if _, err := filepath.Match(s, ""); err != nil {
+			return nil, fmt.Errorf("filex: invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return m, nil
+}
+
+func hasMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+func (m *matcher) match(path string) bool {
+	rel, err := filepath.Rel(m.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	return matchSegments(m.segments, strings.Split(filepath.ToSlash(rel), "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// addDirs 监听dir，recursive时同时监听所有子目录
+func (m *matcher) addDirs(w *fsnotify.Watcher, dir string) error {
+	if !m.recursive {
+		if err := w.Add(dir); err != nil {
+			return fmt.Errorf("filex: watch %s: %w", dir, err)
+		}
+		return nil
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			//遍历期间被删除的子目录忽略
+			if path != dir && os.IsNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("filex: watch %s: %w", path, err)
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := w.Add(path); err != nil {
+			return fmt.Errorf("filex: watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,144 @@
+package filex
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/etc/app/*.yaml", "/etc/app/a.yaml", true},
+		{"/etc/app/*.yaml", "/etc/app/a.json", false},
+		{"/etc/app/*.yaml", "/etc/app/sub/a.yaml", false},
+		{"/etc/app/**/*.yaml", "/etc/app/a.yaml", true},
+		{"/etc/app/**/*.yaml", "/etc/app/x/y/a.yaml", true},
+		{"/etc/app/*/conf.yaml", "/etc/app/x/conf.yaml", true},
+		{"/etc/app/*/conf.yaml", "/etc/other/x/conf.yaml", false},
+		{"/etc/app/conf.yaml", "/etc/app/conf.yaml", true},
+	}
+	for _, c := range cases {
+		m, err := newMatcher(filepath.FromSlash(c.pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.match(filepath.FromSlash(c.path)); got != c.want {
+			t.Errorf("%s %s: got %v", c.pattern, c.path, got)
+		}
+	}
+	if m, _ := newMatcher(filepath.FromSlash("/etc/app/**/*.yaml")); m.root != filepath.FromSlash("/etc/app") || !m.recursive {
+		t.Errorf("root: %+v", m)
+	}
+	if _, err := newMatcher("conf/[.yaml"); err == nil {
+		t.Error("expected invalid pattern error")
+	}
+}
+
+// watchEvents 启动Watch，返回接收每批事件的channel
+func watchEvents(t *testing.T, pattern string) <-chan []Event {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ch := make(chan []Event, 10)
+	if err := Watch(ctx, pattern, 50*time.Millisecond, func(events []Event) { ch <- events }); err != nil {
+		t.Fatal(err)
+	}
+	return ch
+}
+
+func nextBatch(t *testing.T, ch <-chan []Event) []Event {
+	t.Helper()
+	select {
+	case events := <-ch:
+		return events
+	case <-time.After(3 * time.Second):
+		t.Fatal("no events")
+	}
+	return nil
+}
+
+func noBatch(t *testing.T, ch <-chan []Event) {
+	t.Helper()
+	select {
+	case events := <-ch:
+		t.Fatalf("unexpected events: %v", events)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestWatchDebounce(t *testing.T) {
+	dir := t.TempDir()
+	ch := watchEvents(t, filepath.Join(dir, "*.yaml"))
+
+	file := filepath.Join(dir, "app.yaml")
+	for i := 0; i < 5; i++ {
+		if err := os.WriteFile(file, []byte{byte(i)}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o644)
+
+	events := nextBatch(t, ch)
+	if len(events) != 1 || events[0].Path != file || !events[0].Op.Has(fsnotify.Create) || !events[0].Op.Has(fsnotify.Write) {
+		t.Fatalf("events: %v", events)
+	}
+	noBatch(t, ch)
+}
+
+func TestWatchRotate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	_ = os.WriteFile(file, []byte("v1"), 0o644)
+	ch := watchEvents(t, file)
+
+	//改名轮转后重建，监听目录仍能收到新文件的事件
+	if err := os.Rename(file, file+".1"); err != nil {
+		t.Fatal(err)
+	}
+	events := nextBatch(t, ch)
+	if len(events) != 1 || !events[0].Op.Has(fsnotify.Rename) {
+		t.Fatalf("rename: %v", events)
+	}
+	_ = os.WriteFile(file, []byte("v2"), 0o644)
+	events = nextBatch(t, ch)
+	if len(events) != 1 || !events[0].Op.Has(fsnotify.Create) {
+		t.Fatalf("create: %v", events)
+	}
+}
+
+func TestWatchRecursive(t *testing.T) {
+	dir := t.TempDir()
+	ch := watchEvents(t, filepath.Join(dir, "**", "*.yaml"))
+
+	sub := filepath.Join(dir, "a", "b")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(sub, "deep.yaml")
+	if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	events := nextBatch(t, ch)
+	if len(events) != 1 || events[0].Path != file {
+		t.Fatalf("events: %v", events)
+	}
+
+	//已监听的新目录中再次修改
+	_ = os.WriteFile(file, []byte("y"), 0o644)
+	events = nextBatch(t, ch)
+	if len(events) != 1 || !events[0].Op.Has(fsnotify.Write) {
+		t.Fatalf("write: %v", events)
+	}
+}
+
+func TestWatchMissingRoot(t *testing.T) {
+	err := Watch(context.Background(), filepath.Join(t.TempDir(), "missing", "*.yaml"), 0, func([]Event) {})
+	if err == nil {
+		t.Error("expected error")
+	}
+}
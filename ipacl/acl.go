@@ -0,0 +1,168 @@
+package ipacl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Action 规则动作
+type Action int
+
+const (
+	Deny Action = iota
+	Allow
+)
+
+func (a Action) String() string {
+	if a == Allow {
+		return "allow"
+	}
+	return "deny"
+}
+
+// Rule 一条IP规则，单个IP按/32或/128处理。Country或ASN非空时按IP归属匹配，忽略Prefix
+type Rule struct {
+	Prefix  netip.Prefix
+	Country string //国家代码，需通过SetGeoResolver设置归属查询
+	ASN     uint32 //自治系统号，需通过SetGeoResolver设置归属查询
+	Action  Action
+}
+
+func (r Rule) String() string {
+	switch {
+	case r.Country != "":
+		return r.Action.String() + " country:" + r.Country
+	case r.ASN != 0:
+		return r.Action.String() + " asn:" + strconv.FormatUint(uint64(r.ASN), 10)
+	}
+	return r.Action.String() + " " + r.Prefix.String()
+}
+
+// ParseRule 解析一条规则，格式为 [allow|deny] <IP、CIDR、country:CN或asn:13335>，省略动作时使用def
+func ParseRule(s string, def Action) (Rule, error) {
+	r := Rule{Action: def}
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+	case 2:
+		switch strings.ToLower(fields[0]) {
+		case "allow":
+			r.Action = Allow
+		case "deny":
+			r.Action = Deny
+		default:
+			return r, fmt.Errorf("ipacl: unknown action %q", fields[0])
+		}
+		fields = fields[1:]
+	default:
+		return r, fmt.Errorf("ipacl: invalid rule %q", s)
+	}
+	if ok, err := parseGeoTarget(fields[0], &r); ok || err != nil {
+		return r, err
+	}
+	p, err := ParsePrefix(fields[0])
+	if err != nil {
+		return r, err
+	}
+	r.Prefix = p
+	return r, nil
+}
+
+// ParsePrefix 解析IP或CIDR，IPv4映射的IPv6地址转为IPv4
+func ParsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return p, fmt.Errorf("ipacl: invalid CIDR %q: %w", s, err)
+		}
+		if p.Addr().Is4In6() && p.Bits() >= 96 {
+			p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+		}
+		return p.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("ipacl: invalid IP %q: %w", s, err)
+	}
+	addr = addr.Unmap().WithZone("")
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ParseRules 按行解析规则，忽略空行及#开头的注释，行尾#之后的内容也视为注释
+func ParseRules(r io.Reader, def Action) ([]Rule, error) {
+	var rules []Rule
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		rule, err := ParseRule(line, def)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, sc.Err()
+}
+
+// ACL IP访问控制列表，按最长前缀匹配规则，未匹配CIDR规则时依次匹配ASN、国家规则，都未匹配时使用默认动作。
+// 规则可在运行中通过Update或Watch整体替换，查询无锁
+type ACL struct {
+	def   Action
+	rules atomic.Value //*ruleSet
+	geo   atomic.Value //geoHolder
+}
+
+// New 创建ACL。白名单模式使用New(ipacl.Deny, allow规则...)，黑名单模式使用New(ipacl.Allow, deny规则...)
+func New(def Action, rules ...Rule) *ACL {
+	a := &ACL{def: def}
+	a.Update(rules)
+	return a
+}
+
+// Update 替换全部规则
+func (a *ACL) Update(rules []Rule) {
+	a.rules.Store(newRuleSet(rules))
+}
+
+// Rules 当前的全部规则，CIDR规则在前，之后为ASN、国家规则
+func (a *ACL) Rules() []Rule {
+	s := a.rules.Load().(*ruleSet)
+	var rules []Rule
+	s.tree.Walk(func(r Rule) {
+		rules = append(rules, r)
+	})
+	return append(rules, s.geoRules()...)
+}
+
+// Match 返回addr命中的规则，未命中时返回false
+func (a *ACL) Match(addr netip.Addr) (Rule, bool) {
+	return a.rules.Load().(*ruleSet).match(addr, a.geoResolver())
+}
+
+// Allowed 判断addr是否允许访问
+func (a *ACL) Allowed(addr netip.Addr) bool {
+	if r, ok := a.Match(addr); ok {
+		return r.Action == Allow
+	}
+	return a.def == Allow
+}
+
+// AllowedString 同Allowed，ip无法解析时拒绝
+func (a *ACL) AllowedString(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	return a.Allowed(addr)
+}
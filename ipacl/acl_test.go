@@ -0,0 +1,155 @@
+package ipacl
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader(`
+# 办公网
+allow 192.168.0.0/16
+10.0.0.1        # 省略动作
+DENY 2001:db8::/32
+::ffff:1.2.3.4
+`), Allow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"allow 192.168.0.0/16", "allow 10.0.0.1/32", "deny 2001:db8::/32", "allow 1.2.3.4/32"}
+	if len(rules) != len(want) {
+		t.Fatalf("rules = %v", rules)
+	}
+	for i, r := range rules {
+		if r.String() != want[i] {
+			t.Errorf("rule %d = %s, want %s", i, r, want[i])
+		}
+	}
+
+	for _, s := range []string{"block 1.2.3.4", "1.2.3.4 5.6.7.8 9", "1.2.3", "10.0.0.0/33", "allow"} {
+		if _, err := ParseRules(strings.NewReader(s), Deny); err == nil {
+			t.Errorf("ParseRules(%q): expected error", s)
+		}
+	}
+	if p, err := ParsePrefix("10.1.2.3/8"); err != nil || p.String() != "10.0.0.0/8" {
+		t.Errorf("ParsePrefix masked = %v, %v", p, err)
+	}
+}
+
+func TestACL(t *testing.T) {
+	allowlist := New(Deny, mustRule(t, "allow 10.0.0.0/8"), mustRule(t, "deny 10.0.0.66"))
+	for ip, want := range map[string]bool{"10.1.1.1": true, "10.0.0.66": false, "11.0.0.1": false} {
+		if got := allowlist.AllowedString(ip); got != want {
+			t.Errorf("allowlist %s = %v, want %v", ip, got, want)
+		}
+	}
+	if allowlist.AllowedString("not-an-ip") {
+		t.Error("invalid ip should be denied")
+	}
+
+	denylist := New(Allow, mustRule(t, "1.2.3.0/24"))
+	if denylist.AllowedString("1.2.3.4") || !denylist.AllowedString("1.2.4.4") {
+		t.Error("denylist")
+	}
+
+	denylist.Update(nil)
+	if !denylist.AllowedString("1.2.3.4") || len(denylist.Rules()) != 0 {
+		t.Error("update")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acl.txt")
+	if err := os.WriteFile(path, []byte("1.1.1.1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	acl := New(Allow)
+	if err := acl.Watch(ctx, &FileSource{Path: path}, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if acl.AllowedString("1.1.1.1") {
+		t.Fatal("initial rules not loaded")
+	}
+
+	if err := os.WriteFile(path, []byte("2.2.2.2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return acl.AllowedString("1.1.1.1") && !acl.AllowedString("2.2.2.2") })
+
+	//文件错误时保留原有规则
+	if err := os.WriteFile(path, []byte("bad rule here\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if acl.AllowedString("2.2.2.2") {
+		t.Fatal("rules replaced by invalid file")
+	}
+
+	if err := New(Allow).Watch(ctx, &FileSource{Path: filepath.Join(t.TempDir(), "missing")}, 0); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestWatchRedis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	src := &RedisSource{Client: client, Key: "ipacl:rules", Channel: "ipacl:reload"}
+	if err := src.Add(ctx, mustRule(t, "deny 1.1.1.1")); err != nil {
+		t.Fatal(err)
+	}
+	acl := New(Allow)
+	//轮询间隔很长，规则变化只能通过频道通知生效
+	if err := acl.Watch(ctx, src, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if acl.AllowedString("1.1.1.1") {
+		t.Fatal("initial rules not loaded")
+	}
+
+	waitFor(t, func() bool { return mr.PubSubNumSub("ipacl:reload")["ipacl:reload"] == 1 })
+	if err := src.Add(ctx, mustRule(t, "deny 2.2.2.0/24")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return !acl.AllowedString("2.2.2.9") })
+
+	if err := src.Remove(ctx, mustRule(t, "deny 1.1.1.1")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return acl.AllowedString("1.1.1.1") })
+
+	if _, err := (&RedisSource{Client: client, Key: "bad"}).Load(ctx); err != nil {
+		t.Fatalf("empty key: %v", err)
+	}
+	mr.SAdd("bad", "oops")
+	if _, err := (&RedisSource{Client: client, Key: "bad"}).Load(ctx); err == nil {
+		t.Fatal("expected parse error")
+	}
+	if got := acl.Rules(); len(got) != 1 || got[0].Prefix != netip.MustParsePrefix("2.2.2.0/24") {
+		t.Fatalf("Rules = %v", got)
+	}
+}
@@ -0,0 +1,113 @@
+package ipacl
+
+import (
+	"github.com/liuxy92/golib/clockx"
+	"github.com/liuxy92/golib/zaplog"
+	"net/netip"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAlertTopN = 10
+	maxTrackedIPs    = 10000 //单个周期内最多统计的不同IP数，超出部分只计入总数
+)
+
+// IPCount 一个IP在汇总周期内被拒绝的次数
+type IPCount struct {
+	IP    string `json:"ip"`
+	Count int    `json:"count"`
+}
+
+// ViolationReport 一个汇总周期内的拒绝统计
+type ViolationReport struct {
+	Start  time.Time      `json:"start"`
+	End    time.Time      `json:"end"`
+	Total  int            `json:"total"`
+	Rules  map[string]int `json:"rules"`   //按命中规则统计，未命中规则时为default
+	TopIPs []IPCount      `json:"top_ips"` //按次数倒序
+}
+
+// alerter 按周期汇总拒绝记录，周期内第一次拒绝时开始计时，周期结束时输出一次汇总
+type alerter struct {
+	interval time.Duration
+	topN     int
+	clock    clockx.Clock
+	logger   *zaplog.Logger
+	onAlert  func(ViolationReport)
+
+	mu    sync.Mutex
+	start time.Time
+	total int
+	rules map[string]int
+	ips   map[netip.Addr]int
+}
+
+func newAlerter(o *MiddlewareOptions) *alerter {
+	a := &alerter{
+		interval: o.AlertInterval,
+		topN:     o.AlertTopN,
+		clock:    clockx.Or(o.Clock),
+		logger:   o.Logger,
+		onAlert:  o.OnAlert,
+	}
+	if a.topN <= 0 {
+		a.topN = defaultAlertTopN
+	}
+	return a
+}
+
+func (a *alerter) record(ip netip.Addr, rule string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.total == 0 {
+		a.start = a.clock.Now()
+		a.rules = make(map[string]int)
+		a.ips = make(map[netip.Addr]int)
+		go func() {
+			<-a.clock.After(a.interval)
+			a.flush()
+		}()
+	}
+	a.total++
+	a.rules[rule]++
+	if _, ok := a.ips[ip]; ok || len(a.ips) < maxTrackedIPs {
+		a.ips[ip]++
+	}
+}
+
+func (a *alerter) flush() {
+	a.mu.Lock()
+	report := ViolationReport{
+		Start: a.start,
+		End:   a.clock.Now(),
+		Total: a.total,
+		Rules: a.rules,
+	}
+	for ip, n := range a.ips {
+		report.TopIPs = append(report.TopIPs, IPCount{IP: ip.String(), Count: n})
+	}
+	a.total, a.rules, a.ips = 0, nil, nil
+	a.mu.Unlock()
+
+	sort.Slice(report.TopIPs, func(i, j int) bool {
+		if report.TopIPs[i].Count != report.TopIPs[j].Count {
+			return report.TopIPs[i].Count > report.TopIPs[j].Count
+		}
+		return report.TopIPs[i].IP < report.TopIPs[j].IP
+	})
+	if len(report.TopIPs) > a.topN {
+		report.TopIPs = report.TopIPs[:a.topN]
+	}
+
+	lg := a.logger
+	if lg == nil {
+		lg = zaplog.GetLogger()
+	}
+	lg.Warnw("ipacl: requests denied", "total", report.Total, "window", report.End.Sub(report.Start).String(),
+		"rules", report.Rules, "top_ips", report.TopIPs)
+	if a.onAlert != nil {
+		a.onAlert(report)
+	}
+}
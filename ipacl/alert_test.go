@@ -0,0 +1,74 @@
+package ipacl
+
+import (
+	"github.com/liuxy92/golib/clockx"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareAlert(t *testing.T) {
+	lg, logs := testLogger(t)
+	clock := clockx.NewMock(time.Unix(1700000000, 0))
+	reports := make(chan ViolationReport, 1)
+	acl := New(Allow, mustRule(t, "deny 6.6.6.0/24"), mustRule(t, "deny 7.7.7.7"))
+	h := acl.Middleware(&MiddlewareOptions{
+		Logger:        lg,
+		AlertInterval: time.Minute,
+		AlertTopN:     2,
+		OnAlert:       func(r ViolationReport) { reports <- r },
+		Clock:         clock,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(ip string, n int) {
+		for i := 0; i < n; i++ {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = ip + ":1000"
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("%s: status %d", ip, w.Code)
+			}
+		}
+	}
+	serve("6.6.6.1", 3)
+	serve("6.6.6.2", 1)
+	serve("7.7.7.7", 2)
+
+	clock.BlockUntil(1)
+	clock.Add(time.Minute)
+	var r ViolationReport
+	select {
+	case r = <-reports:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no report")
+	}
+	if r.Total != 6 || r.Rules["deny 6.6.6.0/24"] != 4 || r.Rules["deny 7.7.7.7/32"] != 2 || r.End.Sub(r.Start) != time.Minute {
+		t.Errorf("report = %+v", r)
+	}
+	if len(r.TopIPs) != 2 || r.TopIPs[0] != (IPCount{"6.6.6.1", 3}) || r.TopIPs[1] != (IPCount{"7.7.7.7", 2}) {
+		t.Errorf("top ips = %+v", r.TopIPs)
+	}
+	out := logs()
+	if strings.Contains(out, "ipacl: request denied") {
+		t.Errorf("per-request log should be suppressed: %s", out)
+	}
+	if strings.Count(out, "ipacl: requests denied") != 1 || !strings.Contains(out, `"total":6`) || !strings.Contains(out, `{"ip":"6.6.6.1","count":3}`) {
+		t.Errorf("summary log: %s", out)
+	}
+
+	//新周期重新统计
+	serve("6.6.6.9", 1)
+	clock.BlockUntil(1)
+	clock.Add(time.Minute)
+	select {
+	case r = <-reports:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no second report")
+	}
+	if r.Total != 1 || len(r.TopIPs) != 1 || r.TopIPs[0].IP != "6.6.6.9" {
+		t.Errorf("second report = %+v", r)
+	}
+}
@@ -0,0 +1,147 @@
+package ipacl
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GeoInfo IP的归属信息
+type GeoInfo struct {
+	Country string //ISO 3166-1两位国家代码，如CN
+	ASN     uint32 //自治系统号，未知时为0
+}
+
+// GeoResolver 查询IP归属，通常基于MaxMind等离线库实现，需支持并发调用
+type GeoResolver interface {
+	LookupGeo(addr netip.Addr) (GeoInfo, bool)
+}
+
+// GeoResolverFunc 函数形式的GeoResolver
+type GeoResolverFunc func(addr netip.Addr) (GeoInfo, bool)
+
+func (f GeoResolverFunc) LookupGeo(addr netip.Addr) (GeoInfo, bool) {
+	return f(addr)
+}
+
+type geoHolder struct {
+	r GeoResolver
+}
+
+// SetGeoResolver 设置country、asn规则使用的IP归属查询，未设置时这两类规则不生效
+func (a *ACL) SetGeoResolver(r GeoResolver) {
+	a.geo.Store(geoHolder{r})
+}
+
+func (a *ACL) geoResolver() GeoResolver {
+	h, _ := a.geo.Load().(geoHolder)
+	return h.r
+}
+
+// parseGeoTarget 解析 country:CN、asn:13335 形式的规则目标，不是这两种形式时返回false
+func parseGeoTarget(s string, r *Rule) (bool, error) {
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return false, nil
+	}
+	kind, v := strings.ToLower(s[:i]), s[i+1:]
+	switch kind {
+	case "country":
+		if len(v) != 2 || !isLetters(v) {
+			return true, fmt.Errorf("ipacl: invalid country code %q", v)
+		}
+		r.Country = strings.ToUpper(v)
+	case "asn":
+		n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(v), "AS"), 10, 32)
+		if err != nil || n == 0 {
+			return true, fmt.Errorf("ipacl: invalid ASN %q", v)
+		}
+		r.ASN = uint32(n)
+	default:
+		//IPv6地址本身包含冒号
+		return false, nil
+	}
+	return true, nil
+}
+
+func isLetters(s string) bool {
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleSet 一次Update后的全部规则，CIDR规则存于前缀树，country、asn规则按值索引
+type ruleSet struct {
+	tree      *Tree
+	countries map[string]Rule
+	asns      map[uint32]Rule
+}
+
+func newRuleSet(rules []Rule) *ruleSet {
+	s := &ruleSet{tree: &Tree{}}
+	for _, r := range rules {
+		switch {
+		case r.Country != "":
+			if s.countries == nil {
+				s.countries = make(map[string]Rule)
+			}
+			s.countries[strings.ToUpper(r.Country)] = r
+		case r.ASN != 0:
+			if s.asns == nil {
+				s.asns = make(map[uint32]Rule)
+			}
+			s.asns[r.ASN] = r
+		default:
+			s.tree.Insert(r)
+		}
+	}
+	return s
+}
+
+// match CIDR规则优先，其次ASN规则，最后国家规则
+func (s *ruleSet) match(addr netip.Addr, geo GeoResolver) (Rule, bool) {
+	if r, ok := s.tree.Lookup(addr); ok {
+		return r, true
+	}
+	if geo == nil || (len(s.countries) == 0 && len(s.asns) == 0) || !addr.IsValid() {
+		return Rule{}, false
+	}
+	info, ok := geo.LookupGeo(addr.Unmap())
+	if !ok {
+		return Rule{}, false
+	}
+	if r, ok := s.asns[info.ASN]; ok && info.ASN != 0 {
+		return r, true
+	}
+	if r, ok := s.countries[strings.ToUpper(info.Country)]; ok {
+		return r, true
+	}
+	return Rule{}, false
+}
+
+// geoRules 按ASN、国家代码排序的country、asn规则
+func (s *ruleSet) geoRules() []Rule {
+	rules := make([]Rule, 0, len(s.asns)+len(s.countries))
+	for _, r := range s.asns {
+		rules = append(rules, r)
+	}
+	for _, r := range s.countries {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		if (a.ASN == 0) != (b.ASN == 0) {
+			return a.ASN != 0
+		}
+		if a.ASN != b.ASN {
+			return a.ASN < b.ASN
+		}
+		return a.Country < b.Country
+	})
+	return rules
+}
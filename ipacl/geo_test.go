@@ -0,0 +1,85 @@
+package ipacl
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestParseGeoRules(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader(`
+deny country:cn
+allow ASN:AS13335
+asn:4134
+deny 2001:db8::1
+`), Deny)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"deny country:CN", "allow asn:13335", "deny asn:4134", "deny 2001:db8::1/128"}
+	for i, r := range rules {
+		if r.String() != want[i] {
+			t.Errorf("rule %d = %s, want %s", i, r, want[i])
+		}
+	}
+	for _, s := range []string{"country:CHN", "country:1a", "asn:0", "asn:x", "deny asn:"} {
+		if _, err := ParseRule(s, Deny); err == nil {
+			t.Errorf("ParseRule(%q): expected error", s)
+		}
+	}
+}
+
+func TestGeoMatch(t *testing.T) {
+	geo := map[string]GeoInfo{
+		"1.0.0.1": {Country: "US", ASN: 13335},
+		"1.0.0.2": {Country: "CN", ASN: 4134},
+		"1.0.0.3": {Country: "cn", ASN: 4808},
+		"1.0.0.4": {Country: "JP"},
+	}
+	acl := New(Allow,
+		mustRule(t, "deny country:CN"),
+		mustRule(t, "allow asn:4808"),
+		mustRule(t, "deny asn:13335"),
+		mustRule(t, "allow 1.0.0.1"),
+	)
+	//未设置GeoResolver时国家、ASN规则不生效
+	if !acl.AllowedString("1.0.0.2") {
+		t.Fatal("geo rules should be ignored without resolver")
+	}
+
+	lookups := 0
+	acl.SetGeoResolver(GeoResolverFunc(func(addr netip.Addr) (GeoInfo, bool) {
+		lookups++
+		info, ok := geo[addr.String()]
+		return info, ok
+	}))
+	for ip, want := range map[string]bool{
+		"1.0.0.1": true,  //CIDR规则优先于ASN规则
+		"1.0.0.2": false, //国家规则
+		"1.0.0.3": true,  //ASN规则优先于国家规则，国家代码不区分大小写
+		"1.0.0.4": true,
+		"1.0.0.5": true, //查询不到归属时使用默认动作
+	} {
+		if got := acl.AllowedString(ip); got != want {
+			t.Errorf("%s = %v, want %v", ip, got, want)
+		}
+	}
+	if r, ok := acl.Match(netip.MustParseAddr("::ffff:1.0.0.2")); !ok || r.String() != "deny country:CN" {
+		t.Errorf("Match mapped = %v, %v", r, ok)
+	}
+
+	var got []string
+	for _, r := range acl.Rules() {
+		got = append(got, r.String())
+	}
+	if strings.Join(got, ",") != "allow 1.0.0.1/32,allow asn:4808,deny asn:13335,deny country:CN" {
+		t.Errorf("Rules = %v", got)
+	}
+
+	acl.Update([]Rule{mustRule(t, "deny 9.9.9.9")})
+	lookups = 0
+	acl.AllowedString("1.0.0.2")
+	if lookups != 0 {
+		t.Error("resolver should not be called without geo rules")
+	}
+}
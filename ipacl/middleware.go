@@ -0,0 +1,164 @@
+package ipacl
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/liuxy92/golib/clockx"
+	"github.com/liuxy92/golib/zaplog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// MiddlewareOptions 访问控制中间件配置
+type MiddlewareOptions struct {
+	TrustedProxies []string       //可信代理的IP或CIDR，请求来自可信代理时从X-Forwarded-For、X-Real-IP中取客户端IP
+	StatusCode     int            //拒绝访问时的状态码，默认403
+	Logger         *zaplog.Logger //记录拒绝日志，默认zaplog.FromContext(r.Context())，汇总日志默认zaplog.GetLogger()
+
+	AlertInterval time.Duration         //大于0时不再逐条记录拒绝日志，而是按周期汇总，每个周期最多一条warn日志及一次OnAlert
+	AlertTopN     int                   //汇总中保留的拒绝次数最多的IP数，默认10
+	OnAlert       func(ViolationReport) //汇总回调，可用于发送告警通知，在独立的goroutine中调用
+	Clock         clockx.Clock          //汇总周期使用的时间来源，默认系统时间
+}
+
+type guard struct {
+	acl     *ACL
+	trusted *Tree
+	status  int
+	logger  *zaplog.Logger
+	alerts  *alerter
+}
+
+// newGuard TrustedProxies格式错误时panic，属于启动配置错误
+func (a *ACL) newGuard(o *MiddlewareOptions) *guard {
+	g := &guard{acl: a, status: http.StatusForbidden}
+	if o == nil {
+		return g
+	}
+	if o.StatusCode > 0 {
+		g.status = o.StatusCode
+	}
+	g.logger = o.Logger
+	if o.AlertInterval > 0 {
+		g.alerts = newAlerter(o)
+	}
+	if len(o.TrustedProxies) > 0 {
+		g.trusted = &Tree{}
+		for _, s := range o.TrustedProxies {
+			p, err := ParsePrefix(s)
+			if err != nil {
+				panic(err)
+			}
+			g.trusted.Insert(Rule{Prefix: p, Action: Allow})
+		}
+	}
+	return g
+}
+
+// check 判断请求是否允许访问，拒绝时记录warn日志或计入汇总
+func (g *guard) check(r *http.Request) bool {
+	ip := ClientIP(r, g.trusted)
+	if !ip.IsValid() {
+		g.deny(r, ip, "default")
+		return false
+	}
+	m, ok := g.acl.Match(ip)
+	if ok && m.Action == Allow || !ok && g.acl.def == Allow {
+		return true
+	}
+	rule := "default"
+	if ok {
+		rule = m.String()
+	}
+	g.deny(r, ip, rule)
+	return false
+}
+
+func (g *guard) deny(r *http.Request, ip netip.Addr, rule string) {
+	if g.alerts != nil {
+		g.alerts.record(ip, rule)
+		return
+	}
+	lg := g.logger
+	if lg == nil {
+		lg = zaplog.FromContext(r.Context())
+	}
+	lg.Warnw("ipacl: request denied", "client_ip", ip.String(), "rule", rule, "http", zaplog.HTTPRequest(r))
+}
+
+// Middleware net/http中间件，拒绝的请求返回StatusCode且不再调用后续handler
+func (a *ACL) Middleware(o *MiddlewareOptions) func(http.Handler) http.Handler {
+	g := a.newGuard(o)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !g.check(r) {
+				http.Error(w, http.StatusText(g.status), g.status)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GinMiddleware gin中间件，客户端IP按TrustedProxies解析，与gin自身的可信代理配置无关
+func (a *ACL) GinMiddleware(o *MiddlewareOptions) gin.HandlerFunc {
+	g := a.newGuard(o)
+	return func(c *gin.Context) {
+		if !g.check(c.Request) {
+			c.String(g.status, http.StatusText(g.status))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ClientIP 返回请求的客户端IP。RemoteAddr属于trusted时，从右向左取X-Forwarded-For中第一个不可信的地址，
+// 没有X-Forwarded-For时使用X-Real-IP；trusted为nil时只使用RemoteAddr，防止伪造请求头绕过限制
+func ClientIP(r *http.Request, trusted *Tree) netip.Addr {
+	client := parseAddr(r.RemoteAddr)
+	if !client.IsValid() || !isTrusted(trusted, client) {
+		return client
+	}
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		parts := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(parts) - 1; i >= 0; i-- {
+			ip, err := netip.ParseAddr(strings.TrimSpace(parts[i]))
+			if err != nil {
+				//可信代理写入了无法解析的地址，使用最后一个有效地址
+				return client
+			}
+			client = ip.Unmap()
+			if !isTrusted(trusted, client) {
+				return client
+			}
+		}
+		return client
+	}
+	if ip, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return ip.Unmap()
+	}
+	return client
+}
+
+func isTrusted(trusted *Tree, ip netip.Addr) bool {
+	if trusted == nil {
+		return false
+	}
+	r, ok := trusted.Lookup(ip)
+	return ok && r.Action == Allow
+}
+
+// parseAddr 解析RemoteAddr，兼容不带端口的地址
+func parseAddr(s string) netip.Addr {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return ip.Unmap()
+}
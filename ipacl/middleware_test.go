@@ -0,0 +1,138 @@
+package ipacl
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/liuxy92/golib/zaplog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := &Tree{}
+	trusted.Insert(mustRule(t, "allow 10.0.0.0/8"))
+
+	cases := []struct {
+		remote, xff, realIP string
+		trusted             *Tree
+		want                string
+	}{
+		{"1.2.3.4:5678", "", "", trusted, "1.2.3.4"},
+		{"1.2.3.4:5678", "9.9.9.9", "", trusted, "1.2.3.4"},
+		{"10.0.0.1:80", "9.9.9.9", "", nil, "10.0.0.1"},
+		{"10.0.0.1:80", "6.6.6.6, 9.9.9.9, 10.0.0.2", "", trusted, "9.9.9.9"},
+		{"10.0.0.1:80", "10.0.0.3, 10.0.0.2", "", trusted, "10.0.0.3"},
+		{"10.0.0.1:80", "junk, 10.0.0.2", "", trusted, "10.0.0.2"},
+		{"10.0.0.1:80", "", "8.8.8.8", trusted, "8.8.8.8"},
+		{"[::ffff:10.0.0.1]:80", "", "", trusted, "10.0.0.1"},
+		{"5.5.5.5", "", "", trusted, "5.5.5.5"},
+		{"@", "", "", trusted, "invalid IP"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = c.remote
+		if c.xff != "" {
+			r.Header.Set("X-Forwarded-For", c.xff)
+		}
+		if c.realIP != "" {
+			r.Header.Set("X-Real-IP", c.realIP)
+		}
+		if got := ClientIP(r, c.trusted).String(); got != c.want {
+			t.Errorf("ClientIP(%s, xff=%q, real=%q) = %s, want %s", c.remote, c.xff, c.realIP, got, c.want)
+		}
+	}
+}
+
+// testLogger 写入临时目录的logger，返回读取warn日志文件内容的函数
+func testLogger(t *testing.T) (*zaplog.Logger, func() string) {
+	dir := t.TempDir()
+	lg := &zaplog.Logger{Opts: &zaplog.Options{}}
+	if err := lg.Reconfigure(&zaplog.Options{LogFileDir: dir, LogLevel: "info"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = lg.Close() })
+	return lg, func() string {
+		_ = lg.Sync()
+		files, _ := filepath.Glob(filepath.Join(dir, "*warn*"))
+		var b strings.Builder
+		for _, f := range files {
+			data, _ := os.ReadFile(f)
+			b.Write(data)
+		}
+		return b.String()
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	lg, logs := testLogger(t)
+	acl := New(Deny, mustRule(t, "allow 192.168.0.0/16"))
+	h := acl.Middleware(&MiddlewareOptions{
+		TrustedProxies: []string{"10.0.0.1"},
+		Logger:         lg,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	serve := func(remote, xff string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/admin", nil)
+		r.RemoteAddr = remote
+		r.Header.Set("X-Forwarded-For", xff)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	if w := serve("192.168.1.1:1000", ""); w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Fatalf("allowed: %d %s", w.Code, w.Body)
+	}
+	if w := serve("10.0.0.1:1000", "192.168.3.3"); w.Code != http.StatusOK {
+		t.Fatalf("allowed via proxy: %d", w.Code)
+	}
+	if w := serve("8.8.8.8:1000", "192.168.3.3"); w.Code != http.StatusForbidden {
+		t.Fatalf("spoofed header: %d", w.Code)
+	}
+	out := logs()
+	if !strings.Contains(out, "ipacl: request denied") || !strings.Contains(out, `"client_ip":"8.8.8.8"`) || !strings.Contains(out, `"rule":"default"`) {
+		t.Fatalf("denied log: %s", out)
+	}
+	if strings.Count(out, "request denied") != 1 {
+		t.Fatalf("allowed requests should not be logged: %s", out)
+	}
+}
+
+func TestMiddlewareInvalidProxy(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	New(Allow).Middleware(&MiddlewareOptions{TrustedProxies: []string{"bad"}})
+}
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lg, logs := testLogger(t)
+	acl := New(Allow, mustRule(t, "deny 6.6.6.0/24"))
+	r := gin.New()
+	r.Use(acl.GinMiddleware(&MiddlewareOptions{StatusCode: http.StatusUnauthorized, Logger: lg}))
+	r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	for ip, want := range map[string]int{"6.6.6.6": http.StatusUnauthorized, "7.7.7.7": http.StatusOK} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("%s: status %d, want %d", ip, w.Code, want)
+		}
+	}
+	if out := logs(); !strings.Contains(out, `"rule":"deny 6.6.6.0/24"`) {
+		t.Fatalf("denied log: %s", out)
+	}
+	if !acl.Allowed(netip.MustParseAddr("7.7.7.7")) {
+		t.Fatal("unexpected")
+	}
+}
@@ -0,0 +1,158 @@
+package ipacl
+
+import (
+	"context"
+	"fmt"
+	"github.com/liuxy92/golib/zaplog"
+	"github.com/redis/go-redis/v9"
+	"os"
+	"time"
+)
+
+const defaultReloadInterval = 30 * time.Second
+
+// Source 规则来源
+type Source interface {
+	Load(ctx context.Context) ([]Rule, error)
+}
+
+// Notifier 能主动通知规则变化的来源，Watch收到通知后立即重新加载
+type Notifier interface {
+	Notify(ctx context.Context) <-chan struct{}
+}
+
+// FileSource 从文本文件加载规则，每行一条，格式见ParseRules
+type FileSource struct {
+	Path   string
+	Action Action //省略动作的规则使用的动作
+}
+
+func (s *FileSource) Load(ctx context.Context) ([]Rule, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("ipacl: open rules file: %w", err)
+	}
+	defer f.Close()
+	rules, err := ParseRules(f, s.Action)
+	if err != nil {
+		return nil, fmt.Errorf("ipacl: %s: %w", s.Path, err)
+	}
+	return rules, nil
+}
+
+// RedisSource 从Redis集合加载规则，集合成员格式与规则文件中的一行相同，如 "deny 10.0.0.0/8"
+type RedisSource struct {
+	Client  redis.UniversalClient
+	Key     string
+	Action  Action //省略动作的规则使用的动作
+	Channel string //非空时订阅该频道，收到任意消息后立即重新加载，Add、Remove会向该频道发布通知
+}
+
+func (s *RedisSource) Load(ctx context.Context) ([]Rule, error) {
+	members, err := s.Client.SMembers(ctx, s.Key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("ipacl: load rules from redis key %s: %w", s.Key, err)
+	}
+	rules := make([]Rule, 0, len(members))
+	for _, m := range members {
+		r, err := ParseRule(m, s.Action)
+		if err != nil {
+			return nil, fmt.Errorf("ipacl: redis key %s: %w", s.Key, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// Add 添加规则并通知各实例重新加载
+func (s *RedisSource) Add(ctx context.Context, rules ...Rule) error {
+	return s.update(ctx, rules, s.Client.SAdd)
+}
+
+// Remove 删除规则并通知各实例重新加载，规则需与添加时的写法一致
+func (s *RedisSource) Remove(ctx context.Context, rules ...Rule) error {
+	return s.update(ctx, rules, s.Client.SRem)
+}
+
+func (s *RedisSource) update(ctx context.Context, rules []Rule, op func(context.Context, string, ...interface{}) *redis.IntCmd) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(rules))
+	for i, r := range rules {
+		members[i] = r.String()
+	}
+	if err := op(ctx, s.Key, members...).Err(); err != nil {
+		return fmt.Errorf("ipacl: update redis key %s: %w", s.Key, err)
+	}
+	if s.Channel != "" {
+		return s.Client.Publish(ctx, s.Channel, s.Key).Err()
+	}
+	return nil
+}
+
+// Notify 订阅Channel，未设置Channel时返回nil
+func (s *RedisSource) Notify(ctx context.Context) <-chan struct{} {
+	if s.Channel == "" {
+		return nil
+	}
+	ps := s.Client.Subscribe(ctx, s.Channel)
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer ps.Close()
+		msgs := ps.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-msgs:
+				if !ok {
+					return
+				}
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+// Watch 立即加载一次规则，之后每隔interval(默认30s)及收到来源通知时重新加载，ctx结束后停止。
+// 首次加载失败时返回error；之后加载失败时记录warn日志并保留原有规则，日志使用zaplog.FromContext(ctx)
+func (a *ACL) Watch(ctx context.Context, src Source, interval time.Duration) error {
+	rules, err := src.Load(ctx)
+	if err != nil {
+		return err
+	}
+	a.Update(rules)
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	var notify <-chan struct{}
+	if n, ok := src.(Notifier); ok {
+		notify = n.Notify(ctx)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-notify:
+			}
+			rules, err := src.Load(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					zaplog.FromContext(ctx).Warnw("ipacl: reload rules failed, keep previous rules", "error", err)
+				}
+				continue
+			}
+			a.Update(rules)
+		}
+	}()
+	return nil
+}
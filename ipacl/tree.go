@@ -0,0 +1,133 @@
+package ipacl
+
+import (
+	"net/netip"
+)
+
+// Tree 压缩前缀树(radix tree)，按最长前缀匹配查找CIDR规则，IPv4与IPv6分开存储
+type Tree struct {
+	v4  *node
+	v6  *node
+	len int
+}
+
+type node struct {
+	prefix netip.Prefix //已按位数掩码
+	rule   Rule
+	set    bool //中间分支节点没有规则
+	child  [2]*node
+}
+
+// Insert 插入规则，前缀相同时覆盖原规则。country、asn规则不属于前缀规则，忽略
+func (t *Tree) Insert(r Rule) {
+	if r.Country != "" || r.ASN != 0 || !r.Prefix.IsValid() {
+		return
+	}
+	p := r.Prefix.Masked()
+	r.Prefix = p
+	if p.Addr().Is4() {
+		t.v4 = t.insert(t.v4, r)
+	} else {
+		t.v6 = t.insert(t.v6, r)
+	}
+}
+
+func (t *Tree) insert(n *node, r Rule) *node {
+	p := r.Prefix
+	if n == nil {
+		t.len++
+		return &node{prefix: p, rule: r, set: true}
+	}
+	if n.prefix == p {
+		if !n.set {
+			t.len++
+		}
+		n.rule, n.set = r, true
+		return n
+	}
+	nb, pb := n.prefix.Bits(), p.Bits()
+	//n包含p，沿p在第nb位的方向向下插入
+	if nb < pb && n.prefix.Contains(p.Addr()) {
+		b := bitAt(p.Addr(), nb)
+		n.child[b] = t.insert(n.child[b], r)
+		return n
+	}
+	t.len++
+	leaf := &node{prefix: p, rule: r, set: true}
+	//p包含n，p成为n的父节点
+	if pb < nb && p.Contains(n.prefix.Addr()) {
+		leaf.child[bitAt(n.prefix.Addr(), pb)] = n
+		return leaf
+	}
+	//两者不相交，在公共前缀处分叉
+	l := nb
+	if pb < l {
+		l = pb
+	}
+	l = commonBits(p.Addr(), n.prefix.Addr(), l)
+	branch := &node{prefix: netip.PrefixFrom(p.Addr(), l).Masked()}
+	branch.child[bitAt(p.Addr(), l)] = leaf
+	branch.child[bitAt(n.prefix.Addr(), l)] = n
+	return branch
+}
+
+// Lookup 返回包含addr的最长前缀规则，IPv4映射的IPv6地址按IPv4查找，忽略IPv6 zone
+func (t *Tree) Lookup(addr netip.Addr) (Rule, bool) {
+	addr = addr.Unmap().WithZone("")
+	n := t.v6
+	if addr.Is4() {
+		n = t.v4
+	}
+	var best Rule
+	found := false
+	for n != nil && n.prefix.Contains(addr) {
+		if n.set {
+			best, found = n.rule, true
+		}
+		if n.prefix.Bits() == addr.BitLen() {
+			break
+		}
+		n = n.child[bitAt(addr, n.prefix.Bits())]
+	}
+	return best, found
+}
+
+// Len 规则条数
+func (t *Tree) Len() int {
+	return t.len
+}
+
+// Walk 按前缀顺序遍历所有规则
+func (t *Tree) Walk(fn func(Rule)) {
+	walk(t.v4, fn)
+	walk(t.v6, fn)
+}
+
+func walk(n *node, fn func(Rule)) {
+	if n == nil {
+		return
+	}
+	if n.set {
+		fn(n.rule)
+	}
+	walk(n.child[0], fn)
+	walk(n.child[1], fn)
+}
+
+func bitAt(a netip.Addr, i int) int {
+	if a.Is4() {
+		b := a.As4()
+		return int(b[i/8]>>(7-i%8)) & 1
+	}
+	b := a.As16()
+	return int(b[i/8]>>(7-i%8)) & 1
+}
+
+func commonBits(a, b netip.Addr, max int) int {
+	for i := 0; i < max; i++ {
+		if bitAt(a, i) != bitAt(b, i) {
+			return i
+		}
+	}
+	return max
+}
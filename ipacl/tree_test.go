@@ -0,0 +1,128 @@
+package ipacl
+
+import (
+	"math/rand"
+	"net/netip"
+	"testing"
+)
+
+func mustRule(t testing.TB, s string) Rule {
+	t.Helper()
+	r, err := ParseRule(s, Deny)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestTreeLongestPrefix(t *testing.T) {
+	tr := &Tree{}
+	for _, s := range []string{
+		"deny 10.0.0.0/8",
+		"allow 10.1.0.0/16",
+		"deny 10.1.2.3",
+		"allow 192.168.1.0/24",
+		"allow 2001:db8::/32",
+		"deny 2001:db8:1::/48",
+		"allow 0.0.0.0/0",
+	} {
+		tr.Insert(mustRule(t, s))
+	}
+	if tr.Len() != 7 {
+		t.Fatalf("Len = %d", tr.Len())
+	}
+	cases := map[string]string{
+		"10.2.3.4":          "deny 10.0.0.0/8",
+		"10.1.9.9":          "allow 10.1.0.0/16",
+		"10.1.2.3":          "deny 10.1.2.3/32",
+		"::ffff:10.1.2.3":   "deny 10.1.2.3/32",
+		"192.168.1.200":     "allow 192.168.1.0/24",
+		"8.8.8.8":           "allow 0.0.0.0/0",
+		"2001:db8:2::1":     "allow 2001:db8::/32",
+		"2001:db8:1::1":     "deny 2001:db8:1::/48",
+		"fe80::1%eth0":      "",
+		"2001:db8:1::1%eth": "deny 2001:db8:1::/48",
+	}
+	for ip, want := range cases {
+		r, ok := tr.Lookup(netip.MustParseAddr(ip))
+		got := ""
+		if ok {
+			got = r.String()
+		}
+		if got != want {
+			t.Errorf("Lookup(%s) = %q, want %q", ip, got, want)
+		}
+	}
+
+	//覆盖已有前缀不增加条数
+	tr.Insert(mustRule(t, "allow 10.0.0.0/8"))
+	if tr.Len() != 7 {
+		t.Fatalf("Len after overwrite = %d", tr.Len())
+	}
+	if r, _ := tr.Lookup(netip.MustParseAddr("10.2.3.4")); r.Action != Allow {
+		t.Fatalf("overwrite: %v", r)
+	}
+
+	var n int
+	tr.Walk(func(Rule) { n++ })
+	if n != 7 {
+		t.Fatalf("Walk visited %d", n)
+	}
+}
+
+// TestTreeRandom 与线性扫描的结果对比
+func TestTreeRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	randAddr := func() netip.Addr {
+		//集中在少量网段内，使前缀之间有包含与分叉
+		return netip.AddrFrom4([4]byte{10, byte(rnd.Intn(4)), byte(rnd.Intn(256)), byte(rnd.Intn(256))})
+	}
+	tr := &Tree{}
+	var rules []Rule
+	for i := 0; i < 500; i++ {
+		p := netip.PrefixFrom(randAddr(), 8+rnd.Intn(25)).Masked()
+		r := Rule{Prefix: p, Action: Action(rnd.Intn(2))}
+		tr.Insert(r)
+		replaced := false
+		for j := range rules {
+			if rules[j].Prefix == p {
+				rules[j], replaced = r, true
+			}
+		}
+		if !replaced {
+			rules = append(rules, r)
+		}
+	}
+	if tr.Len() != len(rules) {
+		t.Fatalf("Len = %d, want %d", tr.Len(), len(rules))
+	}
+	for i := 0; i < 5000; i++ {
+		addr := randAddr()
+		var want Rule
+		found := false
+		for _, r := range rules {
+			if r.Prefix.Contains(addr) && (!found || r.Prefix.Bits() > want.Prefix.Bits()) {
+				want, found = r, true
+			}
+		}
+		got, ok := tr.Lookup(addr)
+		if ok != found || got != want {
+			t.Fatalf("Lookup(%s) = %v %v, want %v %v", addr, got, ok, want, found)
+		}
+	}
+}
+
+func BenchmarkTreeLookup(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	tr := &Tree{}
+	for i := 0; i < 10000; i++ {
+		addr := netip.AddrFrom4([4]byte{byte(rnd.Intn(256)), byte(rnd.Intn(256)), byte(rnd.Intn(256)), 0})
+		tr.Insert(Rule{Prefix: netip.PrefixFrom(addr, 16+rnd.Intn(9)).Masked()})
+	}
+	addr := netip.MustParseAddr("100.64.3.7")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.Lookup(addr)
+	}
+}
@@ -0,0 +1,69 @@
+package jsonx
+
+import (
+	"io"
+)
+
+// API 序列化实现，默认encoding/json，使用 -tags sonic 编译时切换为sonic
+type API interface {
+	Marshal(v interface{}) ([]byte, error)
+	MarshalIndent(v interface{}, prefix, indent string) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Encoder 流式编码
+type Encoder interface {
+	Encode(v interface{}) error
+	SetEscapeHTML(on bool)
+	SetIndent(prefix, indent string)
+}
+
+// Decoder 流式解码
+type Decoder interface {
+	Decode(v interface{}) error
+	More() bool
+	UseNumber()
+	DisallowUnknownFields()
+	Buffered() io.Reader
+}
+
+// Codec 当前使用的实现
+var Codec API = defaultCodec
+
+// Marshal 序列化
+func Marshal(v interface{}) ([]byte, error) {
+	return Codec.Marshal(v)
+}
+
+// MarshalString 序列化为字符串
+func MarshalString(v interface{}) (string, error) {
+	b, err := Codec.Marshal(v)
+	return string(b), err
+}
+
+// MarshalIndent 带缩进序列化
+func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	return Codec.MarshalIndent(v, prefix, indent)
+}
+
+// Unmarshal 反序列化
+func Unmarshal(data []byte, v interface{}) error {
+	return Codec.Unmarshal(data, v)
+}
+
+// UnmarshalString 从字符串反序列化
+func UnmarshalString(s string, v interface{}) error {
+	return Codec.Unmarshal([]byte(s), v)
+}
+
+// NewEncoder 创建流式编码器
+func NewEncoder(w io.Writer) Encoder {
+	return Codec.NewEncoder(w)
+}
+
+// NewDecoder 创建流式解码器
+func NewDecoder(r io.Reader) Decoder {
+	return Codec.NewDecoder(r)
+}
@@ -0,0 +1,34 @@
+//go:build sonic
+
+package jsonx
+
+import (
+	"github.com/bytedance/sonic"
+	"io"
+)
+
+var defaultCodec API = sonicCodec{api: sonic.ConfigStd}
+
+type sonicCodec struct {
+	api sonic.API
+}
+
+func (c sonicCodec) Marshal(v interface{}) ([]byte, error) {
+	return c.api.Marshal(v)
+}
+
+func (c sonicCodec) MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	return c.api.MarshalIndent(v, prefix, indent)
+}
+
+func (c sonicCodec) Unmarshal(data []byte, v interface{}) error {
+	return c.api.Unmarshal(data, v)
+}
+
+func (c sonicCodec) NewEncoder(w io.Writer) Encoder {
+	return c.api.NewEncoder(w)
+}
+
+func (c sonicCodec) NewDecoder(r io.Reader) Decoder {
+	return c.api.NewDecoder(r)
+}
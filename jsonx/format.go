@@ -0,0 +1,38 @@
+package jsonx
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Pretty 格式化JSON，使用两个空格缩进
+func Pretty(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Compact 压缩JSON，去除空白
+func Compact(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// PrettyString 格式化任意值为带缩进的JSON字符串，失败时返回空串，一般用于调试输出
+func PrettyString(v interface{}) string {
+	b, err := MarshalIndent(v, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// Valid 判断是否为合法JSON
+func Valid(data []byte) bool {
+	return json.Valid(data)
+}
@@ -0,0 +1,201 @@
+package jsonx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type order struct {
+	ID      int64             `json:"id"`
+	Amount  float64           `json:"amount"`
+	Paid    bool              `json:"paid"`
+	Remark  string            `json:"remark"`
+	Tags    []string          `json:"tags"`
+	Created time.Time         `json:"created"`
+	Items   []item            `json:"items"`
+	Extra   map[string]int    `json:"extra"`
+	Any     interface{}       `json:"any"`
+	Meta    map[string]string `json:"meta"`
+}
+
+type item struct {
+	SKU string `json:"sku"`
+	Qty uint   `json:"qty"`
+}
+
+func TestUnmarshalTolerant(t *testing.T) {
+	data := `{"id":"123","amount":"9.5","paid":"yes","remark":100,"tags":"single",
+		"created":"2024-05-01 08:00:00","items":[{"sku":"a","qty":"2"}],"extra":{"n":"3"},
+		"any":1,"meta":{"k":true}}`
+	var o order
+	if err := UnmarshalTolerant([]byte(data), &o); err != nil {
+		t.Fatal(err)
+	}
+	if o.ID != 123 || o.Amount != 9.5 || !o.Paid || o.Remark != "100" {
+		t.Errorf("scalars = %+v", o)
+	}
+	if len(o.Tags) != 1 || o.Tags[0] != "single" {
+		t.Errorf("tags = %v", o.Tags)
+	}
+	if o.Created.Hour() != 8 || len(o.Items) != 1 || o.Items[0].Qty != 2 || o.Extra["n"] != 3 {
+		t.Errorf("nested = %+v", o)
+	}
+	if o.Any != float64(1) || o.Meta["k"] != "true" {
+		t.Errorf("any/meta = %#v %#v", o.Any, o.Meta)
+	}
+
+	var bad order
+	if err := UnmarshalTolerant([]byte(`{"id":"abc"}`), &bad); err == nil || !strings.Contains(err.Error(), "id") {
+		t.Errorf("expected field error, got %v", err)
+	}
+}
+
+func TestGet(t *testing.T) {
+	data := []byte(`{"a":{"b":[{"c":"x"},{"c":2,"d":true}]}}`)
+	if s := GetString(data, "a.b[0].c"); s != "x" {
+		t.Errorf("GetString = %q", s)
+	}
+	if n := GetInt64(data, "a.b.1.c"); n != 2 {
+		t.Errorf("GetInt64 = %d", n)
+	}
+	if !GetBool(data, "a.b[1].d") {
+		t.Error("GetBool = false")
+	}
+	if _, err := Get(data, "a.b[5]"); err != ErrNotFound {
+		t.Errorf("Get missing = %v", err)
+	}
+	if s := GetString(data, "a.x", "def"); s != "def" {
+		t.Errorf("GetString default = %q", s)
+	}
+	var it item
+	if err := GetInto(data, "a.b[1]", &it); err != nil || it.Qty != 0 {
+		t.Errorf("GetInto = %+v %v", it, err)
+	}
+	if _, err := Get(data, "a.b[x]"); err == nil {
+		t.Error("expected path error")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	p, err := Pretty([]byte(`{"a":1,"b":[1,2]}`))
+	if err != nil || !strings.Contains(string(p), "\n  \"a\": 1") {
+		t.Errorf("Pretty = %s %v", p, err)
+	}
+	c, err := Compact(p)
+	if err != nil || string(c) != `{"a":1,"b":[1,2]}` {
+		t.Errorf("Compact = %s %v", c, err)
+	}
+}
+
+func TestDecodeArray(t *testing.T) {
+	r := strings.NewReader(`[{"sku":"a","qty":1},{"sku":"b","qty":2}]`)
+	var total uint
+	err := DecodeArray(r, func(dec *json.Decoder) error {
+		var it item
+		if err := dec.Decode(&it); err != nil {
+			return err
+		}
+		total += it.Qty
+		return nil
+	})
+	if err != nil || total != 3 {
+		t.Errorf("DecodeArray total=%d err=%v", total, err)
+	}
+	if err := DecodeArray(strings.NewReader(`{}`), func(*json.Decoder) error { return nil }); err == nil {
+		t.Error("expected error for non-array")
+	}
+}
+
+func TestStreamArray(t *testing.T) {
+	//边生成边解码，不需要整体载入内存
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = io.WriteString(pw, "[")
+		for i := 0; i < 10000; i++ {
+			if i > 0 {
+				_, _ = io.WriteString(pw, ",")
+			}
+			fmt.Fprintf(pw, `{"sku":"s%d","qty":1}`, i)
+		}
+		_, _ = io.WriteString(pw, "]")
+		pw.Close()
+	}()
+	var total uint
+	err := StreamArray(context.Background(), pr, &StreamOptions{MaxElementBytes: 64}, func(dec *json.Decoder) error {
+		var it item
+		if err := dec.Decode(&it); err != nil {
+			return err
+		}
+		total += it.Qty
+		return nil
+	})
+	if err != nil || total != 10000 {
+		t.Errorf("total=%d err=%v", total, err)
+	}
+}
+
+func TestStreamArrayLimits(t *testing.T) {
+	decode := func(dec *json.Decoder) error {
+		var v interface{}
+		return dec.Decode(&v)
+	}
+	big := `[1, "` + strings.Repeat("x", 1000) + `", 3]`
+	err := StreamArray(context.Background(), strings.NewReader(big), &StreamOptions{MaxElementBytes: 100}, decode)
+	if !errors.Is(err, ErrElementTooLarge) || !strings.Contains(err.Error(), "element 1") {
+		t.Errorf("element size: %v", err)
+	}
+	err = StreamArray(context.Background(), strings.NewReader(`[1,2,3]`), &StreamOptions{MaxElements: 2}, decode)
+	if !errors.Is(err, ErrTooManyElements) {
+		t.Errorf("element count: %v", err)
+	}
+	err = StreamArray(context.Background(), strings.NewReader(`[1,2]`), nil, func(*json.Decoder) error { return nil })
+	if !errors.Is(err, errElementNotDecoded) {
+		t.Errorf("not decoded: %v", err)
+	}
+	if err := StreamArray(context.Background(), strings.NewReader(`[]`), nil, decode); err != nil {
+		t.Errorf("empty: %v", err)
+	}
+}
+
+func TestStreamArrayCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	err := StreamArray(ctx, strings.NewReader(`[1,2,3,4]`), nil, func(dec *json.Decoder) error {
+		var v int
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		if n++; n == 2 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || n != 2 {
+		t.Errorf("n=%d err=%v", n, err)
+	}
+
+	//阻塞在读取输入时，取消后下一次读取返回错误
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- StreamArray(ctx2, pr, nil, func(dec *json.Decoder) error {
+			var v int
+			return dec.Decode(&v)
+		})
+	}()
+	_, _ = io.WriteString(pw, "[1,")
+	cancel2()
+	go func() { _, _ = io.WriteString(pw, "2") }()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("blocked read: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package jsonx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/liuxy92/golib/convert"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound 路径不存在
+var ErrNotFound = errors.New("jsonx: path not found")
+
+// Get 按路径取值，路径格式如 "a.b[0].c"，数组下标也可写作 "a.b.0.c"
+// 返回值类型为 map[string]interface{}、[]interface{}、string、json.Number、bool 或 nil
+func Get(data []byte, path string) (interface{}, error) {
+	keys, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var cur interface{}
+	if err := dec.Decode(&cur); err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			v, ok := node[k]
+			if !ok {
+				return nil, ErrNotFound
+			}
+			cur = v
+		case []interface{}:
+			i, err := strconv.Atoi(k)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, ErrNotFound
+			}
+			cur = node[i]
+		default:
+			return nil, ErrNotFound
+		}
+	}
+	return cur, nil
+}
+
+// GetRaw 按路径取值并返回其JSON编码
+func GetRaw(data []byte, path string) ([]byte, error) {
+	v, err := Get(data, path)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// GetString 按路径取字符串，不存在时返回def
+func GetString(data []byte, path string, def ...string) string {
+	v, err := Get(data, path)
+	if err != nil || v == nil {
+		if len(def) > 0 {
+			return def[0]
+		}
+		return ""
+	}
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+	return convert.ToString(v)
+}
+
+// GetInt64 按路径取整数，不存在或无法转换时返回def
+func GetInt64(data []byte, path string, def ...int64) int64 {
+	v, err := Get(data, path)
+	if err != nil {
+		if len(def) > 0 {
+			return def[0]
+		}
+		return 0
+	}
+	return convert.ToInt64(v, def...)
+}
+
+// GetFloat 按路径取浮点数，不存在或无法转换时返回def
+func GetFloat(data []byte, path string, def ...float64) float64 {
+	v, err := Get(data, path)
+	if err != nil {
+		if len(def) > 0 {
+			return def[0]
+		}
+		return 0
+	}
+	return convert.ToFloat(v, def...)
+}
+
+// GetBool 按路径取bool，不存在或无法转换时返回def
+func GetBool(data []byte, path string, def ...bool) bool {
+	v, err := Get(data, path)
+	if err != nil {
+		if len(def) > 0 {
+			return def[0]
+		}
+		return false
+	}
+	return convert.ToBool(v, def...)
+}
+
+// GetInto 按路径取值并宽松反序列化到v
+func GetInto(data []byte, path string, v interface{}) error {
+	raw, err := GetRaw(data, path)
+	if err != nil {
+		return err
+	}
+	return UnmarshalTolerant(raw, v)
+}
+
+// parsePath 将 "a.b[0].c" 拆分为 ["a", "b", "0", "c"]
+func parsePath(path string) ([]string, error) {
+	var keys []string
+	var b strings.Builder
+	flush := func() {
+		if b.Len() > 0 {
+			keys = append(keys, b.String())
+			b.Reset()
+		}
+	}
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case '.':
+			flush()
+		case '[':
+			flush()
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("jsonx: invalid path %q: missing ]", path)
+			}
+			idx := path[i+1 : i+end]
+			if _, err := strconv.Atoi(idx); err != nil {
+				return nil, fmt.Errorf("jsonx: invalid path %q: bad index %q", path, idx)
+			}
+			keys = append(keys, idx)
+			i += end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	flush()
+	return keys, nil
+}
@@ -0,0 +1,120 @@
+package jsonx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DecodeArray 流式解码JSON数组，每个元素调用一次fn，fn内通过dec.Decode读取当前元素，
+// 适合处理无法整体载入内存的大数组；fn返回错误时立即终止
+func DecodeArray(r io.Reader, fn func(dec *json.Decoder) error) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		if err := fn(dec); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+// DecodeArrayOf 流式解码JSON数组，每个元素解码到newItem返回的对象后调用fn
+func DecodeArrayOf(r io.Reader, newItem func() interface{}, fn func(item interface{}) error) error {
+	return DecodeArray(r, func(dec *json.Decoder) error {
+		item := newItem()
+		if err := dec.Decode(item); err != nil {
+			return err
+		}
+		return fn(item)
+	})
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("jsonx: expected %q, got %v", want, tok)
+	}
+	return nil
+}
+
+var (
+	ErrElementTooLarge   = errors.New("jsonx: array element too large")
+	ErrTooManyElements   = errors.New("jsonx: too many array elements")
+	errElementNotDecoded = errors.New("jsonx: element not decoded")
+)
+
+// StreamOptions StreamArray的限制，零值表示不限制
+type StreamOptions struct {
+	MaxElementBytes int64 //单个元素的最大字节数，超出返回ErrElementTooLarge，用于限制内存占用
+	MaxElements     int   //最多元素个数，超出返回ErrTooManyElements
+}
+
+// StreamArray 逐个元素流式处理JSON数组，fn内通过dec.Decode读取当前元素(每次必须读取一个元素)。
+// fn同步执行，处理完成前不会继续读取输入；ctx结束时在元素之间及读取输入前终止。
+// 内存占用取决于单个元素的大小而非整个数组，可用o.MaxElementBytes限制。
+// 错误包含出错元素的下标，可用errors.Is判断原始错误
+func StreamArray(ctx context.Context, r io.Reader, o *StreamOptions, fn func(dec *json.Decoder) error) error {
+	if o == nil {
+		o = &StreamOptions{}
+	}
+	sr := &streamReader{ctx: ctx, r: r, max: o.MaxElementBytes}
+	dec := json.NewDecoder(sr)
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for i := 0; ; i++ {
+		//元素的大小从上一个元素结束处开始计算
+		sr.start = dec.InputOffset()
+		if !dec.More() {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if o.MaxElements > 0 && i >= o.MaxElements {
+			return ErrTooManyElements
+		}
+		if err := fn(dec); err != nil {
+			return fmt.Errorf("jsonx: element %d: %w", i, err)
+		}
+		if dec.InputOffset() == sr.start {
+			return fmt.Errorf("jsonx: element %d: %w", i, errElementNotDecoded)
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+// streamReader 每次读取前检查ctx，并限制单个元素可读取的字节数
+type streamReader struct {
+	ctx   context.Context
+	r     io.Reader
+	max   int64
+	start int64 //当前元素的起始偏移
+	n     int64 //已读取的字节数
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	if err := s.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if s.max > 0 {
+		remain := s.start + s.max - s.n
+		if remain <= 0 {
+			return 0, ErrElementTooLarge
+		}
+		if int64(len(p)) > remain {
+			p = p[:remain]
+		}
+	}
+	n, err := s.r.Read(p)
+	s.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,271 @@
+package jsonx
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"github.com/liuxy92/golib/convert"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// UnmarshalTolerant 宽松反序列化：数字可以是带引号的字符串，bool可以是1/0、"yes"/"no"等，
+// 字符串字段可以接收数字，单个值可以填充到切片，空字符串视为零值
+func UnmarshalTolerant(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("jsonx: UnmarshalTolerant(non-pointer %T)", v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	return assign(rv.Elem(), raw, "")
+}
+
+func assign(dst reflect.Value, src interface{}, path string) error {
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assign(dst.Elem(), src, path)
+	}
+
+	switch dst.Type() {
+	case timeType:
+		t, err := convert.ToTimeE(src)
+		if err != nil {
+			return typeError(path, src, dst.Type())
+		}
+		dst.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		if s, ok := src.(string); ok {
+			if d, err := time.ParseDuration(s); err == nil {
+				dst.SetInt(int64(d))
+				return nil
+			}
+		}
+	}
+
+	// 自定义解码优先
+	if dst.CanAddr() {
+		addr := dst.Addr()
+		if addr.Type().Implements(jsonUnmarshalerType) {
+			b, err := json.Marshal(src)
+			if err != nil {
+				return err
+			}
+			return addr.Interface().(json.Unmarshaler).UnmarshalJSON(b)
+		}
+		if s, ok := src.(string); ok && addr.Type().Implements(textUnmarshalerType) {
+			return addr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		}
+	}
+
+	if s, ok := src.(string); ok && strings.TrimSpace(s) == "" && dst.Kind() != reflect.String {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if dst.NumMethod() != 0 {
+			return typeError(path, src, dst.Type())
+		}
+		dst.Set(reflect.ValueOf(plain(src)))
+	case reflect.String:
+		switch s := src.(type) {
+		case string:
+			dst.SetString(s)
+		case json.Number, bool:
+			dst.SetString(convert.ToString(s))
+		default:
+			return typeError(path, src, dst.Type())
+		}
+	case reflect.Bool:
+		b, err := convert.ToBoolE(src)
+		if err != nil {
+			return typeError(path, src, dst.Type())
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := convert.ToInt64E(src)
+		if err != nil || dst.OverflowInt(n) {
+			return typeError(path, src, dst.Type())
+		}
+		dst.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := convert.ToInt64E(src)
+		if err != nil || n < 0 || dst.OverflowUint(uint64(n)) {
+			return typeError(path, src, dst.Type())
+		}
+		dst.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := convert.ToFloatE(src)
+		if err != nil || dst.OverflowFloat(f) {
+			return typeError(path, src, dst.Type())
+		}
+		dst.SetFloat(f)
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			// []byte 保持encoding/json的base64语义
+			b, _ := json.Marshal(src)
+			return json.Unmarshal(b, dst.Addr().Interface())
+		}
+		arr, ok := src.([]interface{})
+		if !ok {
+			arr = []interface{}{src}
+		}
+		s := reflect.MakeSlice(dst.Type(), len(arr), len(arr))
+		for i, e := range arr {
+			if err := assign(s.Index(i), e, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(s)
+	case reflect.Array:
+		arr, ok := src.([]interface{})
+		if !ok {
+			return typeError(path, src, dst.Type())
+		}
+		for i := 0; i < dst.Len(); i++ {
+			if i < len(arr) {
+				if err := assign(dst.Index(i), arr[i], fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			} else {
+				dst.Index(i).Set(reflect.Zero(dst.Type().Elem()))
+			}
+		}
+	case reflect.Map:
+		obj, ok := src.(map[string]interface{})
+		if !ok {
+			return typeError(path, src, dst.Type())
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(dst.Type(), len(obj)))
+		}
+		kt := dst.Type().Key()
+		for k, e := range obj {
+			kv := reflect.New(kt).Elem()
+			if err := assign(kv, k, joinPath(path, k)); err != nil {
+				return err
+			}
+			ev := reflect.New(dst.Type().Elem()).Elem()
+			if err := assign(ev, e, joinPath(path, k)); err != nil {
+				return err
+			}
+			dst.SetMapIndex(kv, ev)
+		}
+	case reflect.Struct:
+		obj, ok := src.(map[string]interface{})
+		if !ok {
+			return typeError(path, src, dst.Type())
+		}
+		return assignStruct(dst, obj, path)
+	default:
+		return typeError(path, src, dst.Type())
+	}
+	return nil
+}
+
+func assignStruct(dst reflect.Value, obj map[string]interface{}, path string) error {
+	rt := dst.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if sf.Anonymous && name == "" {
+			fv := dst.Field(i)
+			if fv.Kind() == reflect.Ptr && sf.Type.Elem().Kind() == reflect.Struct {
+				if fv.IsNil() {
+					if !sf.IsExported() {
+						continue
+					}
+					fv.Set(reflect.New(sf.Type.Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := assignStruct(fv, obj, path); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		val, ok := obj[name]
+		if !ok {
+			// 与encoding/json一致，大小写不敏感匹配
+			for k, v := range obj {
+				if strings.EqualFold(k, name) {
+					val, ok = v, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		if err := assign(dst.Field(i), val, joinPath(path, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// plain 将json.Number还原为float64，与encoding/json解码到interface{}的结果保持一致
+func plain(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		f, _ := x.Float64()
+		return f
+	case []interface{}:
+		for i := range x {
+			x[i] = plain(x[i])
+		}
+	case map[string]interface{}:
+		for k := range x {
+			x[k] = plain(x[k])
+		}
+	}
+	return v
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func typeError(path string, src interface{}, t reflect.Type) error {
+	if path == "" {
+		return fmt.Errorf("jsonx: cannot unmarshal %T %v into %s", src, src, t)
+	}
+	return fmt.Errorf("jsonx: cannot unmarshal %T %v into field %s of type %s", src, src, path, t)
+}
@@ -0,0 +1,218 @@
+package netx
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDialTimeout = 10 * time.Second
+	defaultKeepAlive   = 30 * time.Second
+)
+
+// DialerOptions 代理与DNS覆盖配置
+type DialerOptions struct {
+	Rules     []Rule            //按顺序匹配，第一条匹配的规则生效
+	Env       bool              //没有规则匹配时使用HTTP_PROXY、HTTPS_PROXY、NO_PROXY环境变量
+	Hosts     map[string]string //DNS覆盖，主机名到IP，用于直连及代理服务器地址，经代理的目标主机由代理解析
+	Timeout   time.Duration     //建立连接超时，默认10s
+	KeepAlive time.Duration     //默认30s
+	ProxyTLS  *tls.Config       //https代理的TLS配置，nil时使用系统根证书，ServerName默认为代理主机名
+}
+
+// Dialer 按目标主机选择直连、HTTP代理或SOCKS5代理的拨号器，可用于http.Transport
+type Dialer struct {
+	rules    []*rule
+	env      func(*url.URL) (*url.URL, error)
+	hosts    map[string]string
+	base     *net.Dialer
+	proxyTLS *tls.Config
+}
+
+func NewDialer(o *DialerOptions) (*Dialer, error) {
+	if o == nil {
+		o = &DialerOptions{}
+	}
+	d := &Dialer{hosts: make(map[string]string, len(o.Hosts)), base: &net.Dialer{Timeout: o.Timeout, KeepAlive: o.KeepAlive}, proxyTLS: o.ProxyTLS}
+	if d.base.Timeout <= 0 {
+		d.base.Timeout = defaultDialTimeout
+	}
+	if d.base.KeepAlive == 0 {
+		d.base.KeepAlive = defaultKeepAlive
+	}
+	for _, r := range o.Rules {
+		pr, err := parseRule(r)
+		if err != nil {
+			return nil, err
+		}
+		d.rules = append(d.rules, pr)
+	}
+	if o.Env {
+		d.env = httpproxy.FromEnvironment().ProxyFunc()
+	}
+	for host, ip := range o.Hosts {
+		if net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("netx: invalid ip %q for host %q", ip, host)
+		}
+		d.hosts[strings.ToLower(host)] = ip
+	}
+	return d, nil
+}
+
+// ProxyFor 目标地址使用的代理，nil表示直连。scheme为目标请求的协议，用于匹配环境变量
+func (d *Dialer) ProxyFor(scheme, addr string) (*url.URL, error) {
+	host := splitHost(addr)
+	for _, r := range d.rules {
+		if r.match(host) {
+			return r.proxy, nil
+		}
+	}
+	if d.env != nil {
+		return d.env(&url.URL{Scheme: scheme, Host: addr})
+	}
+	return nil, nil
+}
+
+// Proxy 用作http.Transport.Proxy
+func (d *Dialer) Proxy(req *http.Request) (*url.URL, error) {
+	return d.ProxyFor(req.URL.Scheme, canonicalAddr(req.URL))
+}
+
+// DialContext 建立TCP连接，经代理时HTTP代理使用CONNECT隧道。环境变量按https目标匹配
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	p, err := d.ProxyFor("https", addr)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return d.dialDirect(ctx, network, addr)
+	}
+	switch p.Scheme {
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if p.User != nil {
+			pass, _ := p.User.Password()
+			auth = &proxy.Auth{User: p.User.Username(), Password: pass}
+		}
+		sd, err := proxy.SOCKS5("tcp", p.Host, auth, directDialer{d})
+		if err != nil {
+			return nil, err
+		}
+		return sd.(proxy.ContextDialer).DialContext(ctx, network, addr)
+	}
+	return d.dialConnect(ctx, p, addr)
+}
+
+// Transport 使用该拨号器的http.Transport，其余参数与http.DefaultTransport相同
+func (d *Dialer) Transport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = d.Proxy
+	//经代理时http.Transport只拨号到代理服务器，由DNS覆盖解析代理地址
+	t.DialContext = d.dialDirect
+	return t
+}
+
+func (d *Dialer) dialDirect(ctx context.Context, network, addr string) (net.Conn, error) {
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		if ip, ok := d.hosts[strings.ToLower(host)]; ok {
+			addr = net.JoinHostPort(ip, port)
+		}
+	}
+	return d.base.DialContext(ctx, network, addr)
+}
+
+// dialConnect 通过HTTP代理的CONNECT建立隧道，https代理先完成TLS握手，认证信息不以明文发送
+func (d *Dialer) dialConnect(ctx context.Context, p *url.URL, addr string) (net.Conn, error) {
+	conn, err := d.dialDirect(ctx, "tcp", canonicalAddr(p))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	if p.Scheme == "https" {
+		cfg := &tls.Config{}
+		if d.proxyTLS != nil {
+			cfg = d.proxyTLS.Clone()
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = p.Hostname()
+		}
+		tc := tls.Client(conn, cfg)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("netx: tls handshake with proxy %s: %w", p.Host, err)
+		}
+		conn = tc
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if p.User != nil {
+		pass, _ := p.User.Password()
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(p.User.Username()+":"+pass)))
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("netx: connect via %s: %w", p.Host, err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("netx: connect via %s: %w", p.Host, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("netx: connect via %s: %s", p.Host, resp.Status)
+	}
+	if br.Buffered() > 0 {
+		//代理不应在200之后立即发送数据
+		conn.Close()
+		return nil, fmt.Errorf("netx: connect via %s: unexpected data after response", p.Host)
+	}
+	return conn, nil
+}
+
+// canonicalAddr 补全默认端口
+func canonicalAddr(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := "80"
+	switch u.Scheme {
+	case "https":
+		port = "443"
+	case "socks5", "socks5h":
+		port = "1080"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// directDialer SOCKS5代理服务器使用直连及DNS覆盖
+type directDialer struct {
+	d *Dialer
+}
+
+func (x directDialer) Dial(network, addr string) (net.Conn, error) {
+	return x.d.dialDirect(context.Background(), network, addr)
+}
+
+func (x directDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return x.d.dialDirect(ctx, network, addr)
+}
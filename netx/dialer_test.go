@@ -0,0 +1,264 @@
+package netx
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newTarget(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func listen(t *testing.T, serve func(net.Conn)) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func pipe(a, b net.Conn) {
+	go func() {
+		_, _ = io.Copy(a, b)
+		a.Close()
+	}()
+	_, _ = io.Copy(b, a)
+	b.Close()
+}
+
+// connectProxy 处理CONNECT的HTTP代理，返回地址及收到的Proxy-Authorization
+func connectProxy(t *testing.T) (string, *atomic.Value) {
+	var auth atomic.Value
+	addr := listen(t, func(conn net.Conn) {
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil || req.Method != http.MethodConnect {
+			conn.Close()
+			return
+		}
+		auth.Store(req.Header.Get("Proxy-Authorization"))
+		target, err := net.Dial("tcp", req.Host)
+		if err != nil {
+			_, _ = io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+			conn.Close()
+			return
+		}
+		_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		pipe(conn, target)
+	})
+	return addr, &auth
+}
+
+// socks5Proxy 只支持无认证CONNECT的SOCKS5代理
+func socks5Proxy(t *testing.T, used *atomic.Bool) string {
+	return listen(t, func(conn net.Conn) {
+		buf := make([]byte, 262)
+		//版本及认证方式
+		if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+			conn.Close()
+			return
+		}
+		_, _ = io.ReadFull(conn, buf[:buf[1]])
+		_, _ = conn.Write([]byte{5, 0})
+		//请求：VER CMD RSV ATYP
+		if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+			conn.Close()
+			return
+		}
+		var host string
+		switch buf[3] {
+		case 1:
+			_, _ = io.ReadFull(conn, buf[:4])
+			host = net.IP(buf[:4]).String()
+		case 3:
+			_, _ = io.ReadFull(conn, buf[:1])
+			n := int(buf[0])
+			_, _ = io.ReadFull(conn, buf[:n])
+			host = string(buf[:n])
+		}
+		_, _ = io.ReadFull(conn, buf[:2])
+		port := binary.BigEndian.Uint16(buf[:2])
+		target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+		if err != nil {
+			_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+			conn.Close()
+			return
+		}
+		used.Store(true)
+		_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+		pipe(conn, target)
+	})
+}
+
+func get(t *testing.T, client *http.Client, url string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "ok" {
+		t.Fatalf("body: %q", body)
+	}
+}
+
+func TestDialHostsOverride(t *testing.T) {
+	target := newTarget(t)
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(target.URL, "http://"))
+	d, err := NewDialer(&DialerOptions{Hosts: map[string]string{"api.internal": "127.0.0.1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get(t, &http.Client{Transport: d.Transport()}, "http://api.internal:"+port+"/")
+}
+
+func TestDialHTTPConnect(t *testing.T) {
+	target := newTarget(t)
+	proxyAddr, auth := connectProxy(t)
+	d, err := NewDialer(&DialerOptions{Rules: []Rule{
+		{Host: "10.0.0.0/8", Proxy: "direct"},
+		{Host: "127.0.0.1", Proxy: "http://user:pass@" + proxyAddr},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get(t, &http.Client{Transport: &http.Transport{DialContext: d.DialContext}}, target.URL)
+	if got, _ := auth.Load().(string); got != "Basic dXNlcjpwYXNz" {
+		t.Errorf("proxy auth: %q", got)
+	}
+}
+
+func TestDialHTTPSConnect(t *testing.T) {
+	target := newTarget(t)
+	var auth atomic.Value
+	proxySrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "connect only", http.StatusMethodNotAllowed)
+			return
+		}
+		auth.Store(r.Header.Get("Proxy-Authorization"))
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		pipe(conn, upstream)
+	}))
+	t.Cleanup(proxySrv.Close)
+	proxyURL := "https://user:pass@" + strings.TrimPrefix(proxySrv.URL, "https://")
+	rules := []Rule{{Host: "127.0.0.1", Proxy: proxyURL}}
+
+	//不信任代理证书时握手失败，认证信息不会发出
+	d, err := NewDialer(&DialerOptions{Rules: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.DialContext(context.Background(), "tcp", strings.TrimPrefix(target.URL, "http://")); err == nil {
+		t.Fatal("expected tls handshake error")
+	}
+	if auth.Load() != nil {
+		t.Fatal("credentials sent before tls handshake")
+	}
+
+	d, err = NewDialer(&DialerOptions{Rules: rules, ProxyTLS: proxySrv.Client().Transport.(*http.Transport).TLSClientConfig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get(t, &http.Client{Transport: &http.Transport{DialContext: d.DialContext}}, target.URL)
+	if got, _ := auth.Load().(string); got != "Basic dXNlcjpwYXNz" {
+		t.Errorf("proxy auth: %q", got)
+	}
+}
+
+func TestDialSOCKS5(t *testing.T) {
+	target := newTarget(t)
+	var used atomic.Bool
+	proxyAddr := socks5Proxy(t, &used)
+	d, _ := NewDialer(&DialerOptions{Rules: []Rule{{Host: "*", Proxy: "socks5://" + proxyAddr}}})
+
+	conn, err := d.DialContext(context.Background(), "tcp", strings.TrimPrefix(target.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, _ = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: x\r\nConnection: close\r\n\r\n")
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil || resp.StatusCode != http.StatusOK || !used.Load() {
+		t.Fatalf("%v %v %v", resp, err, used.Load())
+	}
+}
+
+func TestProxyFor(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://env-proxy:3128")
+	t.Setenv("HTTPS_PROXY", "http://env-proxy:3129")
+	t.Setenv("NO_PROXY", "internal.example")
+	d, err := NewDialer(&DialerOptions{
+		Env:   true,
+		Rules: []Rule{{Host: "*.corp.example", Proxy: "socks5://corp-proxy:1080"}, {Host: "direct.example"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		scheme, addr, want string
+	}{
+		{"http", "git.corp.example:80", "socks5://corp-proxy:1080"},
+		{"https", "direct.example:443", ""},
+		{"http", "api.example.com:80", "http://env-proxy:3128"},
+		{"https", "api.example.com:443", "http://env-proxy:3129"},
+		{"https", "internal.example:443", ""},
+	}
+	for _, c := range cases {
+		u, err := d.ProxyFor(c.scheme, c.addr)
+		got := ""
+		if u != nil {
+			got = u.String()
+		}
+		if err != nil || got != c.want {
+			t.Errorf("%s %s: got %q %v, want %q", c.scheme, c.addr, got, err, c.want)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "https://git.corp.example/x", nil)
+	if u, _ := d.Transport().Proxy(req); u == nil || u.Host != "corp-proxy:1080" {
+		t.Errorf("transport proxy: %v", u)
+	}
+}
+
+func TestNewDialerInvalid(t *testing.T) {
+	if _, err := NewDialer(&DialerOptions{Hosts: map[string]string{"a": "not-ip"}}); err == nil {
+		t.Error("expected invalid ip error")
+	}
+	if _, err := NewDialer(&DialerOptions{Rules: []Rule{{Host: "*", Proxy: "ftp://x"}}}); err == nil {
+		t.Error("expected invalid proxy error")
+	}
+}
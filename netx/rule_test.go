@@ -0,0 +1,47 @@
+package netx
+
+import "testing"
+
+func TestRuleMatch(t *testing.T) {
+	cases := []struct {
+		pattern, host string
+		want          bool
+	}{
+		{"example.com", "example.com", true},
+		{"example.com", "Example.COM.", true},
+		{"example.com", "api.example.com", false},
+		{"*.example.com", "api.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"*.example.com", "badexample.com", false},
+		{"10.0.0.0/8", "10.1.2.3", true},
+		{"10.0.0.0/8", "11.1.2.3", false},
+		{"10.0.0.0/8", "::ffff:10.1.2.3", true},
+		{"10.0.0.0/8", "example.com", false},
+		{"*", "anything", true},
+	}
+	for _, c := range cases {
+		r, err := parseRule(Rule{Host: c.pattern})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.match(c.host); got != c.want {
+			t.Errorf("%s %s: got %v", c.pattern, c.host, got)
+		}
+	}
+}
+
+func TestParseRuleInvalid(t *testing.T) {
+	for _, r := range []Rule{
+		{Host: ""},
+		{Host: "10.0.0.0/33"},
+		{Host: "*", Proxy: "ftp://proxy"},
+		{Host: "*", Proxy: "http://"},
+	} {
+		if _, err := parseRule(r); err == nil {
+			t.Errorf("%+v: expected error", r)
+		}
+	}
+	if r, err := parseRule(Rule{Host: "*", Proxy: "direct"}); err != nil || r.proxy != nil {
+		t.Errorf("direct: %+v %v", r, err)
+	}
+}
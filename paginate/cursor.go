@@ -0,0 +1,92 @@
+package paginate
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidCursor 游标被篡改、已过期或不属于当前排序方式
+var ErrInvalidCursor = errors.New("paginate: invalid cursor")
+
+// Codec 加密游标，客户端只能原样传回，无法解读或构造
+type Codec struct {
+	aead cipher.AEAD
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// NewCodec secret至少16字节，经SHA-256派生AES-256-GCM密钥。ttl大于0时游标在签发ttl后失效
+func NewCodec(secret []byte, ttl time.Duration) (*Codec, error) {
+	if len(secret) < 16 {
+		return nil, errors.New("paginate: secret must be at least 16 bytes")
+	}
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{aead: aead, ttl: ttl, now: time.Now}, nil
+}
+
+type payload struct {
+	Values []interface{} `json:"v"`
+	Issued int64         `json:"t"`
+}
+
+// Encode 加密排序键值，scope(如排序方式)作为附加数据参与认证，解码时需一致
+func (c *Codec) Encode(scope string, values []interface{}) (string, error) {
+	plain, err := json.Marshal(payload{Values: values, Issued: c.now().Unix()})
+	if err != nil {
+		return "", fmt.Errorf("paginate: encode cursor: %w", err)
+	}
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plain)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, plain, []byte(scope))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decode 解密游标。整数还原为int64，其他数字及字符串(如time.Time编码后的RFC3339)以字符串返回
+func (c *Codec) Decode(scope, cursor string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(data) < c.aead.NonceSize() {
+		return nil, ErrInvalidCursor
+	}
+	n := c.aead.NonceSize()
+	plain, err := c.aead.Open(nil, data[:n], data[n:], []byte(scope))
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var p payload
+	dec := json.NewDecoder(bytes.NewReader(plain))
+	dec.UseNumber()
+	if err := dec.Decode(&p); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.ttl > 0 && c.now().Sub(time.Unix(p.Issued, 0)) > c.ttl {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidCursor)
+	}
+	for i, v := range p.Values {
+		if num, ok := v.(json.Number); ok {
+			if n, err := num.Int64(); err == nil {
+				p.Values[i] = n
+			} else {
+				p.Values[i] = num.String()
+			}
+		}
+	}
+	return p.Values, nil
+}
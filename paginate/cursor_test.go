@@ -0,0 +1,75 @@
+package paginate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCodec(t *testing.T, ttl time.Duration) *Codec {
+	c, err := NewCodec([]byte("0123456789abcdef"), ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	c := newCodec(t, 0)
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 123, time.UTC)
+	cursor, err := c.Encode("id DESC", []interface{}{int64(9007199254740993), "abc", ts, 1.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(cursor, "abc") {
+		t.Error("cursor should be opaque")
+	}
+	values, err := c.Decode("id DESC", cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{int64(9007199254740993), "abc", ts.Format(time.RFC3339Nano), "1.5"}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("got %#v", values)
+	}
+}
+
+func TestCodecInvalid(t *testing.T) {
+	c := newCodec(t, 0)
+	cursor, _ := c.Encode("id", []interface{}{1})
+	tampered := []byte(cursor)
+	tampered[len(tampered)-2] ^= 1
+	for _, in := range []string{"", "not-base64!", "AAAA", string(tampered)} {
+		if _, err := c.Decode("id", in); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%q: %v", in, err)
+		}
+	}
+	//排序方式不同的游标不能使用
+	if _, err := c.Decode("id DESC", cursor); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("scope: %v", err)
+	}
+	other, _ := NewCodec([]byte("fedcba9876543210"), 0)
+	if _, err := other.Decode("id", cursor); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("other key: %v", err)
+	}
+	if _, err := NewCodec([]byte("short"), 0); err == nil {
+		t.Error("expected short secret error")
+	}
+}
+
+func TestCodecTTL(t *testing.T) {
+	c := newCodec(t, time.Hour)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	cursor, _ := c.Encode("id", []interface{}{1})
+	now = now.Add(59 * time.Minute)
+	if _, err := c.Decode("id", cursor); err != nil {
+		t.Errorf("within ttl: %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := c.Decode("id", cursor); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expired: %v", err)
+	}
+}
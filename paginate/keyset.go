@@ -0,0 +1,128 @@
+package paginate
+
+import (
+	"errors"
+	"fmt"
+	"github.com/liuxy92/golib/sqlbuilder"
+	"strings"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 1000
+)
+
+// Key 排序键。最后一个Key必须唯一(通常为主键)，否则相同排序值的行可能被跳过或重复
+type Key struct {
+	Column string
+	Desc   bool
+}
+
+// Paginator keyset(seek)分页：以上一页最后一行的排序键值作为条件查询下一页，
+// 代替 OFFSET 以避免深分页时扫描并丢弃大量行
+type Paginator struct {
+	codec *Codec
+	keys  []Key
+	limit int
+	scope string
+}
+
+// New 创建分页器，limit小于等于0时为20，最大1000
+func New(codec *Codec, limit int, keys ...Key) (*Paginator, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("paginate: no sort keys")
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		if k.Column == "" {
+			return nil, errors.New("paginate: empty sort column")
+		}
+		parts[i] = k.Column
+		if k.Desc {
+			parts[i] += " DESC"
+		}
+	}
+	return &Paginator{codec: codec, keys: keys, limit: limit, scope: strings.Join(parts, ",")}, nil
+}
+
+// Limit 每页行数，查询时应多取一行(Limit()+1)用于判断是否有下一页，Apply会自动设置
+func (p *Paginator) Limit() int {
+	return p.limit
+}
+
+// OrderBy ORDER BY子句的各项，如 ["created_at DESC", "id DESC"]
+func (p *Paginator) OrderBy() []string {
+	return strings.Split(p.scope, ",")
+}
+
+// Cond 游标对应的查询条件，cursor为空(第一页)时返回nil。
+// 对于 (a DESC, id ASC) 生成 a < ? OR (a = ? AND id > ?)
+func (p *Paginator) Cond(cursor string) (sqlbuilder.Cond, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	values, err := p.codec.Decode(p.scope, cursor)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != len(p.keys) {
+		return nil, ErrInvalidCursor
+	}
+	var ors []sqlbuilder.Cond
+	for i, k := range p.keys {
+		ands := make([]sqlbuilder.Cond, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, sqlbuilder.Eq(p.keys[j].Column, values[j]))
+		}
+		if k.Desc {
+			ands = append(ands, sqlbuilder.Lt(k.Column, values[i]))
+		} else {
+			ands = append(ands, sqlbuilder.Gt(k.Column, values[i]))
+		}
+		ors = append(ors, sqlbuilder.And(ands...))
+	}
+	return sqlbuilder.Or(ors...), nil
+}
+
+// Where 游标条件的SQL片段及参数(?占位符)，第一页时sql为空，用于GORM等其他查询方式，
+// 如 db.Where(sql, args...).Order(strings.Join(p.OrderBy(), ", ")).Limit(p.Limit()+1)
+func (p *Paginator) Where(cursor string) (string, []interface{}, error) {
+	c, err := p.Cond(cursor)
+	if err != nil {
+		return "", nil, err
+	}
+	return sqlbuilder.ToSQL(c)
+}
+
+// Apply 在查询上追加游标条件、排序及 LIMIT Limit()+1
+func (p *Paginator) Apply(s *sqlbuilder.SelectBuilder, cursor string) error {
+	c, err := p.Cond(cursor)
+	if err != nil {
+		return err
+	}
+	s.Where(c).OrderBy(p.OrderBy()...).Limit(p.limit + 1)
+	return nil
+}
+
+// Next 根据查询到的行数生成下一页游标。fetched为实际返回的行数，keyValues返回第i行的排序键值(顺序同Key)。
+// 返回本页应返回给客户端的行数(去掉多取的一行)及下一页游标，没有下一页时游标为空
+func (p *Paginator) Next(fetched int, keyValues func(i int) []interface{}) (n int, next string, err error) {
+	if fetched <= p.limit {
+		return fetched, "", nil
+	}
+	values := keyValues(p.limit - 1)
+	if len(values) != len(p.keys) {
+		return 0, "", fmt.Errorf("paginate: got %d key values, want %d", len(values), len(p.keys))
+	}
+	next, err = p.codec.Encode(p.scope, values)
+	if err != nil {
+		return 0, "", err
+	}
+	return p.limit, next, nil
+}
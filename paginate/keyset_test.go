@@ -0,0 +1,110 @@
+package paginate
+
+import (
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/liuxy92/golib/sqlbuilder"
+	"reflect"
+	"testing"
+)
+
+func TestCond(t *testing.T) {
+	c := newCodec(t, 0)
+	p, err := New(c, 10, Key{Column: "score", Desc: true}, Key{Column: "id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond, err := p.Cond(""); cond != nil || err != nil {
+		t.Errorf("first page: %v %v", cond, err)
+	}
+	cursor, _ := c.Encode(p.scope, []interface{}{int64(90), int64(7)})
+	sql, args, err := p.Where(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "(score < ? OR (score = ? AND id > ?))" || !reflect.DeepEqual(args, []interface{}{int64(90), int64(90), int64(7)}) {
+		t.Errorf("got %s %v", sql, args)
+	}
+	//键值个数不符
+	bad, _ := c.Encode(p.scope, []interface{}{1})
+	if _, err := p.Cond(bad); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	c := newCodec(t, 0)
+	if _, err := New(c, 10); err == nil {
+		t.Error("expected no keys error")
+	}
+	if _, err := New(c, 10, Key{}); err == nil {
+		t.Error("expected empty column error")
+	}
+	if p, _ := New(c, 5000, Key{Column: "id"}); p.Limit() != maxLimit {
+		t.Errorf("limit: %d", p.Limit())
+	}
+}
+
+func TestPaginateWithDB(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	p, _ := New(newCodec(t, 0), 2, Key{Column: "created_at", Desc: true}, Key{Column: "id", Desc: true})
+
+	type row struct {
+		id        int64
+		createdAt int64
+	}
+	query := func(cursor string, want string, wantArgs []driver.Value, result []row) (rows []row, next string) {
+		t.Helper()
+		s := sqlbuilder.Select("id", "created_at").From("posts").Where(sqlbuilder.Eq("status", 1))
+		if err := p.Apply(s, cursor); err != nil {
+			t.Fatal(err)
+		}
+		sql, args, err := s.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		mockRows := sqlmock.NewRows([]string{"id", "created_at"})
+		for _, r := range result {
+			mockRows.AddRow(r.id, r.createdAt)
+		}
+		mock.ExpectQuery(want).WithArgs(wantArgs...).WillReturnRows(mockRows)
+
+		rs, err := db.Query(sql, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rs.Close()
+		for rs.Next() {
+			var r row
+			_ = rs.Scan(&r.id, &r.createdAt)
+			rows = append(rows, r)
+		}
+		n, next, err := p.Next(len(rows), func(i int) []interface{} { return []interface{}{rows[i].createdAt, rows[i].id} })
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rows[:n], next
+	}
+
+	rows, next := query("",
+		"SELECT id, created_at FROM posts WHERE status = ? ORDER BY created_at DESC, id DESC LIMIT 3",
+		[]driver.Value{int64(1)},
+		[]row{{5, 300}, {4, 300}, {3, 200}})
+	if len(rows) != 2 || next == "" {
+		t.Fatalf("page 1: %v %q", rows, next)
+	}
+	rows, next = query(next,
+		"SELECT id, created_at FROM posts WHERE status = ? AND (created_at < ? OR (created_at = ? AND id < ?)) ORDER BY created_at DESC, id DESC LIMIT 3",
+		[]driver.Value{int64(1), int64(300), int64(300), int64(4)},
+		[]row{{3, 200}})
+	if len(rows) != 1 || next != "" {
+		t.Fatalf("page 2: %v %q", rows, next)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
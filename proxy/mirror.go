@@ -0,0 +1,129 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultMirrorHeader      = "X-Mirrored-Request"
+	defaultMirrorBodySize    = 1 << 20
+	defaultMirrorTimeout     = 5 * time.Second
+	defaultMirrorConcurrency = 100
+	mirrorDrainSize          = 64 << 10 //丢弃影子响应时最多读取的字节数，读完的连接可以复用
+)
+
+// Mirror 影子流量：按比例将请求复制一份异步发送到影子上游，忽略其响应，用于验证新版本服务。
+// 影子请求使用与主请求相同的路径改写及请求头处理，不影响主请求的响应
+type Mirror struct {
+	Upstream    string                     //影子上游地址，格式同Upstreams
+	Percent     float64                    //复制的请求比例，0~100
+	Sample      func(r *http.Request) bool //自定义采样，设置后忽略Percent，如按用户ID灰度
+	Header      string                     //影子请求上附加的标记请求头，值为1，默认X-Mirrored-Request
+	MaxBodySize int64                      //请求体超过该大小时不复制，默认1MB
+	Timeout     time.Duration              //影子请求超时，默认5s
+	Concurrency int                        //同时进行的影子请求上限，超出时丢弃，默认100
+}
+
+type mirror struct {
+	Mirror
+	url *url.URL
+	sem chan struct{}
+}
+
+func newMirror(m *Mirror) (*mirror, error) {
+	u, err := parseUpstream(m.Upstream)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: mirror: %w", err)
+	}
+	if m.Percent < 0 || m.Percent > 100 {
+		return nil, fmt.Errorf("proxy: mirror percent %v out of range [0, 100]", m.Percent)
+	}
+	mm := &mirror{Mirror: *m, url: u.url}
+	if mm.Header == "" {
+		mm.Header = defaultMirrorHeader
+	}
+	if mm.MaxBodySize <= 0 {
+		mm.MaxBodySize = defaultMirrorBodySize
+	}
+	if mm.Timeout <= 0 {
+		mm.Timeout = defaultMirrorTimeout
+	}
+	if mm.Concurrency <= 0 {
+		mm.Concurrency = defaultMirrorConcurrency
+	}
+	mm.sem = make(chan struct{}, mm.Concurrency)
+	return mm, nil
+}
+
+func (m *mirror) sample(r *http.Request) bool {
+	if m.Sample != nil {
+		return m.Sample(r)
+	}
+	return m.Percent > 0 && rand.Float64()*100 < m.Percent
+}
+
+// tee 采样命中时缓存请求体并异步发送影子请求，返回替换了请求体的主请求。
+// req为改写后、尚未填入上游地址的请求
+func (p *Proxy) tee(req *http.Request) *http.Request {
+	m := p.mirror
+	if req.Header.Get("Upgrade") != "" || !m.sample(req) {
+		return req
+	}
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.ContentLength > m.MaxBodySize {
+			return req
+		}
+		buf, err := io.ReadAll(io.LimitReader(req.Body, m.MaxBodySize+1))
+		//已读取的部分需还给主请求
+		rest := io.MultiReader(bytes.NewReader(buf), req.Body)
+		req = req.Clone(req.Context())
+		req.Body = readCloser{rest, req.Body}
+		if err != nil || int64(len(buf)) > m.MaxBodySize {
+			return req
+		}
+		body = buf
+	}
+
+	select {
+	case m.sem <- struct{}{}:
+	default:
+		p.logger().Debugw("proxy: mirror dropped, too many in flight", "path", req.URL.Path)
+		return req
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.Timeout)
+	shadow := req.Clone(ctx)
+	shadow.URL.Scheme = m.url.Scheme
+	shadow.URL.Host = m.url.Host
+	shadow.URL.Path = m.url.Path + req.URL.Path
+	shadow.URL.RawPath = ""
+	shadow.Header.Set(m.Header, "1")
+	shadow.Body, shadow.GetBody, shadow.ContentLength = http.NoBody, nil, 0
+	if body != nil {
+		shadow.Body, shadow.ContentLength = io.NopCloser(bytes.NewReader(body)), int64(len(body))
+	}
+	go func() {
+		defer func() { <-m.sem }()
+		defer cancel()
+		resp, err := p.transport.RoundTrip(shadow)
+		if err != nil {
+			p.logger().Debugw("proxy: mirror request failed", "upstream", m.url.String(), "path", shadow.URL.Path, "error", err)
+			return
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, mirrorDrainSize))
+		_ = resp.Body.Close()
+	}()
+	return req
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
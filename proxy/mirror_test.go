@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mirrored struct {
+	path, body, tag, token string
+}
+
+// shadowServer 记录收到的影子请求，release关闭前阻塞不返回
+func shadowServer(t *testing.T, release chan struct{}) (*httptest.Server, chan mirrored) {
+	got := make(chan mirrored, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- mirrored{r.URL.Path, string(body), r.Header.Get("X-Mirrored-Request"), r.Header.Get("X-Token")}
+		if release != nil {
+			<-release
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+func TestMirror(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Mirrored-Request") != "" {
+			t.Error("primary request must not be tagged")
+		}
+		_, _ = w.Write([]byte("primary:" + r.URL.Path + ":" + string(body)))
+	}))
+	defer primary.Close()
+	shadow, got := shadowServer(t, nil)
+
+	p, err := New(&Options{
+		Upstreams:   []string{primary.URL},
+		StripPrefix: "/api",
+		SetHeaders:  map[string]string{"X-Token": "secret"},
+		Mirror:      &Mirror{Upstream: shadow.URL + "/v2", Percent: 100, MaxBodySize: 8},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve := func(method, path, body string) string {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s: status %d", method, path, w.Code)
+		}
+		return w.Body.String()
+	}
+
+	if out := serve("POST", "/api/orders", "hello"); out != "primary:/orders:hello" {
+		t.Fatalf("primary response = %q", out)
+	}
+	select {
+	case m := <-got:
+		if m != (mirrored{"/v2/orders", "hello", "1", "secret"}) {
+			t.Fatalf("mirrored = %+v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mirrored request")
+	}
+
+	//请求体超过MaxBodySize时不复制，主请求不受影响
+	if out := serve("POST", "/api/big", "0123456789"); out != "primary:/big:0123456789" {
+		t.Fatalf("big body response = %q", out)
+	}
+	serve("GET", "/api/after", "")
+	select {
+	case m := <-got:
+		if m.path != "/v2/after" {
+			t.Fatalf("large body should not be mirrored: %+v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mirrored request")
+	}
+}
+
+func TestMirrorSampling(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer primary.Close()
+	release := make(chan struct{})
+	shadow, got := shadowServer(t, release)
+	defer close(release)
+
+	p, err := New(&Options{
+		Upstreams: []string{primary.URL},
+		Mirror: &Mirror{
+			Upstream:    shadow.URL,
+			Sample:      func(r *http.Request) bool { return r.URL.Query().Get("uid") == "1" },
+			Concurrency: 1,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/?uid=2", "/?uid=1", "/?uid=1", "/?uid=1"} {
+		w := httptest.NewRecorder()
+		start := time.Now()
+		p.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		//影子上游阻塞不影响主请求
+		if w.Code != http.StatusOK || time.Since(start) > time.Second {
+			t.Fatalf("%s: status %d after %v", path, w.Code, time.Since(start))
+		}
+	}
+	<-got
+	//超出Concurrency的影子请求被丢弃
+	select {
+	case m := <-got:
+		t.Fatalf("unexpected mirrored request %+v", m)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	for _, m := range []*Mirror{{Upstream: "bad"}, {Upstream: "http://shadow", Percent: 101}} {
+		if _, err := New(&Options{Upstreams: []string{primary.URL}, Mirror: m}); err == nil {
+			t.Errorf("%+v: expected error", m)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package proxy
+
+import (
+	"fmt"
+	"github.com/liuxy92/golib/zaplog"
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Rewrite 路径改写规则，Match为正则，Replace可引用分组，如 {Match: "^/api/v1/(.*)", Replace: "/v1/$1"}
+type Rewrite struct {
+	Match   string
+	Replace string
+}
+
+// Options 反向代理配置
+type Options struct {
+	Upstreams       []string                                        //上游地址，如 http://10.0.0.1:8080 或带路径前缀的 http://svc/api
+	Balance         string                                          //上游选择方式：failover(默认)按顺序故障转移，roundrobin轮询
+	StripPrefix     string                                          //转发前去掉的路径前缀
+	Rewrites        []Rewrite                                       //在StripPrefix之后按顺序应用，第一条匹配的规则生效
+	SetHeaders      map[string]string                               //注入或覆盖的请求头，如内部鉴权token
+	RemoveHeaders   []string                                        //转发前删除的请求头，如Cookie
+	ResponseHeaders map[string]string                               //注入的响应头
+	PreserveHost    bool                                            //保留客户端请求的Host，默认使用上游地址的Host
+	FlushInterval   time.Duration                                   //响应刷新间隔，负数表示每次写入后立即刷新；SSE及未知长度的响应总是立即刷新
+	HealthCheck     HealthCheck                                     //上游健康检查
+	Transport       http.RoundTripper                               //默认http.DefaultTransport
+	AccessLog       bool                                            //记录访问日志
+	Logger          *zaplog.Logger                                  //访问日志及上游状态日志，默认全局logger
+	ErrorHandler    func(http.ResponseWriter, *http.Request, error) //所有上游均失败时的处理，默认返回502
+	Mirror          *Mirror                                         //影子流量，按比例复制请求到影子上游
+}
+
+type rewriteRule struct {
+	re      *regexp.Regexp
+	replace string
+}
+
+// Proxy 反向代理，支持路径改写、请求头注入、流式响应、上游故障转移、影子流量与访问日志
+type Proxy struct {
+	opts      Options
+	balance   string
+	health    HealthCheck
+	upstreams []*upstream
+	rewrites  []rewriteRule
+	transport http.RoundTripper
+	mirror    *mirror
+	rp        *httputil.ReverseProxy
+	rr        uint64
+}
+
+// New 创建反向代理，开启主动健康检查时需调用StartHealthChecks
+func New(o *Options) (*Proxy, error) {
+	p := &Proxy{opts: *o, balance: o.Balance, health: o.HealthCheck.withDefaults(), transport: o.Transport}
+	switch p.balance {
+	case "":
+		p.balance = BalanceFailover
+	case BalanceFailover, BalanceRoundRobin:
+	default:
+		return nil, fmt.Errorf("proxy: unknown balance %q", o.Balance)
+	}
+	if len(o.Upstreams) == 0 {
+		return nil, fmt.Errorf("proxy: no upstreams")
+	}
+	for _, s := range o.Upstreams {
+		u, err := parseUpstream(s)
+		if err != nil {
+			return nil, err
+		}
+		p.upstreams = append(p.upstreams, u)
+	}
+	for _, rw := range o.Rewrites {
+		re, err := regexp.Compile(rw.Match)
+		if err != nil {
+			return nil, fmt.Errorf("proxy: invalid rewrite %q: %w", rw.Match, err)
+		}
+		p.rewrites = append(p.rewrites, rewriteRule{re: re, replace: rw.Replace})
+	}
+	if p.transport == nil {
+		p.transport = http.DefaultTransport
+	}
+	if o.Mirror != nil {
+		m, err := newMirror(o.Mirror)
+		if err != nil {
+			return nil, err
+		}
+		p.mirror = m
+	}
+	p.rp = &httputil.ReverseProxy{
+		Rewrite:        p.rewrite,
+		Transport:      &failoverTransport{p: p, next: p.transport},
+		FlushInterval:  o.FlushInterval,
+		ModifyResponse: p.modifyResponse,
+		ErrorHandler:   p.handleError,
+	}
+	return p, nil
+}
+
+// rewritePath 应用StripPrefix及改写规则
+func (p *Proxy) rewritePath(path string) string {
+	if p.opts.StripPrefix != "" && strings.HasPrefix(path, p.opts.StripPrefix) {
+		path = path[len(p.opts.StripPrefix):]
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+	}
+	for _, rw := range p.rewrites {
+		if rw.re.MatchString(path) {
+			return rw.re.ReplaceAllString(path, rw.replace)
+		}
+	}
+	return path
+}
+
+func (p *Proxy) rewrite(pr *httputil.ProxyRequest) {
+	pr.SetXForwarded()
+	//上游地址由failoverTransport在每次尝试时填入
+	pr.Out.URL.Path = p.rewritePath(pr.In.URL.Path)
+	pr.Out.URL.RawPath = ""
+	if !p.opts.PreserveHost {
+		//为空时使用上游地址的Host
+		pr.Out.Host = ""
+	}
+	for _, h := range p.opts.RemoveHeaders {
+		pr.Out.Header.Del(h)
+	}
+	for k, v := range p.opts.SetHeaders {
+		pr.Out.Header.Set(k, v)
+	}
+}
+
+func (p *Proxy) modifyResponse(resp *http.Response) error {
+	for k, v := range p.opts.ResponseHeaders {
+		resp.Header.Set(k, v)
+	}
+	return nil
+}
+
+func (p *Proxy) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if r.Context().Err() == nil {
+		p.logger().Errorw("proxy: upstream error", "error", err, "http", zaplog.HTTPRequest(r))
+	}
+	if p.opts.ErrorHandler != nil {
+		p.opts.ErrorHandler(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !p.opts.AccessLog {
+		p.rp.ServeHTTP(w, r)
+		return
+	}
+	start := time.Now()
+	info := &attemptInfo{}
+	r = r.WithContext(contextWithAttempt(r.Context(), info))
+	rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	//客户端中途断开时ReverseProxy会panic(http.ErrAbortHandler)，在defer中记录日志
+	defer func() {
+		p.logger().Infow("proxy request",
+			"http", zaplog.HTTPRequest(r),
+			"status", rec.status,
+			"bytes", rec.size,
+			"duration", time.Since(start),
+			"upstream", info.upstream,
+			"attempts", info.attempts,
+		)
+	}()
+	p.rp.ServeHTTP(rec, r)
+}
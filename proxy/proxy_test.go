@@ -0,0 +1,191 @@
+package proxy
+
+import (
+	"bufio"
+	"github.com/liuxy92/golib/zaplog"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testLogger 写入临时目录的logger，返回读取info日志文件内容的函数
+func testLogger(t *testing.T) (*zaplog.Logger, func() string) {
+	dir := t.TempDir()
+	lg := &zaplog.Logger{Opts: &zaplog.Options{}}
+	if err := lg.Reconfigure(&zaplog.Options{LogFileDir: dir, LogLevel: "info"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = lg.Close() })
+	return lg, func() string {
+		_ = lg.Sync()
+		files, _ := filepath.Glob(filepath.Join(dir, "*info*"))
+		var b strings.Builder
+		for _, f := range files {
+			data, _ := os.ReadFile(f)
+			b.Write(data)
+		}
+		return b.String()
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	cases := []*Options{
+		{},
+		{Upstreams: []string{"10.0.0.1:8080"}},
+		{Upstreams: []string{"ftp://host"}},
+		{Upstreams: []string{"http://host"}, Balance: "random"},
+		{Upstreams: []string{"http://host"}, Rewrites: []Rewrite{{Match: "("}}},
+	}
+	for i, o := range cases {
+		if _, err := New(o); err == nil {
+			t.Errorf("#%d: expected error", i)
+		}
+	}
+}
+
+func TestRewritePath(t *testing.T) {
+	p, err := New(&Options{
+		Upstreams:   []string{"http://backend"},
+		StripPrefix: "/api",
+		Rewrites: []Rewrite{
+			{Match: "^/v1/(.*)$", Replace: "/internal/$1"},
+			{Match: "^/v1/", Replace: "/never"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"/api/v1/users": "/internal/users",
+		"/api":          "/",
+		"/api/v2/x":     "/v2/x",
+		"/other":        "/other",
+	}
+	for in, want := range cases {
+		if got := p.rewritePath(in); got != want {
+			t.Errorf("%s: got %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestProxyHeaders(t *testing.T) {
+	var got *http.Request
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Header().Set("Server", "backend")
+		_, _ = io.WriteString(w, "hello")
+	}))
+	defer backend.Close()
+
+	p, err := New(&Options{
+		Upstreams:       []string{backend.URL + "/base/"},
+		StripPrefix:     "/api",
+		SetHeaders:      map[string]string{"X-Internal-Token": "secret"},
+		RemoveHeaders:   []string{"Cookie"},
+		ResponseHeaders: map[string]string{"Server": "gateway"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("GET", "http://gateway.example.com/api/users?page=2", nil)
+	r.Header.Set("Cookie", "sid=1")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || w.Body.String() != "hello" || w.Header().Get("Server") != "gateway" {
+		t.Fatalf("response: %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+	if got.URL.Path != "/base/users" || got.URL.RawQuery != "page=2" {
+		t.Errorf("upstream url: %s", got.URL)
+	}
+	if got.Header.Get("X-Internal-Token") != "secret" || got.Header.Get("Cookie") != "" {
+		t.Errorf("upstream headers: %v", got.Header)
+	}
+	if got.Header.Get("X-Forwarded-Host") != "gateway.example.com" || got.Header.Get("X-Forwarded-For") == "" {
+		t.Errorf("forwarded headers: %v", got.Header)
+	}
+	if got.Host != strings.TrimPrefix(backend.URL, "http://") {
+		t.Errorf("host: %s", got.Host)
+	}
+}
+
+func TestPreserveHost(t *testing.T) {
+	var host string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+	}))
+	defer backend.Close()
+
+	p, _ := New(&Options{Upstreams: []string{backend.URL}, PreserveHost: true})
+	p.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://gateway.example.com/", nil))
+	if host != "gateway.example.com" {
+		t.Errorf("host: %s", host)
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	next := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 2; i++ {
+			_, _ = io.WriteString(w, "data: tick\n\n")
+			w.(http.Flusher).Flush()
+			<-next
+		}
+	}))
+	defer backend.Close()
+
+	lg, _ := testLogger(t)
+	p, _ := New(&Options{Upstreams: []string{backend.URL}, AccessLog: true, Logger: lg})
+	gw := httptest.NewServer(p)
+	defer gw.Close()
+
+	resp, err := http.Get(gw.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	br := bufio.NewReader(resp.Body)
+	for i := 0; i < 2; i++ {
+		//上游未结束响应前即可读到事件
+		done := make(chan string)
+		go func() {
+			line, _ := br.ReadString('\n')
+			_, _ = br.ReadString('\n')
+			done <- line
+		}()
+		select {
+		case line := <-done:
+			if line != "data: tick\n" {
+				t.Fatalf("event %d: %q", i, line)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("event %d not flushed", i)
+		}
+		next <- struct{}{}
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, "created")
+	}))
+	defer backend.Close()
+
+	lg, logs := testLogger(t)
+	p, _ := New(&Options{Upstreams: []string{backend.URL}, AccessLog: true, Logger: lg})
+	p.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/items", strings.NewReader("{}")))
+
+	out := logs()
+	for _, want := range []string{`"proxy request"`, `"status":201`, `"bytes":7`, `"attempts":1`, `"upstream":"` + backend.URL + `"`, `"/items"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s in %s", want, out)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+)
+
+func contextWithAttempt(ctx context.Context, info *attemptInfo) context.Context {
+	return context.WithValue(ctx, attemptKey{}, info)
+}
+
+// responseRecorder 记录状态码与字节数，通过Unwrap支持ReverseProxy的刷新及协议升级
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+func (w *responseRecorder) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *responseRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,276 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/liuxy92/golib/zaplog"
+	"go.uber.org/multierr"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	BalanceFailover   = "failover"   //按Upstreams顺序优先使用第一个健康的上游
+	BalanceRoundRobin = "roundrobin" //在健康的上游之间轮询
+
+	defaultFailThreshold = 3
+	defaultRiseThreshold = 2
+	defaultFailTimeout   = 10 * time.Second
+	defaultCheckInterval = 5 * time.Second
+	defaultCheckTimeout  = 2 * time.Second
+)
+
+// HealthCheck 上游健康检查配置。Path为空时只做被动检查：请求连接失败达到FailThreshold次后摘除，FailTimeout后重新尝试
+type HealthCheck struct {
+	Path          string        //主动检查的路径，如 /healthz，2xx、3xx视为健康
+	Interval      time.Duration //主动检查间隔，默认5s
+	Timeout       time.Duration //主动检查超时，默认2s
+	FailThreshold int           //连续失败多少次标记为不健康，默认3
+	RiseThreshold int           //主动检查连续成功多少次恢复健康，默认2
+	FailTimeout   time.Duration //被动检查时不健康上游的冷却时间，默认10s
+}
+
+func (h HealthCheck) withDefaults() HealthCheck {
+	if h.Interval <= 0 {
+		h.Interval = defaultCheckInterval
+	}
+	if h.Timeout <= 0 {
+		h.Timeout = defaultCheckTimeout
+	}
+	if h.FailThreshold <= 0 {
+		h.FailThreshold = defaultFailThreshold
+	}
+	if h.RiseThreshold <= 0 {
+		h.RiseThreshold = defaultRiseThreshold
+	}
+	if h.FailTimeout <= 0 {
+		h.FailTimeout = defaultFailTimeout
+	}
+	return h
+}
+
+// UpstreamStatus 上游的健康状态
+type UpstreamStatus struct {
+	URL     string
+	Healthy bool
+	Fails   int //连续失败次数
+}
+
+type upstream struct {
+	url *url.URL
+
+	mu        sync.Mutex
+	healthy   bool
+	fails     int
+	successes int
+	downUntil time.Time
+}
+
+func parseUpstream(s string) (*upstream, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: invalid upstream %q: %w", s, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("proxy: invalid upstream %q, want http(s)://host[:port][/path]", s)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return &upstream{url: u, healthy: true}, nil
+}
+
+// available 是否可以转发，被动检查模式下冷却时间过后重新尝试
+func (u *upstream) available(now time.Time, passive bool) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.healthy || (passive && !now.Before(u.downUntil))
+}
+
+func (u *upstream) fail(now time.Time, hc *HealthCheck) (down bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.fails++
+	u.successes = 0
+	if u.fails >= hc.FailThreshold {
+		down = u.healthy
+		u.healthy = false
+		u.downUntil = now.Add(hc.FailTimeout)
+	}
+	return down
+}
+
+// success 请求或主动检查成功。被动模式下请求成功立即恢复，主动检查需连续成功RiseThreshold次
+func (u *upstream) success(hc *HealthCheck, active bool) (up bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.fails = 0
+	u.successes++
+	if !u.healthy && (!active || u.successes >= hc.RiseThreshold) {
+		u.healthy = true
+		return true
+	}
+	return false
+}
+
+func (u *upstream) status() UpstreamStatus {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return UpstreamStatus{URL: u.url.String(), Healthy: u.healthy, Fails: u.fails}
+}
+
+// attemptInfo 记录实际转发的上游，用于访问日志
+type attemptInfo struct {
+	upstream string
+	attempts int
+}
+
+type attemptKey struct{}
+
+// failoverTransport 按顺序尝试上游，连接失败时转发到下一个。
+// 有请求体的请求无法重放，只尝试一次
+type failoverTransport struct {
+	p    *Proxy
+	next http.RoundTripper
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	info, _ := req.Context().Value(attemptKey{}).(*attemptInfo)
+	replayable := req.Body == nil || req.Body == http.NoBody
+	if t.p.mirror != nil {
+		req = t.p.tee(req)
+	}
+	var errs error
+	for _, u := range t.p.candidates() {
+		out := req.Clone(req.Context())
+		out.URL.Scheme = u.url.Scheme
+		out.URL.Host = u.url.Host
+		out.URL.Path = u.url.Path + req.URL.Path
+		out.URL.RawPath = ""
+		if info != nil {
+			info.upstream = u.url.String()
+			info.attempts++
+		}
+		resp, err := t.next.RoundTrip(out)
+		if err == nil {
+			if t.p.passive() {
+				t.p.markSuccess(u, false)
+			}
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		t.p.markFail(u, err)
+		errs = multierr.Append(errs, err)
+		if !replayable {
+			break
+		}
+	}
+	if errs == nil {
+		return nil, errors.New("proxy: no upstream")
+	}
+	return nil, errs
+}
+
+// candidates 本次请求尝试上游的顺序：可用的上游在前，不可用的在后作为兜底
+func (p *Proxy) candidates() []*upstream {
+	n := len(p.upstreams)
+	start := 0
+	if p.balance == BalanceRoundRobin {
+		//先在uint64上取模再转换，计数器超过int范围时不为负
+		start = int((atomic.AddUint64(&p.rr, 1) - 1) % uint64(n))
+	}
+	now := time.Now()
+	passive := p.passive()
+	out := make([]*upstream, 0, n)
+	var down []*upstream
+	for i := 0; i < n; i++ {
+		u := p.upstreams[(start+i)%n]
+		if u.available(now, passive) {
+			out = append(out, u)
+		} else {
+			down = append(down, u)
+		}
+	}
+	return append(out, down...)
+}
+
+func (p *Proxy) passive() bool {
+	return p.health.Path == ""
+}
+
+func (p *Proxy) markFail(u *upstream, err error) {
+	if u.fail(time.Now(), &p.health) {
+		p.logger().Warnw("proxy: upstream marked unhealthy", "upstream", u.url.String(), "error", err)
+	}
+}
+
+func (p *Proxy) markSuccess(u *upstream, active bool) {
+	if u.success(&p.health, active) {
+		p.logger().Infow("proxy: upstream recovered", "upstream", u.url.String())
+	}
+}
+
+// StartHealthChecks 开始主动健康检查，HealthCheck.Path为空时不做任何事，ctx结束后停止
+func (p *Proxy) StartHealthChecks(ctx context.Context) {
+	if p.passive() {
+		return
+	}
+	client := &http.Client{Transport: p.transport, Timeout: p.health.Timeout}
+	for _, u := range p.upstreams {
+		go func(u *upstream) {
+			ticker := time.NewTicker(p.health.Interval)
+			defer ticker.Stop()
+			for {
+				p.check(ctx, client, u)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(u)
+	}
+}
+
+func (p *Proxy) check(ctx context.Context, client *http.Client, u *upstream) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.url.String()+p.health.Path, nil)
+	if err != nil {
+		return
+	}
+	resp, err := client.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			err = fmt.Errorf("proxy: health check status %d", resp.StatusCode)
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		p.markFail(u, err)
+		return
+	}
+	p.markSuccess(u, true)
+}
+
+// Upstreams 返回各上游的健康状态
+func (p *Proxy) Upstreams() []UpstreamStatus {
+	out := make([]UpstreamStatus, len(p.upstreams))
+	for i, u := range p.upstreams {
+		out[i] = u.status()
+	}
+	return out
+}
+
+func (p *Proxy) logger() *zaplog.Logger {
+	if p.opts.Logger != nil {
+		return p.opts.Logger
+	}
+	return zaplog.GetLogger()
+}
@@ -0,0 +1,186 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// deadURL 返回一个已关闭的服务地址，连接会被拒绝
+func deadURL() string {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	return srv.URL
+}
+
+func newBackend(t *testing.T, name string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, name)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(p *Proxy, method string, body io.Reader) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest(method, "/", body))
+	return w
+}
+
+func TestFailover(t *testing.T) {
+	b := newBackend(t, "b")
+	lg, _ := testLogger(t)
+	p, _ := New(&Options{
+		Upstreams:   []string{deadURL(), b.URL},
+		HealthCheck: HealthCheck{FailThreshold: 2, FailTimeout: time.Hour},
+		Logger:      lg,
+	})
+	for i := 0; i < 3; i++ {
+		if w := get(p, "GET", nil); w.Code != http.StatusOK || w.Body.String() != "b" {
+			t.Fatalf("#%d: %d %q", i, w.Code, w.Body.String())
+		}
+	}
+	st := p.Upstreams()
+	if st[0].Healthy || st[0].Fails != 2 || !st[1].Healthy {
+		t.Errorf("status: %+v", st)
+	}
+}
+
+func TestFailoverWithBody(t *testing.T) {
+	b := newBackend(t, "b")
+	lg, _ := testLogger(t)
+	p, _ := New(&Options{Upstreams: []string{deadURL(), b.URL}, Logger: lg})
+	//请求体无法重放，不转发到下一个上游
+	if w := get(p, "POST", strings.NewReader("data")); w.Code != http.StatusBadGateway {
+		t.Errorf("post: %d", w.Code)
+	}
+	if w := get(p, "GET", nil); w.Code != http.StatusOK {
+		t.Errorf("get: %d", w.Code)
+	}
+}
+
+func TestAllUpstreamsDown(t *testing.T) {
+	lg, _ := testLogger(t)
+	var handled error
+	p, _ := New(&Options{
+		Upstreams: []string{deadURL(), deadURL()},
+		Logger:    lg,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			handled = err
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+	})
+	if w := get(p, "GET", nil); w.Code != http.StatusServiceUnavailable || handled == nil {
+		t.Errorf("%d %v", w.Code, handled)
+	}
+}
+
+func TestPassiveRecovery(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	a := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			//模拟连接失败
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		_, _ = io.WriteString(w, "a")
+	}))
+	defer a.Close()
+	b := newBackend(t, "b")
+
+	lg, _ := testLogger(t)
+	p, _ := New(&Options{
+		Upstreams:   []string{a.URL, b.URL},
+		HealthCheck: HealthCheck{FailThreshold: 1, FailTimeout: 50 * time.Millisecond},
+		Logger:      lg,
+	})
+	if w := get(p, "GET", nil); w.Body.String() != "b" {
+		t.Fatalf("failover: %q", w.Body.String())
+	}
+	down.Store(false)
+	//冷却时间内不尝试a
+	if w := get(p, "GET", nil); w.Body.String() != "b" {
+		t.Fatalf("cooldown: %q", w.Body.String())
+	}
+	time.Sleep(60 * time.Millisecond)
+	if w := get(p, "GET", nil); w.Body.String() != "a" {
+		t.Fatalf("recovered: %q", w.Body.String())
+	}
+	if st := p.Upstreams(); !st[0].Healthy {
+		t.Errorf("status: %+v", st)
+	}
+}
+
+func TestActiveHealthCheck(t *testing.T) {
+	var healthy atomic.Bool
+	a := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" && !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, "a")
+	}))
+	defer a.Close()
+	b := newBackend(t, "b")
+
+	lg, _ := testLogger(t)
+	p, _ := New(&Options{
+		Upstreams:   []string{a.URL, b.URL},
+		HealthCheck: HealthCheck{Path: "/healthz", Interval: 10 * time.Millisecond, FailThreshold: 1, RiseThreshold: 2},
+		Logger:      lg,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.StartHealthChecks(ctx)
+
+	waitFor(t, func() bool { return !p.Upstreams()[0].Healthy })
+	if w := get(p, "GET", nil); w.Body.String() != "b" {
+		t.Fatalf("unhealthy: %q", w.Body.String())
+	}
+	healthy.Store(true)
+	waitFor(t, func() bool { return p.Upstreams()[0].Healthy })
+	if w := get(p, "GET", nil); w.Body.String() != "a" {
+		t.Fatalf("healthy: %q", w.Body.String())
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	a, b := newBackend(t, "a"), newBackend(t, "b")
+	p, _ := New(&Options{Upstreams: []string{a.URL, b.URL}, Balance: BalanceRoundRobin})
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, get(p, "GET", nil).Body.String())
+	}
+	if strings.Join(got, "") != "abab" {
+		t.Errorf("got %v", got)
+	}
+
+	//计数器超过int范围后仍按顺序轮询
+	c, _ := New(&Options{Upstreams: []string{a.URL, b.URL, newBackend(t, "c").URL}, Balance: BalanceRoundRobin})
+	atomic.StoreUint64(&c.rr, 1<<63)
+	got = got[:0]
+	for i := 0; i < 3; i++ {
+		got = append(got, get(c, "GET", nil).Body.String())
+	}
+	if strings.Join(got, "") != "cab" {
+		t.Errorf("after overflow got %v", got)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
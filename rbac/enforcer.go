@@ -0,0 +1,149 @@
+package rbac
+
+import (
+	"context"
+	"github.com/liuxy92/golib/zaplog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultCacheSize      = 10000
+	defaultReloadInterval = time.Minute
+)
+
+// Enforcer 按Policy判断主体是否有权限，判定结果会被缓存，替换Policy时缓存一并清空
+type Enforcer struct {
+	state     atomic.Value //*state
+	cacheSize int
+}
+
+type state struct {
+	roles    map[string][]Permission //展开继承后的角色权限
+	subjects map[string][]string
+	scopes   map[string][]ScopeRule //展开继承后的角色数据范围
+
+	mu    sync.RWMutex
+	cache map[string]bool
+}
+
+// NewEnforcer 创建Enforcer，p为nil时拒绝所有请求
+func NewEnforcer(p *Policy) (*Enforcer, error) {
+	e := &Enforcer{cacheSize: defaultCacheSize}
+	if p == nil {
+		p = NewPolicy()
+	}
+	if err := e.SetPolicy(p); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// SetCacheSize 设置判定缓存的最大条数，超出时清空重新缓存，小于等于0时不缓存，需在开始判定前调用
+func (e *Enforcer) SetCacheSize(n int) {
+	e.cacheSize = n
+}
+
+// SetPolicy 替换Policy，继承关系有环时返回error并保留原Policy
+func (e *Enforcer) SetPolicy(p *Policy) error {
+	roles, err := p.compile()
+	if err != nil {
+		return err
+	}
+	subjects := make(map[string][]string, len(p.Subjects))
+	for s, rs := range p.Subjects {
+		subjects[s] = append([]string(nil), rs...)
+	}
+	e.state.Store(&state{roles: roles, subjects: subjects, scopes: p.compileScopes(), cache: make(map[string]bool)})
+	return nil
+}
+
+// Enforce 判断subject是否可以对resource执行action
+func (e *Enforcer) Enforce(subject, resource, action string) bool {
+	st := e.state.Load().(*state)
+	if e.cacheSize <= 0 {
+		return st.enforce(subject, resource, action)
+	}
+	key := subject + "\x00" + resource + "\x00" + action
+	st.mu.RLock()
+	allowed, ok := st.cache[key]
+	st.mu.RUnlock()
+	if ok {
+		return allowed
+	}
+	allowed = st.enforce(subject, resource, action)
+	st.mu.Lock()
+	if len(st.cache) >= e.cacheSize {
+		st.cache = make(map[string]bool)
+	}
+	st.cache[key] = allowed
+	st.mu.Unlock()
+	return allowed
+}
+
+func (st *state) enforce(subject, resource, action string) bool {
+	for _, role := range st.subjects[subject] {
+		for _, p := range st.roles[role] {
+			if p.Match(resource, action) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RolesFor 返回主体直接被授予的角色
+func (e *Enforcer) RolesFor(subject string) []string {
+	st := e.state.Load().(*state)
+	return append([]string(nil), st.subjects[subject]...)
+}
+
+// PermissionsFor 返回主体展开继承后的全部权限，按字符串排序
+func (e *Enforcer) PermissionsFor(subject string) []Permission {
+	st := e.state.Load().(*state)
+	var perms []Permission
+	for _, role := range st.subjects[subject] {
+		perms = append(perms, st.roles[role]...)
+	}
+	perms = dedup(perms)
+	sort.Slice(perms, func(i, j int) bool {
+		return perms[i].String() < perms[j].String()
+	})
+	return perms
+}
+
+// Watch 立即加载一次Policy，之后每隔interval(默认1分钟)重新加载，ctx结束后停止。
+// 首次加载失败时返回error；之后加载失败时记录warn日志并保留原Policy，日志使用zaplog.FromContext(ctx)
+func (e *Enforcer) Watch(ctx context.Context, l Loader, interval time.Duration) error {
+	p, err := l.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if err := e.SetPolicy(p); err != nil {
+		return err
+	}
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			p, err := l.Load(ctx)
+			if err == nil {
+				err = e.SetPolicy(p)
+			}
+			if err != nil && ctx.Err() == nil {
+				zaplog.FromContext(ctx).Warnw("rbac: reload policy failed, keep previous policy", "error", err)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,138 @@
+package rbac
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testPolicy() *Policy {
+	return NewPolicy().
+		Grant("viewer", "articles/*", "read").
+		Grant("editor", "articles/*", "write").
+		Inherit("editor", "viewer").
+		Grant("admin", "*", "*").
+		Grant("auditor", "logs", "re?d").
+		Assign("alice", "admin").
+		Assign("bob", "editor").
+		Assign("carol", "viewer", "auditor")
+}
+
+func TestEnforce(t *testing.T) {
+	e, err := NewEnforcer(testPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		sub, res, act string
+		want          bool
+	}{
+		{"alice", "anything/at/all", "delete", true},
+		{"bob", "articles/1", "write", true},
+		{"bob", "articles/1", "read", true},
+		{"bob", "articles/1/comments", "read", false},
+		{"bob", "users/1", "read", false},
+		{"carol", "articles/2", "read", true},
+		{"carol", "articles/2", "write", false},
+		{"carol", "logs", "read", true},
+		{"dave", "articles/1", "read", false},
+	}
+	for i := 0; i < 2; i++ { //第二轮命中缓存
+		for _, c := range cases {
+			if got := e.Enforce(c.sub, c.res, c.act); got != c.want {
+				t.Errorf("Enforce(%s, %s, %s) = %v, want %v", c.sub, c.res, c.act, got, c.want)
+			}
+		}
+	}
+
+	if got := e.RolesFor("carol"); !reflect.DeepEqual(got, []string{"viewer", "auditor"}) {
+		t.Errorf("RolesFor = %v", got)
+	}
+	perms := e.PermissionsFor("bob")
+	if len(perms) != 2 || perms[0].String() != "articles/*:read" || perms[1].String() != "articles/*:write" {
+		t.Errorf("PermissionsFor = %v", perms)
+	}
+
+	//替换Policy后缓存失效
+	if err := e.SetPolicy(NewPolicy().Assign("bob", "editor")); err != nil {
+		t.Fatal(err)
+	}
+	if e.Enforce("bob", "articles/1", "write") {
+		t.Fatal("stale cache after SetPolicy")
+	}
+}
+
+func TestEnforceCacheLimit(t *testing.T) {
+	e, _ := NewEnforcer(testPolicy())
+	e.SetCacheSize(2)
+	for _, r := range []string{"a", "b", "c", "d"} {
+		e.Enforce("alice", r, "read")
+	}
+	if n := len(e.state.Load().(*state).cache); n > 2 {
+		t.Fatalf("cache size = %d", n)
+	}
+	e.SetCacheSize(0)
+	if !e.Enforce("alice", "x", "y") {
+		t.Fatal("uncached enforce")
+	}
+}
+
+func TestInheritCycle(t *testing.T) {
+	p := NewPolicy().Inherit("a", "b").Inherit("b", "c").Inherit("c", "a")
+	if _, err := NewEnforcer(p); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	e, _ := NewEnforcer(nil)
+	if err := e.SetPolicy(p); err == nil {
+		t.Fatal("expected cycle error")
+	}
+	if e.Enforce("x", "y", "z") {
+		t.Fatal("empty policy should deny")
+	}
+}
+
+func TestParsePermission(t *testing.T) {
+	p, err := ParsePermission("urn:order:*:read")
+	if err != nil || p.Resource != "urn:order:*" || p.Action != "read" {
+		t.Fatalf("ParsePermission = %+v %v", p, err)
+	}
+	for _, s := range []string{"read", ":read", "res:", "[:read"} {
+		if _, err := ParsePermission(s); err == nil {
+			t.Errorf("ParsePermission(%q): expected error", s)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	write := func(s string) {
+		if err := os.WriteFile(path, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("roles:\n  viewer:\n    permissions: [\"docs:read\"]\nsubjects:\n  bob: [viewer]\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e, _ := NewEnforcer(nil)
+	if err := e.Watch(ctx, &FileLoader{Path: path}, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if !e.Enforce("bob", "docs", "read") {
+		t.Fatal("initial policy")
+	}
+	write("subjects:\n  bob: []\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for e.Enforce("bob", "docs", "read") {
+		if time.Now().After(deadline) {
+			t.Fatal("policy not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := e.Watch(ctx, &FileLoader{Path: path + ".missing"}, 0); err == nil {
+		t.Fatal("expected error")
+	}
+}
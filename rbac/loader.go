@@ -0,0 +1,176 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Loader Policy来源
+type Loader interface {
+	Load(ctx context.Context) (*Policy, error)
+}
+
+// FileLoader 从YAML或JSON文件加载Policy，按扩展名区分(.json为JSON，其余按YAML解析)：
+//
+//	roles:
+//	  editor:
+//	    permissions: ["articles:read", "articles/*:write"]
+//	  admin:
+//	    inherits: [editor]
+//	    permissions: ["*:*"]
+//	    data_scopes: {"*": all}
+//	  sales:
+//	    permissions: ["orders:read"]
+//	    data_scopes: {orders: department}
+//	subjects:
+//	  alice: [admin]
+type FileLoader struct {
+	Path string
+}
+
+type fileRole struct {
+	Inherits    []string          `json:"inherits" yaml:"inherits"`
+	Permissions []string          `json:"permissions" yaml:"permissions"`
+	DataScopes  map[string]string `json:"data_scopes" yaml:"data_scopes"` //资源 → none/own/department/all
+}
+
+type filePolicy struct {
+	Roles    map[string]fileRole `json:"roles" yaml:"roles"`
+	Subjects map[string][]string `json:"subjects" yaml:"subjects"`
+}
+
+func (l *FileLoader) Load(ctx context.Context) (*Policy, error) {
+	data, err := os.ReadFile(l.Path)
+	if err != nil {
+		return nil, fmt.Errorf("rbac: read policy file: %w", err)
+	}
+	var fp filePolicy
+	if strings.EqualFold(filepath.Ext(l.Path), ".json") {
+		err = json.Unmarshal(data, &fp)
+	} else {
+		err = yaml.Unmarshal(data, &fp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rbac: parse policy file %s: %w", l.Path, err)
+	}
+	p := NewPolicy()
+	for role, r := range fp.Roles {
+		for _, s := range r.Permissions {
+			perm, err := ParsePermission(s)
+			if err != nil {
+				return nil, fmt.Errorf("rbac: role %s: %w", role, err)
+			}
+			p.Permissions[role] = append(p.Permissions[role], perm)
+		}
+		if len(r.Inherits) > 0 {
+			p.Inherit(role, r.Inherits...)
+		}
+		for resource, v := range r.DataScopes {
+			scope, err := ParseDataScope(v)
+			if err != nil {
+				return nil, fmt.Errorf("rbac: role %s: %w", role, err)
+			}
+			p.GrantScope(role, resource, scope)
+		}
+	}
+	for subject, roles := range fp.Subjects {
+		p.Assign(subject, roles...)
+	}
+	return p, nil
+}
+
+const (
+	defaultPermissionQuery = "SELECT role, resource, action FROM rbac_role_permissions"
+	defaultInheritQuery    = "SELECT role, parent FROM rbac_role_inherits"
+	defaultSubjectQuery    = "SELECT subject, role FROM rbac_subject_roles"
+)
+
+// DBLoader 从数据库加载Policy，三条查询分别返回(role, resource, action)、(role, parent)、(subject, role)两到三列，
+// 默认查询rbac_role_permissions、rbac_role_inherits、rbac_subject_roles表，InheritQuery为"-"时不加载继承关系
+type DBLoader struct {
+	DB              *sql.DB
+	PermissionQuery string
+	InheritQuery    string
+	SubjectQuery    string
+	ScopeQuery      string //返回(role, resource, scope)三列，scope取值见ParseDataScope，为空时不加载数据范围
+}
+
+func (l *DBLoader) Load(ctx context.Context) (*Policy, error) {
+	p := NewPolicy()
+	err := l.query(ctx, or(l.PermissionQuery, defaultPermissionQuery), 3, func(v []string) {
+		p.Grant(v[0], v[1], v[2])
+	})
+	if err != nil {
+		return nil, err
+	}
+	if l.InheritQuery != "-" {
+		err = l.query(ctx, or(l.InheritQuery, defaultInheritQuery), 2, func(v []string) {
+			p.Inherit(v[0], v[1])
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = l.query(ctx, or(l.SubjectQuery, defaultSubjectQuery), 2, func(v []string) {
+		p.Assign(v[0], v[1])
+	})
+	if err != nil {
+		return nil, err
+	}
+	if l.ScopeQuery != "" {
+		var scopeErr error
+		err = l.query(ctx, l.ScopeQuery, 3, func(v []string) {
+			scope, err := ParseDataScope(v[2])
+			if err != nil {
+				if scopeErr == nil {
+					scopeErr = fmt.Errorf("rbac: role %s: %w", v[0], err)
+				}
+				return
+			}
+			p.GrantScope(v[0], v[1], scope)
+		})
+		if err == nil {
+			err = scopeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (l *DBLoader) query(ctx context.Context, q string, cols int, fn func([]string)) error {
+	rows, err := l.DB.QueryContext(ctx, q)
+	if err != nil {
+		return fmt.Errorf("rbac: query %q: %w", q, err)
+	}
+	defer rows.Close()
+	v := make([]string, cols)
+	dest := make([]interface{}, cols)
+	for i := range v {
+		dest[i] = &v[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("rbac: scan %q: %w", q, err)
+		}
+		fn(append([]string(nil), v...))
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rbac: query %q: %w", q, err)
+	}
+	return nil
+}
+
+func or(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
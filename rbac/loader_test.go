@@ -0,0 +1,114 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileLoader(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"policy.yaml": `
+roles:
+  viewer:
+    permissions: ["articles/*:read"]
+  editor:
+    inherits: [viewer]
+    permissions: ["articles/*:write"]
+    data_scopes: {articles: dept}
+subjects:
+  bob: [editor]
+`,
+		"policy.json": `{
+  "roles": {
+    "viewer": {"permissions": ["articles/*:read"]},
+    "editor": {"inherits": ["viewer"], "permissions": ["articles/*:write"], "data_scopes": {"articles": "department"}}
+  },
+  "subjects": {"bob": ["editor"]}
+}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		p, err := (&FileLoader{Path: path}).Load(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		e, err := NewEnforcer(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !e.Enforce("bob", "articles/1", "read") || !e.Enforce("bob", "articles/1", "write") || e.Enforce("bob", "users/1", "read") {
+			t.Errorf("%s: unexpected decisions", name)
+		}
+		if e.DataScope("bob", "articles") != ScopeDepartment {
+			t.Errorf("%s: data scope = %s", name, e.DataScope("bob", "articles"))
+		}
+	}
+
+	bad := filepath.Join(dir, "bad.yaml")
+	_ = os.WriteFile(bad, []byte("roles:\n  x:\n    permissions: [\"nocolon\"]\n"), 0644)
+	if _, err := (&FileLoader{Path: bad}).Load(context.Background()); err == nil {
+		t.Fatal("expected invalid permission error")
+	}
+	_ = os.WriteFile(bad, []byte("roles:\n  x:\n    data_scopes: {orders: company}\n"), 0644)
+	if _, err := (&FileLoader{Path: bad}).Load(context.Background()); err == nil {
+		t.Fatal("expected invalid data scope error")
+	}
+	_ = os.WriteFile(bad, []byte("roles: ["), 0644)
+	if _, err := (&FileLoader{Path: bad}).Load(context.Background()); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
+func TestDBLoader(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(defaultPermissionQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"role", "resource", "action"}).
+			AddRow("viewer", "orders", "read").
+			AddRow("editor", "orders", "write"))
+	mock.ExpectQuery(defaultInheritQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"role", "parent"}).AddRow("editor", "viewer"))
+	mock.ExpectQuery(defaultSubjectQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"subject", "role"}).AddRow("u1", "editor").AddRow("u2", "viewer"))
+
+	mock.ExpectQuery("SELECT role, resource, scope FROM data_scopes").WillReturnRows(
+		sqlmock.NewRows([]string{"role", "resource", "scope"}).AddRow("viewer", "orders", "own").AddRow("editor", "orders", "all"))
+
+	p, err := (&DBLoader{DB: db, ScopeQuery: "SELECT role, resource, scope FROM data_scopes"}).Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := NewEnforcer(p)
+	if !e.Enforce("u1", "orders", "read") || !e.Enforce("u1", "orders", "write") || e.Enforce("u2", "orders", "write") {
+		t.Fatal("unexpected decisions")
+	}
+	if e.DataScope("u1", "orders") != ScopeAll || e.DataScope("u2", "orders") != ScopeOwn {
+		t.Fatal("unexpected data scopes")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	//自定义查询，跳过继承
+	mock.ExpectQuery("SELECT r, res, act FROM perms").WillReturnRows(sqlmock.NewRows([]string{"r", "res", "act"}))
+	mock.ExpectQuery("SELECT uid, r FROM user_roles").WillReturnError(errors.New("boom"))
+	_, err = (&DBLoader{DB: db, PermissionQuery: "SELECT r, res, act FROM perms", InheritQuery: "-", SubjectQuery: "SELECT uid, r FROM user_roles"}).Load(context.Background())
+	if err == nil {
+		t.Fatal("expected query error")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,115 @@
+package rbac
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/liuxy92/golib/zaplog"
+	"net/http"
+	"strings"
+)
+
+// Error 带业务错误码的鉴权错误，中间件以JSON {"code":..., "message":...} 返回
+type Error struct {
+	Status  int    `json:"-"` //HTTP状态码
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+var (
+	ErrUnauthenticated = &Error{Status: http.StatusUnauthorized, Code: 40100, Message: "unauthenticated"}
+	ErrForbidden       = &Error{Status: http.StatusForbidden, Code: 40300, Message: "permission denied"}
+)
+
+// MiddlewareOptions 鉴权中间件配置
+type MiddlewareOptions struct {
+	Subject      func(r *http.Request) string //当前主体，通常读取认证中间件放入context的用户ID，返回空字符串时视为未登录
+	Resource     func(r *http.Request) string //默认为URL路径去掉开头的/，如 articles/1
+	Action       func(r *http.Request) string //默认为小写的请求方法，如 get、post
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err *Error)
+}
+
+func (o *MiddlewareOptions) withDefaults() *MiddlewareOptions {
+	opts := MiddlewareOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.Subject == nil {
+		panic("rbac: MiddlewareOptions.Subject is required")
+	}
+	if opts.Resource == nil {
+		opts.Resource = func(r *http.Request) string {
+			return strings.TrimPrefix(r.URL.Path, "/")
+		}
+	}
+	if opts.Action == nil {
+		opts.Action = func(r *http.Request) string {
+			return strings.ToLower(r.Method)
+		}
+	}
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = WriteError
+	}
+	return &opts
+}
+
+// WriteError 以JSON返回错误
+func WriteError(w http.ResponseWriter, r *http.Request, err *Error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(err.Status)
+	_ = json.NewEncoder(w).Encode(err)
+}
+
+// authorize 返回nil表示放行，拒绝时记录日志
+func (e *Enforcer) authorize(r *http.Request, o *MiddlewareOptions) *Error {
+	subject := o.Subject(r)
+	if subject == "" {
+		return ErrUnauthenticated
+	}
+	resource, action := o.Resource(r), o.Action(r)
+	if e.Enforce(subject, resource, action) {
+		return nil
+	}
+	zaplog.FromContext(r.Context()).Infow("rbac: permission denied",
+		"subject", subject, "resource", resource, "action", action, "http", zaplog.HTTPRequest(r))
+	return ErrForbidden
+}
+
+// Middleware net/http中间件，未登录返回401，无权限返回403，Subject为nil时panic
+func (e *Enforcer) Middleware(o *MiddlewareOptions) func(http.Handler) http.Handler {
+	opts := o.withDefaults()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := e.authorize(r, opts); err != nil {
+				opts.ErrorHandler(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GinMiddleware gin中间件，Subject等函数接收c.Request，gin中设置的用户需放入c.Request的context
+func (e *Enforcer) GinMiddleware(o *MiddlewareOptions) gin.HandlerFunc {
+	opts := o.withDefaults()
+	return func(c *gin.Context) {
+		if err := e.authorize(c.Request, opts); err != nil {
+			opts.ErrorHandler(c.Writer, c.Request, err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Require 要求固定的资源与操作，用于单个路由，如 mux.Handle("/admin", e.Require(subject, "admin", "access")(h))
+func (e *Enforcer) Require(subject func(r *http.Request) string, resource, action string) func(http.Handler) http.Handler {
+	return e.Middleware(&MiddlewareOptions{
+		Subject:  subject,
+		Resource: func(*http.Request) string { return resource },
+		Action:   func(*http.Request) string { return action },
+	})
+}
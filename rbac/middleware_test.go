@@ -0,0 +1,114 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type userKey struct{}
+
+func userOf(r *http.Request) string {
+	u, _ := r.Context().Value(userKey{}).(string)
+	return u
+}
+
+func request(method, path, user string) *http.Request {
+	r := httptest.NewRequest(method, path, nil)
+	if user != "" {
+		r = r.WithContext(context.WithValue(r.Context(), userKey{}, user))
+	}
+	return r
+}
+
+func TestMiddleware(t *testing.T) {
+	e, _ := NewEnforcer(NewPolicy().
+		Grant("viewer", "articles/*", "get").
+		Assign("bob", "viewer"))
+	h := e.Middleware(&MiddlewareOptions{Subject: userOf})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	cases := []struct {
+		method, path, user string
+		status, code       int
+	}{
+		{"GET", "/articles/1", "bob", http.StatusOK, 0},
+		{"DELETE", "/articles/1", "bob", http.StatusForbidden, 40300},
+		{"GET", "/articles/1", "", http.StatusUnauthorized, 40100},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, request(c.method, c.path, c.user))
+		if w.Code != c.status {
+			t.Errorf("%s %s as %q: status %d, want %d", c.method, c.path, c.user, w.Code, c.status)
+			continue
+		}
+		if c.code != 0 {
+			var body Error
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != c.code || body.Message == "" {
+				t.Errorf("%s %s: body %s", c.method, c.path, w.Body)
+			}
+		}
+	}
+}
+
+func TestRequireAndErrorHandler(t *testing.T) {
+	e, _ := NewEnforcer(NewPolicy().Grant("ops", "admin", "access").Assign("alice", "ops"))
+	h := e.Require(userOf, "admin", "access")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for user, want := range map[string]int{"alice": http.StatusOK, "bob": http.StatusForbidden} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, request("POST", "/whatever", user))
+		if w.Code != want {
+			t.Errorf("%s: %d", user, w.Code)
+		}
+	}
+
+	var got *Error
+	h = e.Middleware(&MiddlewareOptions{
+		Subject:      userOf,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err *Error) { got = err; w.WriteHeader(http.StatusTeapot) },
+	})(http.NotFoundHandler())
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, request("GET", "/x", "bob"))
+	if got != ErrForbidden || w.Code != http.StatusTeapot {
+		t.Fatalf("custom handler: %v %d", got, w.Code)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic without Subject")
+		}
+	}()
+	e.Middleware(nil)
+}
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e, _ := NewEnforcer(NewPolicy().Grant("viewer", "articles/*", "get").Assign("bob", "viewer"))
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if u := c.GetHeader("X-User"); u != "" {
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), userKey{}, u))
+		}
+	})
+	r.Use(e.GinMiddleware(&MiddlewareOptions{Subject: userOf}))
+	r.GET("/articles/:id", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	r.POST("/articles/:id", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	for _, c := range []struct {
+		method string
+		status int
+	}{{"GET", http.StatusOK}, {"POST", http.StatusForbidden}} {
+		req := httptest.NewRequest(c.method, "/articles/7", nil)
+		req.Header.Set("X-User", "bob")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s: %d, want %d", c.method, w.Code, c.status)
+		}
+	}
+}
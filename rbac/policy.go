@@ -0,0 +1,135 @@
+package rbac
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Permission 对资源的操作权限，Resource与Action支持path.Match通配，单独的*匹配任意值，如 articles/*:read
+type Permission struct {
+	Resource string
+	Action   string
+}
+
+// ParsePermission 解析 resource:action 格式的权限，以最后一个冒号分隔
+func ParsePermission(s string) (Permission, error) {
+	i := strings.LastIndexByte(s, ':')
+	if i <= 0 || i == len(s)-1 {
+		return Permission{}, fmt.Errorf("rbac: invalid permission %q, want resource:action", s)
+	}
+	p := Permission{Resource: strings.TrimSpace(s[:i]), Action: strings.TrimSpace(s[i+1:])}
+	if _, err := path.Match(p.Resource, ""); err != nil {
+		return p, fmt.Errorf("rbac: invalid resource pattern %q: %w", p.Resource, err)
+	}
+	if _, err := path.Match(p.Action, ""); err != nil {
+		return p, fmt.Errorf("rbac: invalid action pattern %q: %w", p.Action, err)
+	}
+	return p, nil
+}
+
+func (p Permission) String() string {
+	return p.Resource + ":" + p.Action
+}
+
+// Match 判断权限是否覆盖对resource的action操作
+func (p Permission) Match(resource, action string) bool {
+	return match(p.Resource, resource) && match(p.Action, action)
+}
+
+func match(pattern, s string) bool {
+	if pattern == "*" || pattern == s {
+		return true
+	}
+	ok, _ := path.Match(pattern, s)
+	return ok
+}
+
+// Policy 角色权限模型：角色拥有权限并可继承其他角色，主体(用户、服务等)被授予若干角色
+type Policy struct {
+	Permissions map[string][]Permission //角色 → 权限
+	Inherits    map[string][]string     //角色 → 继承的父角色
+	Subjects    map[string][]string     //主体 → 角色
+	DataScopes  map[string][]ScopeRule  //角色 → 数据范围
+}
+
+// NewPolicy 创建空的Policy，可通过Grant、Inherit、Assign、GrantScope在代码中构建
+func NewPolicy() *Policy {
+	return &Policy{
+		Permissions: make(map[string][]Permission),
+		Inherits:    make(map[string][]string),
+		Subjects:    make(map[string][]string),
+		DataScopes:  make(map[string][]ScopeRule),
+	}
+}
+
+// Grant 为角色授予权限
+func (p *Policy) Grant(role, resource, action string) *Policy {
+	p.Permissions[role] = append(p.Permissions[role], Permission{Resource: resource, Action: action})
+	return p
+}
+
+// Inherit 角色继承父角色的全部权限
+func (p *Policy) Inherit(role string, parents ...string) *Policy {
+	p.Inherits[role] = append(p.Inherits[role], parents...)
+	return p
+}
+
+// Assign 为主体授予角色
+func (p *Policy) Assign(subject string, roles ...string) *Policy {
+	p.Subjects[subject] = append(p.Subjects[subject], roles...)
+	return p
+}
+
+// compile 展开角色继承，得到每个角色的全部权限，继承关系有环时返回error
+func (p *Policy) compile() (map[string][]Permission, error) {
+	out := make(map[string][]Permission)
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var visit func(role string, chain []string) error
+	visit = func(role string, chain []string) error {
+		switch state[role] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("rbac: role inheritance cycle: %s", strings.Join(append(chain, role), " -> "))
+		}
+		state[role] = visiting
+		perms := append([]Permission(nil), p.Permissions[role]...)
+		for _, parent := range p.Inherits[role] {
+			if err := visit(parent, append(chain, role)); err != nil {
+				return err
+			}
+			perms = append(perms, out[parent]...)
+		}
+		out[role] = dedup(perms)
+		state[role] = done
+		return nil
+	}
+	for role := range p.Permissions {
+		if err := visit(role, nil); err != nil {
+			return nil, err
+		}
+	}
+	for role := range p.Inherits {
+		if err := visit(role, nil); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func dedup(perms []Permission) []Permission {
+	seen := make(map[Permission]bool, len(perms))
+	out := perms[:0]
+	for _, p := range perms {
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	return out
+}
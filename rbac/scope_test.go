@@ -0,0 +1,80 @@
+package rbac
+
+import (
+	"github.com/liuxy92/golib/sqlbuilder"
+	"reflect"
+	"testing"
+)
+
+func TestDataScope(t *testing.T) {
+	p := NewPolicy().
+		GrantScope("staff", "*", ScopeOwn).
+		GrantScope("manager", "orders", ScopeDepartment).
+		Inherit("manager", "staff").
+		GrantScope("admin", "*", ScopeAll).
+		Assign("u1", "staff").
+		Assign("u2", "manager").
+		Assign("u3", "staff", "admin")
+	e, err := NewEnforcer(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		subject, resource string
+		want              DataScope
+	}{
+		{"u1", "orders", ScopeOwn},
+		{"u2", "orders", ScopeDepartment},
+		{"u2", "invoices", ScopeOwn}, //继承自staff
+		{"u3", "orders", ScopeAll},   //多个角色取最大范围
+		{"nobody", "orders", ScopeNone},
+	}
+	for _, c := range cases {
+		if got := e.DataScope(c.subject, c.resource); got != c.want {
+			t.Errorf("DataScope(%s, %s) = %s, want %s", c.subject, c.resource, got, c.want)
+		}
+	}
+
+	sql := func(c sqlbuilder.Cond) (string, []interface{}) {
+		s, args, err := sqlbuilder.ToSQL(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s, args
+	}
+	user := Principal{Subject: "u2", UserID: int64(7), Departments: []interface{}{int64(3), int64(4)}}
+	if s, args := sql(e.ScopeCond(user, "orders", nil)); s != "dept_id IN (?, ?)" || !reflect.DeepEqual(args, []interface{}{int64(3), int64(4)}) {
+		t.Errorf("department cond = %s %v", s, args)
+	}
+	if s, args := sql(e.ScopeCond(user, "invoices", &ScopeColumns{Owner: "o.owner_id"})); s != "o.owner_id = ?" || !reflect.DeepEqual(args, []interface{}{int64(7)}) {
+		t.Errorf("own cond = %s %v", s, args)
+	}
+	if c := e.ScopeCond(Principal{Subject: "u3"}, "orders", nil); c != nil {
+		t.Errorf("all cond = %v", c)
+	}
+	//没有数据范围或缺少用户信息时不返回任何行
+	for _, pr := range []Principal{{Subject: "nobody", UserID: 1}, {Subject: "u1"}, {Subject: "u2", UserID: 1}} {
+		if s, _ := sql(e.ScopeCond(pr, "orders", nil)); s != "1 = 0" {
+			t.Errorf("%+v: cond = %s", pr, s)
+		}
+	}
+
+	q, args, err := sqlbuilder.Select("id").From("orders").Where(sqlbuilder.Eq("status", 1), e.ScopeCond(user, "orders", nil)).Build()
+	if err != nil || q != "SELECT id FROM orders WHERE status = ? AND dept_id IN (?, ?)" || len(args) != 3 {
+		t.Errorf("select = %s %v %v", q, args, err)
+	}
+}
+
+func TestParseDataScope(t *testing.T) {
+	for s, want := range map[string]DataScope{"none": ScopeNone, "Own": ScopeOwn, "dept": ScopeDepartment, " department ": ScopeDepartment, "ALL": ScopeAll} {
+		if got, err := ParseDataScope(s); err != nil || got != want {
+			t.Errorf("ParseDataScope(%q) = %v, %v", s, got, err)
+		}
+	}
+	if _, err := ParseDataScope("company"); err == nil {
+		t.Error("expected error")
+	}
+	if ScopeDepartment.String() != "department" || DataScope(9).String() != "DataScope(9)" {
+		t.Error("String")
+	}
+}
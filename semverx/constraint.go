@@ -0,0 +1,296 @@
+package semverx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint 版本约束。空格或逗号分隔的条件需同时满足，||分隔的条件组满足任一即可。
+// 支持 =、!=、>、>=、<、<=、^、~、通配符(1.2.x、1.*、*)及连字符范围(1.2 - 2.0)，省略的版本段按通配处理：
+//
+//	^1.2      >=1.2.0 <2.0.0
+//	^0.2.3    >=0.2.3 <0.3.0
+//	~1.2      >=1.2.0 <1.3.0
+//	1.2.x     >=1.2.0 <1.3.0
+//	>=2.0 <3  >=2.0.0 <3.0.0
+//	<=1.2     <1.3.0
+//
+// 预发布版本只有在同组条件中出现相同MAJOR.MINOR.PATCH的预发布版本时才匹配，如 >=1.2.0-rc.1 匹配1.2.0-rc.2但不匹配1.3.0-rc.1
+type Constraint struct {
+	str    string
+	groups [][]comparator
+}
+
+type comparator struct {
+	op string // = != > >= < <=
+	v  Version
+}
+
+// NewConstraint 解析版本约束
+func NewConstraint(s string) (*Constraint, error) {
+	c := &Constraint{str: strings.TrimSpace(s)}
+	for _, g := range strings.Split(s, "||") {
+		cmps, err := parseGroup(g)
+		if err != nil {
+			return nil, fmt.Errorf("semverx: invalid constraint %q: %w", s, err)
+		}
+		c.groups = append(c.groups, cmps)
+	}
+	return c, nil
+}
+
+// MustConstraint 同NewConstraint，解析失败时panic
+func MustConstraint(s string) *Constraint {
+	c, err := NewConstraint(s)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Satisfies 判断版本是否满足约束，如 Satisfies("1.4.2", "^1.2")
+func Satisfies(version, constraint string) (bool, error) {
+	v, err := Parse(version)
+	if err != nil {
+		return false, err
+	}
+	c, err := NewConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	return c.Check(v), nil
+}
+
+func (c *Constraint) String() string {
+	return c.str
+}
+
+// Check 判断版本是否满足约束
+func (c *Constraint) Check(v Version) bool {
+	for _, g := range c.groups {
+		if matchGroup(g, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Latest 返回满足约束的最大版本，没有满足的版本时返回false
+func (c *Constraint) Latest(vs []Version) (Version, bool) {
+	var latest Version
+	found := false
+	for _, v := range vs {
+		if c.Check(v) && (!found || v.GreaterThan(latest)) {
+			latest, found = v, true
+		}
+	}
+	return latest, found
+}
+
+func (c *Constraint) MarshalText() ([]byte, error) {
+	return []byte(c.str), nil
+}
+
+func (c *Constraint) UnmarshalText(b []byte) error {
+	p, err := NewConstraint(string(b))
+	if err != nil {
+		return err
+	}
+	*c = *p
+	return nil
+}
+
+func matchGroup(g []comparator, v Version) bool {
+	for _, cmp := range g {
+		if !cmp.match(v) {
+			return false
+		}
+	}
+	if v.Prerelease == "" {
+		return true
+	}
+	//避免^1.2匹配到2.0.0-rc.1这类尚未发布的版本
+	for _, cmp := range g {
+		if cmp.v.Prerelease != "" && cmp.v.Major == v.Major && cmp.v.Minor == v.Minor && cmp.v.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+func (c comparator) match(v Version) bool {
+	n := v.Compare(c.v)
+	switch c.op {
+	case "=":
+		return n == 0
+	case "!=":
+		return n != 0
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	}
+	return false
+}
+
+var operators = []string{">=", "<=", "!=", ">", "<", "=", "^", "~"}
+
+// parseGroup 解析一组需同时满足的条件，空组匹配所有正式版本
+func parseGroup(s string) ([]comparator, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ','
+	})
+	var out []comparator
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		//运算符与版本号之间有空格，如 ">= 1.2"
+		if isOperator(f) {
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("missing version after %q", f)
+			}
+			i++
+			f += fields[i]
+		}
+		var cmps []comparator
+		var err error
+		if i+2 < len(fields) && fields[i+1] == "-" {
+			cmps, err = hyphenRange(f, fields[i+2])
+			i += 2
+		} else {
+			cmps, err = parseTerm(f)
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, cmps...)
+	}
+	return out, nil
+}
+
+func isOperator(s string) bool {
+	for _, op := range operators {
+		if s == op {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTerm 将单个条件展开为比较条件
+func parseTerm(s string) ([]comparator, error) {
+	op := ""
+	for _, o := range operators {
+		if strings.HasPrefix(s, o) {
+			op, s = o, s[len(o):]
+			break
+		}
+	}
+	v, parts, err := parse(s, true)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "", "=":
+		if parts == 3 {
+			return []comparator{{"=", v}}, nil
+		}
+		return lowerUpper(v, parts), nil
+	case "!=":
+		if parts < 3 {
+			return nil, fmt.Errorf("partial version not allowed with !=: %q", s)
+		}
+		return []comparator{{"!=", v}}, nil
+	case ">":
+		if parts == 3 {
+			return []comparator{{">", v}}, nil
+		}
+		if parts == 0 {
+			return none(), nil
+		}
+		return []comparator{{">=", next(v, parts)}}, nil
+	case ">=":
+		return []comparator{{">=", v}}, nil
+	case "<":
+		if parts == 0 {
+			return none(), nil
+		}
+		return []comparator{{"<", v}}, nil
+	case "<=":
+		if parts == 3 {
+			return []comparator{{"<=", v}}, nil
+		}
+		if parts == 0 {
+			return nil, nil
+		}
+		return []comparator{{"<", next(v, parts)}}, nil
+	case "~":
+		//允许patch变化，只给出major时允许minor变化
+		if parts == 0 {
+			return nil, nil
+		}
+		if parts == 3 {
+			parts = 2
+		}
+		return []comparator{{">=", v}, {"<", next(v, parts)}}, nil
+	case "^":
+		//第一个非0的版本段不能变化，^0.x中的minor、^0.0.x中的patch视为不兼容
+		switch {
+		case parts == 0:
+			return nil, nil
+		case v.Major > 0 || parts == 1:
+			parts = 1
+		case v.Minor > 0 || parts == 2:
+			parts = 2
+		}
+		return []comparator{{">=", v}, {"<", next(v, parts)}}, nil
+	}
+	return nil, fmt.Errorf("unknown operator in %q", s)
+}
+
+// hyphenRange 连字符范围，上界为部分版本时按通配处理，如 1.2 - 2.3 等价于 >=1.2.0 <2.4.0
+func hyphenRange(lo, hi string) ([]comparator, error) {
+	from, _, err := parse(lo, true)
+	if err != nil {
+		return nil, err
+	}
+	to, parts, err := parse(hi, true)
+	if err != nil {
+		return nil, err
+	}
+	out := []comparator{{">=", from}}
+	switch {
+	case parts == 3:
+		out = append(out, comparator{"<=", to})
+	case parts > 0:
+		out = append(out, comparator{"<", next(to, parts)})
+	}
+	return out, nil
+}
+
+// lowerUpper 部分版本对应的范围，如 1.2 为 >=1.2.0 <1.3.0，parts为0时不限制
+func lowerUpper(v Version, parts int) []comparator {
+	if parts == 0 {
+		return nil
+	}
+	return []comparator{{">=", v}, {"<", next(v, parts)}}
+}
+
+// next 部分版本的下一个版本，如 1.2 为 1.3.0，1 为 2.0.0，parts为3时为下一个patch
+func next(v Version, parts int) Version {
+	switch parts {
+	case 1:
+		return Version{Major: v.Major + 1}
+	case 2:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	}
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+}
+
+// none 不匹配任何版本
+func none() []comparator {
+	return []comparator{{"<", Version{}}}
+}
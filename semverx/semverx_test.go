@@ -0,0 +1,212 @@
+package semverx
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Version
+	}{
+		{"1.2.3", Version{Major: 1, Minor: 2, Patch: 3}},
+		{"v1.2.3", Version{Major: 1, Minor: 2, Patch: 3}},
+		{"1.2", Version{Major: 1, Minor: 2}},
+		{"V2", Version{Major: 2}},
+		{"1.0.0-rc.1", Version{Major: 1, Prerelease: "rc.1"}},
+		{"1.0.0-alpha-1+build.5", Version{Major: 1, Prerelease: "alpha-1", Build: "build.5"}},
+		{"1.0.0+20240101", Version{Major: 1, Build: "20240101"}},
+		{" 0.0.0 ", Version{}},
+	}
+	for _, c := range cases {
+		got, err := Parse(c.in)
+		if err != nil || got != c.want {
+			t.Errorf("Parse(%q) = %+v, %v; want %+v", c.in, got, err, c.want)
+		}
+	}
+
+	for _, s := range []string{"", "v", "1.2.3.4", "01.2.3", "1.a.3", "1.2.3-", "1.2.3-rc..1", "1.2.3-01", "1.2.3+", "1.2-rc.1", "1.x", "-1.2.3", "1.2.3-rc_1"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q): expected error", s)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	for in, want := range map[string]string{
+		"v1.2":           "1.2.0",
+		"1.2.3-rc.1+b.2": "1.2.3-rc.1+b.2",
+		"0":              "0.0.0",
+	} {
+		if got := MustParse(in).String(); got != want {
+			t.Errorf("String(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	//semver规范中的优先级示例，从小到大
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"1.10.0",
+		"2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, b := MustParse(ordered[i]), MustParse(ordered[j])
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := a.Compare(b); got != want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+	if !MustParse("1.0.0+a").Equal(MustParse("1.0.0+b")) {
+		t.Error("build metadata should be ignored")
+	}
+	if !MustParse("1.0.0-99999999999999999999").LessThan(MustParse("1.0.0-100000000000000000000")) {
+		t.Error("large numeric prerelease identifiers")
+	}
+}
+
+func TestSort(t *testing.T) {
+	ss := []string{"1.10.0", "v1.2.0", "1.2.0-rc.1", "0.9", "1.2.0+b"}
+	if err := SortStrings(ss); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"0.9", "1.2.0-rc.1", "v1.2.0", "1.2.0+b", "1.10.0"}
+	if !reflect.DeepEqual(ss, want) {
+		t.Fatalf("SortStrings = %v, want %v", ss, want)
+	}
+
+	bad := []string{"2.0", "x"}
+	if err := SortStrings(bad); err == nil || bad[0] != "2.0" {
+		t.Fatalf("SortStrings with invalid version: %v %v", bad, err)
+	}
+
+	vs := []Version{MustParse("3"), MustParse("1"), MustParse("2")}
+	Sort(vs)
+	if vs[0].Major != 1 || vs[1].Major != 2 || vs[2].Major != 3 {
+		t.Fatalf("Sort = %v", vs)
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	cases := []struct {
+		constraint string
+		match      []string
+		noMatch    []string
+	}{
+		{"^1.2", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0", "2.0.0-rc.1"}},
+		{"^1.2.3", []string{"1.2.3", "1.3.0"}, []string{"1.2.2", "2.0.0"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"^0.0", []string{"0.0.9"}, []string{"0.1.0"}},
+		{"^0", []string{"0.9.0"}, []string{"1.0.0"}},
+		{"~1.2", []string{"1.2.0", "1.2.9"}, []string{"1.3.0"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.2.2", "1.3.0"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{">=2.0 <3", []string{"2.0.0", "2.99.0"}, []string{"1.9.9", "3.0.0", "3.0.0-rc.1"}},
+		{">= 2.0, < 3", []string{"2.5.0"}, []string{"3.0.0"}},
+		{">1.2", []string{"1.3.0"}, []string{"1.2.9"}},
+		{">1.2.3", []string{"1.2.4"}, []string{"1.2.3"}},
+		{"<=1.2", []string{"1.2.9"}, []string{"1.3.0"}},
+		{"<=1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+		{"<1.2", []string{"1.1.9"}, []string{"1.2.0"}},
+		{"1.2.x", []string{"1.2.0", "1.2.5"}, []string{"1.3.0"}},
+		{"1.*", []string{"1.0.0", "1.5.0"}, []string{"2.0.0"}},
+		{"1.2", []string{"1.2.7"}, []string{"1.3.0"}},
+		{"=1.2.3", []string{"1.2.3", "1.2.3+build"}, []string{"1.2.4"}},
+		{"!=1.2.3", []string{"1.2.4"}, []string{"1.2.3"}},
+		{"*", []string{"0.0.0", "99.0.0"}, []string{"1.0.0-rc.1"}},
+		{"", []string{"1.0.0"}, nil},
+		{"1.2 - 2.3", []string{"1.2.0", "2.3.9"}, []string{"1.1.9", "2.4.0"}},
+		{"1.2.0 - 2.3.4", []string{"2.3.4"}, []string{"2.3.5"}},
+		{"^1.2 || ^2.1", []string{"1.5.0", "2.1.0"}, []string{"2.0.0", "3.0.0"}},
+		{">=1.2.0-rc.1", []string{"1.2.0-rc.2", "1.2.0", "1.3.0"}, []string{"1.2.0-beta", "1.3.0-rc.1"}},
+		{">*", nil, []string{"0.0.0", "1.0.0"}},
+		{"<*", nil, []string{"0.0.0"}},
+	}
+	for _, c := range cases {
+		con, err := NewConstraint(c.constraint)
+		if err != nil {
+			t.Errorf("NewConstraint(%q): %v", c.constraint, err)
+			continue
+		}
+		for _, v := range c.match {
+			if !con.Check(MustParse(v)) {
+				t.Errorf("%q should match %s", c.constraint, v)
+			}
+		}
+		for _, v := range c.noMatch {
+			if con.Check(MustParse(v)) {
+				t.Errorf("%q should not match %s", c.constraint, v)
+			}
+		}
+	}
+
+	for _, s := range []string{">=", "1.2.3.4", "!=1.2", "~>1.2", "1.x.3", ">=a", "1.2 -"} {
+		if _, err := NewConstraint(s); err == nil {
+			t.Errorf("NewConstraint(%q): expected error", s)
+		}
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	ok, err := Satisfies("v1.4.2", "^1.2")
+	if err != nil || !ok {
+		t.Fatalf("Satisfies = %v, %v", ok, err)
+	}
+	if _, err := Satisfies("bad", "^1.2"); err == nil {
+		t.Fatal("expected version error")
+	}
+	if _, err := Satisfies("1.0.0", ">="); err == nil {
+		t.Fatal("expected constraint error")
+	}
+}
+
+func TestLatest(t *testing.T) {
+	vs := []Version{MustParse("1.2.0"), MustParse("1.9.1"), MustParse("2.0.0"), MustParse("1.10.0-rc.1")}
+	v, ok := MustConstraint("^1.2").Latest(vs)
+	if !ok || v.String() != "1.9.1" {
+		t.Fatalf("Latest = %v, %v", v, ok)
+	}
+	if _, ok := MustConstraint(">=3").Latest(vs); ok {
+		t.Fatal("Latest should not match")
+	}
+}
+
+func TestText(t *testing.T) {
+	var cfg struct {
+		Version    Version     `json:"version"`
+		Compatible *Constraint `json:"compatible"`
+	}
+	if err := json.Unmarshal([]byte(`{"version":"v1.3","compatible":">=1.2 <2"}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Compatible.Check(cfg.Version) {
+		t.Fatalf("%s should satisfy %s", cfg.Version, cfg.Compatible)
+	}
+	b, err := json.Marshal(cfg)
+	if err != nil || string(b) != `{"version":"1.3.0","compatible":"\u003e=1.2 \u003c2"}` {
+		t.Fatalf("Marshal = %s, %v", b, err)
+	}
+	if err := json.Unmarshal([]byte(`{"version":"x"}`), &cfg); err == nil {
+		t.Fatal("expected error")
+	}
+}
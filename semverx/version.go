@@ -0,0 +1,267 @@
+package semverx
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version 语义化版本 MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD]，零值为0.0.0
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease string //预发布标识，如 rc.1
+	Build      string //构建元数据，比较时忽略
+}
+
+// Parse 解析版本号，允许v前缀以及省略minor、patch(按0处理)，如 v1.2、1.2.3-rc.1+build.5
+func Parse(s string) (Version, error) {
+	v, _, err := parse(s, false)
+	return v, err
+}
+
+// MustParse 同Parse，解析失败时panic，用于常量版本号
+func MustParse(s string) Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// parse 解析版本号，返回给出的数字段个数；wildcard为true时允许x、X、*通配，通配之后的段不计数
+func parse(s string, wildcard bool) (Version, int, error) {
+	var v Version
+	str := strings.TrimSpace(s)
+	if len(str) > 0 && (str[0] == 'v' || str[0] == 'V') {
+		str = str[1:]
+	}
+	if i := strings.IndexByte(str, '+'); i >= 0 {
+		v.Build = str[i+1:]
+		str = str[:i]
+		if err := checkIdents(v.Build, false); err != nil {
+			return v, 0, fmt.Errorf("semverx: invalid build metadata in %q: %w", s, err)
+		}
+	}
+	if i := strings.IndexByte(str, '-'); i >= 0 {
+		v.Prerelease = str[i+1:]
+		str = str[:i]
+		if err := checkIdents(v.Prerelease, true); err != nil {
+			return v, 0, fmt.Errorf("semverx: invalid prerelease in %q: %w", s, err)
+		}
+	}
+	nums := strings.Split(str, ".")
+	if len(nums) > 3 {
+		return v, 0, fmt.Errorf("semverx: invalid version %q", s)
+	}
+	parts := 0
+	for i, p := range nums {
+		if wildcard && isWildcard(p) {
+			for _, q := range nums[i+1:] {
+				if !isWildcard(q) {
+					return v, 0, fmt.Errorf("semverx: invalid version %q", s)
+				}
+			}
+			break
+		}
+		n, err := parseNumber(p)
+		if err != nil {
+			return v, 0, fmt.Errorf("semverx: invalid version %q: %w", s, err)
+		}
+		switch i {
+		case 0:
+			v.Major = n
+		case 1:
+			v.Minor = n
+		case 2:
+			v.Patch = n
+		}
+		parts++
+	}
+	if parts < 3 && (v.Prerelease != "" || v.Build != "") {
+		return v, 0, fmt.Errorf("semverx: prerelease or build requires a full version: %q", s)
+	}
+	return v, parts, nil
+}
+
+func isWildcard(s string) bool {
+	return s == "x" || s == "X" || s == "*"
+}
+
+func parseNumber(s string) (uint64, error) {
+	if s == "" {
+		return 0, errors.New("empty number")
+	}
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("leading zero in %q", s)
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// checkIdents 校验点分隔的标识符，只能包含[0-9A-Za-z-]，预发布中的数字标识符不能有前导0
+func checkIdents(s string, prerelease bool) error {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return errors.New("empty identifier")
+		}
+		numeric := true
+		for i := 0; i < len(id); i++ {
+			c := id[i]
+			switch {
+			case c >= '0' && c <= '9':
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '-':
+				numeric = false
+			default:
+				return fmt.Errorf("invalid character %q", c)
+			}
+		}
+		if prerelease && numeric && len(id) > 1 && id[0] == '0' {
+			return fmt.Errorf("leading zero in %q", id)
+		}
+	}
+	return nil
+}
+
+func (v Version) String() string {
+	s := strconv.FormatUint(v.Major, 10) + "." + strconv.FormatUint(v.Minor, 10) + "." + strconv.FormatUint(v.Patch, 10)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare 比较版本优先级，v小于、等于、大于o时分别返回-1、0、1，忽略Build
+func (v Version) Compare(o Version) int {
+	if c := compareUint(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+func (v Version) LessThan(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+func (v Version) GreaterThan(o Version) bool {
+	return v.Compare(o) > 0
+}
+
+func (v Version) Equal(o Version) bool {
+	return v.Compare(o) == 0
+}
+
+// IsPrerelease 是否为预发布版本
+func (v Version) IsPrerelease() bool {
+	return v.Prerelease != ""
+}
+
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v *Version) UnmarshalText(b []byte) error {
+	p, err := Parse(string(b))
+	if err != nil {
+		return err
+	}
+	*v = p
+	return nil
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// comparePrerelease 按semver规则比较：无预发布的版本更大；逐段比较，数字段按数值比较且小于字母段；前缀相同时段少的更小
+func comparePrerelease(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, y := as[i], bs[i]
+		if x == y {
+			continue
+		}
+		xn, yn := isNumeric(x), isNumeric(y)
+		switch {
+		case xn && yn:
+			//数字段没有前导0，先比较长度即可避免溢出
+			if len(x) != len(y) {
+				return compareUint(uint64(len(x)), uint64(len(y)))
+			}
+			return strings.Compare(x, y)
+		case xn:
+			return -1
+		case yn:
+			return 1
+		}
+		return strings.Compare(x, y)
+	}
+	return compareUint(uint64(len(as)), uint64(len(bs)))
+}
+
+func isNumeric(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Sort 按版本从小到大排序，优先级相同的版本保持原有顺序
+func Sort(vs []Version) {
+	sort.SliceStable(vs, func(i, j int) bool {
+		return vs[i].LessThan(vs[j])
+	})
+}
+
+// SortStrings 按版本从小到大排序版本字符串，存在无法解析的版本时返回error且不修改ss
+func SortStrings(ss []string) error {
+	vs := make([]Version, len(ss))
+	for i, s := range ss {
+		v, err := Parse(s)
+		if err != nil {
+			return err
+		}
+		vs[i] = v
+	}
+	idx := make([]int, len(ss))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return vs[idx[i]].LessThan(vs[idx[j]])
+	})
+	sorted := make([]string, len(ss))
+	for i, k := range idx {
+		sorted[i] = ss[k]
+	}
+	copy(ss, sorted)
+	return nil
+}
@@ -0,0 +1,230 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"go.uber.org/multierr"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	userKey   = "_uid"
+	deviceKey = "_did"
+	seenKey   = "_seen" //上次更新设备LastSeen的时间，unix秒
+
+	defaultTouchInterval = time.Minute
+)
+
+// ErrNoRegistry 未配置Options.Registry
+var ErrNoRegistry = errors.New("session: registry not configured")
+
+// DeviceFromRequest 从请求中取User-Agent及RemoteAddr作为设备信息，经过代理时需自行设置IP
+func DeviceFromRequest(r *http.Request) Device {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return Device{UserAgent: r.UserAgent(), IP: ip}
+}
+
+func newDeviceID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic("session: read random: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Login 将会话绑定到用户并更换会话ID，保存时记录为用户的一个登录设备。
+// d通常由DeviceFromRequest生成，ID及各时间字段由Manager填写。配置了MaxSessions时，超出数量的最早活跃设备被踢下线
+func (m *Manager) Login(s *Session, userID string, d Device) {
+	now := m.clock.Now()
+	d.ID, d.CreatedAt = newDeviceID(), now
+	s.Regenerate()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if uid, _ := s.values[userKey].(string); uid != "" && s.bind == nil {
+		did, _ := s.values[deviceKey].(string)
+		s.unbind = [2]string{uid, did}
+	}
+	s.values[userKey] = userID
+	s.values[deviceKey] = d.ID
+	s.values[seenKey] = now.Unix()
+	s.bind = &d
+}
+
+// UserID 会话绑定的用户，未登录时为空
+func (s *Session) UserID() string {
+	return s.GetString(userKey)
+}
+
+// DeviceID 会话对应的设备ID，未登录时为空
+func (s *Session) DeviceID() string {
+	return s.GetString(deviceKey)
+}
+
+// touchDue 已登录的会话距上次更新LastSeen超过TouchInterval时标记为需保存，由Load调用
+func (m *Manager) touchDue(s *Session, now time.Time) {
+	if m.opts.Registry == nil || s.values[userKey] == nil {
+		return
+	}
+	seen := time.Unix(s.GetInt64(seenKey), 0)
+	if now.Sub(seen) >= m.opts.TouchInterval {
+		s.values[seenKey] = now.Unix()
+		s.dirty = true
+	}
+}
+
+// register 会话写入存储后更新设备记录，调用方需持有s.mu。设备已被踢下线时返回false
+func (m *Manager) register(ctx context.Context, s *Session, now time.Time) (bool, error) {
+	reg := m.opts.Registry
+	userID, _ := s.values[userKey].(string)
+	deviceID, _ := s.values[deviceKey].(string)
+	if reg == nil || userID == "" || deviceID == "" {
+		return true, nil
+	}
+	if s.bind == nil {
+		return reg.Touch(ctx, userID, Device{ID: deviceID, SessionID: s.id, LastSeen: now, ExpiresAt: s.expiresAt})
+	}
+	if s.unbind[0] != "" {
+		if err := reg.Remove(ctx, s.unbind[0], s.unbind[1]); err != nil {
+			return false, err
+		}
+		s.unbind = [2]string{}
+	}
+	d := *s.bind
+	d.SessionID, d.LastSeen, d.ExpiresAt = s.id, now, s.expiresAt
+	if err := reg.Add(ctx, userID, d); err != nil {
+		return false, err
+	}
+	s.bind = nil
+	if m.opts.MaxSessions <= 0 {
+		return true, nil
+	}
+	devices, err := m.Devices(ctx, userID)
+	if err != nil {
+		return true, err
+	}
+	var evict []Device
+	//Devices按LastSeen倒序，保留最近活跃的MaxSessions个，当前设备始终保留
+	kept := 1
+	for _, o := range devices {
+		if o.ID == d.ID {
+			continue
+		}
+		if kept < m.opts.MaxSessions {
+			kept++
+			continue
+		}
+		evict = append(evict, o)
+	}
+	return true, m.revoke(ctx, userID, evict)
+}
+
+// unregister 销毁已登录的会话时删除设备记录，调用方需持有s.mu
+func (m *Manager) unregister(ctx context.Context, s *Session) error {
+	userID, _ := s.values[userKey].(string)
+	deviceID, _ := s.values[deviceKey].(string)
+	if m.opts.Registry == nil || userID == "" || deviceID == "" || s.isNew {
+		return nil
+	}
+	return m.opts.Registry.Remove(ctx, userID, deviceID)
+}
+
+// Devices 用户当前登录的设备，按LastSeen倒序，已过期的设备记录会被删除
+func (m *Manager) Devices(ctx context.Context, userID string) ([]Device, error) {
+	reg := m.opts.Registry
+	if reg == nil {
+		return nil, ErrNoRegistry
+	}
+	devices, err := reg.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := m.clock.Now()
+	var expired []string
+	alive := devices[:0]
+	for _, d := range devices {
+		if !now.Before(d.ExpiresAt) {
+			expired = append(expired, d.ID)
+			continue
+		}
+		alive = append(alive, d)
+	}
+	if len(expired) > 0 {
+		if err := reg.Remove(ctx, userID, expired...); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(alive, func(i, j int) bool {
+		if !alive[i].LastSeen.Equal(alive[j].LastSeen) {
+			return alive[i].LastSeen.After(alive[j].LastSeen)
+		}
+		return alive[i].ID < alive[j].ID
+	})
+	return alive, nil
+}
+
+// Revoke 踢下线用户的指定设备，删除其会话，设备不存在时不报错
+func (m *Manager) Revoke(ctx context.Context, userID string, deviceIDs ...string) error {
+	devices, err := m.Devices(ctx, userID)
+	if err != nil {
+		return err
+	}
+	want := make(map[string]bool, len(deviceIDs))
+	for _, id := range deviceIDs {
+		want[id] = true
+	}
+	var revoke []Device
+	for _, d := range devices {
+		if want[d.ID] {
+			revoke = append(revoke, d)
+		}
+	}
+	return m.revoke(ctx, userID, revoke)
+}
+
+// RevokeAll 踢下线用户的全部设备，except中的设备(通常为当前设备)除外
+func (m *Manager) RevokeAll(ctx context.Context, userID string, except ...string) error {
+	devices, err := m.Devices(ctx, userID)
+	if err != nil {
+		return err
+	}
+	keep := make(map[string]bool, len(except))
+	for _, id := range except {
+		keep[id] = true
+	}
+	var revoke []Device
+	for _, d := range devices {
+		if !keep[d.ID] {
+			revoke = append(revoke, d)
+		}
+	}
+	return m.revoke(ctx, userID, revoke)
+}
+
+// revoke 先删除设备记录，之后该会话的请求在保存时发现设备不存在会自行销毁
+func (m *Manager) revoke(ctx context.Context, userID string, devices []Device) error {
+	if len(devices) == 0 {
+		return nil
+	}
+	ids := make([]string, len(devices))
+	for i, d := range devices {
+		ids[i] = d.ID
+	}
+	if err := m.opts.Registry.Remove(ctx, userID, ids...); err != nil {
+		return err
+	}
+	var err error
+	for _, d := range devices {
+		if d.SessionID != "" {
+			err = multierr.Append(err, m.store.Delete(ctx, d.SessionID))
+		}
+	}
+	return err
+}
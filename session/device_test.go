@@ -0,0 +1,162 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newDeviceEnv(o Options) (*testEnv, *MemoryRegistry) {
+	reg := NewMemoryRegistry()
+	o.Registry = reg
+	o.MaxAge = time.Hour
+	return newEnv(o), reg
+}
+
+// login 以新会话登录，返回携带token的请求
+func (e *testEnv) login(t *testing.T, userID, ua string) (*Session, *http.Request) {
+	t.Helper()
+	r := httptest.NewRequest("POST", "/login", nil)
+	r.Header.Set("User-Agent", ua)
+	s, w := e.roundTrip(t, r, func(s *Session) { e.m.Login(s, userID, DeviceFromRequest(r)) })
+	return s, withCookies(w)
+}
+
+func TestLoginDevices(t *testing.T) {
+	e, _ := newDeviceEnv(Options{})
+	ctx := context.Background()
+
+	s, req := e.login(t, "u1", "Chrome")
+	if s.UserID() != "u1" || s.DeviceID() == "" {
+		t.Fatalf("session = %q %q", s.UserID(), s.DeviceID())
+	}
+	e.clock.Add(time.Second)
+	e.login(t, "u1", "Safari")
+
+	devices, err := e.m.Devices(ctx, "u1")
+	if err != nil || len(devices) != 2 || devices[0].UserAgent != "Safari" || devices[1].ID != s.DeviceID() {
+		t.Fatalf("devices = %+v %v", devices, err)
+	}
+	d := devices[1]
+	if d.IP != "192.0.2.1" || d.SessionID != s.ID() || !d.ExpiresAt.Equal(e.clock.Now().Add(time.Hour-time.Second)) {
+		t.Fatalf("device = %+v", d)
+	}
+	data, _ := json.Marshal(d)
+	if strings.Contains(string(data), s.ID()) {
+		t.Fatalf("session token leaked: %s", data)
+	}
+
+	//TouchInterval内不更新LastSeen，超过后更新
+	e.clock.Add(30 * time.Second)
+	if s2, _ := e.roundTrip(t, req, nil); s2.UserID() != "u1" || s2.dirty {
+		t.Fatal("reload within touch interval")
+	}
+	e.clock.Add(time.Minute)
+	e.roundTrip(t, req, nil)
+	devices, _ = e.m.Devices(ctx, "u1")
+	if devices[0].ID != s.DeviceID() || !devices[0].LastSeen.Equal(e.clock.Now()) {
+		t.Fatalf("last seen = %+v", devices)
+	}
+
+	//退出登录删除设备
+	e.roundTrip(t, req, func(s *Session) { s.Destroy() })
+	if devices, _ = e.m.Devices(ctx, "u1"); len(devices) != 1 || devices[0].UserAgent != "Safari" {
+		t.Fatalf("after logout = %+v", devices)
+	}
+
+	//过期的设备不再列出
+	e.clock.Add(time.Hour)
+	if devices, _ = e.m.Devices(ctx, "u1"); len(devices) != 0 {
+		t.Fatalf("expired = %+v", devices)
+	}
+
+	if _, err := NewManager(e.store, nil).Devices(ctx, "u1"); err != ErrNoRegistry {
+		t.Fatalf("no registry: %v", err)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	e, reg := newDeviceEnv(Options{})
+	ctx := context.Background()
+	a, reqA := e.login(t, "u1", "A")
+	_, reqB := e.login(t, "u1", "B")
+	c, reqC := e.login(t, "u1", "C")
+
+	//请求处理中被踢下线，保存时不会重新写入会话
+	sA, err := e.m.Load(reqA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.m.Revoke(ctx, "u1", a.DeviceID()); err != nil {
+		t.Fatal(err)
+	}
+	sA.Set("k", "v")
+	w := httptest.NewRecorder()
+	if err := e.m.Save(ctx, w, sA); err != nil {
+		t.Fatal(err)
+	}
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge != -1 {
+		t.Fatalf("revoked cookie = %+v", cookies)
+	}
+	if s, _ := e.roundTrip(t, reqA, nil); !s.IsNew() {
+		t.Fatal("revoked session should be gone")
+	}
+
+	if err := e.m.RevokeAll(ctx, "u1", c.DeviceID()); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := e.roundTrip(t, reqB, nil); !s.IsNew() {
+		t.Fatal("RevokeAll should remove other sessions")
+	}
+	if s, _ := e.roundTrip(t, reqC, nil); s.IsNew() || s.UserID() != "u1" {
+		t.Fatal("excepted session should stay")
+	}
+	if devices, _ := reg.List(ctx, "u1"); len(devices) != 1 || devices[0].ID != c.DeviceID() {
+		t.Fatalf("registry = %+v", devices)
+	}
+}
+
+func TestMaxSessions(t *testing.T) {
+	e, _ := newDeviceEnv(Options{MaxSessions: 2})
+	ctx := context.Background()
+	_, req1 := e.login(t, "u1", "1")
+	e.clock.Add(time.Minute)
+	_, req2 := e.login(t, "u1", "2")
+	e.clock.Add(time.Minute)
+	//设备1最近活跃，设备2被踢下线
+	e.roundTrip(t, req1, nil)
+	e.clock.Add(time.Minute)
+	e.login(t, "u1", "3")
+
+	devices, _ := e.m.Devices(ctx, "u1")
+	if len(devices) != 2 || devices[0].UserAgent != "3" || devices[1].UserAgent != "1" {
+		t.Fatalf("devices = %+v", devices)
+	}
+	if s, _ := e.roundTrip(t, req2, nil); !s.IsNew() {
+		t.Fatal("evicted session should be gone")
+	}
+	e.login(t, "u2", "x")
+	if devices, _ := e.m.Devices(ctx, "u1"); len(devices) != 2 {
+		t.Fatal("limit is per user")
+	}
+}
+
+func TestLoginSwitchUser(t *testing.T) {
+	e, _ := newDeviceEnv(Options{})
+	ctx := context.Background()
+	_, req := e.login(t, "u1", "A")
+	s, _ := e.roundTrip(t, req, func(s *Session) { e.m.Login(s, "u2", Device{}) })
+	if s.UserID() != "u2" {
+		t.Fatal("switch user")
+	}
+	if devices, _ := e.m.Devices(ctx, "u1"); len(devices) != 0 {
+		t.Fatalf("previous device should be removed: %+v", devices)
+	}
+	if devices, _ := e.m.Devices(ctx, "u2"); len(devices) != 1 {
+		t.Fatalf("u2 devices = %+v", devices)
+	}
+}
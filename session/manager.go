@@ -0,0 +1,252 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/liuxy92/golib/clockx"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	ModeCookie = "cookie" //token通过cookie传递，适用于浏览器
+	ModeHeader = "header" //token通过请求头传递，新建会话时在响应头返回，适用于App及API
+	ModeBoth   = "both"   //优先读取请求头，没有时读取cookie，响应时同时写入
+
+	defaultCookieName = "session_id"
+	defaultHeaderName = "X-Session-Token"
+	defaultMaxAge     = 24 * time.Hour
+	defaultCSRFHeader = "X-CSRF-Token"
+	defaultCSRFField  = "csrf_token"
+)
+
+// Options 会话配置
+type Options struct {
+	Mode         string        //token传递方式：cookie(默认)、header、both
+	CookieName   string        //默认 session_id
+	HeaderName   string        //默认 X-Session-Token
+	MaxAge       time.Duration //会话有效期，默认24h
+	Rolling      bool          //滚动过期：每次请求都将有效期重新延长MaxAge，否则从创建时起算
+	CookiePath   string        //默认 /
+	CookieDomain string
+	Secure       bool          //cookie仅通过HTTPS发送
+	SameSite     http.SameSite //默认Lax
+	CSRF         bool          //中间件对cookie会话的POST、PUT、PATCH、DELETE等请求校验CSRF token
+	CSRFHeader   string        //CSRF token请求头，默认 X-CSRF-Token
+	CSRFField    string        //CSRF token表单字段，默认 csrf_token
+	Clock        clockx.Clock  //默认系统时间
+
+	Registry      Registry      //记录用户的登录设备，配置后Login的会话可通过Devices、Revoke管理
+	MaxSessions   int           //每个用户最多同时登录的设备数，超出时踢下线最早活跃的设备，0为不限制，需配置Registry
+	TouchInterval time.Duration //已登录会话更新设备LastSeen的最小间隔，默认1m
+}
+
+// Manager 负责会话的加载、保存及token的读写
+type Manager struct {
+	store Store
+	opts  Options
+	clock clockx.Clock
+}
+
+// NewManager 创建Manager，Mode未知时panic
+func NewManager(store Store, o *Options) *Manager {
+	opts := Options{}
+	if o != nil {
+		opts = *o
+	}
+	switch opts.Mode {
+	case "":
+		opts.Mode = ModeCookie
+	case ModeCookie, ModeHeader, ModeBoth:
+	default:
+		panic(fmt.Sprintf("session: unknown mode %q", opts.Mode))
+	}
+	if opts.CookieName == "" {
+		opts.CookieName = defaultCookieName
+	}
+	if opts.HeaderName == "" {
+		opts.HeaderName = defaultHeaderName
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = defaultMaxAge
+	}
+	if opts.CookiePath == "" {
+		opts.CookiePath = "/"
+	}
+	if opts.SameSite == 0 {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+	if opts.CSRFHeader == "" {
+		opts.CSRFHeader = defaultCSRFHeader
+	}
+	if opts.CSRFField == "" {
+		opts.CSRFField = defaultCSRFField
+	}
+	if opts.TouchInterval <= 0 {
+		opts.TouchInterval = defaultTouchInterval
+	}
+	return &Manager{store: store, opts: opts, clock: clockx.Or(opts.Clock)}
+}
+
+func (m *Manager) useCookie() bool {
+	return m.opts.Mode != ModeHeader
+}
+
+func (m *Manager) useHeader() bool {
+	return m.opts.Mode != ModeCookie
+}
+
+// record 存储中的会话数据
+type record struct {
+	Values    map[string]interface{} `json:"v"`
+	CreatedAt time.Time              `json:"c"`
+	ExpiresAt time.Time              `json:"e"`
+}
+
+// Load 读取请求携带的会话，token缺失、无效或会话已过期时返回新会话。仅存储出错时返回error
+func (m *Manager) Load(r *http.Request) (*Session, error) {
+	now := m.clock.Now()
+	id, fromHeader := m.token(r)
+	if id == "" {
+		return newSession(now, m.opts.MaxAge), nil
+	}
+	data, err := m.store.Get(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	var rec record
+	if data != nil {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&rec); err != nil {
+			data = nil
+		}
+	}
+	if data == nil || !now.Before(rec.ExpiresAt) {
+		return newSession(now, m.opts.MaxAge), nil
+	}
+	if rec.Values == nil {
+		rec.Values = make(map[string]interface{})
+	}
+	s := &Session{
+		id:         id,
+		values:     rec.Values,
+		createdAt:  rec.CreatedAt,
+		expiresAt:  rec.ExpiresAt,
+		fromHeader: fromHeader,
+	}
+	m.touchDue(s, now)
+	return s, nil
+}
+
+// token 按Mode读取请求中的token，格式不正确时视为没有
+func (m *Manager) token(r *http.Request) (string, bool) {
+	if m.useHeader() {
+		if t := strings.TrimSpace(r.Header.Get(m.opts.HeaderName)); validID(t) {
+			return t, true
+		}
+	}
+	if m.useCookie() {
+		if c, err := r.Cookie(m.opts.CookieName); err == nil && validID(c.Value) {
+			return c.Value, false
+		}
+	}
+	return "", false
+}
+
+// Save 保存会话并写入token，需在写入响应头之前调用。
+// 未修改的新会话不保存也不下发token，避免为匿名访问创建会话；开启Rolling时已有会话每次都会续期
+func (m *Manager) Save(ctx context.Context, w http.ResponseWriter, s *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.destroyed {
+		if err := m.unregister(ctx, s); err != nil {
+			return err
+		}
+		return m.destroy(ctx, w, s)
+	}
+
+	regenerated := s.oldID != ""
+	roll := m.opts.Rolling && !s.isNew
+	if !s.dirty && !roll {
+		return nil
+	}
+	now := m.clock.Now()
+	if roll {
+		s.expiresAt = now.Add(m.opts.MaxAge)
+	}
+	ttl := s.expiresAt.Sub(now)
+	if ttl <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(record{Values: s.values, CreatedAt: s.createdAt, ExpiresAt: s.expiresAt})
+	if err != nil {
+		return fmt.Errorf("session: encode: %w", err)
+	}
+	if err := m.store.Set(ctx, s.id, data, ttl); err != nil {
+		return err
+	}
+	if regenerated {
+		if err := m.store.Delete(ctx, s.oldID); err != nil {
+			return err
+		}
+		s.oldID = ""
+	}
+	//设备已被踢下线时销毁刚写入的会话
+	if ok, err := m.register(ctx, s, now); err != nil {
+		return err
+	} else if !ok {
+		s.isNew = false
+		return m.destroy(ctx, w, s)
+	}
+
+	//新建、更换ID及续期时下发token，cookie需同步更新过期时间
+	if s.isNew || regenerated || roll {
+		if m.useCookie() {
+			http.SetCookie(w, m.cookie(s.id, s.expiresAt, int(ttl/time.Second)))
+		}
+		if m.useHeader() && (s.isNew || regenerated) {
+			w.Header().Set(m.opts.HeaderName, s.id)
+		}
+	}
+	s.isNew, s.dirty = false, false
+	return nil
+}
+
+// destroy 从存储删除会话并使cookie过期，调用方需持有s.mu
+func (m *Manager) destroy(ctx context.Context, w http.ResponseWriter, s *Session) error {
+	if !s.isNew {
+		if err := m.store.Delete(ctx, s.id); err != nil {
+			return err
+		}
+	}
+	if s.oldID != "" {
+		if err := m.store.Delete(ctx, s.oldID); err != nil {
+			return err
+		}
+	}
+	if m.useCookie() {
+		http.SetCookie(w, m.cookie("", time.Unix(0, 0), -1))
+	}
+	s.values, s.dirty, s.isNew, s.oldID = make(map[string]interface{}), false, true, ""
+	s.bind, s.unbind = nil, [2]string{}
+	return nil
+}
+
+func (m *Manager) cookie(value string, expires time.Time, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.opts.CookieName,
+		Value:    value,
+		Path:     m.opts.CookiePath,
+		Domain:   m.opts.CookieDomain,
+		Expires:  expires,
+		MaxAge:   maxAge,
+		Secure:   m.opts.Secure,
+		HttpOnly: true,
+		SameSite: m.opts.SameSite,
+	}
+}
@@ -0,0 +1,176 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/liuxy92/golib/zaplog"
+	"net"
+	"net/http"
+	"sync"
+)
+
+type ctxKey struct{}
+
+// ginKey gin.Context中保存会话的key
+const ginKey = "golib/session"
+
+// NewContext 将会话放入context
+func NewContext(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, ctxKey{}, s)
+}
+
+// FromContext 返回中间件放入context的会话，没有时返回nil
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(ctxKey{}).(*Session)
+	return s
+}
+
+// Get 返回gin中间件加载的会话，没有时返回nil
+func Get(c *gin.Context) *Session {
+	if v, ok := c.Get(ginKey); ok {
+		return v.(*Session)
+	}
+	return FromContext(c.Request.Context())
+}
+
+// begin 加载会话并校验CSRF，失败时已写入响应
+func (m *Manager) begin(w http.ResponseWriter, r *http.Request) (*Session, bool) {
+	s, err := m.Load(r)
+	if err != nil {
+		zaplog.FromContext(r.Context()).Errorw("session: load failed", "error", err)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return nil, false
+	}
+	if m.opts.CSRF && !s.fromHeader && !safeMethod(r.Method) && !s.VerifyCSRF(m.csrfToken(r)) {
+		zaplog.FromContext(r.Context()).Warnw("session: invalid csrf token", "http", zaplog.HTTPRequest(r))
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return nil, false
+	}
+	return s, true
+}
+
+func (m *Manager) csrfToken(r *http.Request) string {
+	if t := r.Header.Get(m.opts.CSRFHeader); t != "" {
+		return t
+	}
+	return r.PostFormValue(m.opts.CSRFField)
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// committer 在第一次写入响应头前保存会话，handler未写入响应时在结束后保存
+type committer struct {
+	once sync.Once
+	save func()
+}
+
+func (c *committer) commit() {
+	c.once.Do(c.save)
+}
+
+func (m *Manager) newCommitter(w http.ResponseWriter, r *http.Request, s *Session) *committer {
+	return &committer{save: func() {
+		if err := m.Save(r.Context(), w, s); err != nil {
+			zaplog.FromContext(r.Context()).Errorw("session: save failed", "error", err)
+		}
+	}}
+}
+
+// Middleware net/http中间件，加载会话放入context并在响应前保存，handler通过FromContext取得会话
+func (m *Manager) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s, ok := m.begin(w, r)
+			if !ok {
+				return
+			}
+			r = r.WithContext(NewContext(r.Context(), s))
+			sw := &sessionWriter{ResponseWriter: w, committer: m.newCommitter(w, r, s)}
+			next.ServeHTTP(sw, r)
+			sw.commit()
+		})
+	}
+}
+
+type sessionWriter struct {
+	http.ResponseWriter
+	*committer
+}
+
+func (w *sessionWriter) WriteHeader(code int) {
+	w.commit()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sessionWriter) Write(p []byte) (int, error) {
+	w.commit()
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *sessionWriter) Flush() {
+	w.commit()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *sessionWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+func (w *sessionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// GinMiddleware gin中间件，handler通过session.Get(c)取得会话
+func (m *Manager) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s, ok := m.begin(c.Writer, c.Request)
+		if !ok {
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), s))
+		c.Set(ginKey, s)
+		gw := &ginWriter{ResponseWriter: c.Writer, committer: m.newCommitter(c.Writer, c.Request, s)}
+		c.Writer = gw
+		c.Next()
+		gw.commit()
+	}
+}
+
+// ginWriter gin在Write、WriteString、WriteHeaderNow时才真正写入响应头
+type ginWriter struct {
+	gin.ResponseWriter
+	*committer
+}
+
+func (w *ginWriter) WriteHeaderNow() {
+	w.commit()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *ginWriter) Write(p []byte) (int, error) {
+	w.commit()
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *ginWriter) WriteString(s string) (int, error) {
+	w.commit()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *ginWriter) Flush() {
+	w.commit()
+	w.ResponseWriter.Flush()
+}
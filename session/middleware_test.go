@@ -0,0 +1,129 @@
+package session
+
+import (
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := NewManager(NewMemoryStore(nil), &Options{CSRF: true})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		s := FromContext(r.Context())
+		s.Regenerate()
+		s.Set("user", "alice")
+		_, _ = w.Write([]byte(s.CSRFToken()))
+	})
+	mux.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(FromContext(r.Context()).GetString("user")))
+	})
+	srv := httptest.NewServer(m.Middleware()(mux))
+	defer srv.Close()
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+	get := func(path string) string {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	csrf := get("/login")
+	if got := get("/me"); got != "alice" {
+		t.Fatalf("/me = %q", got)
+	}
+
+	post := func(form url.Values, header string) int {
+		req, _ := http.NewRequest("POST", srv.URL+"/me", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if header != "" {
+			req.Header.Set("X-CSRF-Token", header)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := post(nil, ""); code != http.StatusForbidden {
+		t.Fatalf("POST without csrf = %d", code)
+	}
+	if code := post(nil, csrf); code != http.StatusOK {
+		t.Fatalf("POST with csrf header = %d", code)
+	}
+	if code := post(url.Values{"csrf_token": {csrf}}, ""); code != http.StatusOK {
+		t.Fatalf("POST with csrf field = %d", code)
+	}
+}
+
+func TestMiddlewareHeaderSkipsCSRF(t *testing.T) {
+	m := NewManager(NewMemoryStore(nil), &Options{Mode: ModeHeader, CSRF: true})
+	h := m.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Set("n", 1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	//token通过请求头传递时不会被浏览器自动携带，POST无需CSRF token
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	token := w.Header().Get("X-Session-Token")
+	if w.Code != http.StatusCreated || token == "" {
+		t.Fatalf("GET = %d token=%q", w.Code, token)
+	}
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("X-Session-Token", token)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST with header token = %d", w.Code)
+	}
+}
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewManager(NewMemoryStore(nil), nil)
+	r := gin.New()
+	r.Use(m.GinMiddleware())
+	r.GET("/set", func(c *gin.Context) {
+		Get(c).Set("n", 5)
+		c.String(http.StatusOK, "ok")
+	})
+	r.GET("/get", func(c *gin.Context) {
+		c.String(http.StatusOK, Get(c).GetString("n"))
+	})
+	r.GET("/abort", func(c *gin.Context) {
+		Get(c).Set("n", 6)
+		c.AbortWithStatus(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/set", nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("cookies = %v", cookies)
+	}
+	req := httptest.NewRequest("GET", "/get", nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Body.String() != "5" {
+		t.Fatalf("/get = %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/abort", nil))
+	if w.Code != http.StatusNoContent || len(w.Result().Cookies()) != 1 {
+		t.Fatalf("abort: %d cookies=%v", w.Code, w.Result().Cookies())
+	}
+}
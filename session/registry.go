@@ -0,0 +1,198 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Device 用户的一个登录会话，通常对应一台设备或一个浏览器
+type Device struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name,omitempty"` //设备名称，由业务在登录时指定
+	UserAgent string    `json:"user_agent,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	ExpiresAt time.Time `json:"expires_at"`
+	SessionID string    `json:"-"` //当前的会话token，不对外输出
+}
+
+// Registry 记录每个用户的登录设备，用于设备列表、踢下线及并发登录数限制。
+// 不负责过期清理，Manager在查询时过滤并删除已过期的设备
+type Registry interface {
+	Add(ctx context.Context, userID string, d Device) error
+	Touch(ctx context.Context, userID string, d Device) (bool, error) //更新SessionID、LastSeen、ExpiresAt，设备不存在时返回false
+	Remove(ctx context.Context, userID string, deviceIDs ...string) error
+	List(ctx context.Context, userID string) ([]Device, error)
+}
+
+// MemoryRegistry 进程内的设备记录，仅适用于单实例部署及测试
+type MemoryRegistry struct {
+	mu    sync.Mutex
+	users map[string]map[string]Device
+}
+
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{users: make(map[string]map[string]Device)}
+}
+
+func (r *MemoryRegistry) Add(ctx context.Context, userID string, d Device) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	devices := r.users[userID]
+	if devices == nil {
+		devices = make(map[string]Device)
+		r.users[userID] = devices
+	}
+	devices[d.ID] = d
+	return nil
+}
+
+func (r *MemoryRegistry) Touch(ctx context.Context, userID string, d Device) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.users[userID][d.ID]
+	if !ok {
+		return false, nil
+	}
+	old.SessionID, old.LastSeen, old.ExpiresAt = d.SessionID, d.LastSeen, d.ExpiresAt
+	r.users[userID][d.ID] = old
+	return true, nil
+}
+
+func (r *MemoryRegistry) Remove(ctx context.Context, userID string, deviceIDs ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range deviceIDs {
+		delete(r.users[userID], id)
+	}
+	if len(r.users[userID]) == 0 {
+		delete(r.users, userID)
+	}
+	return nil
+}
+
+func (r *MemoryRegistry) List(ctx context.Context, userID string) ([]Device, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	devices := make([]Device, 0, len(r.users[userID]))
+	for _, d := range r.users[userID] {
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+const (
+	defaultRegistryTTL = 30 * 24 * time.Hour
+	seenSuffix         = ":s" //设备ID不含冒号
+)
+
+// RedisRegistry 使用Redis hash记录每个用户的设备，每个设备两个字段：
+// <设备ID> 保存登录时的设备信息，<设备ID>:s 保存SessionID、LastSeen、ExpiresAt
+type RedisRegistry struct {
+	Client redis.UniversalClient
+	Prefix string        //key前缀，默认 session:user:
+	TTL    time.Duration //key的过期时间，每次写入时刷新，需不小于会话有效期，默认30天
+}
+
+// deviceState 设备信息中随请求变化的部分
+type deviceState struct {
+	SessionID string    `json:"sid"`
+	LastSeen  time.Time `json:"l"`
+	ExpiresAt time.Time `json:"e"`
+}
+
+// touchScript 设备存在时才更新，避免已被踢下线的设备重新出现
+var touchScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1] .. ':s', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+func (r *RedisRegistry) key(userID string) string {
+	if r.Prefix == "" {
+		return "session:user:" + userID
+	}
+	return r.Prefix + userID
+}
+
+func (r *RedisRegistry) ttl() time.Duration {
+	if r.TTL > 0 {
+		return r.TTL
+	}
+	return defaultRegistryTTL
+}
+
+func (r *RedisRegistry) Add(ctx context.Context, userID string, d Device) error {
+	info := d
+	info.LastSeen, info.ExpiresAt = time.Time{}, time.Time{}
+	infoData, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("session: encode device: %w", err)
+	}
+	state, _ := json.Marshal(deviceState{SessionID: d.SessionID, LastSeen: d.LastSeen, ExpiresAt: d.ExpiresAt})
+	key := r.key(userID)
+	pipe := r.Client.TxPipeline()
+	pipe.HSet(ctx, key, d.ID, infoData, d.ID+seenSuffix, state)
+	pipe.PExpire(ctx, key, r.ttl())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("session: redis add device: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisRegistry) Touch(ctx context.Context, userID string, d Device) (bool, error) {
+	state, _ := json.Marshal(deviceState{SessionID: d.SessionID, LastSeen: d.LastSeen, ExpiresAt: d.ExpiresAt})
+	n, err := touchScript.Run(ctx, r.Client, []string{r.key(userID)}, d.ID, state, r.ttl().Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("session: redis touch device: %w", err)
+	}
+	return n == 1, nil
+}
+
+func (r *RedisRegistry) Remove(ctx context.Context, userID string, deviceIDs ...string) error {
+	if len(deviceIDs) == 0 {
+		return nil
+	}
+	fields := make([]string, 0, len(deviceIDs)*2)
+	for _, id := range deviceIDs {
+		fields = append(fields, id, id+seenSuffix)
+	}
+	if err := r.Client.HDel(ctx, r.key(userID), fields...).Err(); err != nil {
+		return fmt.Errorf("session: redis remove device: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisRegistry) List(ctx context.Context, userID string) ([]Device, error) {
+	m, err := r.Client.HGetAll(ctx, r.key(userID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("session: redis list devices: %w", err)
+	}
+	devices := make([]Device, 0, len(m)/2)
+	for field, v := range m {
+		if strings.HasSuffix(field, seenSuffix) {
+			continue
+		}
+		var d Device
+		if err := json.Unmarshal([]byte(v), &d); err != nil {
+			continue
+		}
+		var st deviceState
+		if s, ok := m[field+seenSuffix]; ok {
+			_ = json.Unmarshal([]byte(s), &st)
+		}
+		d.ID, d.SessionID, d.LastSeen, d.ExpiresAt = field, st.SessionID, st.LastSeen, st.ExpiresAt
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
@@ -0,0 +1,81 @@
+package session
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+func testRegistry(t *testing.T, r Registry) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if devices, err := r.List(ctx, "u1"); err != nil || len(devices) != 0 {
+		t.Fatalf("List empty = %v %v", devices, err)
+	}
+	if ok, err := r.Touch(ctx, "u1", Device{ID: "a"}); ok || err != nil {
+		t.Fatalf("Touch missing = %v %v", ok, err)
+	}
+	d := Device{ID: "a", Name: "iPhone", UserAgent: "ua", IP: "1.2.3.4", CreatedAt: now, LastSeen: now, ExpiresAt: now.Add(time.Hour), SessionID: "s1"}
+	if err := r.Add(ctx, "u1", d); err != nil {
+		t.Fatal(err)
+	}
+	_ = r.Add(ctx, "u1", Device{ID: "b", CreatedAt: now, SessionID: "s2"})
+	_ = r.Add(ctx, "u2", Device{ID: "c", SessionID: "s3"})
+
+	later := now.Add(time.Minute)
+	if ok, err := r.Touch(ctx, "u1", Device{ID: "a", SessionID: "s4", LastSeen: later, ExpiresAt: later.Add(time.Hour)}); !ok || err != nil {
+		t.Fatalf("Touch = %v %v", ok, err)
+	}
+	devices, err := r.List(ctx, "u1")
+	if err != nil || len(devices) != 2 {
+		t.Fatalf("List = %v %v", devices, err)
+	}
+	for _, got := range devices {
+		if got.ID != "a" {
+			continue
+		}
+		want := d
+		want.SessionID, want.LastSeen, want.ExpiresAt = "s4", later, later.Add(time.Hour)
+		if !got.CreatedAt.Equal(want.CreatedAt) || !got.LastSeen.Equal(want.LastSeen) || !got.ExpiresAt.Equal(want.ExpiresAt) {
+			t.Errorf("device times = %+v", got)
+		}
+		got.CreatedAt, got.LastSeen, got.ExpiresAt = want.CreatedAt, want.LastSeen, want.ExpiresAt
+		if got != want {
+			t.Errorf("device = %+v, want %+v", got, want)
+		}
+	}
+
+	if err := r.Remove(ctx, "u1", "a", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := r.Touch(ctx, "u1", Device{ID: "a"}); ok {
+		t.Fatal("removed device must not be touched back")
+	}
+	if devices, _ := r.List(ctx, "u1"); len(devices) != 1 || devices[0].ID != "b" {
+		t.Fatalf("after remove = %v", devices)
+	}
+	if devices, _ := r.List(ctx, "u2"); len(devices) != 1 || devices[0].SessionID != "s3" {
+		t.Fatalf("other user = %v", devices)
+	}
+}
+
+func TestMemoryRegistry(t *testing.T) {
+	testRegistry(t, NewMemoryRegistry())
+}
+
+func TestRedisRegistry(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	testRegistry(t, &RedisRegistry{Client: client, TTL: time.Hour})
+
+	if ttl := mr.TTL("session:user:u1"); ttl != time.Hour {
+		t.Fatalf("key ttl = %v", ttl)
+	}
+	mr.FastForward(time.Hour)
+	if devices, _ := (&RedisRegistry{Client: client}).List(context.Background(), "u1"); len(devices) != 0 {
+		t.Fatalf("expired key = %v", devices)
+	}
+}
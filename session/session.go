@@ -0,0 +1,174 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"github.com/liuxy92/golib/convert"
+	"sync"
+	"time"
+)
+
+const (
+	idBytes     = 32
+	csrfKey     = "_csrf"
+	tokenLength = 43 //32字节base64url编码后的长度
+)
+
+// Session 一次会话的数据，并发安全。修改后由Manager.Save或中间件在响应前保存
+type Session struct {
+	mu         sync.RWMutex
+	id         string
+	oldID      string //Regenerate前的ID，保存时从存储中删除
+	values     map[string]interface{}
+	createdAt  time.Time
+	expiresAt  time.Time
+	isNew      bool
+	dirty      bool
+	destroyed  bool
+	fromHeader bool      //token来自请求头，不会被浏览器自动携带，无需校验CSRF
+	bind       *Device   //Login后待记录的设备
+	unbind     [2]string //Login前已绑定的用户及设备，保存时删除
+}
+
+func newSession(now time.Time, maxAge time.Duration) *Session {
+	return &Session{
+		id:        newID(),
+		values:    make(map[string]interface{}),
+		createdAt: now,
+		expiresAt: now.Add(maxAge),
+		isNew:     true,
+	}
+}
+
+// newID 生成32字节随机数的base64url编码
+func newID() string {
+	b := make([]byte, idBytes)
+	if _, err := rand.Read(b); err != nil {
+		panic("session: read random: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// validID 校验客户端传入的token格式，避免无效token访问存储
+func validID(s string) bool {
+	if len(s) != tokenLength {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// ID 会话token
+func (s *Session) ID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.id
+}
+
+// IsNew 是否为本次请求新建的会话
+func (s *Session) IsNew() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.isNew
+}
+
+func (s *Session) CreatedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.createdAt
+}
+
+func (s *Session) ExpiresAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.expiresAt
+}
+
+// Get 读取值，从存储加载的数字为json.Number，建议使用GetInt64等方法读取
+func (s *Session) Get(key string) interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.values[key]
+}
+
+func (s *Session) GetString(key string) string {
+	return convert.ToString(s.Get(key))
+}
+
+func (s *Session) GetInt64(key string) int64 {
+	return convert.ToInt64(s.Get(key))
+}
+
+func (s *Session) GetBool(key string) bool {
+	return convert.ToBool(s.Get(key))
+}
+
+// Set 设置值，值需能被JSON编码
+func (s *Session) Set(key string, v interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = v
+	s.dirty = true
+}
+
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.dirty = true
+	}
+}
+
+// Clear 清空所有值，会话本身保留
+func (s *Session) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = make(map[string]interface{})
+	s.dirty = true
+}
+
+// Regenerate 更换会话ID并保留数据，登录等权限变化后调用以防止会话固定攻击
+func (s *Session) Regenerate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isNew && s.oldID == "" {
+		s.oldID = s.id
+	}
+	s.id = newID()
+	s.dirty = true
+}
+
+// Destroy 销毁会话，保存时从存储删除并使cookie过期
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.destroyed = true
+}
+
+// CSRFToken 返回会话的CSRF token，不存在时生成，用于表单隐藏字段或页面meta标签
+func (s *Session) CSRFToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.values[csrfKey].(string); ok && t != "" {
+		return t
+	}
+	t := newID()
+	s.values[csrfKey] = t
+	s.dirty = true
+	return t
+}
+
+// VerifyCSRF 校验请求携带的CSRF token
+func (s *Session) VerifyCSRF(token string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	want, _ := s.values[csrfKey].(string)
+	return want != "" && subtle.ConstantTimeCompare([]byte(want), []byte(token)) == 1
+}
@@ -0,0 +1,206 @@
+package session
+
+import (
+	"context"
+	"github.com/liuxy92/golib/clockx"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionValues(t *testing.T) {
+	s := newSession(time.Now(), time.Hour)
+	if !s.IsNew() || !validID(s.ID()) {
+		t.Fatalf("new session id = %q", s.ID())
+	}
+	s.Set("uid", int64(42))
+	s.Set("name", "alice")
+	s.Set("admin", true)
+	if s.GetInt64("uid") != 42 || s.GetString("name") != "alice" || !s.GetBool("admin") {
+		t.Fatal("typed getters")
+	}
+	s.Delete("name")
+	if s.Get("name") != nil {
+		t.Fatal("delete")
+	}
+	s.Clear()
+	if s.Get("uid") != nil {
+		t.Fatal("clear")
+	}
+
+	tok := s.CSRFToken()
+	if tok == "" || s.CSRFToken() != tok {
+		t.Fatal("csrf token should be stable")
+	}
+	if !s.VerifyCSRF(tok) || s.VerifyCSRF("x") || s.VerifyCSRF("") {
+		t.Fatal("verify csrf")
+	}
+	if newSession(time.Now(), time.Hour).VerifyCSRF("") {
+		t.Fatal("empty token must not verify")
+	}
+
+	for _, id := range []string{"", "short", s.ID() + "x", "!" + s.ID()[1:]} {
+		if validID(id) {
+			t.Errorf("validID(%q)", id)
+		}
+	}
+}
+
+type testEnv struct {
+	m     *Manager
+	store *MemoryStore
+	clock *clockx.Mock
+}
+
+func newEnv(o Options) *testEnv {
+	clock := clockx.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewMemoryStore(clock)
+	o.Clock = clock
+	return &testEnv{m: NewManager(store, &o), store: store, clock: clock}
+}
+
+// roundTrip 加载请求携带的会话，调用fn后保存，返回响应
+func (e *testEnv) roundTrip(t *testing.T, r *http.Request, fn func(s *Session)) (*Session, *httptest.ResponseRecorder) {
+	t.Helper()
+	s, err := e.m.Load(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fn != nil {
+		fn(s)
+	}
+	w := httptest.NewRecorder()
+	if err := e.m.Save(context.Background(), w, s); err != nil {
+		t.Fatal(err)
+	}
+	return s, w
+}
+
+func withCookies(w *httptest.ResponseRecorder) *http.Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	for _, c := range w.Result().Cookies() {
+		r.AddCookie(c)
+	}
+	return r
+}
+
+func TestManagerCookie(t *testing.T) {
+	e := newEnv(Options{MaxAge: time.Hour})
+
+	//未修改的新会话不保存
+	_, w := e.roundTrip(t, httptest.NewRequest("GET", "/", nil), nil)
+	if len(w.Result().Cookies()) != 0 || e.store.Len() != 0 {
+		t.Fatal("anonymous session should not be saved")
+	}
+
+	s, w := e.roundTrip(t, httptest.NewRequest("GET", "/", nil), func(s *Session) { s.Set("uid", 7) })
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != s.ID() || !cookies[0].HttpOnly || cookies[0].MaxAge != 3600 || cookies[0].SameSite != http.SameSiteLaxMode {
+		t.Fatalf("cookie = %+v", cookies)
+	}
+
+	s2, w2 := e.roundTrip(t, withCookies(w), nil)
+	if s2.IsNew() || s2.ID() != s.ID() || s2.GetInt64("uid") != 7 {
+		t.Fatalf("reload = %+v", s2)
+	}
+	if len(w2.Result().Cookies()) != 0 {
+		t.Fatal("unchanged session should not reset cookie")
+	}
+
+	//非滚动过期从创建时起算
+	e.clock.Add(30 * time.Minute)
+	e.roundTrip(t, withCookies(w), func(s *Session) { s.Set("uid", 8) })
+	e.clock.Add(31 * time.Minute)
+	if s3, _ := e.roundTrip(t, withCookies(w), nil); !s3.IsNew() {
+		t.Fatal("session should expire after MaxAge")
+	}
+}
+
+func TestManagerRolling(t *testing.T) {
+	e := newEnv(Options{MaxAge: time.Hour, Rolling: true})
+	_, w := e.roundTrip(t, httptest.NewRequest("GET", "/", nil), func(s *Session) { s.Set("uid", 1) })
+	for i := 0; i < 3; i++ {
+		e.clock.Add(50 * time.Minute)
+		s, w2 := e.roundTrip(t, withCookies(w), nil)
+		if s.IsNew() || s.GetInt64("uid") != 1 {
+			t.Fatalf("rolling #%d expired", i)
+		}
+		if c := w2.Result().Cookies(); len(c) != 1 || c[0].MaxAge != 3600 {
+			t.Fatalf("rolling cookie = %+v", c)
+		}
+	}
+	e.clock.Add(61 * time.Minute)
+	if s, _ := e.roundTrip(t, withCookies(w), nil); !s.IsNew() {
+		t.Fatal("idle session should expire")
+	}
+}
+
+func TestManagerHeader(t *testing.T) {
+	e := newEnv(Options{Mode: ModeHeader})
+	s, w := e.roundTrip(t, httptest.NewRequest("GET", "/", nil), func(s *Session) { s.Set("k", "v") })
+	if w.Header().Get("X-Session-Token") != s.ID() || len(w.Result().Cookies()) != 0 {
+		t.Fatalf("header mode response: %v", w.Header())
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Session-Token", s.ID())
+	s2, w2 := e.roundTrip(t, r, nil)
+	if s2.GetString("k") != "v" || !s2.fromHeader || w2.Header().Get("X-Session-Token") != "" {
+		t.Fatal("header mode reload")
+	}
+
+	//header模式忽略cookie
+	r = httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: defaultCookieName, Value: s.ID()})
+	if s3, _ := e.roundTrip(t, r, nil); !s3.IsNew() {
+		t.Fatal("cookie should be ignored in header mode")
+	}
+}
+
+func TestManagerRegenerateDestroy(t *testing.T) {
+	e := newEnv(Options{Mode: ModeBoth})
+	s, w := e.roundTrip(t, httptest.NewRequest("GET", "/", nil), func(s *Session) { s.Set("step", "anon") })
+	oldID := s.ID()
+
+	s2, w2 := e.roundTrip(t, withCookies(w), func(s *Session) {
+		s.Set("uid", 1)
+		s.Regenerate()
+	})
+	if s2.ID() == oldID || w2.Header().Get("X-Session-Token") != s2.ID() || w2.Result().Cookies()[0].Value != s2.ID() {
+		t.Fatal("regenerate should issue new token")
+	}
+	if data, _ := e.store.Get(context.Background(), oldID); data != nil {
+		t.Fatal("old session should be deleted")
+	}
+	if s3, _ := e.roundTrip(t, withCookies(w2), nil); s3.GetString("step") != "anon" || s3.GetInt64("uid") != 1 {
+		t.Fatal("regenerate should keep values")
+	}
+
+	_, w4 := e.roundTrip(t, withCookies(w2), func(s *Session) { s.Destroy() })
+	if c := w4.Result().Cookies(); len(c) != 1 || c[0].MaxAge != -1 {
+		t.Fatalf("destroy cookie = %+v", c)
+	}
+	if e.store.Len() != 0 {
+		t.Fatal("destroyed session should be deleted")
+	}
+}
+
+func TestManagerCorrupted(t *testing.T) {
+	e := newEnv(Options{})
+	id := newID()
+	_ = e.store.Set(context.Background(), id, []byte("not json"), time.Hour)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: defaultCookieName, Value: id})
+	if s, _ := e.roundTrip(t, r, nil); !s.IsNew() || s.ID() == id {
+		t.Fatal("corrupted data should start a new session")
+	}
+}
+
+func TestNewManagerUnknownMode(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	NewManager(NewMemoryStore(nil), &Options{Mode: "query"})
+}
@@ -0,0 +1,116 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/liuxy92/golib/clockx"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+// Store 会话存储，数据在ttl后过期
+type Store interface {
+	Get(ctx context.Context, id string) ([]byte, error) //不存在或已过期时返回nil, nil
+	Set(ctx context.Context, id string, data []byte, ttl time.Duration) error
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryStore 进程内存储，仅适用于单实例部署及测试
+type MemoryStore struct {
+	mu        sync.Mutex
+	clock     clockx.Clock
+	items     map[string]memoryItem
+	nextSweep time.Time
+}
+
+type memoryItem struct {
+	data   []byte
+	expire time.Time
+}
+
+const sweepInterval = time.Minute
+
+// NewMemoryStore 创建进程内存储，clock为nil时使用系统时间
+func NewMemoryStore(clock clockx.Clock) *MemoryStore {
+	return &MemoryStore{clock: clockx.Or(clock), items: make(map[string]memoryItem)}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[id]
+	if !ok || !s.clock.Now().Before(it.expire) {
+		return nil, nil
+	}
+	return it.data, nil
+}
+
+func (s *MemoryStore) Set(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	if !now.Before(s.nextSweep) {
+		for k, it := range s.items {
+			if !now.Before(it.expire) {
+				delete(s.items, k)
+			}
+		}
+		s.nextSweep = now.Add(sweepInterval)
+	}
+	s.items[id] = memoryItem{data: data, expire: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, id)
+	return nil
+}
+
+// Len 未清理的会话数，包含已过期但尚未清理的会话
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// RedisStore Redis存储，多实例共享会话
+type RedisStore struct {
+	Client redis.UniversalClient
+	Prefix string //key前缀，默认 session:
+}
+
+func (s *RedisStore) key(id string) string {
+	if s.Prefix == "" {
+		return "session:" + id
+	}
+	return s.Prefix + id
+}
+
+func (s *RedisStore) Get(ctx context.Context, id string) ([]byte, error) {
+	data, err := s.Client.Get(ctx, s.key(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("session: redis get: %w", err)
+	}
+	return data, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	if err := s.Client.Set(ctx, s.key(id), data, ttl).Err(); err != nil {
+		return fmt.Errorf("session: redis set: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Delete(ctx context.Context, id string) error {
+	if err := s.Client.Del(ctx, s.key(id)).Err(); err != nil {
+		return fmt.Errorf("session: redis del: %w", err)
+	}
+	return nil
+}
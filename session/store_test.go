@@ -0,0 +1,63 @@
+package session
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/liuxy92/golib/clockx"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+func testStore(t *testing.T, s Store, advance func(time.Duration)) {
+	ctx := context.Background()
+	if data, err := s.Get(ctx, "missing"); data != nil || err != nil {
+		t.Fatalf("Get missing = %q %v", data, err)
+	}
+	if err := s.Set(ctx, "a", []byte("1"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := s.Get(ctx, "a"); string(data) != "1" || err != nil {
+		t.Fatalf("Get = %q %v", data, err)
+	}
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := s.Get(ctx, "a"); data != nil {
+		t.Fatal("deleted")
+	}
+	_ = s.Set(ctx, "b", []byte("2"), time.Minute)
+	advance(time.Minute)
+	if data, _ := s.Get(ctx, "b"); data != nil {
+		t.Fatal("expired")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	clock := clockx.NewMock(time.Now())
+	s := NewMemoryStore(clock)
+	testStore(t, s, clock.Add)
+
+	//过期数据在之后的Set中清理
+	clock.Add(time.Minute)
+	_ = s.Set(context.Background(), "c", nil, time.Minute)
+	if s.Len() != 1 {
+		t.Fatalf("Len = %d", s.Len())
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	testStore(t, &RedisStore{Client: client, Prefix: "sess:"}, mr.FastForward)
+
+	_ = (&RedisStore{Client: client}).Set(context.Background(), "x", []byte("1"), time.Minute)
+	if !mr.Exists("session:x") {
+		t.Fatal("default prefix")
+	}
+	mr.Close()
+	if _, err := (&RedisStore{Client: client}).Get(context.Background(), "x"); err == nil {
+		t.Fatal("expected error")
+	}
+}
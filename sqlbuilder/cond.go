@@ -0,0 +1,359 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Cond WHERE/HAVING条件。列名原样拼接不做转义，不要使用用户输入作为列名；
+// 值均以占位符传递。值为nil的Cond表示无条件，组合时会被忽略
+type Cond interface {
+	build(b *buffer)
+}
+
+// buffer 拼接SQL并收集参数，占位符统一使用?，Build时按方言转换
+type buffer struct {
+	strings.Builder
+	flavor Flavor //决定引号内反斜杠转义等方言差异
+	args   []interface{}
+	err    error
+}
+
+func (b *buffer) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// value 写入一个值：Expr原样写入，其他值写占位符
+func (b *buffer) value(v interface{}) {
+	if r, ok := v.(rawExpr); ok {
+		b.expr(r.sql, r.args)
+		return
+	}
+	b.WriteByte('?')
+	b.args = append(b.args, v)
+}
+
+// expr 写入带?占位符的SQL片段，切片参数展开为多个占位符
+func (b *buffer) expr(sql string, args []interface{}) {
+	i := 0
+	for j := 0; j < len(sql); j++ {
+		c := sql[j]
+		switch {
+		case isQuote(c):
+			end := b.flavor.quoteEnd(sql, j)
+			b.WriteString(sql[j:end])
+			j = end - 1
+			continue
+		case c == '?':
+			if i >= len(args) {
+				b.setErr(fmt.Errorf("sqlbuilder: not enough args for %q", sql))
+				return
+			}
+			b.list(args[i])
+			i++
+			continue
+		}
+		b.WriteByte(c)
+	}
+	if i != len(args) {
+		b.setErr(fmt.Errorf("sqlbuilder: %d args for %d placeholders in %q", len(args), i, sql))
+	}
+}
+
+// list 写入一个值，切片展开为逗号分隔的占位符
+func (b *buffer) list(v interface{}) {
+	vals, ok := expand(v)
+	if !ok {
+		b.value(v)
+		return
+	}
+	if len(vals) == 0 {
+		b.setErr(errors.New("sqlbuilder: empty slice arg"))
+		return
+	}
+	for i, v := range vals {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.value(v)
+	}
+}
+
+// expand 展开切片及数组，[]byte视为单个值
+func expand(v interface{}) ([]interface{}, bool) {
+	switch vs := v.(type) {
+	case []interface{}:
+		return vs, true
+	case []byte, nil:
+		return nil, false
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	out := make([]interface{}, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out, true
+}
+
+type rawExpr struct {
+	sql  string
+	args []interface{}
+}
+
+func (r rawExpr) build(b *buffer) {
+	b.expr(r.sql, r.args)
+}
+
+// Expr 带?占位符的SQL表达式，切片参数自动展开，如 Expr("id IN (?) AND status = ?", ids, 1)。
+// 也可作为INSERT的值及UPDATE的赋值原样写入，如 Expr("NOW()")、Expr("stock - ?", n)
+func Expr(sql string, args ...interface{}) Cond {
+	return rawExpr{sql: sql, args: args}
+}
+
+type compare struct {
+	col string
+	op  string
+	val interface{}
+}
+
+func (c compare) build(b *buffer) {
+	b.WriteString(c.col)
+	b.WriteByte(' ')
+	b.WriteString(c.op)
+	b.WriteByte(' ')
+	b.value(c.val)
+}
+
+// Eq col = val，val为nil时为 col IS NULL
+func Eq(col string, val interface{}) Cond {
+	if val == nil {
+		return IsNull(col)
+	}
+	return compare{col, "=", val}
+}
+
+// Ne col <> val，val为nil时为 col IS NOT NULL
+func Ne(col string, val interface{}) Cond {
+	if val == nil {
+		return IsNotNull(col)
+	}
+	return compare{col, "<>", val}
+}
+
+func Gt(col string, val interface{}) Cond  { return compare{col, ">", val} }
+func Gte(col string, val interface{}) Cond { return compare{col, ">=", val} }
+func Lt(col string, val interface{}) Cond  { return compare{col, "<", val} }
+func Lte(col string, val interface{}) Cond { return compare{col, "<=", val} }
+
+// like col LIKE pattern。SQLite没有默认的转义字符，需显式指定 ESCAPE '\'；
+// MySQL及PostgreSQL默认以\转义，且MySQL字面量中的\本身随sql_mode需要转义，因此不加ESCAPE
+type like struct {
+	col     string
+	pattern string
+}
+
+func (l like) build(b *buffer) {
+	compare{l.col, "LIKE", l.pattern}.build(b)
+	if b.flavor == SQLite {
+		b.WriteString(` ESCAPE '\'`)
+	}
+}
+
+// Like col LIKE pattern，pattern需自行包含%，可用EscapeLike转义其中的普通文本
+func Like(col, pattern string) Cond { return like{col, pattern} }
+
+// Contains col LIKE %s%，s中的%、_及\会被转义
+func Contains(col, s string) Cond {
+	return like{col, "%" + EscapeLike(s) + "%"}
+}
+
+// EscapeLike 以\转义LIKE模式中的通配符
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type unary struct {
+	col string
+	op  string
+}
+
+func (u unary) build(b *buffer) {
+	b.WriteString(u.col)
+	b.WriteByte(' ')
+	b.WriteString(u.op)
+}
+
+func IsNull(col string) Cond    { return unary{col, "IS NULL"} }
+func IsNotNull(col string) Cond { return unary{col, "IS NOT NULL"} }
+
+type in struct {
+	col  string
+	not  bool
+	vals []interface{}
+}
+
+func (c in) build(b *buffer) {
+	//空列表时IN恒为假，NOT IN恒为真，避免生成非法的 IN ()
+	if len(c.vals) == 0 {
+		if c.not {
+			b.WriteString("1 = 1")
+		} else {
+			b.WriteString("1 = 0")
+		}
+		return
+	}
+	b.WriteString(c.col)
+	if c.not {
+		b.WriteString(" NOT IN (")
+	} else {
+		b.WriteString(" IN (")
+	}
+	for i, v := range c.vals {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.value(v)
+	}
+	b.WriteByte(')')
+}
+
+// In col IN (...)，values为切片时展开，如 In("id", []int64{1, 2})；列表为空时条件恒为假
+func In(col string, values ...interface{}) Cond {
+	return in{col: col, vals: flatten(values)}
+}
+
+// NotIn col NOT IN (...)，列表为空时条件恒为真
+func NotIn(col string, values ...interface{}) Cond {
+	return in{col: col, not: true, vals: flatten(values)}
+}
+
+func flatten(values []interface{}) []interface{} {
+	if len(values) == 1 {
+		if vs, ok := expand(values[0]); ok {
+			return vs
+		}
+	}
+	return values
+}
+
+type between struct {
+	col      string
+	from, to interface{}
+}
+
+func (c between) build(b *buffer) {
+	b.WriteString(c.col)
+	b.WriteString(" BETWEEN ")
+	b.value(c.from)
+	b.WriteString(" AND ")
+	b.value(c.to)
+}
+
+func Between(col string, from, to interface{}) Cond { return between{col, from, to} }
+
+type junction struct {
+	op    string
+	conds []Cond
+}
+
+func (j junction) build(b *buffer) {
+	b.WriteByte('(')
+	for i, c := range j.conds {
+		if i > 0 {
+			b.WriteString(j.op)
+		}
+		c.build(b)
+	}
+	b.WriteByte(')')
+}
+
+func newJunction(op string, conds []Cond) Cond {
+	conds = compact(conds)
+	switch len(conds) {
+	case 0:
+		return nil
+	case 1:
+		return conds[0]
+	}
+	return junction{op, conds}
+}
+
+// And 以AND连接条件，忽略nil
+func And(conds ...Cond) Cond { return newJunction(" AND ", conds) }
+
+// Or 以OR连接条件，忽略nil
+func Or(conds ...Cond) Cond { return newJunction(" OR ", conds) }
+
+type not struct {
+	c Cond
+}
+
+func (n not) build(b *buffer) {
+	b.WriteString("NOT (")
+	n.c.build(b)
+	b.WriteByte(')')
+}
+
+func Not(c Cond) Cond {
+	if c == nil {
+		return nil
+	}
+	return not{c}
+}
+
+// If ok为true时返回c，否则返回nil，用于按参数拼接查询条件
+func If(ok bool, c Cond) Cond {
+	if !ok {
+		return nil
+	}
+	return c
+}
+
+func compact(conds []Cond) []Cond {
+	out := conds[:0:0]
+	for _, c := range conds {
+		if c != nil {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// writeConds 写入 WHERE/HAVING 子句，conds为空时不写
+func writeConds(b *buffer, keyword string, conds []Cond) {
+	if len(conds) == 0 {
+		return
+	}
+	b.WriteString(keyword)
+	for i, c := range conds {
+		if i > 0 {
+			b.WriteString(" AND ")
+		}
+		c.build(b)
+	}
+}
+
+// ToSQL 单独生成条件的SQL片段及参数，占位符为?，用于与其他查询方式(如GORM的Where)组合
+func ToSQL(c Cond) (string, []interface{}, error) {
+	if c == nil {
+		return "", nil, nil
+	}
+	b := &buffer{flavor: DefaultFlavor}
+	c.build(b)
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	return b.String(), b.args, nil
+}
@@ -0,0 +1,71 @@
+package sqlbuilder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func buildCond(c Cond) (string, []interface{}, error) {
+	b := &buffer{}
+	c.build(b)
+	return b.String(), b.args, b.err
+}
+
+func TestConds(t *testing.T) {
+	cases := []struct {
+		c    Cond
+		sql  string
+		args []interface{}
+	}{
+		{Eq("a", 1), "a = ?", []interface{}{1}},
+		{Eq("a", nil), "a IS NULL", nil},
+		{Ne("a", nil), "a IS NOT NULL", nil},
+		{Gte("age", 18), "age >= ?", []interface{}{18}},
+		{Contains("name", "50%_off"), "name LIKE ?", []interface{}{`%50\%\_off%`}},
+		{In("id", []int64{1, 2, 3}), "id IN (?, ?, ?)", []interface{}{int64(1), int64(2), int64(3)}},
+		{In("id", 1, 2), "id IN (?, ?)", []interface{}{1, 2}},
+		{In("id", []int{}), "1 = 0", nil},
+		{NotIn("id", []string{}), "1 = 1", nil},
+		{In("data", []byte("x")), "data IN (?)", []interface{}{[]byte("x")}},
+		{Between("t", 1, 2), "t BETWEEN ? AND ?", []interface{}{1, 2}},
+		{Or(Eq("a", 1), And(Eq("b", 2), Lt("c", 3))), "(a = ? OR (b = ? AND c < ?))", []interface{}{1, 2, 3}},
+		{And(nil, Eq("a", 1), If(false, Eq("b", 2))), "a = ?", []interface{}{1}},
+		{Not(In("s", "x", "y")), "NOT (s IN (?, ?))", []interface{}{"x", "y"}},
+		{Expr("id IN (?) AND s = ?", []int{1, 2}, "ok"), "id IN (?, ?) AND s = ?", []interface{}{1, 2, "ok"}},
+		{Expr("note = '?' AND id = ?", 1), "note = '?' AND id = ?", []interface{}{1}},
+		{Expr(`note = 'it\'s ?' AND id = ?`, 1), `note = 'it\'s ?' AND id = ?`, []interface{}{1}},
+	}
+	for i, c := range cases {
+		sql, args, err := buildCond(c.c)
+		if err != nil || sql != c.sql || !reflect.DeepEqual(args, c.args) {
+			t.Errorf("#%d: got %q %v %v, want %q %v", i, sql, args, err, c.sql, c.args)
+		}
+	}
+}
+
+func TestCondEmpty(t *testing.T) {
+	if And() != nil || Or(nil, nil) != nil || Not(nil) != nil || If(false, Eq("a", 1)) != nil {
+		t.Error("expected nil conds")
+	}
+}
+
+func TestExprArgCount(t *testing.T) {
+	for _, c := range []Cond{Expr("a = ? AND b = ?", 1), Expr("a = ?", 1, 2), Expr("a IN (?)", []int{})} {
+		if _, _, err := buildCond(c); err == nil {
+			t.Errorf("%v: expected error", c)
+		}
+	}
+}
+
+func TestToSQL(t *testing.T) {
+	sql, args, err := ToSQL(And(Eq("a", 1), In("b", []string{"x", "y"})))
+	if err != nil || sql != "(a = ? AND b IN (?, ?))" || len(args) != 3 {
+		t.Errorf("%s %v %v", sql, args, err)
+	}
+	if sql, _, err := ToSQL(nil); sql != "" || err != nil {
+		t.Errorf("nil: %q %v", sql, err)
+	}
+	if _, _, err := ToSQL(Expr("a = ?")); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,86 @@
+package sqlbuilder
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Flavor 数据库方言，决定占位符格式
+type Flavor int
+
+const (
+	MySQL      Flavor = iota //占位符为?
+	PostgreSQL               //占位符为$1、$2...
+	SQLite                   //占位符为?
+)
+
+// DefaultFlavor 新建builder时使用的方言
+var DefaultFlavor = MySQL
+
+func (f Flavor) String() string {
+	switch f {
+	case MySQL:
+		return "mysql"
+	case PostgreSQL:
+		return "postgresql"
+	case SQLite:
+		return "sqlite"
+	}
+	return "unknown"
+}
+
+// Rebind 将?占位符转换为当前方言的格式，引号内的?不做转换
+func (f Flavor) Rebind(query string) string {
+	if f != PostgreSQL || !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case isQuote(c):
+			end := f.quoteEnd(query, i)
+			b.WriteString(query[i:end])
+			i = end - 1
+			continue
+		case c == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func isQuote(c byte) bool {
+	return c == '\'' || c == '"' || c == '`'
+}
+
+// quoteEnd 返回从query[i]处的引号开始的字面量或标识符之后的位置，未闭合时返回len(query)。
+// 连续两个引号表示引号本身；MySQL的字符串及PostgreSQL的E'...'字符串中\转义下一个字符，
+// 其他情况下\为普通字符(PostgreSQL开启standard_conforming_strings，SQLite不支持反斜杠转义)
+func (f Flavor) quoteEnd(query string, i int) int {
+	q := query[i]
+	backslash := false
+	switch f {
+	case MySQL:
+		backslash = q != '`'
+	case PostgreSQL:
+		backslash = q == '\'' && i > 0 && (query[i-1] == 'E' || query[i-1] == 'e') && (i < 2 || !isNameChar(query[i-2]))
+	}
+	for j := i + 1; j < len(query); j++ {
+		switch query[j] {
+		case '\\':
+			if backslash {
+				j++
+			}
+		case q:
+			return j + 1
+		}
+	}
+	return len(query)
+}
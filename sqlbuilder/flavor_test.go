@@ -0,0 +1,23 @@
+package sqlbuilder
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	cases := []struct {
+		f        Flavor
+		in, want string
+	}{
+		{MySQL, "a = ? AND b = ?", "a = ? AND b = ?"},
+		{PostgreSQL, "a = ? AND b IN (?, ?)", "a = $1 AND b IN ($2, $3)"},
+		{PostgreSQL, "a = '?' AND b = ? AND \"c?\" = ?", "a = '?' AND b = $1 AND \"c?\" = $2"},
+		{SQLite, "a = ?", "a = ?"},
+		{PostgreSQL, `a = E'it\'s ?' AND b = ?`, `a = E'it\'s ?' AND b = $1`},
+		{PostgreSQL, `a = 'C:\' AND b = ? AND c = 'x''?'`, `a = 'C:\' AND b = $1 AND c = 'x''?'`},
+		{PostgreSQL, `a = ? AND b = 'unclosed ?`, `a = $1 AND b = 'unclosed ?`},
+	}
+	for _, c := range cases {
+		if got := c.f.Rebind(c.in); got != c.want {
+			t.Errorf("%s %q: got %q, want %q", c.f, c.in, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxPlaceholders MySQL及PostgreSQL单条语句的占位符上限
+const maxPlaceholders = 65535
+
+// InsertBuilder 构造INSERT语句，支持多行插入及冲突时更新(upsert)
+type InsertBuilder struct {
+	flavor     Flavor
+	table      string
+	columns    []string
+	rows       [][]interface{}
+	returning  []string
+	conflict   []string
+	action     conflictAction
+	updateCols []string
+	updates    []assignment
+	err        error
+}
+
+// Statement 一条待执行的SQL及其参数
+type Statement struct {
+	SQL  string
+	Args []interface{}
+}
+
+func InsertInto(table string) *InsertBuilder {
+	return &InsertBuilder{flavor: DefaultFlavor, table: table}
+}
+
+func (s *InsertBuilder) SetFlavor(f Flavor) *InsertBuilder {
+	s.flavor = f
+	return s
+}
+
+func (s *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	s.columns = columns
+	return s
+}
+
+// Values 追加一行，值的个数需与Columns一致，值可以为Expr
+func (s *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	s.rows = append(s.rows, values)
+	return s
+}
+
+// Record 以列名到值的映射追加一行。第一次调用时按列名排序设置Columns，之后每行的列需相同
+func (s *InsertBuilder) Record(m map[string]interface{}) *InsertBuilder {
+	if len(s.columns) == 0 {
+		for col := range m {
+			s.columns = append(s.columns, col)
+		}
+		sort.Strings(s.columns)
+	}
+	row := make([]interface{}, len(s.columns))
+	for i, col := range s.columns {
+		v, ok := m[col]
+		if !ok || len(m) != len(s.columns) {
+			//列不一致时在Build时报错
+			if s.err == nil {
+				s.err = fmt.Errorf("sqlbuilder: record %d columns differ from %v", len(s.rows), s.columns)
+			}
+			return s
+		}
+		row[i] = v
+	}
+	return s.Values(row...)
+}
+
+// Returning PostgreSQL及SQLite的 RETURNING 子句
+func (s *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	s.returning = columns
+	return s
+}
+
+func (s *InsertBuilder) Build() (string, []interface{}, error) {
+	if s.table == "" {
+		return "", nil, errors.New("sqlbuilder: insert without table")
+	}
+	if s.err != nil {
+		return "", nil, s.err
+	}
+	if len(s.columns) == 0 || len(s.rows) == 0 {
+		return "", nil, errors.New("sqlbuilder: insert without values")
+	}
+	b := &buffer{flavor: s.flavor}
+	b.WriteString("INSERT INTO ")
+	b.WriteString(s.table)
+	b.WriteString(" (")
+	b.WriteString(strings.Join(s.columns, ", "))
+	b.WriteString(") VALUES ")
+	for i, row := range s.rows {
+		if len(row) != len(s.columns) {
+			return "", nil, fmt.Errorf("sqlbuilder: row %d has %d values, want %d", i, len(row), len(s.columns))
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j, v := range row {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.value(v)
+		}
+		b.WriteByte(')')
+	}
+	if err := s.buildConflict(b); err != nil {
+		return "", nil, err
+	}
+	if len(s.returning) > 0 {
+		if s.flavor == MySQL {
+			return "", nil, errors.New("sqlbuilder: mysql does not support RETURNING")
+		}
+		b.WriteString(" RETURNING ")
+		b.WriteString(strings.Join(s.returning, ", "))
+	}
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	return s.flavor.Rebind(b.String()), b.args, nil
+}
+
+// BuildBatches 将多行插入按每批最多size行拆分为多条语句，冲突处理及RETURNING应用于每一批。
+// 每批的占位符按各行实际参数(含Expr参数及展开的切片)加上冲突更新子句的参数计算，超过65535时提前分批
+func (s *InsertBuilder) BuildBatches(size int) ([]Statement, error) {
+	if len(s.columns) == 0 || len(s.rows) == 0 {
+		sql, args, err := s.Build()
+		if err != nil {
+			return nil, err
+		}
+		return []Statement{{sql, args}}, nil
+	}
+	if size <= 0 {
+		size = len(s.rows)
+	}
+	fixed := s.conflictArgs()
+	starts := []int{0} //每批的起始行
+	n := fixed
+	for i, row := range s.rows {
+		c := s.rowArgs(row)
+		if fixed+c > maxPlaceholders {
+			return nil, fmt.Errorf("sqlbuilder: row %d needs %d placeholders, over %d", i, fixed+c, maxPlaceholders)
+		}
+		if start := starts[len(starts)-1]; i > start && (i-start >= size || n+c > maxPlaceholders) {
+			starts = append(starts, i)
+			n = fixed
+		}
+		n += c
+	}
+	if len(starts) == 1 {
+		sql, args, err := s.Build()
+		if err != nil {
+			return nil, err
+		}
+		return []Statement{{sql, args}}, nil
+	}
+	out := make([]Statement, 0, len(starts))
+	batch := *s
+	for k, start := range starts {
+		end := len(s.rows)
+		if k+1 < len(starts) {
+			end = starts[k+1]
+		}
+		batch.rows = s.rows[start:end]
+		sql, args, err := batch.Build()
+		if err != nil {
+			return nil, fmt.Errorf("sqlbuilder: batch at row %d: %w", start, err)
+		}
+		out = append(out, Statement{sql, args})
+	}
+	return out, nil
+}
+
+// rowArgs 一行写入的参数个数，行内的错误由Build报告
+func (s *InsertBuilder) rowArgs(row []interface{}) int {
+	b := &buffer{flavor: s.flavor}
+	for _, v := range row {
+		b.value(v)
+	}
+	return len(b.args)
+}
+
+// conflictArgs 冲突更新子句中的参数个数，每批都会重复
+func (s *InsertBuilder) conflictArgs() int {
+	b := &buffer{flavor: s.flavor}
+	if s.buildConflict(b) != nil {
+		return 0
+	}
+	return len(b.args)
+}
@@ -0,0 +1,54 @@
+package sqlbuilder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInsert(t *testing.T) {
+	sql, args, err := InsertInto("users").
+		Columns("name", "age", "created_at").
+		Values("a", 18, Expr("NOW()")).
+		Values("b", 20, Expr("NOW()")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "INSERT INTO users (name, age, created_at) VALUES (?, ?, NOW()), (?, ?, NOW())" {
+		t.Error(sql)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a", 18, "b", 20}) {
+		t.Errorf("args: %v", args)
+	}
+}
+
+func TestInsertRecord(t *testing.T) {
+	sql, args, err := InsertInto("users").SetFlavor(PostgreSQL).
+		Record(map[string]interface{}{"name": "a", "age": 18}).
+		Record(map[string]interface{}{"age": 20, "name": "b"}).
+		Returning("id").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "INSERT INTO users (age, name) VALUES ($1, $2), ($3, $4) RETURNING id" {
+		t.Error(sql)
+	}
+	if !reflect.DeepEqual(args, []interface{}{18, "a", 20, "b"}) {
+		t.Errorf("args: %v", args)
+	}
+}
+
+func TestInsertErrors(t *testing.T) {
+	cases := []*InsertBuilder{
+		InsertInto("t"),
+		InsertInto("t").Columns("a", "b").Values(1),
+		InsertInto("t").Record(map[string]interface{}{"a": 1}).Record(map[string]interface{}{"b": 1}),
+		InsertInto("t").Columns("a").Values(1).Returning("id"),
+	}
+	for i, c := range cases {
+		if _, _, err := c.Build(); err == nil {
+			t.Errorf("#%d: expected error", i)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Named 将:name形式的命名参数转换为?占位符及参数列表。arg为map[string]interface{}或结构体(指针)，
+// 结构体字段名取db tag，没有tag时使用字段名；切片参数展开，如 "id IN (:ids)"。
+// 引号内的内容及PostgreSQL的::类型转换不做处理，结果可用Flavor.Rebind转换占位符
+func Named(query string, arg interface{}) (string, []interface{}, error) {
+	lookup, err := namedLookup(arg)
+	if err != nil {
+		return "", nil, err
+	}
+	b := &buffer{flavor: DefaultFlavor}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case isQuote(c):
+			end := DefaultFlavor.quoteEnd(query, i)
+			b.WriteString(query[i:end])
+			i = end - 1
+			continue
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			b.WriteString("::")
+			i++
+			continue
+		case c == ':' && i+1 < len(query) && isNameChar(query[i+1]):
+			j := i + 1
+			for j < len(query) && isNameChar(query[j]) {
+				j++
+			}
+			name := query[i+1 : j]
+			v, ok := lookup(name)
+			if !ok {
+				return "", nil, fmt.Errorf("sqlbuilder: missing named arg %q", name)
+			}
+			b.list(v)
+			i = j - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	return b.String(), b.args, nil
+}
+
+// NamedExpr 使用命名参数的条件，参数错误在Build时返回
+func NamedExpr(query string, arg interface{}) Cond {
+	sql, args, err := Named(query, arg)
+	if err != nil {
+		return errCond{err}
+	}
+	return rawExpr{sql: sql, args: args}
+}
+
+type errCond struct {
+	err error
+}
+
+func (c errCond) build(b *buffer) {
+	b.setErr(c.err)
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func namedLookup(arg interface{}) (func(string) (interface{}, bool), error) {
+	if m, ok := arg.(map[string]interface{}); ok {
+		return func(name string) (interface{}, bool) {
+			v, ok := m[name]
+			return v, ok
+		}, nil
+	}
+	rv := reflect.ValueOf(arg)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("sqlbuilder: named arg must be map[string]interface{} or struct, got %T", arg)
+	}
+	fields := make(map[string]int)
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("db"); tag != "" {
+			name = strings.Split(tag, ",")[0]
+			if name == "-" {
+				continue
+			}
+		}
+		fields[name] = i
+	}
+	return func(name string) (interface{}, bool) {
+		i, ok := fields[name]
+		if !ok {
+			return nil, false
+		}
+		return rv.Field(i).Interface(), true
+	}, nil
+}
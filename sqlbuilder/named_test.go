@@ -0,0 +1,66 @@
+package sqlbuilder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	type filter struct {
+		Status int     `db:"status"`
+		IDs    []int64 `db:"ids"`
+		Name   string
+		Secret string `db:"-"`
+	}
+	cases := []struct {
+		query string
+		arg   interface{}
+		sql   string
+		args  []interface{}
+	}{
+		{
+			"SELECT * FROM t WHERE status = :status AND id IN (:ids) AND name = :Name",
+			&filter{Status: 1, IDs: []int64{3, 4}, Name: "a"},
+			"SELECT * FROM t WHERE status = ? AND id IN (?, ?) AND name = ?",
+			[]interface{}{1, int64(3), int64(4), "a"},
+		},
+		{
+			"SELECT id::text FROM t WHERE note = ':skip' AND a = :a OR b = :a",
+			map[string]interface{}{"a": 1},
+			"SELECT id::text FROM t WHERE note = ':skip' AND a = ? OR b = ?",
+			[]interface{}{1, 1},
+		},
+	}
+	for i, c := range cases {
+		sql, args, err := Named(c.query, c.arg)
+		if err != nil || sql != c.sql || !reflect.DeepEqual(args, c.args) {
+			t.Errorf("#%d: got %q %v %v", i, sql, args, err)
+		}
+	}
+}
+
+func TestNamedErrors(t *testing.T) {
+	if _, _, err := Named("a = :missing", map[string]interface{}{}); err == nil {
+		t.Error("expected missing arg error")
+	}
+	if _, _, err := Named("a = :secret", struct {
+		Secret string `db:"-"`
+	}{}); err == nil {
+		t.Error("expected ignored field error")
+	}
+	if _, _, err := Named("a = :a", 1); err == nil {
+		t.Error("expected arg type error")
+	}
+}
+
+func TestNamedExpr(t *testing.T) {
+	sql, args, err := Select("id").From("t").SetFlavor(PostgreSQL).
+		Where(NamedExpr("created_at BETWEEN :from AND :to", map[string]interface{}{"from": 1, "to": 2})).
+		Build()
+	if err != nil || sql != "SELECT id FROM t WHERE created_at BETWEEN $1 AND $2" || len(args) != 2 {
+		t.Errorf("%s %v %v", sql, args, err)
+	}
+	if _, _, err := Select().From("t").Where(NamedExpr(":x", map[string]interface{}{})).Build(); err == nil {
+		t.Error("expected error")
+	}
+}
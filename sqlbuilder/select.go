@@ -0,0 +1,198 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type join struct {
+	kind  string
+	table string
+	on    string
+	args  []interface{}
+}
+
+// SelectBuilder 构造SELECT语句，方法返回自身以便链式调用
+type SelectBuilder struct {
+	flavor   Flavor
+	distinct bool
+	columns  []string
+	table    string
+	joins    []join
+	where    []Cond
+	groupBy  []string
+	having   []Cond
+	orderBy  []string
+	limit    int
+	offset   int
+	err      error
+}
+
+// Select 新建SELECT，columns为空时为 SELECT *
+func Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{flavor: DefaultFlavor, columns: columns, limit: -1, offset: -1}
+}
+
+func (s *SelectBuilder) SetFlavor(f Flavor) *SelectBuilder {
+	s.flavor = f
+	return s
+}
+
+func (s *SelectBuilder) Distinct() *SelectBuilder {
+	s.distinct = true
+	return s
+}
+
+func (s *SelectBuilder) From(table string) *SelectBuilder {
+	s.table = table
+	return s
+}
+
+// Join INNER JOIN，on可带?占位符
+func (s *SelectBuilder) Join(table, on string, args ...interface{}) *SelectBuilder {
+	s.joins = append(s.joins, join{"JOIN", table, on, args})
+	return s
+}
+
+func (s *SelectBuilder) LeftJoin(table, on string, args ...interface{}) *SelectBuilder {
+	s.joins = append(s.joins, join{"LEFT JOIN", table, on, args})
+	return s
+}
+
+// Where 追加条件，多次调用及多个条件之间以AND连接，nil条件被忽略
+func (s *SelectBuilder) Where(conds ...Cond) *SelectBuilder {
+	s.where = append(s.where, compact(conds)...)
+	return s
+}
+
+func (s *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	s.groupBy = append(s.groupBy, columns...)
+	return s
+}
+
+func (s *SelectBuilder) Having(conds ...Cond) *SelectBuilder {
+	s.having = append(s.having, compact(conds)...)
+	return s
+}
+
+// OrderBy 追加排序，如 OrderBy("created_at DESC", "id")。原样拼接，排序字段来自用户输入时需先校验
+func (s *SelectBuilder) OrderBy(columns ...string) *SelectBuilder {
+	s.orderBy = append(s.orderBy, columns...)
+	return s
+}
+
+// Limit 小于0表示不限制
+func (s *SelectBuilder) Limit(n int) *SelectBuilder {
+	s.limit = n
+	return s
+}
+
+func (s *SelectBuilder) Offset(n int) *SelectBuilder {
+	s.offset = n
+	return s
+}
+
+// Page 分页，page从1开始，小于1时视为1；size需大于0，否则Build时报错
+func (s *SelectBuilder) Page(page, size int) *SelectBuilder {
+	if size <= 0 {
+		s.err = fmt.Errorf("sqlbuilder: page size %d must be positive", size)
+		return s
+	}
+	if page < 1 {
+		page = 1
+	}
+	s.limit = size
+	s.offset = (page - 1) * size
+	return s
+}
+
+// Build 生成SQL及参数
+func (s *SelectBuilder) Build() (string, []interface{}, error) {
+	b := &buffer{flavor: s.flavor}
+	s.build(b, false)
+	return s.finish(b)
+}
+
+// BuildCount 生成相同条件下统计总数的SQL，忽略排序及分页，用于分页查询的总数。
+// 有DISTINCT或GROUP BY时以子查询统计
+func (s *SelectBuilder) BuildCount() (string, []interface{}, error) {
+	b := &buffer{flavor: s.flavor}
+	if s.distinct || len(s.groupBy) > 0 {
+		b.WriteString("SELECT COUNT(*) FROM (")
+		s.build(b, true)
+		b.WriteString(") AS t")
+	} else {
+		c := *s
+		c.columns = []string{"COUNT(*)"}
+		c.build(b, true)
+	}
+	return s.finish(b)
+}
+
+func (s *SelectBuilder) finish(b *buffer) (string, []interface{}, error) {
+	if s.table == "" {
+		return "", nil, errors.New("sqlbuilder: select without table")
+	}
+	if s.err != nil {
+		return "", nil, s.err
+	}
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	return s.flavor.Rebind(b.String()), b.args, nil
+}
+
+func (s *SelectBuilder) build(b *buffer, count bool) {
+	b.WriteString("SELECT ")
+	if s.distinct {
+		b.WriteString("DISTINCT ")
+	}
+	if len(s.columns) == 0 {
+		b.WriteByte('*')
+	} else {
+		b.WriteString(strings.Join(s.columns, ", "))
+	}
+	b.WriteString(" FROM ")
+	b.WriteString(s.table)
+	for _, j := range s.joins {
+		b.WriteByte(' ')
+		b.WriteString(j.kind)
+		b.WriteByte(' ')
+		b.WriteString(j.table)
+		if j.on != "" {
+			b.WriteString(" ON ")
+			b.expr(j.on, j.args)
+		}
+	}
+	writeConds(b, " WHERE ", s.where)
+	if len(s.groupBy) > 0 {
+		b.WriteString(" GROUP BY ")
+		b.WriteString(strings.Join(s.groupBy, ", "))
+	}
+	writeConds(b, " HAVING ", s.having)
+	if count {
+		return
+	}
+	if len(s.orderBy) > 0 {
+		b.WriteString(" ORDER BY ")
+		b.WriteString(strings.Join(s.orderBy, ", "))
+	}
+	if s.limit >= 0 {
+		b.WriteString(" LIMIT ")
+		b.WriteString(strconv.Itoa(s.limit))
+	} else if s.offset > 0 {
+		//MySQL及SQLite不支持单独的OFFSET
+		switch s.flavor {
+		case MySQL:
+			b.WriteString(" LIMIT 18446744073709551615")
+		case SQLite:
+			b.WriteString(" LIMIT -1")
+		}
+	}
+	if s.offset > 0 {
+		b.WriteString(" OFFSET ")
+		b.WriteString(strconv.Itoa(s.offset))
+	}
+}
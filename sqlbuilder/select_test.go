@@ -0,0 +1,132 @@
+package sqlbuilder
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"reflect"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	name, status := "", 1
+	sql, args, err := Select("u.id", "u.name").
+		From("users u").
+		LeftJoin("orders o", "o.user_id = u.id AND o.state = ?", "paid").
+		Where(
+			Eq("u.status", status),
+			If(name != "", Contains("u.name", name)),
+			In("u.role", []string{"admin", "dev"}),
+		).
+		Where(Gt("u.id", 100)).
+		OrderBy("u.id DESC").
+		Page(3, 20).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT u.id, u.name FROM users u LEFT JOIN orders o ON o.user_id = u.id AND o.state = ? " +
+		"WHERE u.status = ? AND u.role IN (?, ?) AND u.id > ? ORDER BY u.id DESC LIMIT 20 OFFSET 40"
+	if sql != want {
+		t.Errorf("sql:\n%s\nwant:\n%s", sql, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"paid", 1, "admin", "dev", 100}) {
+		t.Errorf("args: %v", args)
+	}
+}
+
+func TestSelectPostgreSQL(t *testing.T) {
+	sql, args, err := Select().SetFlavor(PostgreSQL).
+		From("events").
+		Where(Or(Eq("kind", "a"), Eq("kind", "b")), Lt("ts", 10)).
+		GroupBy("kind").
+		Having(Expr("COUNT(*) > ?", 5)).
+		Offset(10).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT * FROM events WHERE (kind = $1 OR kind = $2) AND ts < $3 GROUP BY kind HAVING COUNT(*) > $4 OFFSET 10"
+	if sql != want || len(args) != 4 {
+		t.Errorf("got %s %v", sql, args)
+	}
+}
+
+func TestSelectOffset(t *testing.T) {
+	sql, _, _ := Select("id").From("t").Offset(5).Build()
+	if sql != "SELECT id FROM t LIMIT 18446744073709551615 OFFSET 5" {
+		t.Error(sql)
+	}
+	if sql, _, _ := Select("id").From("t").SetFlavor(SQLite).Offset(5).Build(); sql != "SELECT id FROM t LIMIT -1 OFFSET 5" {
+		t.Error(sql)
+	}
+	if sql, _, _ := Select("id").From("t").SetFlavor(PostgreSQL).Offset(5).Build(); sql != "SELECT id FROM t OFFSET 5" {
+		t.Error(sql)
+	}
+}
+
+func TestBuildCount(t *testing.T) {
+	s := Select("id", "name").From("users").Where(Eq("status", 1)).OrderBy("id").Page(2, 10)
+	sql, args, err := s.BuildCount()
+	if err != nil || sql != "SELECT COUNT(*) FROM users WHERE status = ?" || len(args) != 1 {
+		t.Errorf("count: %s %v %v", sql, args, err)
+	}
+	//原builder不受影响
+	if sql, _, _ := s.Build(); sql != "SELECT id, name FROM users WHERE status = ? ORDER BY id LIMIT 10 OFFSET 10" {
+		t.Errorf("select: %s", sql)
+	}
+
+	sql, _, _ = Select("user_id").From("orders").GroupBy("user_id").BuildCount()
+	if sql != "SELECT COUNT(*) FROM (SELECT user_id FROM orders GROUP BY user_id) AS t" {
+		t.Errorf("group count: %s", sql)
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	if _, _, err := Select().Build(); err == nil {
+		t.Error("expected missing table error")
+	}
+	if _, _, err := Select().From("t").Where(Expr("a = ?")).Build(); err == nil {
+		t.Error("expected arg count error")
+	}
+	if _, _, err := Select().From("t").Page(1, 0).Build(); err == nil {
+		t.Error("expected page size error")
+	}
+}
+
+func TestSelectLikeSQLite(t *testing.T) {
+	//SQLite没有默认转义符，需显式ESCAPE
+	sql, args, err := Select("id").From("t").SetFlavor(SQLite).Where(Contains("name", "50%_off")).Build()
+	if err != nil || sql != `SELECT id FROM t WHERE name LIKE ? ESCAPE '\'` || args[0] != `%50\%\_off%` {
+		t.Errorf("%s %v %v", sql, args, err)
+	}
+}
+
+func TestSelectWithDB(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.ExpectQuery("SELECT id, name FROM users WHERE id IN (?, ?) LIMIT 10").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+
+	sql, args, err := Select("id", "name").From("users").Where(In("id", []int{1, 2})).Limit(10).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query(sql, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("rows: %d", n)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,123 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type assignment struct {
+	col string
+	val interface{}
+}
+
+// UpdateBuilder 构造UPDATE语句
+type UpdateBuilder struct {
+	flavor   Flavor
+	table    string
+	sets     []assignment
+	where    []Cond
+	orderBy  []string
+	limit    int
+	allowAll bool
+}
+
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{flavor: DefaultFlavor, table: table, limit: -1}
+}
+
+func (s *UpdateBuilder) SetFlavor(f Flavor) *UpdateBuilder {
+	s.flavor = f
+	return s
+}
+
+// Set col = val，val可以为Expr，如 Set("stock", Expr("stock - ?", 1))
+func (s *UpdateBuilder) Set(col string, val interface{}) *UpdateBuilder {
+	s.sets = append(s.sets, assignment{col, val})
+	return s
+}
+
+// SetIf ok为true时才设置，用于部分更新
+func (s *UpdateBuilder) SetIf(ok bool, col string, val interface{}) *UpdateBuilder {
+	if ok {
+		s.Set(col, val)
+	}
+	return s
+}
+
+// SetMap 按列名排序后依次设置
+func (s *UpdateBuilder) SetMap(m map[string]interface{}) *UpdateBuilder {
+	cols := make([]string, 0, len(m))
+	for col := range m {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	for _, col := range cols {
+		s.Set(col, m[col])
+	}
+	return s
+}
+
+// Where 追加条件，多个条件以AND连接，nil条件被忽略
+func (s *UpdateBuilder) Where(conds ...Cond) *UpdateBuilder {
+	s.where = append(s.where, compact(conds)...)
+	return s
+}
+
+// AllowFullTable 允许不带WHERE条件更新全表，默认Build时报错，防止条件全部被忽略时误更新
+func (s *UpdateBuilder) AllowFullTable() *UpdateBuilder {
+	s.allowAll = true
+	return s
+}
+
+// OrderBy 及 Limit 仅MySQL支持
+func (s *UpdateBuilder) OrderBy(columns ...string) *UpdateBuilder {
+	s.orderBy = append(s.orderBy, columns...)
+	return s
+}
+
+func (s *UpdateBuilder) Limit(n int) *UpdateBuilder {
+	s.limit = n
+	return s
+}
+
+func (s *UpdateBuilder) Build() (string, []interface{}, error) {
+	if s.table == "" {
+		return "", nil, errors.New("sqlbuilder: update without table")
+	}
+	if len(s.sets) == 0 {
+		return "", nil, errors.New("sqlbuilder: update without assignments")
+	}
+	if len(s.where) == 0 && !s.allowAll {
+		return "", nil, errors.New("sqlbuilder: update without where, call AllowFullTable to update all rows")
+	}
+	if (len(s.orderBy) > 0 || s.limit >= 0) && s.flavor != MySQL {
+		return "", nil, errors.New("sqlbuilder: ORDER BY and LIMIT in update are only supported by mysql")
+	}
+	b := &buffer{flavor: s.flavor}
+	b.WriteString("UPDATE ")
+	b.WriteString(s.table)
+	b.WriteString(" SET ")
+	for i, a := range s.sets {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(a.col)
+		b.WriteString(" = ")
+		b.value(a.val)
+	}
+	writeConds(b, " WHERE ", s.where)
+	if len(s.orderBy) > 0 {
+		b.WriteString(" ORDER BY ")
+		b.WriteString(strings.Join(s.orderBy, ", "))
+	}
+	if s.limit >= 0 {
+		b.WriteString(" LIMIT ")
+		b.WriteString(strconv.Itoa(s.limit))
+	}
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	return s.flavor.Rebind(b.String()), b.args, nil
+}
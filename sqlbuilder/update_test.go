@@ -0,0 +1,49 @@
+package sqlbuilder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	var nickname *string
+	sql, args, err := Update("products").
+		Set("stock", Expr("stock - ?", 2)).
+		SetIf(nickname != nil, "nickname", nickname).
+		SetMap(map[string]interface{}{"updated_by": 7, "status": 1}).
+		Where(Eq("id", 42), Gte("stock", 2)).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "UPDATE products SET stock = stock - ?, status = ?, updated_by = ? WHERE id = ? AND stock >= ?" {
+		t.Error(sql)
+	}
+	if !reflect.DeepEqual(args, []interface{}{2, 1, 7, 42, 2}) {
+		t.Errorf("args: %v", args)
+	}
+}
+
+func TestUpdateMySQLLimit(t *testing.T) {
+	sql, _, err := Update("jobs").Set("state", "done").Where(Eq("state", "running")).OrderBy("id").Limit(100).Build()
+	if err != nil || sql != "UPDATE jobs SET state = ? WHERE state = ? ORDER BY id LIMIT 100" {
+		t.Errorf("%s %v", sql, err)
+	}
+	if _, _, err := Update("jobs").SetFlavor(PostgreSQL).Set("a", 1).Where(Eq("b", 1)).Limit(1).Build(); err == nil {
+		t.Error("expected limit error for postgresql")
+	}
+}
+
+func TestUpdateWithoutWhere(t *testing.T) {
+	//条件全部被忽略时拒绝更新全表
+	if _, _, err := Update("t").Set("a", 1).Where(If(false, Eq("id", 1))).Build(); err == nil {
+		t.Error("expected error")
+	}
+	sql, _, err := Update("t").Set("a", 1).AllowFullTable().SetFlavor(PostgreSQL).Build()
+	if err != nil || sql != "UPDATE t SET a = $1" {
+		t.Errorf("%s %v", sql, err)
+	}
+	if _, _, err := Update("t").Where(Eq("id", 1)).Build(); err == nil {
+		t.Error("expected no assignments error")
+	}
+}
@@ -0,0 +1,135 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type conflictAction int
+
+const (
+	conflictNone conflictAction = iota
+	conflictNothing
+	conflictUpdate
+)
+
+type excluded string
+
+// Excluded 在DoUpdateSet中引用本行待插入的值：MySQL为 VALUES(col)，PostgreSQL及SQLite为 EXCLUDED.col
+func Excluded(col string) interface{} {
+	return excluded(col)
+}
+
+// OnConflict 冲突目标，即判断重复的唯一索引列。PostgreSQL及SQLite执行DoUpdate时必须设置；
+// MySQL由表上的任一唯一索引判断重复，忽略该设置
+func (s *InsertBuilder) OnConflict(columns ...string) *InsertBuilder {
+	s.conflict = columns
+	return s
+}
+
+// DoNothing 冲突时跳过该行。MySQL生成 ON DUPLICATE KEY UPDATE col = col 的空更新，不使用会吞掉其他错误的 INSERT IGNORE
+func (s *InsertBuilder) DoNothing() *InsertBuilder {
+	s.action = conflictNothing
+	return s
+}
+
+// DoUpdate 冲突时以待插入的值更新columns，不指定时更新除冲突目标外的全部插入列
+func (s *InsertBuilder) DoUpdate(columns ...string) *InsertBuilder {
+	s.action = conflictUpdate
+	s.updateCols = append(s.updateCols, columns...)
+	return s
+}
+
+// DoUpdateSet 冲突时 col = val，val可以为Expr或Excluded，如 DoUpdateSet("stock", Expr("stock + 1"))
+func (s *InsertBuilder) DoUpdateSet(col string, val interface{}) *InsertBuilder {
+	s.action = conflictUpdate
+	s.updates = append(s.updates, assignment{col, val})
+	return s
+}
+
+// buildConflict 写入 ON DUPLICATE KEY UPDATE 或 ON CONFLICT 子句
+func (s *InsertBuilder) buildConflict(b *buffer) error {
+	if s.action == conflictNone {
+		if len(s.conflict) > 0 {
+			return errors.New("sqlbuilder: OnConflict without DoNothing or DoUpdate")
+		}
+		return nil
+	}
+	var sets []assignment
+	if s.action == conflictUpdate {
+		cols := s.updateCols
+		if len(cols) == 0 && len(s.updates) == 0 {
+			cols = s.nonConflictColumns()
+		}
+		for _, col := range cols {
+			sets = append(sets, assignment{col, excluded(col)})
+		}
+		sets = append(sets, s.updates...)
+		if len(sets) == 0 {
+			return errors.New("sqlbuilder: upsert without columns to update")
+		}
+	}
+
+	if s.flavor == MySQL {
+		b.WriteString(" ON DUPLICATE KEY UPDATE ")
+		if s.action == conflictNothing {
+			col := s.columns[0]
+			if len(s.conflict) > 0 {
+				col = s.conflict[0]
+			}
+			b.WriteString(col + " = " + col)
+			return nil
+		}
+		s.writeSets(b, sets)
+		return nil
+	}
+
+	if s.action == conflictUpdate && len(s.conflict) == 0 {
+		return fmt.Errorf("sqlbuilder: %s upsert requires OnConflict columns", s.flavor)
+	}
+	b.WriteString(" ON CONFLICT")
+	if len(s.conflict) > 0 {
+		b.WriteString(" (" + strings.Join(s.conflict, ", ") + ")")
+	}
+	if s.action == conflictNothing {
+		b.WriteString(" DO NOTHING")
+		return nil
+	}
+	b.WriteString(" DO UPDATE SET ")
+	s.writeSets(b, sets)
+	return nil
+}
+
+func (s *InsertBuilder) writeSets(b *buffer, sets []assignment) {
+	for i, a := range sets {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(a.col)
+		b.WriteString(" = ")
+		if e, ok := a.val.(excluded); ok {
+			if s.flavor == MySQL {
+				b.WriteString("VALUES(" + string(e) + ")")
+			} else {
+				b.WriteString("EXCLUDED." + string(e))
+			}
+			continue
+		}
+		b.value(a.val)
+	}
+}
+
+func (s *InsertBuilder) nonConflictColumns() []string {
+	skip := make(map[string]bool, len(s.conflict))
+	for _, col := range s.conflict {
+		skip[col] = true
+	}
+	var cols []string
+	for _, col := range s.columns {
+		if !skip[col] {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
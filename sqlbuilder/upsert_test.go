@@ -0,0 +1,115 @@
+package sqlbuilder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpsert(t *testing.T) {
+	newInsert := func(f Flavor) *InsertBuilder {
+		return InsertInto("stock").SetFlavor(f).
+			Columns("sku", "qty", "updated_at").
+			Values("a", 1, Expr("NOW()")).
+			Values("b", 2, Expr("NOW()")).
+			OnConflict("sku")
+	}
+	cases := []struct {
+		b    *InsertBuilder
+		want string
+	}{
+		{newInsert(MySQL).DoUpdate(),
+			"INSERT INTO stock (sku, qty, updated_at) VALUES (?, ?, NOW()), (?, ?, NOW()) " +
+				"ON DUPLICATE KEY UPDATE qty = VALUES(qty), updated_at = VALUES(updated_at)"},
+		{newInsert(PostgreSQL).DoUpdate().Returning("id"),
+			"INSERT INTO stock (sku, qty, updated_at) VALUES ($1, $2, NOW()), ($3, $4, NOW()) " +
+				"ON CONFLICT (sku) DO UPDATE SET qty = EXCLUDED.qty, updated_at = EXCLUDED.updated_at RETURNING id"},
+		{newInsert(MySQL).DoUpdate("updated_at").DoUpdateSet("qty", Expr("qty + ?", 10)),
+			"INSERT INTO stock (sku, qty, updated_at) VALUES (?, ?, NOW()), (?, ?, NOW()) " +
+				"ON DUPLICATE KEY UPDATE updated_at = VALUES(updated_at), qty = qty + ?"},
+		{newInsert(SQLite).DoUpdateSet("qty", Excluded("qty")),
+			"INSERT INTO stock (sku, qty, updated_at) VALUES (?, ?, NOW()), (?, ?, NOW()) " +
+				"ON CONFLICT (sku) DO UPDATE SET qty = EXCLUDED.qty"},
+		{newInsert(MySQL).DoNothing(),
+			"INSERT INTO stock (sku, qty, updated_at) VALUES (?, ?, NOW()), (?, ?, NOW()) " +
+				"ON DUPLICATE KEY UPDATE sku = sku"},
+		{newInsert(PostgreSQL).OnConflict().DoNothing(),
+			"INSERT INTO stock (sku, qty, updated_at) VALUES ($1, $2, NOW()), ($3, $4, NOW()) ON CONFLICT DO NOTHING"},
+	}
+	for i, c := range cases {
+		sql, _, err := c.b.Build()
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if sql != c.want {
+			t.Errorf("#%d:\n%s\nwant:\n%s", i, sql, c.want)
+		}
+	}
+
+	_, args, _ := newInsert(MySQL).DoUpdateSet("qty", Expr("qty + ?", 10)).Build()
+	if !reflect.DeepEqual(args, []interface{}{"a", 1, "b", 2, 10}) {
+		t.Errorf("args: %v", args)
+	}
+}
+
+func TestUpsertErrors(t *testing.T) {
+	cases := []*InsertBuilder{
+		InsertInto("t").Columns("a").Values(1).OnConflict("a"),
+		InsertInto("t").Columns("a").Values(1).OnConflict("a").DoUpdate(),
+		InsertInto("t").SetFlavor(PostgreSQL).Columns("a", "b").Values(1, 2).DoUpdate(),
+	}
+	for i, c := range cases {
+		if _, _, err := c.Build(); err == nil {
+			t.Errorf("#%d: expected error", i)
+		}
+	}
+}
+
+func TestBuildBatches(t *testing.T) {
+	b := InsertInto("t").SetFlavor(PostgreSQL).Columns("a", "b").OnConflict("a").DoUpdate().Returning("id")
+	for i := 0; i < 5; i++ {
+		b.Values(i, i*10)
+	}
+	stmts, err := b.BuildBatches(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 3 {
+		t.Fatalf("got %d batches", len(stmts))
+	}
+	suffix := " ON CONFLICT (a) DO UPDATE SET b = EXCLUDED.b RETURNING id"
+	want := []Statement{
+		{"INSERT INTO t (a, b) VALUES ($1, $2), ($3, $4)" + suffix, []interface{}{0, 0, 1, 10}},
+		{"INSERT INTO t (a, b) VALUES ($1, $2), ($3, $4)" + suffix, []interface{}{2, 20, 3, 30}},
+		{"INSERT INTO t (a, b) VALUES ($1, $2)" + suffix, []interface{}{4, 40}},
+	}
+	if !reflect.DeepEqual(stmts, want) {
+		t.Errorf("batches: %+v", stmts)
+	}
+
+	//按占位符上限自动缩小批次
+	wide := InsertInto("t").Columns("a", "b", "c")
+	for i := 0; i < 30000; i++ {
+		wide.Values(i, i, i)
+	}
+	if stmts, err = wide.BuildBatches(0); err != nil || len(stmts) != 2 || len(stmts[0].Args) != 65535 {
+		t.Errorf("got %d batches, err %v", len(stmts), err)
+	}
+
+	//Expr的参数及DoUpdateSet的参数计入占位符
+	exprs := InsertInto("t").SetFlavor(PostgreSQL).Columns("a", "b").OnConflict("a").DoUpdateSet("n", Expr("t.n + ?", 1))
+	for i := 0; i < 21845; i++ {
+		exprs.Values(i, Expr("? + ?", i, i))
+	}
+	stmts, err = exprs.BuildBatches(0)
+	if err != nil || len(stmts) != 2 || len(stmts[0].Args) != 21844*3+1 || len(stmts[1].Args) != 3+1 {
+		t.Errorf("got %d batches, err %v", len(stmts), err)
+	}
+
+	if _, err := InsertInto("t").Columns("a").Values(Expr("ARRAY[?]", make([]int, 65536))).BuildBatches(0); err == nil {
+		t.Error("expected too many placeholders error")
+	}
+	if _, err := InsertInto("t").Columns("a", "b").Values(1).Values(2, 3).BuildBatches(1); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,183 @@
+package testingx
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	// SkipDockerEnv 设置该环境变量时跳过所有依赖docker的测试
+	SkipDockerEnv = "TESTINGX_SKIP_DOCKER"
+
+	defaultMaxWait   = 2 * time.Minute
+	containerExpire  = 600 //容器最长存活秒数，测试进程异常退出时由docker自动清理
+	mysqlRootPass    = "secret"
+	defaultMySQLTag  = "8.0"
+	defaultRedisTag  = "7-alpine"
+	defaultKafkaTag  = "3.6"
+	defaultMySQLName = "test"
+)
+
+var (
+	poolOnce sync.Once
+	pool     *dockertest.Pool
+	poolErr  error
+)
+
+// Pool 返回共享的docker连接，docker不可用或设置了TESTINGX_SKIP_DOCKER时跳过测试
+func Pool(t testing.TB) *dockertest.Pool {
+	t.Helper()
+	if os.Getenv(SkipDockerEnv) != "" {
+		t.Skipf("%s is set", SkipDockerEnv)
+	}
+	if testing.Short() {
+		t.Skip("docker tests are skipped in short mode")
+	}
+	poolOnce.Do(func() {
+		pool, poolErr = dockertest.NewPool("")
+		if poolErr == nil {
+			poolErr = pool.Client.Ping()
+		}
+		if pool != nil {
+			pool.MaxWait = defaultMaxWait
+		}
+	})
+	if poolErr != nil {
+		t.Skipf("docker unavailable: %v", poolErr)
+	}
+	return pool
+}
+
+// Container 测试容器
+type Container struct {
+	*dockertest.Resource
+}
+
+// Addr 返回容器端口映射到本机的地址，如 Addr("6379/tcp") -> "localhost:49153"
+func (c *Container) Addr(port string) string {
+	return c.GetHostPort(port)
+}
+
+// Run 启动容器并等待ready返回nil，测试结束时自动删除容器
+func Run(t testing.TB, opts *dockertest.RunOptions, ready func(c *Container) error) *Container {
+	t.Helper()
+	p := Pool(t)
+	res, err := p.RunWithOptions(opts, func(hc *docker.HostConfig) {
+		hc.AutoRemove = true
+		hc.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		t.Fatalf("testingx: start %s:%s: %v", opts.Repository, opts.Tag, err)
+	}
+	t.Cleanup(func() { _ = p.Purge(res) })
+	_ = res.Expire(containerExpire)
+	c := &Container{res}
+	if ready != nil {
+		if err := p.Retry(func() error { return ready(c) }); err != nil {
+			t.Fatalf("testingx: %s:%s not ready: %v", opts.Repository, opts.Tag, err)
+		}
+	}
+	return c
+}
+
+// MySQL 启动MySQL容器，返回可直接用于sql.Open("mysql", dsn)的DSN，已创建test库
+func MySQL(t testing.TB, tag ...string) string {
+	t.Helper()
+	var dsn string
+	Run(t, &dockertest.RunOptions{
+		Repository: "mysql",
+		Tag:        firstOr(tag, defaultMySQLTag),
+		Env:        []string{"MYSQL_ROOT_PASSWORD=" + mysqlRootPass, "MYSQL_DATABASE=" + defaultMySQLName},
+	}, func(c *Container) error {
+		cfg := mysql.NewConfig()
+		cfg.User, cfg.Passwd, cfg.Net, cfg.Addr, cfg.DBName = "root", mysqlRootPass, "tcp", c.Addr("3306/tcp"), defaultMySQLName
+		cfg.ParseTime, cfg.MultiStatements = true, true
+		dsn = cfg.FormatDSN()
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		return db.Ping()
+	})
+	return dsn
+}
+
+// Redis 启动Redis容器，返回 host:port
+func Redis(t testing.TB, tag ...string) string {
+	t.Helper()
+	c := Run(t, &dockertest.RunOptions{Repository: "redis", Tag: firstOr(tag, defaultRedisTag)}, func(c *Container) error {
+		return pingRedis(c.Addr("6379/tcp"))
+	})
+	return c.Addr("6379/tcp")
+}
+
+// pingRedis 直接发送RESP协议的PING，避免依赖redis客户端
+func pingRedis(addr string) error {
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+		return err
+	}
+	buf := make([]byte, 7)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return err
+	}
+	if string(buf[:n]) != "+PONG\r\n" {
+		return fmt.Errorf("unexpected reply %q", buf[:n])
+	}
+	return nil
+}
+
+// Kafka 启动单节点KRaft模式的Kafka容器，返回broker地址。
+// Kafka需要对外公布可访问的地址，因此先选定本机空闲端口再做固定映射
+func Kafka(t testing.TB, tag ...string) string {
+	t.Helper()
+	port, err := FreePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	broker := net.JoinHostPort("localhost", strconv.Itoa(port))
+	Run(t, &dockertest.RunOptions{
+		Repository: "bitnami/kafka",
+		Tag:        firstOr(tag, defaultKafkaTag),
+		Env: []string{
+			"KAFKA_CFG_NODE_ID=0",
+			"KAFKA_CFG_PROCESS_ROLES=controller,broker",
+			"KAFKA_CFG_CONTROLLER_QUORUM_VOTERS=0@localhost:9093",
+			"KAFKA_CFG_LISTENERS=PLAINTEXT://:9092,CONTROLLER://:9093",
+			"KAFKA_CFG_ADVERTISED_LISTENERS=PLAINTEXT://" + broker,
+			"KAFKA_CFG_LISTENER_SECURITY_PROTOCOL_MAP=CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT",
+			"KAFKA_CFG_CONTROLLER_LISTENER_NAMES=CONTROLLER",
+			"KAFKA_CFG_AUTO_CREATE_TOPICS_ENABLE=true",
+		},
+		ExposedPorts: []string{"9092/tcp"},
+		PortBindings: map[docker.Port][]docker.PortBinding{
+			"9092/tcp": {{HostIP: "0.0.0.0", HostPort: strconv.Itoa(port)}},
+		},
+	}, func(c *Container) error {
+		return WaitPort(broker, time.Second)
+	})
+	return broker
+}
+
+func firstOr(list []string, def string) string {
+	if len(list) > 0 && list[0] != "" {
+		return list[0]
+	}
+	return def
+}
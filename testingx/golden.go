@@ -0,0 +1,53 @@
+package testingx
+
+import (
+	"bytes"
+	"flag"
+	"github.com/liuxy92/golib/jsonx"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// update 使用 go test -testingx.update 或环境变量 UPDATE_GOLDEN=1 重新生成golden文件。
+// flag带包名前缀，避免与测试包自行定义的 -update 冲突
+var update = flag.Bool("testingx.update", false, "update golden files")
+
+func updateGolden() bool {
+	return *update || os.Getenv("UPDATE_GOLDEN") == "1"
+}
+
+// GoldenDir golden文件所在目录
+var GoldenDir = "testdata"
+
+// Golden 将got与 testdata/<name>.golden 比较，-testingx.update时写入got
+func Golden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join(GoldenDir, name+".golden")
+	if updateGolden() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("testingx: read golden file (run with -testingx.update or UPDATE_GOLDEN=1 to create): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("testingx: %s mismatch\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
+
+// GoldenJSON 将v格式化为缩进的JSON后与golden文件比较，map按键排序，结果稳定
+func GoldenJSON(t testing.TB, name string, v interface{}) {
+	t.Helper()
+	data, err := jsonx.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Golden(t, name, append(data, '\n'))
+}
@@ -0,0 +1,92 @@
+package testingx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LoadSQL 依次执行SQL文件中的语句，支持通配符，匹配的文件按名称排序执行，如 LoadSQL(db, "testdata/seed/*.sql")
+func LoadSQL(db *sql.DB, patterns ...string) error {
+	return LoadSQLContext(context.Background(), db, patterns...)
+}
+
+// LoadSQLContext 同LoadSQL，可通过ctx取消
+func LoadSQLContext(ctx context.Context, db *sql.DB, patterns ...string) error {
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("testingx: no sql file matches %s", pattern)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			for i, stmt := range SplitSQL(string(data)) {
+				if _, err := db.ExecContext(ctx, stmt); err != nil {
+					return fmt.Errorf("testingx: %s statement %d: %w", file, i+1, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// SplitSQL 按分号拆分SQL语句，忽略引号内的分号及 --、# 、/* */ 注释
+func SplitSQL(script string) []string {
+	var (
+		stmts []string
+		b     strings.Builder
+		quote byte
+	)
+	flush := func() {
+		if s := strings.TrimSpace(b.String()); s != "" {
+			stmts = append(stmts, s)
+		}
+		b.Reset()
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0:
+			b.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(script) {
+				i++
+				b.WriteByte(script[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			b.WriteByte(c)
+		case c == '#' || (c == '-' && strings.HasPrefix(script[i:], "-- ")):
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			b.WriteByte('\n')
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 3
+			}
+			b.WriteByte(' ')
+		case c == ';':
+			flush()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	flush()
+	return stmts
+}
@@ -0,0 +1,3 @@
+-- 测试数据
+CREATE TABLE users (id INT PRIMARY KEY, name VARCHAR(32));
+INSERT INTO users VALUES (1, 'alice'), (2, 'bob; admin');
@@ -0,0 +1,108 @@
+package testingx
+
+import (
+	"bufio"
+	"database/sql"
+	"flag"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWaitPort(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := WaitPort(l.Addr().String(), time.Second); err != nil {
+		t.Fatal(err)
+	}
+	port, _ := FreePort()
+	if err := WaitPort(net.JoinHostPort("localhost", strconv.Itoa(port)), 200*time.Millisecond); err == nil {
+		t.Error("closed port should time out")
+	}
+}
+
+func TestSplitSQL(t *testing.T) {
+	script := `
+-- users
+CREATE TABLE t (id INT, name VARCHAR(10)); # trailing comment
+INSERT INTO t VALUES (1, 'a;b'), (2, "it\'s; ok");
+/* block; comment */ INSERT INTO t VALUES (3, 'c')
+`
+	want := []string{
+		"CREATE TABLE t (id INT, name VARCHAR(10))",
+		`INSERT INTO t VALUES (1, 'a;b'), (2, "it\'s; ok")`,
+		"INSERT INTO t VALUES (3, 'c')",
+	}
+	if got := SplitSQL(script); !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitSQL = %q, want %q", got, want)
+	}
+}
+
+// 测试包常见的 -update 定义不应与testingx的flag冲突
+var _ = flag.Bool("update", false, "update golden files")
+
+func TestGolden(t *testing.T) {
+	GoldenJSON(t, "sample", map[string]interface{}{"b": []int{1, 2}, "a": "x"})
+}
+
+func TestGoldenUpdateEnv(t *testing.T) {
+	old := GoldenDir
+	GoldenDir = t.TempDir()
+	defer func() { GoldenDir = old }()
+	t.Setenv("UPDATE_GOLDEN", "1")
+
+	Golden(t, "new", []byte("v1"))
+	if data, err := os.ReadFile(filepath.Join(GoldenDir, "new.golden")); err != nil || string(data) != "v1" {
+		t.Fatalf("golden file = %q %v", data, err)
+	}
+}
+
+func TestPingRedis(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if line, _ := bufio.NewReader(conn).ReadString('\n'); line == "PING\r\n" {
+			_, _ = conn.Write([]byte("+PONG\r\n"))
+		}
+	}()
+	if err := pingRedis(l.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRedisContainer(t *testing.T) {
+	addr := Redis(t)
+	if err := pingRedis(addr); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMySQLContainer(t *testing.T) {
+	db, err := sql.Open("mysql", MySQL(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := LoadSQL(db, "testdata/*.sql"); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&n); err != nil || n != 2 {
+		t.Fatalf("count = %d, err = %v", n, err)
+	}
+}
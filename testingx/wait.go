@@ -0,0 +1,44 @@
+package testingx
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// WaitPort 等待addr可以建立TCP连接，超时返回最后一次连接的错误
+func WaitPort(addr string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return WaitPortContext(ctx, addr)
+}
+
+// WaitPortContext 等待addr可以建立TCP连接，直到ctx结束
+func WaitPortContext(ctx context.Context, addr string) error {
+	var d net.Dialer
+	interval := 50 * time.Millisecond
+	for {
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			return conn.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+		if interval < time.Second {
+			interval *= 2
+		}
+	}
+}
+
+// FreePort 返回本机当前空闲的TCP端口
+func FreePort() (int, error) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,69 @@
+package throttle
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/liuxy92/golib/zaplog"
+	"net/http"
+)
+
+// MiddlewareOptions 限流中间件配置
+type MiddlewareOptions struct {
+	FailClosed bool           //存储出错时拒绝请求并返回503，默认放行
+	Logger     *zaplog.Logger //记录超限及存储错误，默认zaplog.FromContext(r.Context())
+	Skip       func(*http.Request) bool
+}
+
+// check 返回是否放行，拒绝时已写入响应
+func (l *Limiter) check(w http.ResponseWriter, r *http.Request, o *MiddlewareOptions) bool {
+	if o.Skip != nil && o.Skip(r) {
+		return true
+	}
+	lg := o.Logger
+	if lg == nil {
+		lg = zaplog.FromContext(r.Context())
+	}
+	res, err := l.Allow(r.Context(), r)
+	if err != nil {
+		lg.Warnw("throttle: store error", "error", err, "fail_closed", o.FailClosed)
+		if o.FailClosed {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return false
+		}
+		return true
+	}
+	res.SetHeaders(w.Header())
+	if !res.Allowed {
+		lg.Infow("throttle: rate limit exceeded", "rule", res.Rule, "key_hash", HashKey(res.Key), "limit", res.Limit, "http", zaplog.HTTPRequest(r))
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// Middleware net/http中间件，超限时返回429
+func (l *Limiter) Middleware(o *MiddlewareOptions) func(http.Handler) http.Handler {
+	if o == nil {
+		o = &MiddlewareOptions{}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if l.check(w, r, o) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// GinMiddleware gin中间件，超限时返回429并中止后续handler
+func (l *Limiter) GinMiddleware(o *MiddlewareOptions) gin.HandlerFunc {
+	if o == nil {
+		o = &MiddlewareOptions{}
+	}
+	return func(c *gin.Context) {
+		if !l.check(c.Writer, c.Request, o) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,110 @@
+package throttle
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/liuxy92/golib/zaplog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testLogger 写入临时目录的logger，返回读取info日志文件内容的函数
+func testLogger(t *testing.T) (*zaplog.Logger, func() string) {
+	dir := t.TempDir()
+	lg := &zaplog.Logger{Opts: &zaplog.Options{}}
+	if err := lg.Reconfigure(&zaplog.Options{LogFileDir: dir, LogLevel: "info"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = lg.Close() })
+	return lg, func() string {
+		_ = lg.Sync()
+		files, _ := filepath.Glob(filepath.Join(dir, "*info*"))
+		var b strings.Builder
+		for _, f := range files {
+			data, _ := os.ReadFile(f)
+			b.Write(data)
+		}
+		return b.String()
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	lg, logs := testLogger(t)
+	l := New(NewMemoryStore(nil), ByIP(PerMinute(2)))
+	h := l.Middleware(&MiddlewareOptions{
+		Logger: lg,
+		Skip:   func(r *http.Request) bool { return r.URL.Path == "/health" },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		r.RemoteAddr = "1.2.3.4:5678"
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	for i, want := range []string{"1", "0"} {
+		w := serve("/")
+		if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != want || w.Header().Get("X-RateLimit-Limit") != "2" {
+			t.Fatalf("#%d: %d %v", i, w.Code, w.Header())
+		}
+	}
+	w := serve("/")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("over quota: %d %v", w.Code, w.Header())
+	}
+	if w := serve("/health"); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Fatalf("skip: %d %v", w.Code, w.Header())
+	}
+	if out := logs(); !strings.Contains(out, "throttle: rate limit exceeded") || !strings.Contains(out, `"key_hash":"`+HashKey("1.2.3.4")+`"`) {
+		t.Fatalf("log: %s", out)
+	}
+}
+
+func TestMiddlewareStoreError(t *testing.T) {
+	lg, _ := testLogger(t)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, failClosed := range []bool{false, true} {
+		h := New(errStore{}, ByIP(PerSecond(1))).Middleware(&MiddlewareOptions{FailClosed: failClosed, Logger: lg})(next)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		want := http.StatusOK
+		if failClosed {
+			want = http.StatusServiceUnavailable
+		}
+		if w.Code != want {
+			t.Errorf("FailClosed=%v: status %d", failClosed, w.Code)
+		}
+	}
+}
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lg, logs := testLogger(t)
+	r := gin.New()
+	r.Use(New(NewMemoryStore(nil), ByHeader("apikey", "X-API-Key", PerMinute(1))).GinMiddleware(&MiddlewareOptions{Logger: lg}))
+	called := 0
+	r.GET("/", func(c *gin.Context) {
+		called++
+		c.String(http.StatusOK, "ok")
+	})
+	codes := []int{}
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", "sk-live-0123456789")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests || called != 1 {
+		t.Fatalf("codes = %v, called = %d", codes, called)
+	}
+	if out := logs(); !strings.Contains(out, "rate limit exceeded") || strings.Contains(out, "sk-live") {
+		t.Fatalf("api key leaked or not logged: %s", out)
+	}
+}
@@ -0,0 +1,96 @@
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"github.com/liuxy92/golib/clockx"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"time"
+)
+
+// Store 固定窗口计数存储，Incr将key在当前窗口内的计数加1，返回加1后的计数及距窗口重置的时间
+type Store interface {
+	Incr(ctx context.Context, key string, window time.Duration) (count int64, reset time.Duration, err error)
+}
+
+// MemoryStore 进程内计数，仅适用于单实例部署
+type MemoryStore struct {
+	mu        sync.Mutex
+	clock     clockx.Clock
+	counters  map[string]*counter
+	nextSweep time.Time
+}
+
+type counter struct {
+	n      int64
+	expire time.Time
+}
+
+const sweepInterval = time.Minute
+
+// NewMemoryStore 创建进程内计数存储，clock为nil时使用系统时间
+func NewMemoryStore(clock clockx.Clock) *MemoryStore {
+	return &MemoryStore{clock: clockx.Or(clock), counters: make(map[string]*counter)}
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	//定期清理过期的窗口，避免大量一次性key占用内存
+	if !now.Before(s.nextSweep) {
+		for k, c := range s.counters {
+			if !now.Before(c.expire) {
+				delete(s.counters, k)
+			}
+		}
+		s.nextSweep = now.Add(sweepInterval)
+	}
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expire) {
+		c = &counter{expire: now.Add(window)}
+		s.counters[key] = c
+	}
+	c.n++
+	return c.n, c.expire.Sub(now), nil
+}
+
+// incrScript 原子地计数并在窗口第一次计数时设置过期时间，key意外丢失过期时间时重新设置
+var incrScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {n, ttl}
+`)
+
+// RedisStore Redis计数，多实例共享配额
+type RedisStore struct {
+	Client redis.UniversalClient
+	Prefix string //key前缀，默认 throttle:
+}
+
+func (s *RedisStore) Incr(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "throttle:"
+	}
+	ms := window.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+	res, err := incrScript.Run(ctx, s.Client, []string{prefix + key}, ms).Int64Slice()
+	if err != nil {
+		return 0, 0, fmt.Errorf("throttle: redis incr %s: %w", key, err)
+	}
+	if len(res) != 2 {
+		return 0, 0, fmt.Errorf("throttle: unexpected redis reply %v", res)
+	}
+	return res[0], time.Duration(res[1]) * time.Millisecond, nil
+}
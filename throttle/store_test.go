@@ -0,0 +1,75 @@
+package throttle
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/liuxy92/golib/clockx"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	clock := clockx.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := NewMemoryStore(clock)
+	ctx := context.Background()
+
+	for i := int64(1); i <= 3; i++ {
+		n, reset, err := s.Incr(ctx, "a", time.Minute)
+		if err != nil || n != i || reset != time.Minute {
+			t.Fatalf("Incr #%d = %d %v %v", i, n, reset, err)
+		}
+	}
+	clock.Add(40 * time.Second)
+	if n, reset, _ := s.Incr(ctx, "a", time.Minute); n != 4 || reset != 20*time.Second {
+		t.Fatalf("Incr in window = %d %v", n, reset)
+	}
+	if n, _, _ := s.Incr(ctx, "b", time.Minute); n != 1 {
+		t.Fatalf("other key = %d", n)
+	}
+
+	clock.Add(20 * time.Second)
+	if n, reset, _ := s.Incr(ctx, "a", time.Minute); n != 1 || reset != time.Minute {
+		t.Fatalf("Incr after window = %d %v", n, reset)
+	}
+
+	//过期的key在清理时删除
+	clock.Add(2 * time.Minute)
+	s.Incr(ctx, "c", time.Minute)
+	if len(s.counters) != 1 {
+		t.Fatalf("counters after sweep = %d", len(s.counters))
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	s := &RedisStore{Client: client}
+	ctx := context.Background()
+
+	for i := int64(1); i <= 3; i++ {
+		n, reset, err := s.Incr(ctx, "ip:1.2.3.4", time.Minute)
+		if err != nil || n != i || reset <= 0 || reset > time.Minute {
+			t.Fatalf("Incr #%d = %d %v %v", i, n, reset, err)
+		}
+	}
+	if !mr.Exists("throttle:ip:1.2.3.4") {
+		t.Fatal("key prefix")
+	}
+	mr.FastForward(time.Minute)
+	if n, _, _ := s.Incr(ctx, "ip:1.2.3.4", time.Minute); n != 1 {
+		t.Fatalf("Incr after expire = %d", n)
+	}
+
+	//key丢失过期时间时重新设置
+	mr.Set("throttle:k", "5")
+	if n, reset, err := s.Incr(ctx, "k", time.Second); err != nil || n != 6 || reset != time.Second {
+		t.Fatalf("Incr without ttl = %d %v %v", n, reset, err)
+	}
+
+	mr.Close()
+	if _, _, err := s.Incr(ctx, "x", time.Second); err == nil {
+		t.Fatal("expected error")
+	}
+}
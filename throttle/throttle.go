@@ -0,0 +1,171 @@
+package throttle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/liuxy92/golib/ipacl"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Quota 窗口内允许的请求数
+type Quota struct {
+	Limit  int
+	Window time.Duration
+}
+
+// PerSecond 每秒n次
+func PerSecond(n int) Quota {
+	return Quota{Limit: n, Window: time.Second}
+}
+
+// PerMinute 每分钟n次
+func PerMinute(n int) Quota {
+	return Quota{Limit: n, Window: time.Minute}
+}
+
+// PerHour 每小时n次
+func PerHour(n int) Quota {
+	return Quota{Limit: n, Window: time.Hour}
+}
+
+// Rule 一条限流规则，按Key函数的返回值分别计数，Key返回空字符串时该规则不生效
+type Rule struct {
+	Name  string //规则名，用于计数key及日志，如 ip、user、apikey
+	Key   func(r *http.Request) string
+	Quota Quota
+	//按key单独设置配额，如付费用户更高的配额，返回false时使用Quota
+	QuotaFor func(key string) (Quota, bool)
+}
+
+func (r Rule) quota(key string) Quota {
+	if r.QuotaFor != nil {
+		if q, ok := r.QuotaFor(key); ok {
+			return q
+		}
+	}
+	return r.Quota
+}
+
+// ByIP 按客户端IP限流，trustedProxies为可信代理的IP或CIDR，格式错误时panic，客户端IP的解析规则见ipacl.ClientIP
+func ByIP(q Quota, trustedProxies ...string) Rule {
+	var trusted *ipacl.Tree
+	if len(trustedProxies) > 0 {
+		trusted = &ipacl.Tree{}
+		for _, s := range trustedProxies {
+			p, err := ipacl.ParsePrefix(s)
+			if err != nil {
+				panic(err)
+			}
+			trusted.Insert(ipacl.Rule{Prefix: p, Action: ipacl.Allow})
+		}
+	}
+	return Rule{
+		Name: "ip",
+		Key: func(r *http.Request) string {
+			if ip := ipacl.ClientIP(r, trusted); ip.IsValid() {
+				return ip.String()
+			}
+			return ""
+		},
+		Quota: q,
+	}
+}
+
+// ByHeader 按请求头限流，如 ByHeader("apikey", "X-API-Key", PerMinute(600))
+func ByHeader(name, header string, q Quota) Rule {
+	return Rule{
+		Name: name,
+		Key: func(r *http.Request) string {
+			return strings.TrimSpace(r.Header.Get(header))
+		},
+		Quota: q,
+	}
+}
+
+// ByUser 按用户限流，user从请求中取得用户标识，通常读取认证中间件放入context的用户ID，未登录时返回空字符串
+func ByUser(q Quota, user func(r *http.Request) string) Rule {
+	return Rule{Name: "user", Key: user, Quota: q}
+}
+
+// HashKey key的短哈希，用作存储中的计数key及日志字段，API key等原值不写入Redis或日志
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Result 单条规则的限流结果
+type Result struct {
+	Rule      string
+	Key       string //Key函数返回的原值，可能是API key等敏感信息，记录日志时使用HashKey
+	Limit     int
+	Remaining int
+	Reset     time.Duration //距窗口重置的时间
+	Allowed   bool
+}
+
+// Limiter 按多条规则限流，所有生效的规则均未超限时才放行
+type Limiter struct {
+	store Store
+	rules []Rule
+}
+
+// New 创建Limiter，多实例部署时使用RedisStore保证配额一致
+func New(store Store, rules ...Rule) *Limiter {
+	return &Limiter{store: store, rules: rules}
+}
+
+// Allow 对请求计数，返回是否放行及用于响应头的结果：拒绝时为超限的规则，否则为剩余次数最少的规则。
+// 没有生效的规则时返回的Result.Limit为0。存储出错时返回error，由调用方决定放行或拒绝
+func (l *Limiter) Allow(ctx context.Context, r *http.Request) (Result, error) {
+	res := Result{Allowed: true}
+	for _, rule := range l.rules {
+		key := rule.Key(r)
+		if key == "" {
+			continue
+		}
+		q := rule.quota(key)
+		if q.Limit <= 0 || q.Window <= 0 {
+			continue
+		}
+		n, reset, err := l.store.Incr(ctx, rule.Name+":"+HashKey(key), q.Window)
+		if err != nil {
+			return res, err
+		}
+		cur := Result{
+			Rule:      rule.Name,
+			Key:       key,
+			Limit:     q.Limit,
+			Remaining: q.Limit - int(n),
+			Reset:     reset,
+			Allowed:   n <= int64(q.Limit),
+		}
+		if cur.Remaining < 0 {
+			cur.Remaining = 0
+		}
+		if !cur.Allowed {
+			return cur, nil
+		}
+		if res.Limit == 0 || cur.Remaining < res.Remaining {
+			res = cur
+		}
+	}
+	return res, nil
+}
+
+// SetHeaders 写入X-RateLimit-Limit、X-RateLimit-Remaining、X-RateLimit-Reset(距重置的秒数)，拒绝时另写入Retry-After
+func (res Result) SetHeaders(h http.Header) {
+	if res.Limit == 0 {
+		return
+	}
+	reset := strconv.FormatInt(int64((res.Reset+time.Second-1)/time.Second), 10)
+	h.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("X-RateLimit-Reset", reset)
+	if !res.Allowed {
+		h.Set("Retry-After", reset)
+	}
+}
@@ -0,0 +1,141 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"github.com/liuxy92/golib/clockx"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type ctxUser struct{}
+
+func userFromContext(r *http.Request) string {
+	u, _ := r.Context().Value(ctxUser{}).(string)
+	return u
+}
+
+func newRequest(ip, user, apiKey string) *http.Request {
+	r := httptest.NewRequest("GET", "/api", nil)
+	r.RemoteAddr = ip + ":1234"
+	if user != "" {
+		r = r.WithContext(context.WithValue(r.Context(), ctxUser{}, user))
+	}
+	if apiKey != "" {
+		r.Header.Set("X-API-Key", apiKey)
+	}
+	return r
+}
+
+func TestLimiterAllow(t *testing.T) {
+	clock := clockx.NewMock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	vip := ByUser(PerMinute(2), userFromContext)
+	vip.QuotaFor = func(user string) (Quota, bool) {
+		if user == "vip" {
+			return PerMinute(5), true
+		}
+		return Quota{}, false
+	}
+	store := NewMemoryStore(clock)
+	l := New(store,
+		ByIP(PerMinute(10)),
+		vip,
+		ByHeader("apikey", "X-API-Key", PerSecond(1)),
+	)
+	ctx := context.Background()
+
+	//匿名请求只受IP规则限制
+	res, err := l.Allow(ctx, newRequest("1.1.1.1", "", ""))
+	if err != nil || !res.Allowed || res.Rule != "ip" || res.Remaining != 9 {
+		t.Fatalf("anonymous = %+v %v", res, err)
+	}
+
+	//结果为剩余次数最少的规则
+	res, _ = l.Allow(ctx, newRequest("2.2.2.2", "alice", ""))
+	if !res.Allowed || res.Rule != "user" || res.Key != "alice" || res.Remaining != 1 {
+		t.Fatalf("user = %+v", res)
+	}
+	l.Allow(ctx, newRequest("3.3.3.3", "alice", ""))
+	res, _ = l.Allow(ctx, newRequest("4.4.4.4", "alice", ""))
+	if res.Allowed || res.Rule != "user" || res.Remaining != 0 || res.Reset != time.Minute {
+		t.Fatalf("user over quota = %+v", res)
+	}
+
+	for i := 0; i < 5; i++ {
+		if res, _ := l.Allow(ctx, newRequest("5.5.5.5", "vip", "")); !res.Allowed {
+			t.Fatalf("vip #%d denied: %+v", i, res)
+		}
+	}
+	if res, _ := l.Allow(ctx, newRequest("5.5.5.6", "vip", "")); res.Allowed {
+		t.Fatalf("vip over quota allowed: %+v", res)
+	}
+
+	if res, _ := l.Allow(ctx, newRequest("6.6.6.6", "", "k1")); !res.Allowed {
+		t.Fatalf("apikey = %+v", res)
+	}
+	if res, _ := l.Allow(ctx, newRequest("6.6.6.7", "", "k1")); res.Allowed || res.Rule != "apikey" {
+		t.Fatalf("apikey over quota = %+v", res)
+	}
+	clock.Add(time.Second)
+	if res, _ := l.Allow(ctx, newRequest("6.6.6.8", "", "k1")); !res.Allowed {
+		t.Fatalf("apikey next window = %+v", res)
+	}
+	//存储中的key为哈希，不含原值
+	for k := range store.counters {
+		if strings.Contains(k, "k1") || strings.Contains(k, "alice") {
+			t.Errorf("raw key in store: %s", k)
+		}
+	}
+
+	if res, _ := New(NewMemoryStore(clock)).Allow(ctx, newRequest("1.1.1.1", "", "")); !res.Allowed || res.Limit != 0 {
+		t.Fatalf("no rules = %+v", res)
+	}
+}
+
+type errStore struct{}
+
+func (errStore) Incr(context.Context, string, time.Duration) (int64, time.Duration, error) {
+	return 0, 0, errors.New("down")
+}
+
+func TestLimiterStoreError(t *testing.T) {
+	if _, err := New(errStore{}, ByIP(PerSecond(1))).Allow(context.Background(), newRequest("1.1.1.1", "", "")); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestByIPTrustedProxy(t *testing.T) {
+	rule := ByIP(PerSecond(1), "10.0.0.0/8")
+	r := newRequest("10.0.0.1", "", "")
+	r.Header.Set("X-Forwarded-For", "8.8.8.8")
+	if got := rule.Key(r); got != "8.8.8.8" {
+		t.Fatalf("key = %q", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	ByIP(PerSecond(1), "bad")
+}
+
+func TestSetHeaders(t *testing.T) {
+	h := http.Header{}
+	Result{Limit: 10, Remaining: 0, Reset: 1500 * time.Millisecond}.SetHeaders(h)
+	if h.Get("X-RateLimit-Limit") != "10" || h.Get("X-RateLimit-Remaining") != "0" || h.Get("X-RateLimit-Reset") != "2" || h.Get("Retry-After") != "2" {
+		t.Fatalf("headers = %v", h)
+	}
+	h = http.Header{}
+	Result{Limit: 10, Remaining: 3, Reset: time.Second, Allowed: true}.SetHeaders(h)
+	if h.Get("X-RateLimit-Reset") != "1" || h.Get("Retry-After") != "" {
+		t.Fatalf("headers = %v", h)
+	}
+	h = http.Header{}
+	Result{Allowed: true}.SetHeaders(h)
+	if len(h) != 0 {
+		t.Fatalf("headers without rule = %v", h)
+	}
+}
@@ -0,0 +1,67 @@
+package timex
+
+import (
+	"github.com/liuxy92/golib/clockx"
+	"sync"
+	"time"
+)
+
+type sample struct {
+	at    time.Time
+	value float64
+}
+
+// RateOfChange 根据单调递增的累计值(如已处理条数、写入字节数)计算窗口内每秒的增长速度。
+// 累计值变小时视为计数器重置(如进程重启)，从新值重新累计。并发安全
+type RateOfChange struct {
+	mu      sync.Mutex
+	clock   clockx.Clock
+	window  time.Duration
+	samples []sample
+	offset  float64 //重置前累计的值
+}
+
+// NewRateOfChange clock为nil时使用真实时间
+func NewRateOfChange(window time.Duration, clock clockx.Clock) *RateOfChange {
+	return &RateOfChange{clock: clockx.Or(clock), window: window}
+}
+
+// Observe 记录当前的累计值
+func (r *RateOfChange) Observe(total float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	if n := len(r.samples); n > 0 && total+r.offset < r.samples[n-1].value {
+		r.offset = r.samples[n-1].value
+	}
+	r.samples = append(r.samples, sample{now, total + r.offset})
+	r.trim(now)
+}
+
+// trim 保留窗口内的样本及窗口开始前的最后一个样本作为基准
+func (r *RateOfChange) trim(now time.Time) {
+	start := now.Add(-r.window)
+	i := 0
+	for i+1 < len(r.samples) && !r.samples[i+1].at.After(start) {
+		i++
+	}
+	if i > 0 {
+		r.samples = append(r.samples[:0], r.samples[i:]...)
+	}
+}
+
+// Rate 窗口内每秒的增长，样本不足两个时返回0
+func (r *RateOfChange) Rate() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trim(r.clock.Now())
+	if len(r.samples) < 2 {
+		return 0
+	}
+	first, last := r.samples[0], r.samples[len(r.samples)-1]
+	elapsed := last.at.Sub(first.at).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return (last.value - first.value) / elapsed
+}
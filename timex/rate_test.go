@@ -0,0 +1,35 @@
+package timex
+
+import (
+	"github.com/liuxy92/golib/clockx"
+	"testing"
+	"time"
+)
+
+func TestRateOfChange(t *testing.T) {
+	clock := clockx.NewMock(time.Time{})
+	r := NewRateOfChange(time.Minute, clock)
+	r.Observe(100)
+	if r.Rate() != 0 {
+		t.Error("single sample should be 0")
+	}
+	clock.Add(10 * time.Second)
+	r.Observe(200)
+	if got := r.Rate(); got != 10 {
+		t.Errorf("rate: %v", got)
+	}
+	//计数器重置：500 -> 50，增长按 100 + 50 计算
+	clock.Add(10 * time.Second)
+	r.Observe(500)
+	clock.Add(10 * time.Second)
+	r.Observe(50)
+	if got := r.Rate(); got != (500+50-100)/30.0 {
+		t.Errorf("after reset: %v", got)
+	}
+	//窗口外的样本被丢弃，保留窗口开始前的最后一个作为基准
+	clock.Add(50 * time.Second)
+	r.Observe(110)
+	if got := r.Rate(); got != (110+500-500)/60.0 {
+		t.Errorf("windowed: %v", got)
+	}
+}
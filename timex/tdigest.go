@@ -0,0 +1,151 @@
+package timex
+
+import (
+	"math"
+	"sort"
+)
+
+const defaultCompression = 100
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// TDigest 分位数估计(merging t-digest)，内存占用与数据量无关，两端分位数(如p99)精度较高。
+// 可合并，非并发安全
+type TDigest struct {
+	compression float64
+	centroids   []centroid
+	buf         []centroid //未合并的数据，攒满后批量合并
+	count       float64
+	min, max    float64
+}
+
+// NewTDigest compression越大精度越高、占用越多，小于等于0时为100，质心数不超过compression
+func NewTDigest(compression float64) *TDigest {
+	if compression <= 0 {
+		compression = defaultCompression
+	}
+	return &TDigest{compression: compression, min: math.Inf(1), max: math.Inf(-1)}
+}
+
+func (t *TDigest) Add(x float64) {
+	t.AddWeighted(x, 1)
+}
+
+// AddWeighted 添加权重为w的值，NaN及非正权重被忽略
+func (t *TDigest) AddWeighted(x, w float64) {
+	if math.IsNaN(x) || w <= 0 {
+		return
+	}
+	t.buf = append(t.buf, centroid{x, w})
+	t.count += w
+	t.min = math.Min(t.min, x)
+	t.max = math.Max(t.max, x)
+	if len(t.buf) >= int(t.compression)*4 {
+		t.compress()
+	}
+}
+
+// Merge 将other的数据合并进来，other不变
+func (t *TDigest) Merge(other *TDigest) {
+	if other.count == 0 {
+		return
+	}
+	t.buf = append(t.buf, other.centroids...)
+	t.buf = append(t.buf, other.buf...)
+	t.count += other.count
+	t.min = math.Min(t.min, other.min)
+	t.max = math.Max(t.max, other.max)
+	t.compress()
+}
+
+// compress 按均值排序后合并相邻质心。使用k1缩放函数 k(q)=δ/2π·asin(2q-1)，
+// 每个质心覆盖的k不超过1，两端的质心更小
+func (t *TDigest) compress() {
+	if len(t.buf) == 0 {
+		return
+	}
+	all := append(t.centroids, t.buf...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+	out := all[:1]
+	var sofar float64
+	limit := t.qLimit(0)
+	for _, c := range all[1:] {
+		cur := &out[len(out)-1]
+		proposed := cur.weight + c.weight
+		if (sofar+proposed)/t.count <= limit {
+			cur.mean += (c.mean - cur.mean) * c.weight / proposed
+			cur.weight = proposed
+			continue
+		}
+		sofar += cur.weight
+		limit = t.qLimit(sofar / t.count)
+		out = append(out, c)
+	}
+	t.centroids = out
+	t.buf = t.buf[:0]
+}
+
+// qLimit 从分位q0开始的质心最多能覆盖到的分位
+func (t *TDigest) qLimit(q0 float64) float64 {
+	k := t.compression/(2*math.Pi)*math.Asin(2*q0-1) + 1
+	return (math.Sin(math.Min(k*2*math.Pi/t.compression, math.Pi/2)) + 1) / 2
+}
+
+// Count 数据总权重
+func (t *TDigest) Count() float64 {
+	return t.count
+}
+
+func (t *TDigest) Min() float64 {
+	return t.min
+}
+
+func (t *TDigest) Max() float64 {
+	return t.max
+}
+
+// Quantile 估计分位数，q取值0到1，没有数据时返回NaN
+func (t *TDigest) Quantile(q float64) float64 {
+	t.compress()
+	if t.count == 0 || math.IsNaN(q) {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return t.min
+	}
+	if q >= 1 {
+		return t.max
+	}
+	cs := t.centroids
+	if len(cs) == 1 {
+		return cs[0].mean
+	}
+	//每个质心的权重视为分布在均值两侧，在相邻质心中心之间线性插值
+	target := q * t.count
+	var cum float64
+	for i, c := range cs {
+		center := cum + c.weight/2
+		if target < center {
+			if i == 0 {
+				return t.min + (c.mean-t.min)*target/center
+			}
+			prev := cs[i-1]
+			prevCenter := cum - prev.weight/2
+			return prev.mean + (c.mean-prev.mean)*(target-prevCenter)/(center-prevCenter)
+		}
+		cum += c.weight
+	}
+	last := cs[len(cs)-1]
+	lastCenter := t.count - last.weight/2
+	return last.mean + (t.max-last.mean)*(target-lastCenter)/(t.count-lastCenter)
+}
+
+func (t *TDigest) Reset() {
+	t.centroids = t.centroids[:0]
+	t.buf = t.buf[:0]
+	t.count = 0
+	t.min, t.max = math.Inf(1), math.Inf(-1)
+}
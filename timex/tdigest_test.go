@@ -0,0 +1,81 @@
+package timex
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func exactQuantile(sorted []float64, q float64) float64 {
+	return sorted[int(q*float64(len(sorted)-1))]
+}
+
+func TestTDigestAccuracy(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	d := NewTDigest(100)
+	data := make([]float64, 100000)
+	for i := range data {
+		data[i] = r.ExpFloat64()
+		d.Add(data[i])
+	}
+	sort.Float64s(data)
+	for _, q := range []float64{0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+		want := exactQuantile(data, q)
+		got := d.Quantile(q)
+		//以排名误差衡量：估计值在真实数据中的位置与q的差
+		rank := float64(sort.SearchFloat64s(data, got)) / float64(len(data))
+		tolerance := 0.01
+		if q < 0.05 || q > 0.95 {
+			tolerance = 0.002
+		}
+		if math.Abs(rank-q) > tolerance {
+			t.Errorf("q=%v: got %v (rank %v), want %v", q, got, rank, want)
+		}
+	}
+	if len(d.centroids) > 100 {
+		t.Errorf("too many centroids: %d", len(d.centroids))
+	}
+	if d.Min() != data[0] || d.Max() != data[len(data)-1] || d.Quantile(0) != data[0] || d.Quantile(1) != data[len(data)-1] {
+		t.Error("min/max mismatch")
+	}
+}
+
+func TestTDigestMerge(t *testing.T) {
+	a, b := NewTDigest(0), NewTDigest(0)
+	for i := 0; i < 5000; i++ {
+		a.Add(float64(i))
+		b.Add(float64(i + 5000))
+	}
+	a.Merge(b)
+	if a.Count() != 10000 || b.Count() != 5000 {
+		t.Fatalf("count: %v %v", a.Count(), b.Count())
+	}
+	if got := a.Quantile(0.5); math.Abs(got-5000) > 100 {
+		t.Errorf("median: %v", got)
+	}
+	if got := a.Quantile(0.99); math.Abs(got-9900) > 20 {
+		t.Errorf("p99: %v", got)
+	}
+}
+
+func TestTDigestSmall(t *testing.T) {
+	d := NewTDigest(0)
+	if !math.IsNaN(d.Quantile(0.5)) {
+		t.Error("empty should be NaN")
+	}
+	d.Add(3)
+	if d.Quantile(0.5) != 3 {
+		t.Error("single value")
+	}
+	d.Add(math.NaN())
+	d.AddWeighted(1, 0)
+	d.AddWeighted(1, 3)
+	if d.Count() != 4 || d.Quantile(0.1) != 1 || d.Quantile(1) != 3 {
+		t.Errorf("count %v p10 %v", d.Count(), d.Quantile(0.1))
+	}
+	d.Reset()
+	if d.Count() != 0 || !math.IsNaN(d.Quantile(0.5)) {
+		t.Error("reset")
+	}
+}
@@ -0,0 +1,155 @@
+package timex
+
+import (
+	"github.com/liuxy92/golib/clockx"
+	"math"
+	"sync"
+	"time"
+)
+
+// ring 将时间按固定宽度分桶，桶序号为 时间/宽度，advance时清空已滑出窗口的桶
+type ring struct {
+	width time.Duration
+	n     int
+	last  int64 //最近一次写入的桶序号
+	reset func(i int)
+}
+
+func newRing(size time.Duration, buckets int, now time.Time, reset func(int)) *ring {
+	if buckets <= 0 {
+		buckets = 10
+	}
+	width := size / time.Duration(buckets)
+	if width <= 0 {
+		width = 1
+	}
+	r := &ring{width: width, n: buckets, reset: reset}
+	r.last = r.seq(now)
+	return r
+}
+
+func (r *ring) seq(now time.Time) int64 {
+	return now.UnixNano() / int64(r.width)
+}
+
+// advance 返回当前时间对应的桶下标，时钟回拨时仍写入最近的桶
+func (r *ring) advance(now time.Time) int {
+	if seq := r.seq(now); seq > r.last {
+		gap := seq - r.last
+		if gap > int64(r.n) {
+			gap = int64(r.n)
+		}
+		for i := seq - gap + 1; i <= seq; i++ {
+			r.reset(int(i % int64(r.n)))
+		}
+		r.last = seq
+	}
+	return int(r.last % int64(r.n))
+}
+
+// Counter 滑动窗口计数器，用于"最近5分钟错误数"之类的判断。
+// 窗口分为若干个桶，精度为一个桶的宽度，并发安全
+type Counter struct {
+	mu      sync.Mutex
+	clock   clockx.Clock
+	size    time.Duration
+	buckets []float64
+	ring    *ring
+}
+
+// NewCounter 创建窗口为size、分为buckets个桶的计数器，buckets小于等于0时为10，clock为nil时使用真实时间
+func NewCounter(size time.Duration, buckets int, clock clockx.Clock) *Counter {
+	clock = clockx.Or(clock)
+	c := &Counter{clock: clock, size: size}
+	c.ring = newRing(size, buckets, clock.Now(), func(i int) { c.buckets[i] = 0 })
+	c.buckets = make([]float64, c.ring.n)
+	return c
+}
+
+func (c *Counter) Add(v float64) {
+	c.mu.Lock()
+	c.buckets[c.ring.advance(c.clock.Now())] += v
+	c.mu.Unlock()
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Sum 窗口内的总和
+func (c *Counter) Sum() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ring.advance(c.clock.Now())
+	var sum float64
+	for _, v := range c.buckets {
+		sum += v
+	}
+	return sum
+}
+
+// Rate 窗口内每秒的平均值
+func (c *Counter) Rate() float64 {
+	return c.Sum() / c.size.Seconds()
+}
+
+func (c *Counter) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.buckets {
+		c.buckets[i] = 0
+	}
+}
+
+// Quantiles 滑动窗口分位数估计，每个桶一个TDigest，查询时合并窗口内的桶。并发安全
+type Quantiles struct {
+	mu          sync.Mutex
+	clock       clockx.Clock
+	compression float64
+	digests     []*TDigest
+	ring        *ring
+}
+
+// NewQuantiles 创建窗口为size、分为buckets个桶的分位数估计，compression含义同NewTDigest
+func NewQuantiles(size time.Duration, buckets int, compression float64, clock clockx.Clock) *Quantiles {
+	clock = clockx.Or(clock)
+	q := &Quantiles{clock: clock, compression: compression}
+	q.ring = newRing(size, buckets, clock.Now(), func(i int) { q.digests[i].Reset() })
+	q.digests = make([]*TDigest, q.ring.n)
+	for i := range q.digests {
+		q.digests[i] = NewTDigest(compression)
+	}
+	return q
+}
+
+func (q *Quantiles) Observe(v float64) {
+	q.mu.Lock()
+	q.digests[q.ring.advance(q.clock.Now())].Add(v)
+	q.mu.Unlock()
+}
+
+// ObserveDuration 以秒为单位记录耗时
+func (q *Quantiles) ObserveDuration(d time.Duration) {
+	q.Observe(d.Seconds())
+}
+
+// Snapshot 合并窗口内所有桶，返回的TDigest可多次查询
+func (q *Quantiles) Snapshot() *TDigest {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.ring.advance(q.clock.Now())
+	out := NewTDigest(q.compression)
+	for _, d := range q.digests {
+		out.Merge(d)
+	}
+	return out
+}
+
+// Quantile 窗口内的分位数，q取值0到1，窗口内没有数据时返回NaN
+func (q *Quantiles) Quantile(p float64) float64 {
+	d := q.Snapshot()
+	if d.Count() == 0 {
+		return math.NaN()
+	}
+	return d.Quantile(p)
+}
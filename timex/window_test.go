@@ -0,0 +1,78 @@
+package timex
+
+import (
+	"github.com/liuxy92/golib/clockx"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCounter(t *testing.T) {
+	clock := clockx.NewMock(time.Time{})
+	c := NewCounter(time.Minute, 6, clock)
+	c.Inc()
+	clock.Add(15 * time.Second)
+	c.Add(2)
+	if got := c.Sum(); got != 3 {
+		t.Fatalf("sum: %v", got)
+	}
+	if got := c.Rate(); got != 3.0/60 {
+		t.Errorf("rate: %v", got)
+	}
+	//第一个桶滑出窗口
+	clock.Add(50 * time.Second)
+	if got := c.Sum(); got != 2 {
+		t.Errorf("after slide: %v", got)
+	}
+	//长时间无写入后全部过期
+	clock.Add(time.Hour)
+	if got := c.Sum(); got != 0 {
+		t.Errorf("expired: %v", got)
+	}
+	c.Add(5)
+	c.Reset()
+	if got := c.Sum(); got != 0 {
+		t.Errorf("reset: %v", got)
+	}
+}
+
+func TestCounterClockBackwards(t *testing.T) {
+	clock := clockx.NewMock(time.Time{})
+	c := NewCounter(time.Minute, 6, clock)
+	clock.Add(30 * time.Second)
+	c.Inc()
+	clock.Add(-20 * time.Second)
+	c.Inc()
+	if got := c.Sum(); got != 2 {
+		t.Errorf("sum: %v", got)
+	}
+}
+
+func TestQuantiles(t *testing.T) {
+	clock := clockx.NewMock(time.Time{})
+	q := NewQuantiles(time.Minute, 6, 0, clock)
+	if !math.IsNaN(q.Quantile(0.5)) {
+		t.Error("empty window should be NaN")
+	}
+	for i := 1; i <= 1000; i++ {
+		q.ObserveDuration(time.Duration(i) * time.Millisecond)
+	}
+	clock.Add(30 * time.Second)
+	for i := 0; i < 1000; i++ {
+		q.Observe(5)
+	}
+	if got := q.Quantile(0.25); math.Abs(got-0.5) > 0.05 {
+		t.Errorf("p25: %v", got)
+	}
+	if got := q.Quantile(0.75); got != 5 {
+		t.Errorf("p75: %v", got)
+	}
+	//旧数据滑出窗口后只剩5
+	clock.Add(35 * time.Second)
+	if got := q.Quantile(0.01); got != 5 {
+		t.Errorf("after slide p1: %v", got)
+	}
+	if got := q.Snapshot().Count(); got != 1000 {
+		t.Errorf("count: %v", got)
+	}
+}
@@ -0,0 +1,372 @@
+package tlsx
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/liuxy92/golib/clockx"
+	"github.com/liuxy92/golib/zaplog"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultRenewBefore = 30 * 24 * time.Hour
+	minRenewRetry      = time.Minute
+	maxRenewRetry      = time.Hour
+	accountKeyName     = "acme_account+key" //与autocert相同，两种方式可共用账号
+)
+
+// DNSProvider 写入及删除DNS-01验证所需的TXT记录，fqdn形如 _acme-challenge.example.com
+type DNSProvider interface {
+	Present(ctx context.Context, fqdn, value string) error
+	CleanUp(ctx context.Context, fqdn, value string) error
+}
+
+// ACMEOptions ACME自动签发证书的配置
+type ACMEOptions struct {
+	Domains        []string       //申请证书的域名，DNS-01时支持*.example.com
+	Email          string         //账号联系邮箱，用于接收过期提醒
+	DirectoryURL   string         //ACME服务地址，默认Let's Encrypt正式环境
+	Cache          autocert.Cache //证书及账号私钥的存储，可使用autocert.DirCache或RedisCache，必填
+	DNS            DNSProvider    //非空时使用DNS-01签发一张包含全部Domains的证书，否则由autocert按需使用HTTP-01、TLS-ALPN-01签发
+	DNSPropagation time.Duration  //写入TXT记录后通知ACME服务验证前的等待时间，默认不等待
+	RenewBefore    time.Duration  //到期前多久续期，默认30天
+	HTTPClient     *http.Client   //访问ACME服务使用的客户端，默认http.DefaultClient
+	Clock          clockx.Clock   //续期计时使用的时间来源，默认系统时间
+}
+
+// ACME 通过ACME协议(如Let's Encrypt)自动签发及续期证书，作为tls.Config.GetCertificate或ServerOptions.ACME使用
+type ACME struct {
+	opts    ACMEOptions
+	clock   clockx.Clock
+	manager *autocert.Manager //HTTP-01、TLS-ALPN-01方式
+	cert    atomic.Value      //*tls.Certificate，DNS-01方式
+}
+
+// NewACME 创建ACME，Domains或Cache为空时返回error
+func NewACME(o ACMEOptions) (*ACME, error) {
+	if len(o.Domains) == 0 {
+		return nil, errors.New("tlsx: ACMEOptions.Domains is required")
+	}
+	if o.Cache == nil {
+		return nil, errors.New("tlsx: ACMEOptions.Cache is required")
+	}
+	if o.DirectoryURL == "" {
+		o.DirectoryURL = autocert.DefaultACMEDirectory
+	}
+	if o.RenewBefore <= 0 {
+		o.RenewBefore = defaultRenewBefore
+	}
+	a := &ACME{opts: o, clock: clockx.Or(o.Clock)}
+	if o.DNS == nil {
+		a.manager = &autocert.Manager{
+			Prompt:      autocert.AcceptTOS,
+			Cache:       o.Cache,
+			HostPolicy:  autocert.HostWhitelist(o.Domains...),
+			RenewBefore: o.RenewBefore,
+			Email:       o.Email,
+			Client:      &acme.Client{DirectoryURL: o.DirectoryURL, HTTPClient: o.HTTPClient},
+		}
+	}
+	return a, nil
+}
+
+// GetCertificate 用于tls.Config.GetCertificate。DNS-01方式在Start成功前返回error
+func (a *ACME) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if a.manager != nil {
+		return a.manager.GetCertificate(hello)
+	}
+	if c, _ := a.cert.Load().(*tls.Certificate); c != nil {
+		return c, nil
+	}
+	return nil, errors.New("tlsx: acme certificate not ready")
+}
+
+// HTTPHandler 在80端口处理HTTP-01验证请求，其他请求交给fallback，fallback为nil时重定向到https。
+// DNS-01方式不需要验证请求，直接返回fallback
+func (a *ACME) HTTPHandler(fallback http.Handler) http.Handler {
+	if a.manager != nil {
+		return a.manager.HTTPHandler(fallback)
+	}
+	if fallback == nil {
+		return http.HandlerFunc(redirectHTTPS)
+	}
+	return fallback
+}
+
+func redirectHTTPS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Use HTTPS", http.StatusBadRequest)
+		return
+	}
+	host := r.Host
+	if i := strings.LastIndexByte(host, ':'); i > strings.LastIndexByte(host, ']') {
+		host = host[:i]
+	}
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusFound)
+}
+
+// nextProtos 需加入tls.Config.NextProtos的协议，TLS-ALPN-01验证需要acme-tls/1
+func (a *ACME) nextProtos() []string {
+	if a.manager != nil {
+		return []string{acme.ALPNProto}
+	}
+	return nil
+}
+
+// Start DNS-01方式下加载缓存的证书，不存在或即将过期时立即签发，之后在到期前RenewBefore自动续期，ctx结束后停止。
+// 首次签发失败时返回error；续期失败时记录warn日志并按1分钟起、最长1小时的间隔重试，日志使用zaplog.FromContext(ctx)。
+// HTTP-01方式由autocert在握手时按需签发及续期，Start直接返回nil
+func (a *ACME) Start(ctx context.Context) error {
+	if a.manager != nil {
+		return nil
+	}
+	cert, err := a.load(ctx)
+	if err != nil {
+		return err
+	}
+	if cert == nil || a.renewAt(cert).Before(a.clock.Now()) {
+		if cert, err = a.issue(ctx); err != nil {
+			return err
+		}
+	}
+	a.cert.Store(cert)
+	go a.renewLoop(ctx)
+	return nil
+}
+
+func (a *ACME) renewAt(c *tls.Certificate) time.Time {
+	return c.Leaf.NotAfter.Add(-a.opts.RenewBefore)
+}
+
+func (a *ACME) renewLoop(ctx context.Context) {
+	retry := minRenewRetry
+	for {
+		wait := a.renewAt(a.cert.Load().(*tls.Certificate)).Sub(a.clock.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.clock.After(wait):
+		}
+		cert, err := a.issue(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			zaplog.FromContext(ctx).Warnw("tlsx: renew acme certificate failed", "domains", a.opts.Domains, "retry_in", retry, "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-a.clock.After(retry):
+			}
+			if retry *= 2; retry > maxRenewRetry {
+				retry = maxRenewRetry
+			}
+			continue
+		}
+		retry = minRenewRetry
+		a.cert.Store(cert)
+		zaplog.FromContext(ctx).Infow("tlsx: acme certificate renewed", "domains", a.opts.Domains, "not_after", cert.Leaf.NotAfter)
+	}
+}
+
+// certKey 证书在Cache中的key，*不能用于部分文件系统的文件名
+func (a *ACME) certKey() string {
+	return "dns01+" + strings.ReplaceAll(a.opts.Domains[0], "*", "_")
+}
+
+// load 读取缓存的证书，不存在或与当前域名不一致时返回nil
+func (a *ACME) load(ctx context.Context) (*tls.Certificate, error) {
+	data, err := a.opts.Cache.Get(ctx, a.certKey())
+	if errors.Is(err, autocert.ErrCacheMiss) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tlsx: load acme certificate: %w", err)
+	}
+	cert, err := tls.X509KeyPair(data, data)
+	if err != nil {
+		return nil, nil
+	}
+	l, err := leaf(&cert)
+	if err != nil {
+		return nil, nil
+	}
+	for _, d := range a.opts.Domains {
+		if !containsName(l.DNSNames, d) {
+			return nil, nil
+		}
+	}
+	cert.Leaf = l
+	return &cert, nil
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// issue 通过DNS-01验证全部域名后签发证书并写入Cache
+func (a *ACME) issue(ctx context.Context) (*tls.Certificate, error) {
+	client, err := a.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(a.opts.Domains...))
+	if err != nil {
+		return nil, fmt.Errorf("tlsx: acme new order: %w", err)
+	}
+	for _, u := range order.AuthzURLs {
+		if err := a.authorize(ctx, client, u); err != nil {
+			return nil, err
+		}
+	}
+	if order, err = client.WaitOrder(ctx, order.URI); err != nil {
+		return nil, fmt.Errorf("tlsx: acme wait order: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: a.opts.Domains[0]},
+		DNSNames: a.opts.Domains,
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+	der, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, fmt.Errorf("tlsx: acme finalize order: %w", err)
+	}
+	cert := &tls.Certificate{Certificate: der, PrivateKey: key}
+	if cert.Leaf, err = leaf(cert); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	_ = pem.Encode(&buf, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	for _, b := range der {
+		_ = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: b})
+	}
+	if err := a.opts.Cache.Put(ctx, a.certKey(), buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("tlsx: save acme certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// authorize 完成一个域名的DNS-01验证，已验证过的域名直接跳过
+func (a *ACME) authorize(ctx context.Context, client *acme.Client, url string) error {
+	z, err := client.GetAuthorization(ctx, url)
+	if err != nil {
+		return fmt.Errorf("tlsx: acme get authorization: %w", err)
+	}
+	if z.Status == acme.StatusValid {
+		return nil
+	}
+	domain := z.Identifier.Value
+	var chal *acme.Challenge
+	for _, c := range z.Challenges {
+		if c.Type == "dns-01" {
+			chal = c
+			break
+		}
+	}
+	if chal == nil {
+		return fmt.Errorf("tlsx: acme: no dns-01 challenge for %s", domain)
+	}
+	value, err := client.DNS01ChallengeRecord(chal.Token)
+	if err != nil {
+		return err
+	}
+	fqdn := "_acme-challenge." + strings.TrimPrefix(domain, "*.")
+	if err := a.opts.DNS.Present(ctx, fqdn, value); err != nil {
+		return fmt.Errorf("tlsx: acme present %s: %w", fqdn, err)
+	}
+	defer func() {
+		if err := a.opts.DNS.CleanUp(context.Background(), fqdn, value); err != nil {
+			zaplog.FromContext(ctx).Warnw("tlsx: acme clean up dns record failed", "fqdn", fqdn, "error", err)
+		}
+	}()
+	if a.opts.DNSPropagation > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-a.clock.After(a.opts.DNSPropagation):
+		}
+	}
+	if _, err := client.Accept(ctx, chal); err != nil {
+		return fmt.Errorf("tlsx: acme accept challenge for %s: %w", domain, err)
+	}
+	if _, err := client.WaitAuthorization(ctx, z.URI); err != nil {
+		return fmt.Errorf("tlsx: acme authorize %s: %w", domain, err)
+	}
+	return nil
+}
+
+// client 使用Cache中的账号私钥创建客户端，没有时生成并注册新账号
+func (a *ACME) client(ctx context.Context) (*acme.Client, error) {
+	key, err := a.accountKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client := &acme.Client{Key: key, DirectoryURL: a.opts.DirectoryURL, HTTPClient: a.opts.HTTPClient}
+	acct := &acme.Account{}
+	if a.opts.Email != "" {
+		acct.Contact = []string{"mailto:" + a.opts.Email}
+	}
+	if _, err := client.Register(ctx, acct, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return nil, fmt.Errorf("tlsx: acme register: %w", err)
+	}
+	return client, nil
+}
+
+func (a *ACME) accountKey(ctx context.Context) (crypto.Signer, error) {
+	data, err := a.opts.Cache.Get(ctx, accountKeyName)
+	if err == nil {
+		if b, _ := pem.Decode(data); b != nil {
+			if key, err := x509.ParseECPrivateKey(b.Bytes); err == nil {
+				return key, nil
+			}
+		}
+		return nil, errors.New("tlsx: invalid acme account key in cache")
+	}
+	if !errors.Is(err, autocert.ErrCacheMiss) {
+		return nil, fmt.Errorf("tlsx: load acme account key: %w", err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.opts.Cache.Put(ctx, accountKeyName, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})); err != nil {
+		return nil, fmt.Errorf("tlsx: save acme account key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,351 @@
+package tlsx
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/liuxy92/golib/clockx"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/acme/autocert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeACME 最简的RFC 8555服务端，不校验签名，DNS-01验证在提交后直接通过，签发的证书有效期为validity
+type fakeACME struct {
+	t        *testing.T
+	srv      *httptest.Server
+	ca       tls.Certificate
+	caCert   *x509.Certificate
+	now      func() time.Time
+	validity time.Duration
+
+	mu       sync.Mutex
+	accounts int
+	issued   int
+	certs    map[int][]byte
+	domains  []string
+	accepted map[int]bool
+	failNext bool
+}
+
+func newFakeACME(t *testing.T, now func() time.Time) *fakeACME {
+	caPEM, caKey, err := GenerateCert(CertOptions{CommonName: "fake acme ca", IsCA: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := tls.X509KeyPair(caPEM, caKey)
+	caCert, _ := x509.ParseCertificate(ca.Certificate[0])
+	f := &fakeACME{t: t, ca: ca, caCert: caCert, now: now, validity: 90 * 24 * time.Hour, certs: make(map[int][]byte)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeACME) url(path string) string {
+	return f.srv.URL + path
+}
+
+func (f *fakeACME) serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Replay-Nonce", "nonce")
+	if r.URL.Path == "/directory" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"newNonce":   f.url("/nonce"),
+			"newAccount": f.url("/account"),
+			"newOrder":   f.url("/order"),
+			"revokeCert": f.url("/revoke"),
+			"keyChange":  f.url("/key-change"),
+		})
+		return
+	}
+	if r.URL.Path == "/nonce" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	var jws struct {
+		Payload string `json:"payload"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&jws)
+	payload, _ := base64.RawURLEncoding.DecodeString(jws.Payload)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.URL.Path == "/account":
+		f.accounts++
+		w.Header().Set("Location", f.url("/account/1"))
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"status": "valid"})
+	case r.URL.Path == "/order":
+		var req struct {
+			Identifiers []struct{ Value string } `json:"identifiers"`
+		}
+		_ = json.Unmarshal(payload, &req)
+		f.domains, f.accepted = nil, make(map[int]bool)
+		var authz []string
+		for i, id := range req.Identifiers {
+			f.domains = append(f.domains, id.Value)
+			authz = append(authz, f.url(fmt.Sprintf("/authz/%d", i)))
+		}
+		w.Header().Set("Location", f.url("/order/1"))
+		writeJSON(w, http.StatusCreated, f.order("pending", authz))
+	case strings.HasPrefix(r.URL.Path, "/authz/"):
+		var i int
+		fmt.Sscanf(r.URL.Path, "/authz/%d", &i)
+		domain := f.domains[i]
+		status := "pending"
+		if f.accepted[i] {
+			status = "valid"
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"status":     status,
+			"identifier": map[string]string{"type": "dns", "value": strings.TrimPrefix(domain, "*.")},
+			"wildcard":   strings.HasPrefix(domain, "*."),
+			"challenges": []map[string]string{
+				{"type": "http-01", "url": f.url("/chal/http"), "token": "t-http", "status": "pending"},
+				{"type": "dns-01", "url": f.url(fmt.Sprintf("/chal/%d", i)), "token": fmt.Sprintf("t-%d", i), "status": "pending"},
+			},
+		})
+	case strings.HasPrefix(r.URL.Path, "/chal/"):
+		var i int
+		fmt.Sscanf(r.URL.Path, "/chal/%d", &i)
+		f.accepted[i] = true
+		writeJSON(w, http.StatusOK, map[string]interface{}{"type": "dns-01", "url": f.url(r.URL.Path), "status": "valid"})
+	case r.URL.Path == "/order/1":
+		w.Header().Set("Location", f.url("/order/1"))
+		writeJSON(w, http.StatusOK, f.order("ready", nil))
+	case r.URL.Path == "/finalize":
+		if f.failNext {
+			f.failNext = false
+			writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"type": "urn:ietf:params:acme:error:serverInternal", "detail": "boom"})
+			return
+		}
+		var req struct {
+			CSR string `json:"csr"`
+		}
+		_ = json.Unmarshal(payload, &req)
+		der, _ := base64.RawURLEncoding.DecodeString(req.CSR)
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil {
+			f.t.Errorf("parse csr: %v", err)
+			return
+		}
+		now := f.now()
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(f.issued + 1)),
+			Subject:      csr.Subject,
+			DNSNames:     csr.DNSNames,
+			NotBefore:    now.Add(-time.Minute),
+			NotAfter:     now.Add(f.validity),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		cert, err := x509.CreateCertificate(nil, tmpl, f.caCert, csr.PublicKey, f.ca.PrivateKey)
+		if err != nil {
+			f.t.Errorf("sign: %v", err)
+			return
+		}
+		f.issued++
+		f.certs[f.issued] = cert
+		w.Header().Set("Location", f.url("/order/1"))
+		o := f.order("valid", nil)
+		o["certificate"] = f.url(fmt.Sprintf("/cert/%d", f.issued))
+		writeJSON(w, http.StatusOK, o)
+	case strings.HasPrefix(r.URL.Path, "/cert/"):
+		var i int
+		fmt.Sscanf(r.URL.Path, "/cert/%d", &i)
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		_ = pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: f.certs[i]})
+		_ = pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: f.ca.Certificate[0]})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeACME) order(status string, authz []string) map[string]interface{} {
+	var ids []map[string]string
+	for _, d := range f.domains {
+		ids = append(ids, map[string]string{"type": "dns", "value": d})
+	}
+	return map[string]interface{}{
+		"status":         status,
+		"identifiers":    ids,
+		"authorizations": authz,
+		"finalize":       f.url("/finalize"),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// fakeDNS 记录写入及删除的TXT记录
+type fakeDNS struct {
+	mu      sync.Mutex
+	present map[string]string
+	cleaned []string
+}
+
+func (d *fakeDNS) Present(ctx context.Context, fqdn, value string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.present == nil {
+		d.present = make(map[string]string)
+	}
+	d.present[fqdn] = value
+	return nil
+}
+
+func (d *fakeDNS) CleanUp(ctx context.Context, fqdn, value string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cleaned = append(d.cleaned, fqdn)
+	return nil
+}
+
+func TestACMEDNS01(t *testing.T) {
+	clock := clockx.NewMock(time.Now())
+	f := newFakeACME(t, clock.Now)
+	f.validity = 10 * 24 * time.Hour
+	cache := autocert.DirCache(t.TempDir())
+	dns := &fakeDNS{}
+	opts := ACMEOptions{
+		Domains:      []string{"*.example.com", "example.com"},
+		Email:        "ops@example.com",
+		DirectoryURL: f.url("/directory"),
+		Cache:        cache,
+		DNS:          dns,
+		RenewBefore:  3 * 24 * time.Hour,
+		Clock:        clock,
+	}
+	a, err := NewACME(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.GetCertificate(&tls.ClientHelloInfo{}); err == nil {
+		t.Fatal("certificate should not be ready before Start")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := a.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := a.GetCertificate(&tls.ClientHelloInfo{ServerName: "www.example.com"})
+	if err != nil || cert.Leaf.DNSNames[0] != "*.example.com" || len(cert.Certificate) != 2 {
+		t.Fatalf("cert = %v, %v", cert, err)
+	}
+	if len(dns.present) != 1 || dns.present["_acme-challenge.example.com"] == "" || len(dns.cleaned) != 2 {
+		t.Fatalf("dns records: present=%v cleaned=%v", dns.present, dns.cleaned)
+	}
+
+	//重启时使用缓存的证书与账号，不重新签发
+	b, _ := NewACME(opts)
+	if err := b.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	if f.issued != 1 || f.accounts != 1 {
+		t.Fatalf("issued=%d accounts=%d", f.issued, f.accounts)
+	}
+	f.failNext = true
+	f.mu.Unlock()
+	if c, _ := b.GetCertificate(nil); c.Leaf.SerialNumber.Int64() != 1 {
+		t.Fatal("cached certificate not used")
+	}
+
+	//到期前RenewBefore续期，失败后重试
+	clock.BlockUntil(2)
+	clock.Add(7 * 24 * time.Hour)
+	clock.BlockUntil(2) //a进入重试等待，b续期成功后等待下次续期
+	clock.Add(time.Minute)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ca, _ := a.GetCertificate(nil)
+		cb, _ := b.GetCertificate(nil)
+		if ca.Leaf.SerialNumber.Int64() > 1 && cb.Leaf.SerialNumber.Int64() > 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("certificate not renewed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.issued != 3 {
+		t.Fatalf("issued = %d", f.issued)
+	}
+}
+
+func TestACMEServerConfig(t *testing.T) {
+	cache := autocert.DirCache(t.TempDir())
+	if _, err := NewACME(ACMEOptions{Cache: cache}); err == nil {
+		t.Fatal("expected domains error")
+	}
+	if _, err := NewACME(ACMEOptions{Domains: []string{"example.com"}}); err == nil {
+		t.Fatal("expected cache error")
+	}
+	a, err := NewACME(ACMEOptions{Domains: []string{"example.com"}, Cache: cache})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := ServerConfig(ServerOptions{ACME: a})
+	if err != nil || cfg.GetCertificate == nil || cfg.NextProtos[len(cfg.NextProtos)-1] != "acme-tls/1" {
+		t.Fatalf("config = %+v, %v", cfg, err)
+	}
+	if _, err := ServerConfig(ServerOptions{}); err == nil {
+		t.Fatal("expected error without certificates")
+	}
+	//HTTP-01方式下HTTPHandler处理验证路径，其他请求交给fallback
+	h := a.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) }))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/", nil))
+	if w.Code != http.StatusTeapot {
+		t.Fatalf("fallback status = %d", w.Code)
+	}
+
+	d, _ := NewACME(ACMEOptions{Domains: []string{"example.com"}, Cache: cache, DNS: &fakeDNS{}})
+	w = httptest.NewRecorder()
+	d.HTTPHandler(nil).ServeHTTP(w, httptest.NewRequest("GET", "http://example.com:80/a?b=1", nil))
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "https://example.com/a?b=1" {
+		t.Fatalf("redirect = %d %s", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestRedisCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+	c := &RedisCache{Client: client}
+	if _, err := c.Get(ctx, "example.com"); err != autocert.ErrCacheMiss {
+		t.Fatalf("Get missing: %v", err)
+	}
+	if err := c.Put(ctx, "example.com", []byte("pem")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := c.Get(ctx, "example.com"); string(data) != "pem" || err != nil {
+		t.Fatalf("Get = %q %v", data, err)
+	}
+	if !mr.Exists("tlsx:acme:example.com") {
+		t.Fatal("default prefix")
+	}
+	if err := c.Delete(ctx, "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "example.com"); err != autocert.ErrCacheMiss {
+		t.Fatalf("Get deleted: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package tlsx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// RedisCache 将ACME证书及账号私钥保存在Redis，多实例共享，实现autocert.Cache
+type RedisCache struct {
+	Client redis.UniversalClient
+	Prefix string //key前缀，默认 tlsx:acme:
+}
+
+func (c *RedisCache) key(name string) string {
+	if c.Prefix == "" {
+		return "tlsx:acme:" + name
+	}
+	return c.Prefix + name
+}
+
+func (c *RedisCache) Get(ctx context.Context, name string) ([]byte, error) {
+	data, err := c.Client.Get(ctx, c.key(name)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, autocert.ErrCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tlsx: redis get %s: %w", name, err)
+	}
+	return data, nil
+}
+
+func (c *RedisCache) Put(ctx context.Context, name string, data []byte) error {
+	if err := c.Client.Set(ctx, c.key(name), data, 0).Err(); err != nil {
+		return fmt.Errorf("tlsx: redis set %s: %w", name, err)
+	}
+	return nil
+}
+
+func (c *RedisCache) Delete(ctx context.Context, name string) error {
+	if err := c.Client.Del(ctx, c.key(name)).Err(); err != nil {
+		return fmt.Errorf("tlsx: redis del %s: %w", name, err)
+	}
+	return nil
+}
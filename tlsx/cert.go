@@ -0,0 +1,148 @@
+package tlsx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"time"
+)
+
+const defaultValidFor = 365 * 24 * time.Hour
+
+// CertOptions 生成证书的参数
+type CertOptions struct {
+	CommonName   string
+	Organization string
+	Hosts        []string         //DNS名或IP，写入SAN
+	ValidFor     time.Duration    //有效期，默认1年
+	NotBefore    time.Time        //默认当前时间
+	IsCA         bool             //生成CA证书，可作为Parent签发其他证书
+	ClientAuth   bool             //用于mTLS客户端证书，默认生成服务端证书
+	Parent       *tls.Certificate //签发者，nil时自签名
+}
+
+// GenerateCert 生成ECDSA P-256证书，返回PEM编码的证书与私钥，用于开发环境及测试
+func GenerateCert(o CertOptions) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	notBefore := o.NotBefore
+	if notBefore.IsZero() {
+		notBefore = time.Now().Add(-time.Minute)
+	}
+	validFor := o.ValidFor
+	if validFor <= 0 {
+		validFor = defaultValidFor
+	}
+	cn := o.CommonName
+	if cn == "" && len(o.Hosts) > 0 {
+		cn = o.Hosts[0]
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(validFor),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	if o.Organization != "" {
+		tmpl.Subject.Organization = []string{o.Organization}
+	}
+	for _, h := range o.Hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	switch {
+	case o.IsCA:
+		tmpl.IsCA = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	case o.ClientAuth:
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	default:
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+
+	parent, signer := tmpl, interface{}(key)
+	if o.Parent != nil {
+		if parent, err = leaf(o.Parent); err != nil {
+			return nil, nil, err
+		}
+		signer = o.Parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("tlsx: create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// WriteCert 生成证书并写入文件，私钥文件权限为0600
+func WriteCert(certFile, keyFile string, o CertOptions) error {
+	certPEM, keyPEM, err := GenerateCert(o)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(certFile, certPEM, 0644)
+}
+
+// SelfSigned 生成内存中的自签名证书，用于开发环境快速启动HTTPS服务
+func SelfSigned(hosts ...string) (tls.Certificate, error) {
+	certPEM, keyPEM, err := GenerateCert(CertOptions{Hosts: hosts})
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// leaf 返回证书链中的第一个证书
+func leaf(c *tls.Certificate) (*x509.Certificate, error) {
+	if c.Leaf != nil {
+		return c.Leaf, nil
+	}
+	if len(c.Certificate) == 0 {
+		return nil, errors.New("tlsx: empty certificate")
+	}
+	return x509.ParseCertificate(c.Certificate[0])
+}
+
+// LoadCertPool 加载PEM格式的CA证书文件
+func LoadCertPool(files ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("tlsx: read CA file: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("tlsx: no certificates found in %s", f)
+		}
+	}
+	return pool, nil
+}
@@ -0,0 +1,100 @@
+package tlsx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGenerateCert(t *testing.T) {
+	certPEM, keyPEM, err := GenerateCert(CertOptions{Hosts: []string{"localhost", "127.0.0.1"}, ValidFor: time.Hour, Organization: "golib"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := leaf(&cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Subject.CommonName != "localhost" || len(l.DNSNames) != 1 || len(l.IPAddresses) != 1 || l.Subject.Organization[0] != "golib" {
+		t.Fatalf("subject/SAN = %v %v %v", l.Subject, l.DNSNames, l.IPAddresses)
+	}
+	if d := l.NotAfter.Sub(l.NotBefore); d != time.Hour {
+		t.Fatalf("validity = %v", d)
+	}
+	if err := l.VerifyHostname("127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if l.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth {
+		t.Fatal("server cert should have server auth usage")
+	}
+}
+
+func TestGenerateSignedCert(t *testing.T) {
+	caPEM, caKey, err := GenerateCert(CertOptions{CommonName: "test ca", IsCA: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := tls.X509KeyPair(caPEM, caKey)
+	clientPEM, _, err := GenerateCert(CertOptions{CommonName: "svc-a", ClientAuth: true, Parent: &ca})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	_ = os.WriteFile(caFile, caPEM, 0644)
+	pool, err := LoadCertPool(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := x509.ParseCertificate(mustDecode(t, clientPEM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parsed.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+		t.Fatalf("verify client cert: %v", err)
+	}
+
+	if _, err := LoadCertPool(filepath.Join(dir, "missing.pem")); err == nil {
+		t.Fatal("expected missing file error")
+	}
+	bad := filepath.Join(dir, "bad.pem")
+	_ = os.WriteFile(bad, []byte("not pem"), 0644)
+	if _, err := LoadCertPool(bad); err == nil {
+		t.Fatal("expected no certificates error")
+	}
+}
+
+func mustDecode(t *testing.T, certPEM []byte) []byte {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		t.Fatal("invalid pem")
+	}
+	return block.Bytes
+}
+
+func TestSelfSignedAndWrite(t *testing.T) {
+	cert, err := SelfSigned("example.test")
+	if err != nil || len(cert.Certificate) != 1 {
+		t.Fatalf("SelfSigned = %v", err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := WriteCert(certFile, keyFile, CertOptions{Hosts: []string{"example.test"}}); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(keyFile); err != nil || fi.Mode().Perm() != 0600 {
+		t.Fatalf("key file mode = %v %v", fi, err)
+	}
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		t.Fatal(err)
+	}
+}
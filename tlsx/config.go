@@ -0,0 +1,78 @@
+package tlsx
+
+import (
+	"crypto/tls"
+	"errors"
+)
+
+// ServerOptions 服务端TLS配置
+type ServerOptions struct {
+	Certs        *CertReloader //服务端证书，支持热更新
+	ACME         *ACME         //使用ACME自动签发的证书，与Certs二选一
+	ClientCAs    []string      //校验客户端证书的CA文件，非空时开启mTLS
+	OptionalMTLS bool          //客户端证书可选，提供时校验，默认必须提供
+	MinVersion   uint16        //默认TLS 1.2
+}
+
+// ServerConfig 创建服务端tls.Config
+func ServerConfig(o ServerOptions) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: o.MinVersion}
+	switch {
+	case o.Certs != nil:
+		cfg.GetCertificate = o.Certs.GetCertificate
+	case o.ACME != nil:
+		cfg.GetCertificate = o.ACME.GetCertificate
+		if p := o.ACME.nextProtos(); p != nil {
+			cfg.NextProtos = append([]string{"h2", "http/1.1"}, p...)
+		}
+	default:
+		return nil, errors.New("tlsx: ServerOptions.Certs or ACME is required")
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	if len(o.ClientCAs) > 0 {
+		pool, err := LoadCertPool(o.ClientCAs...)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		if o.OptionalMTLS {
+			cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+	return cfg, nil
+}
+
+// ClientOptions 客户端TLS配置
+type ClientOptions struct {
+	RootCAs            []string      //校验服务端证书的CA文件，默认使用系统CA
+	Certs              *CertReloader //mTLS客户端证书，支持热更新
+	ServerName         string        //校验的服务端名称，默认使用连接地址中的主机名
+	InsecureSkipVerify bool          //不校验服务端证书，仅用于测试
+	MinVersion         uint16        //默认TLS 1.2
+}
+
+// ClientConfig 创建客户端tls.Config，可用于http.Transport、gRPC等
+func ClientConfig(o ClientOptions) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         o.MinVersion,
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	if len(o.RootCAs) > 0 {
+		pool, err := LoadCertPool(o.RootCAs...)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	if o.Certs != nil {
+		cfg.GetClientCertificate = o.Certs.GetClientCertificate
+	}
+	return cfg, nil
+}
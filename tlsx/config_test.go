@@ -0,0 +1,104 @@
+package tlsx
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mtlsFiles 生成CA、服务端证书与客户端证书文件
+func mtlsFiles(t *testing.T) (dir string) {
+	dir = t.TempDir()
+	caPEM, caKey, err := GenerateCert(CertOptions{CommonName: "ca", IsCA: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := tls.X509KeyPair(caPEM, caKey)
+	_ = os.WriteFile(filepath.Join(dir, "ca.pem"), caPEM, 0644)
+	for _, c := range []struct {
+		name string
+		opts CertOptions
+	}{
+		{"server", CertOptions{Hosts: []string{"127.0.0.1"}, Parent: &ca}},
+		{"client", CertOptions{CommonName: "svc-a", ClientAuth: true, Parent: &ca}},
+	} {
+		if err := WriteCert(filepath.Join(dir, c.name+".crt"), filepath.Join(dir, c.name+".key"), c.opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := mtlsFiles(t)
+	serverCerts, err := NewCertReloader(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverCfg, err := ServerConfig(ServerOptions{Certs: serverCerts, ClientCAs: []string{filepath.Join(dir, "ca.pem")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	//httptest的StartTLS会设置自带的证书，这里直接使用TLS listener
+	srv.Listener = tls.NewListener(srv.Listener, serverCfg)
+	srv.Start()
+	defer srv.Close()
+	url := strings.Replace(srv.URL, "http://", "https://", 1)
+
+	clientCerts, err := NewCertReloader(filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCfg, err := ClientConfig(ClientOptions{RootCAs: []string{filepath.Join(dir, "ca.pem")}, Certs: clientCerts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientCfg}}
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "svc-a" {
+		t.Fatalf("peer CN = %q", body)
+	}
+
+	//没有客户端证书时握手失败
+	noCert, _ := ClientConfig(ClientOptions{RootCAs: []string{filepath.Join(dir, "ca.pem")}})
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: noCert}}
+	if resp, err := client.Get(url); err == nil {
+		resp.Body.Close()
+		t.Fatal("expected handshake failure without client certificate")
+	}
+}
+
+func TestConfigOptions(t *testing.T) {
+	if _, err := ServerConfig(ServerOptions{}); err == nil {
+		t.Fatal("expected error without certs")
+	}
+	dir := mtlsFiles(t)
+	certs, _ := NewCertReloader(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	cfg, err := ServerConfig(ServerOptions{Certs: certs, ClientCAs: []string{filepath.Join(dir, "ca.pem")}, OptionalMTLS: true})
+	if err != nil || cfg.ClientAuth != tls.VerifyClientCertIfGiven || cfg.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("cfg = %+v %v", cfg, err)
+	}
+	if _, err := ServerConfig(ServerOptions{Certs: certs, ClientCAs: []string{"missing"}}); err == nil {
+		t.Fatal("expected CA error")
+	}
+	if _, err := ClientConfig(ClientOptions{RootCAs: []string{"missing"}}); err == nil {
+		t.Fatal("expected CA error")
+	}
+	cfg, _ = ClientConfig(ClientOptions{ServerName: "svc", MinVersion: tls.VersionTLS13})
+	if cfg.ServerName != "svc" || cfg.MinVersion != tls.VersionTLS13 || cfg.RootCAs != nil {
+		t.Fatalf("client cfg = %+v", cfg)
+	}
+}
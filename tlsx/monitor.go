@@ -0,0 +1,74 @@
+package tlsx
+
+import (
+	"context"
+	"crypto/x509"
+	"github.com/liuxy92/golib/clockx"
+	"github.com/liuxy92/golib/zaplog"
+	"time"
+)
+
+const (
+	defaultWarnBefore    = 30 * 24 * time.Hour
+	defaultCheckInterval = time.Hour
+)
+
+// MonitorOptions 证书过期监控配置
+type MonitorOptions struct {
+	WarnBefore time.Duration //距过期小于该时间时记录warn日志，默认30天
+	Interval   time.Duration //检查间隔，默认1小时
+	Clock      clockx.Clock  //默认系统时间
+}
+
+// MonitorExpiry 立即及之后每隔Interval检查certs返回的证书，即将过期时记录warn日志，已过期时记录error日志，ctx结束后停止。
+// 日志使用zaplog.FromContext(ctx)
+func MonitorExpiry(ctx context.Context, certs func() []*x509.Certificate, o *MonitorOptions) {
+	opts := MonitorOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.WarnBefore <= 0 {
+		opts.WarnBefore = defaultWarnBefore
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultCheckInterval
+	}
+	clock := clockx.Or(opts.Clock)
+	check := func() {
+		lg := zaplog.FromContext(ctx)
+		now := clock.Now()
+		for _, c := range certs() {
+			if c == nil {
+				continue
+			}
+			left := c.NotAfter.Sub(now)
+			kv := []interface{}{"subject", c.Subject.String(), "not_after", c.NotAfter, "remaining", left}
+			switch {
+			case left <= 0:
+				lg.Errorw("tlsx: certificate expired", kv...)
+			case left <= opts.WarnBefore:
+				lg.Warnw("tlsx: certificate expiring soon", kv...)
+			}
+		}
+	}
+	check()
+	go func() {
+		ticker := clock.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				check()
+			}
+		}
+	}()
+}
+
+// MonitorExpiry 监控当前证书的过期时间，证书热更新后检查新证书
+func (r *CertReloader) MonitorExpiry(ctx context.Context, o *MonitorOptions) {
+	MonitorExpiry(ctx, func() []*x509.Certificate {
+		return []*x509.Certificate{r.Leaf()}
+	}, o)
+}
@@ -0,0 +1,65 @@
+package tlsx
+
+import (
+	"context"
+	"crypto/x509"
+	"github.com/liuxy92/golib/clockx"
+	"github.com/liuxy92/golib/zaplog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonitorExpiry(t *testing.T) {
+	dir := t.TempDir()
+	lg := &zaplog.Logger{Opts: &zaplog.Options{}}
+	if err := lg.Reconfigure(&zaplog.Options{LogFileDir: dir, LogLevel: "info"}); err != nil {
+		t.Fatal(err)
+	}
+	defer lg.Close()
+	logs := func() string {
+		_ = lg.Sync()
+		files, _ := filepath.Glob(filepath.Join(dir, "*info*"))
+		var b strings.Builder
+		for _, f := range files {
+			data, _ := os.ReadFile(f)
+			b.Write(data)
+		}
+		return b.String()
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := clockx.NewMock(start)
+	cert := &x509.Certificate{NotAfter: start.Add(45 * 24 * time.Hour)}
+	cert.Subject.CommonName = "api.example.com"
+
+	ctx, cancel := context.WithCancel(zaplog.NewContext(context.Background(), lg))
+	defer cancel()
+	MonitorExpiry(ctx, func() []*x509.Certificate { return []*x509.Certificate{cert, nil} }, &MonitorOptions{Interval: 24 * time.Hour, Clock: clock})
+	if out := logs(); strings.Contains(out, "tlsx:") {
+		t.Fatalf("unexpected log: %s", out)
+	}
+
+	clock.BlockUntil(1)
+	clock.Add(20 * 24 * time.Hour)
+	waitLog(t, logs, "tlsx: certificate expiring soon")
+	if !strings.Contains(logs(), "CN=api.example.com") {
+		t.Fatal("subject missing in log")
+	}
+
+	clock.Add(30 * 24 * time.Hour)
+	waitLog(t, logs, "tlsx: certificate expired")
+}
+
+func waitLog(t *testing.T, logs func() string, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(logs(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("log %q not found: %s", want, logs())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,125 @@
+package tlsx
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/liuxy92/golib/zaplog"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// reloadDelay 文件变化后等待的时间，证书与私钥通常先后写入，合并为一次加载
+const reloadDelay = 200 * time.Millisecond
+
+// CertReloader 从文件加载证书，文件变化时自动重新加载，用于证书续期时不重启服务。
+// 作为tls.Config.GetCertificate或GetClientCertificate使用
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	leaf *x509.Certificate
+}
+
+// NewCertReloader 加载证书与私钥，调用Watch后监听文件变化
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload 重新加载证书，失败时保留原证书
+func (r *CertReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("tlsx: load %s: %w", r.certFile, err)
+	}
+	l, err := leaf(&cert)
+	if err != nil {
+		return err
+	}
+	cert.Leaf = l
+	r.mu.Lock()
+	r.cert, r.leaf = &cert, l
+	r.mu.Unlock()
+	return nil
+}
+
+// Certificate 当前证书
+func (r *CertReloader) Certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+// Leaf 当前证书链中的第一个证书
+func (r *CertReloader) Leaf() *x509.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.leaf
+}
+
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// Watch 监听证书与私钥所在目录，文件被修改、替换或经符号链接切换(如Kubernetes Secret)后重新加载，ctx结束后停止。
+// 加载失败时记录warn日志并保留原证书，日志使用zaplog.FromContext(ctx)
+func (r *CertReloader) Watch(ctx context.Context) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("tlsx: create watcher: %w", err)
+	}
+	dirs := map[string]bool{filepath.Dir(r.certFile): true, filepath.Dir(r.keyFile): true}
+	for dir := range dirs {
+		if err := w.Add(dir); err != nil {
+			_ = w.Close()
+			return fmt.Errorf("tlsx: watch %s: %w", dir, err)
+		}
+	}
+	go func() {
+		defer w.Close()
+		lg := zaplog.FromContext(ctx)
+		timer := time.NewTimer(reloadDelay)
+		timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case _, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				//目录中的任意变化都可能是证书更新(如..data符号链接切换)，延迟合并后加载
+				timer.Reset(reloadDelay)
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				lg.Warnw("tlsx: watch certificate error", "error", err)
+			case <-timer.C:
+				old := r.Leaf()
+				if err := r.Reload(); err != nil {
+					lg.Warnw("tlsx: reload certificate failed, keep previous certificate", "error", err)
+					continue
+				}
+				if l := r.Leaf(); !l.Equal(old) {
+					lg.Infow("tlsx: certificate reloaded", "file", r.certFile, "subject", l.Subject.String(), "not_after", l.NotAfter)
+				}
+			}
+		}
+	}()
+	return nil
+}
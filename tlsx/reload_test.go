@@ -0,0 +1,77 @@
+package tlsx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeCert(t *testing.T, certFile, keyFile, cn string) {
+	t.Helper()
+	if err := WriteCert(certFile, keyFile, CertOptions{CommonName: cn}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitCN(t *testing.T, r *CertReloader, cn string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Leaf().Subject.CommonName != cn {
+		if time.Now().After(deadline) {
+			t.Fatalf("certificate not reloaded, CN = %s, want %s", r.Leaf().Subject.CommonName, cn)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCert(t, certFile, keyFile, "v1")
+
+	r, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := r.GetCertificate(nil); c.Leaf.Subject.CommonName != "v1" {
+		t.Fatalf("CN = %s", c.Leaf.Subject.CommonName)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := r.Watch(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	//原地覆盖
+	writeCert(t, certFile, keyFile, "v2")
+	waitCN(t, r, "v2")
+
+	//写入临时文件后rename替换
+	tmpCert, tmpKey := filepath.Join(dir, "new.crt"), filepath.Join(dir, "new.key")
+	writeCert(t, tmpCert, tmpKey, "v3")
+	if err := os.Rename(tmpKey, keyFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmpCert, certFile); err != nil {
+		t.Fatal(err)
+	}
+	waitCN(t, r, "v3")
+
+	//损坏的文件不影响当前证书
+	if err := os.WriteFile(certFile, []byte("broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(500 * time.Millisecond)
+	if c, _ := r.GetClientCertificate(nil); c.Leaf.Subject.CommonName != "v3" {
+		t.Fatal("broken file replaced certificate")
+	}
+	if err := r.Reload(); err == nil {
+		t.Fatal("expected reload error")
+	}
+
+	if _, err := NewCertReloader(filepath.Join(dir, "missing.crt"), keyFile); err == nil {
+		t.Fatal("expected error")
+	}
+}
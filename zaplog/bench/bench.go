@@ -0,0 +1,207 @@
+package bench
+
+import (
+	"fmt"
+	"github.com/liuxy92/golib/zaplog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 压测的输出类型
+const (
+	SinkDiscard      = "discard"      //zap直接写io.Discard，作为对照基线
+	SinkSize         = "size"         //按大小切割(lumberjack)
+	SinkTime         = "time"         //按时间切割(rotatelogs)
+	SinkCopyTruncate = "copytruncate" //copy-truncate方式切割(WindowsMode)
+)
+
+// Sinks 支持的全部输出类型
+var Sinks = []string{SinkDiscard, SinkSize, SinkTime, SinkCopyTruncate}
+
+// Config 压测参数，相同参数多次运行输出的日志内容一致
+type Config struct {
+	Sink        string        //输出类型，默认size
+	Dir         string        //日志目录，为空时使用临时目录并在结束后删除
+	Workers     int           //并发写日志的goroutine数，默认GOMAXPROCS
+	Duration    time.Duration //压测时长，默认5s
+	MessageSize int           //每条日志消息的字节数，默认128
+	Fields      int           //每条日志附带的字段数，默认8
+	MaxSize     int           //按大小切割的文件大小(MB)，默认100
+	RotateEvery time.Duration //大于0时按此间隔强制切割，用于测试切割时的写入性能
+}
+
+// Result 压测结果
+type Result struct {
+	Sink           string
+	Entries        uint64
+	Elapsed        time.Duration
+	EntriesPerSec  float64
+	AllocsPerEntry float64
+	BytesPerEntry  float64
+	Rotations      int
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%-12s entries=%d elapsed=%s rate=%.0f/s allocs/op=%.2f bytes/op=%.1f rotations=%d",
+		r.Sink, r.Entries, r.Elapsed.Round(time.Millisecond), r.EntriesPerSec, r.AllocsPerEntry, r.BytesPerEntry, r.Rotations)
+}
+
+func (c *Config) defaults() {
+	if c.Sink == "" {
+		c.Sink = SinkSize
+	}
+	if c.Workers <= 0 {
+		c.Workers = runtime.GOMAXPROCS(0)
+	}
+	if c.Duration <= 0 {
+		c.Duration = 5 * time.Second
+	}
+	if c.MessageSize <= 0 {
+		c.MessageSize = 128
+	}
+	if c.Fields < 0 {
+		c.Fields = 0
+	} else if c.Fields == 0 {
+		c.Fields = 8
+	}
+}
+
+// target 压测对象，zaplog与基线zap logger共用
+type target struct {
+	infow  func(msg string, keysAndValues ...interface{})
+	rotate func() error
+	close  func() error
+}
+
+// Options 返回指定输出类型对应的zaplog配置
+func Options(sink, dir string, maxSize int) (*zaplog.Options, error) {
+	no := false
+	opts := &zaplog.Options{
+		LogLevel:   "info",
+		LogFileDir: dir,
+		AppName:    "bench",
+		MaxSize:    maxSize,
+		Compress:   &no, //避免后台压缩影响吞吐
+	}
+	switch sink {
+	case SinkSize:
+	case SinkTime:
+		opts.CutType = 1
+	case SinkCopyTruncate:
+		opts.WindowsMode = true
+	default:
+		return nil, fmt.Errorf("bench: unknown sink %q", sink)
+	}
+	return opts, nil
+}
+
+func newTarget(cfg Config) (*target, error) {
+	if cfg.Sink == SinkDiscard {
+		enc := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+		l := zap.New(zapcore.NewCore(enc, zapcore.AddSync(io.Discard), zapcore.InfoLevel)).Sugar()
+		return &target{infow: l.Infow, rotate: func() error { return nil }, close: l.Sync}, nil
+	}
+	opts, err := Options(cfg.Sink, cfg.Dir, cfg.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := zaplog.Reconfigure(opts); err != nil {
+		return nil, err
+	}
+	lg := zaplog.GetLogger()
+	return &target{infow: lg.Infow, rotate: lg.Rotate, close: lg.Close}, nil
+}
+
+// Run 按配置并发写日志直到Duration结束，返回吞吐与分配统计。
+// 文件类输出会重新配置全局logger，不要在业务进程中调用
+func Run(cfg Config) (Result, error) {
+	cfg.defaults()
+	if cfg.Dir == "" && cfg.Sink != SinkDiscard {
+		dir, err := os.MkdirTemp("", "zaplog-bench-")
+		if err != nil {
+			return Result{}, err
+		}
+		defer os.RemoveAll(dir)
+		cfg.Dir = dir
+	}
+	t, err := newTarget(cfg)
+	if err != nil {
+		return Result{}, err
+	}
+
+	msg := strings.Repeat("x", cfg.MessageSize)
+	kv := make([]interface{}, 0, cfg.Fields*2)
+	for i := 0; i < cfg.Fields; i++ {
+		kv = append(kv, "field"+strconv.Itoa(i), i)
+	}
+
+	var (
+		entries   atomic.Uint64
+		rotations int
+		wg        sync.WaitGroup
+		stop      = make(chan struct{})
+	)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				t.infow(msg, kv...)
+				entries.Add(1)
+			}
+		}()
+	}
+	var rotateErr error
+	if cfg.RotateEvery > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(cfg.RotateEvery)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+					if err := t.rotate(); err != nil && rotateErr == nil {
+						rotateErr = err
+					}
+					rotations++
+				}
+			}
+		}()
+	}
+	time.Sleep(cfg.Duration)
+	close(stop)
+	wg.Wait()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	if err := t.close(); err != nil && rotateErr == nil {
+		rotateErr = err
+	}
+
+	res := Result{Sink: cfg.Sink, Entries: entries.Load(), Elapsed: elapsed, Rotations: rotations}
+	if res.Entries > 0 {
+		res.EntriesPerSec = float64(res.Entries) / elapsed.Seconds()
+		res.AllocsPerEntry = float64(after.Mallocs-before.Mallocs) / float64(res.Entries)
+		res.BytesPerEntry = float64(after.TotalAlloc-before.TotalAlloc) / float64(res.Entries)
+	}
+	return res, rotateErr
+}
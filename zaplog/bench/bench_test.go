@@ -0,0 +1,74 @@
+package bench
+
+import (
+	"github.com/liuxy92/golib/zaplog"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	for _, sink := range Sinks {
+		res, err := Run(Config{Sink: sink, Dir: t.TempDir(), Workers: 2, Duration: 50 * time.Millisecond, RotateEvery: 20 * time.Millisecond})
+		if err != nil {
+			t.Fatalf("%s: %v", sink, err)
+		}
+		if res.Entries == 0 || res.EntriesPerSec <= 0 {
+			t.Errorf("%s: no entries written: %v", sink, res)
+		}
+	}
+	if _, err := Run(Config{Sink: "unknown", Duration: time.Millisecond}); err == nil {
+		t.Error("unknown sink should fail")
+	}
+}
+
+func benchmarkSink(b *testing.B, sink string) {
+	t, err := newTarget(Config{Sink: sink, Dir: b.TempDir(), MaxSize: 100})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer t.close()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			t.infow("benchmark message", "user", "alice", "order_id", 42, "cost", 1.5)
+		}
+	})
+}
+
+func BenchmarkSinks(b *testing.B) {
+	for _, sink := range Sinks {
+		b.Run(sink, func(b *testing.B) { benchmarkSink(b, sink) })
+	}
+}
+
+// BenchmarkRotateUnderLoad 写入的同时每毫秒强制切割一次
+func BenchmarkRotateUnderLoad(b *testing.B) {
+	opts, _ := Options(SinkSize, b.TempDir(), 100)
+	if err := zaplog.Reconfigure(opts); err != nil {
+		b.Fatal(err)
+	}
+	lg := zaplog.GetLogger()
+	defer lg.Close()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				_ = lg.Rotate()
+			}
+		}
+	}()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			lg.Infow("benchmark message", "user", "alice", "order_id", 42)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/liuxy92/golib/zaplog/bench"
+	"os"
+	"strings"
+	"time"
+)
+
+// zaplog-bench 日志写入压测工具，如：
+//
+//	zaplog-bench -sink all -workers 8 -duration 10s -rotate 1s
+func main() {
+	var cfg bench.Config
+	sink := flag.String("sink", bench.SinkSize, "输出类型: "+strings.Join(bench.Sinks, "|")+"|all")
+	flag.StringVar(&cfg.Dir, "dir", "", "日志目录，默认使用临时目录")
+	flag.IntVar(&cfg.Workers, "workers", 0, "并发数，默认GOMAXPROCS")
+	flag.DurationVar(&cfg.Duration, "duration", 5*time.Second, "每种输出的压测时长")
+	flag.IntVar(&cfg.MessageSize, "size", 128, "消息字节数")
+	flag.IntVar(&cfg.Fields, "fields", 8, "每条日志的字段数")
+	flag.IntVar(&cfg.MaxSize, "maxsize", 100, "按大小切割的文件大小(MB)")
+	flag.DurationVar(&cfg.RotateEvery, "rotate", 0, "强制切割间隔，0表示不强制切割")
+	flag.Parse()
+
+	sinks := []string{*sink}
+	if *sink == "all" {
+		sinks = bench.Sinks
+	}
+	failed := false
+	for _, s := range sinks {
+		cfg.Sink = s
+		res, err := bench.Run(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", s, err)
+			failed = true
+			continue
+		}
+		fmt.Println(res)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,32 @@
+package zaplog
+
+import (
+	"github.com/liuxy92/golib/clockx"
+	"time"
+)
+
+// clockHolder atomic.Value要求存入相同的具体类型
+type clockHolder struct {
+	clockx.Clock
+}
+
+// clock 返回当前配置的时间来源，Reconfigure后立即生效
+func (lg *Logger) clock() clockx.Clock {
+	if h, ok := lg.clk.Load().(clockHolder); ok {
+		return h.Clock
+	}
+	return clockx.Real
+}
+
+// zapClock 日志时间使用Options.Clock
+type zapClock struct {
+	lg *Logger
+}
+
+func (z zapClock) Now() time.Time {
+	return z.lg.clock().Now()
+}
+
+func (z zapClock) NewTicker(d time.Duration) *time.Ticker {
+	return time.NewTicker(d)
+}
@@ -0,0 +1,51 @@
+package zaplog
+
+import (
+	"encoding/json"
+	"github.com/liuxy92/golib/clockx"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClockTimestamps(t *testing.T) {
+	mock := clockx.NewMock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	lg := newTestLogger(t, &Options{AppName: "clk", Clock: mock})
+	lg.Info("hello")
+	data, _ := os.ReadFile(filepath.Join(lg.Opts.LogFileDir, "clk-info.log"))
+	var entry struct {
+		TS int64 `json:"ts"`
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("invalid json %s: %v", data, err)
+	}
+	if want := mock.Now().UnixNano() / 1e6; entry.TS != want {
+		t.Errorf("ts = %d, want %d", entry.TS, want)
+	}
+}
+
+func TestClockDailyLayout(t *testing.T) {
+	mock := clockx.NewMock(time.Date(2024, 5, 1, 23, 59, 0, 0, time.Local))
+	lg := newTestLogger(t, &Options{AppName: "clk", Clock: mock, DirLayout: DirLayoutDaily})
+	lg.Info("day1")
+	mock.Add(2 * time.Minute)
+	lg.Info("day2")
+	for _, day := range []string{"2024-05-01", "2024-05-02"} {
+		if _, err := os.Stat(filepath.Join(lg.Opts.LogFileDir, day, "clk-info.log")); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestClockTimeOp(t *testing.T) {
+	lg, logs := observedLogger()
+	mock := clockx.NewMock(time.Time{})
+	lg.clk.Store(clockHolder{mock})
+	done := lg.TimeOp("query", time.Second)
+	mock.Add(2 * time.Second)
+	done()
+	if e := logs.All(); len(e) != 1 || e[0].Message != "slow operation" || e[0].ContextMap()["duration"] != 2*time.Second {
+		t.Errorf("unexpected logs: %+v", e)
+	}
+}
@@ -0,0 +1,58 @@
+package zaplog
+
+import (
+	"encoding/json"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"sort"
+	"sync"
+)
+
+// JSONCodecStd 标准库encoding/json，默认值
+const JSONCodecStd = "std"
+
+// ReflectedEncoderFactory 创建反射字段(zap.Any/zap.Reflect)使用的JSON编码器
+type ReflectedEncoderFactory func(w io.Writer) zapcore.ReflectedEncoder
+
+var (
+	codecMu sync.RWMutex
+	codecs  = map[string]ReflectedEncoderFactory{
+		JSONCodecStd: stdReflectedEncoder,
+	}
+)
+
+// RegisterJSONCodec 注册文件输出使用的JSON编码器，通过Options.JSONCodec按名称选择
+func RegisterJSONCodec(name string, factory ReflectedEncoderFactory) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	codecs[name] = factory
+}
+
+// JSONCodecs 返回已注册的编码器名称
+func JSONCodecs() []string {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupJSONCodec(name string) (ReflectedEncoderFactory, bool) {
+	if name == "" {
+		name = JSONCodecStd
+	}
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	f, ok := codecs[name]
+	return f, ok
+}
+
+// stdReflectedEncoder 与zap默认行为一致，不转义HTML字符
+func stdReflectedEncoder(w io.Writer) zapcore.ReflectedEncoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc
+}
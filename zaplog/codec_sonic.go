@@ -0,0 +1,18 @@
+//go:build sonic
+
+package zaplog
+
+import (
+	"github.com/bytedance/sonic"
+	"go.uber.org/zap/zapcore"
+	"io"
+)
+
+// JSONCodecSonic 使用 -tags sonic 编译时可用
+const JSONCodecSonic = "sonic"
+
+func init() {
+	RegisterJSONCodec(JSONCodecSonic, func(w io.Writer) zapcore.ReflectedEncoder {
+		return sonic.ConfigDefault.NewEncoder(w)
+	})
+}
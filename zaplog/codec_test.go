@@ -0,0 +1,63 @@
+package zaplog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"testing"
+)
+
+type benchPayload struct {
+	OrderID  string            `json:"order_id"`
+	UserID   int64             `json:"user_id"`
+	Amount   float64           `json:"amount"`
+	Items    []string          `json:"items"`
+	Headers  map[string]string `json:"headers"`
+	Disabled bool              `json:"disabled"`
+}
+
+var payload = benchPayload{
+	OrderID: "202405010001",
+	UserID:  10086,
+	Amount:  99.5,
+	Items:   []string{"sku-1", "sku-2", "sku-3"},
+	Headers: map[string]string{"X-Request-Id": "abc", "User-Agent": "bench"},
+}
+
+func TestLookupJSONCodec(t *testing.T) {
+	if _, ok := lookupJSONCodec(""); !ok {
+		t.Fatal("empty codec should resolve to std")
+	}
+	if _, ok := lookupJSONCodec("no-such-codec"); ok {
+		t.Fatal("unknown codec resolved")
+	}
+	RegisterJSONCodec("test-codec", stdReflectedEncoder)
+	t.Cleanup(func() {
+		codecMu.Lock()
+		delete(codecs, "test-codec")
+		codecMu.Unlock()
+	})
+	if _, ok := lookupJSONCodec("test-codec"); !ok {
+		t.Fatal("registered codec not found")
+	}
+}
+
+// BenchmarkJSONCodec 对比各编码器写文件时的吞吐，使用 -tags sonic 可加入sonic对比
+func BenchmarkJSONCodec(b *testing.B) {
+	for _, name := range JSONCodecs() {
+		codec, _ := lookupJSONCodec(name)
+		b.Run(name, func(b *testing.B) {
+			cfg := zap.NewProductionEncoderConfig()
+			cfg.NewReflectedEncoder = codec
+			core := zapcore.NewCore(zapcore.NewJSONEncoder(cfg), zapcore.AddSync(io.Discard), zapcore.InfoLevel)
+			l := zap.New(core)
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					l.Info("order created", zap.Any("order", payload))
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package zaplog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"github.com/liuxy92/golib/buffer/bytespool"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 旧日志文件压缩算法
+const (
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+	CompressNone = "none"
+)
+
+const millInterval = time.Minute //后台检查待压缩旧文件的间隔
+
+var compressExt = map[string]string{
+	CompressGzip: ".gz",
+	CompressZstd: ".zst",
+}
+
+// millConfig 文件切割后的压缩配置，由sinkRegistry后台定期执行
+type millConfig struct {
+	algo       string
+	filename   string
+	current    func() string //当前正在写入的文件，nil表示filename
+	maxBackups int           //压缩后按个数清理，仅lumberjack使用(lumberjack不识别.zst文件)
+	maxAge     time.Duration
+}
+
+// compressionAlgo 解析压缩配置，返回空串表示沿用旧行为：lumberjack使用gzip，按时间切割时不压缩；
+// 无法识别的CompressionAlgo同样沿用旧行为，初始化时输出警告
+func (o *Options) compressionAlgo() string {
+	if o.Compress != nil && !*o.Compress {
+		return CompressNone
+	}
+	switch o.CompressionAlgo {
+	case CompressGzip, CompressZstd, CompressNone:
+		return o.CompressionAlgo
+	}
+	if o.Compress != nil {
+		return CompressGzip
+	}
+	return ""
+}
+
+// backups 列出已切割完成、尚未压缩的旧文件，调用方需持有对应fileSink的锁
+func (m *millConfig) backups() []string {
+	dir := filepath.Dir(m.filename)
+	base := filepath.Base(m.filename)
+	name, ext := splitExt(base)
+	current := base
+	if m.current != nil {
+		current = filepath.Base(m.current())
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() || n == base || n == current || isCompressedOrTemp(n) {
+			continue
+		}
+		if (strings.HasPrefix(n, name+"-") && strings.HasSuffix(n, ext)) || strings.HasPrefix(n, base+".") {
+			files = append(files, filepath.Join(dir, n))
+		}
+	}
+	return files
+}
+
+// cleanup 清理超出个数或天数的压缩文件
+func (m *millConfig) cleanup() {
+	if m.maxBackups <= 0 && m.maxAge <= 0 {
+		return
+	}
+	suffix := compressExt[m.algo]
+	name, ext := splitExt(m.filename)
+	matches, _ := filepath.Glob(name + "-*" + ext + suffix)
+	type backup struct {
+		path    string
+		modTime time.Time
+	}
+	list := make([]backup, 0, len(matches))
+	for _, path := range matches {
+		if fi, err := os.Stat(path); err == nil {
+			list = append(list, backup{path, fi.ModTime()})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].modTime.After(list[j].modTime) })
+	cutoff := time.Now().Add(-m.maxAge)
+	for i, b := range list {
+		if (m.maxBackups > 0 && i >= m.maxBackups) || (m.maxAge > 0 && b.modTime.Before(cutoff)) {
+			_ = os.Remove(b.path)
+		}
+	}
+}
+
+func isCompressedOrTemp(name string) bool {
+	for _, ext := range compressExt {
+		if strings.HasSuffix(name, ext) || strings.HasSuffix(name, ext+".tmp") {
+			return true
+		}
+	}
+	return false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return !os.IsNotExist(err)
+}
+
+// compressFile 压缩src为src+扩展名，成功后删除src，压缩文件保留原文件的修改时间
+func compressFile(algo, src string) (err error) {
+	ext, ok := compressExt[algo]
+	if !ok {
+		return fmt.Errorf("zaplog: unsupported compression %q", algo)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	//已存在同名压缩文件时追加序号，不覆盖
+	dst := src + ext
+	name, srcExt := splitExt(src)
+	for n := 1; fileExists(dst); n++ {
+		dst = name + "." + strconv.Itoa(n) + srcExt + ext
+	}
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fi.Mode())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = out.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	var w io.WriteCloser
+	switch algo {
+	case CompressGzip:
+		w = gzip.NewWriter(out)
+	case CompressZstd:
+		if w, err = zstd.NewWriter(out); err != nil {
+			return err
+		}
+	}
+	if _, err = bytespool.Copy(w, in); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	_ = os.Chtimes(dst, fi.ModTime(), fi.ModTime())
+	_ = in.Close()
+	return os.Remove(src)
+}
+
+// compress 压缩sink已切割的旧文件
+func (s *fileSink) compress() error {
+	if s.mill == nil {
+		return nil
+	}
+	s.mu.Lock()
+	closed := s.closed
+	files := s.mill.backups()
+	mill := *s.mill //按日期分目录时filename随写入变化，复制一份在锁外使用
+	s.mu.Unlock()
+	if closed {
+		return nil
+	}
+	var firstErr error
+	for _, f := range files {
+		if err := compressFile(mill.algo, f); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	mill.cleanup()
+	return firstErr
+}
+
+// mill 压缩所有sink的旧文件，同一时间只有一个压缩任务
+func (r *sinkRegistry) mill() error {
+	r.millMu.Lock()
+	defer r.millMu.Unlock()
+	var firstErr error
+	for _, s := range r.list() {
+		if err := s.compress(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// startMill 启动后台压缩，调用方需持有r.mu
+func (r *sinkRegistry) startMill() {
+	if r.millStop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	r.millStop = stop
+	r.millNow = make(chan struct{}, 1)
+	now := r.millNow
+	go func() {
+		ticker := time.NewTicker(millInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-now:
+			case <-stop:
+				return
+			}
+			_ = r.mill()
+		}
+	}()
+}
+
+// triggerMill 切割后通知后台立即压缩
+func (r *sinkRegistry) triggerMill() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.millNow == nil {
+		return
+	}
+	select {
+	case r.millNow <- struct{}{}:
+	default:
+	}
+}
+
+// stopMill 停止后台压缩，调用方需持有r.mu
+func (r *sinkRegistry) stopMill() {
+	if r.millStop != nil {
+		close(r.millStop)
+		r.millStop, r.millNow = nil, nil
+	}
+}
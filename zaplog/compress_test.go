@@ -0,0 +1,111 @@
+package zaplog
+
+import (
+	"compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressionAlgo(t *testing.T) {
+	yes, no := true, false
+	cases := []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, ""},
+		{Options{Compress: &yes}, CompressGzip},
+		{Options{Compress: &no, CompressionAlgo: CompressZstd}, CompressNone},
+		{Options{CompressionAlgo: CompressZstd}, CompressZstd},
+		{Options{CompressionAlgo: "lz4"}, ""},
+	}
+	for _, c := range cases {
+		if got := c.opts.compressionAlgo(); got != c.want {
+			t.Errorf("compressionAlgo(%+v) = %q, want %q", c.opts, got, c.want)
+		}
+	}
+}
+
+func TestCompressFile(t *testing.T) {
+	dir := t.TempDir()
+	for algo, open := range map[string]func(io.Reader) (io.Reader, error){
+		CompressGzip: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		CompressZstd: func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	} {
+		src := filepath.Join(dir, "app-info-"+algo+".log")
+		if err := os.WriteFile(src, []byte("hello "+algo), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := compressFile(algo, src); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(src); !os.IsNotExist(err) {
+			t.Errorf("%s: source not removed", algo)
+		}
+		f, err := os.Open(src + compressExt[algo])
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := open(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(r)
+		f.Close()
+		if string(data) != "hello "+algo {
+			t.Errorf("%s: got %q", algo, data)
+		}
+	}
+}
+
+func TestCompressFileExisting(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app-info-2024-05-01T10-00-00.000.log")
+	if err := os.WriteFile(src+".gz", []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := compressFile(CompressGzip, src); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(src + ".gz"); string(data) != "old" {
+		t.Errorf("existing archive overwritten: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app-info-2024-05-01T10-00-00.000.1.log.gz")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestZstdRotation(t *testing.T) {
+	lg := newTestLogger(t, &Options{AppName: "zst", CompressionAlgo: CompressZstd})
+	lg.Info("before rotate")
+	if err := lg.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := lg.sinks.mill(); err != nil {
+		t.Fatal(err)
+	}
+	zst, _ := filepath.Glob(filepath.Join(lg.Opts.LogFileDir, "zst-info-*.log.zst"))
+	plain, _ := filepath.Glob(filepath.Join(lg.Opts.LogFileDir, "zst-info-*.log"))
+	if len(zst) != 1 || len(plain) != 0 {
+		t.Errorf("zst=%v plain=%v", zst, plain)
+	}
+}
+
+func TestNoCompression(t *testing.T) {
+	no := false
+	lg := newTestLogger(t, &Options{AppName: "plain", Compress: &no})
+	lg.Info("before rotate")
+	if err := lg.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	_ = lg.sinks.mill()
+	plain, _ := filepath.Glob(filepath.Join(lg.Opts.LogFileDir, "plain-info-*.log"))
+	if len(plain) != 1 {
+		t.Errorf("expected uncompressed backup, got %v", plain)
+	}
+}
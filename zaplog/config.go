@@ -0,0 +1,199 @@
+package zaplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileOptions 配置文件格式，与Options分开定义以使用嵌套结构及字符串形式的时长、权限
+type fileOptions struct {
+	Level            string            `json:"level" yaml:"level" toml:"level"`
+	Dir              string            `json:"dir" yaml:"dir" toml:"dir"`
+	AppName          string            `json:"app_name" yaml:"app_name" toml:"app_name"`
+	Format           string            `json:"format" yaml:"format" toml:"format"` //json|ecs|console，同--log-format
+	JSONCodec        string            `json:"json_codec" yaml:"json_codec" toml:"json_codec"`
+	DirPerm          string            `json:"dir_perm" yaml:"dir_perm" toml:"dir_perm"` //八进制，如 "0750"
+	FallbackToStdout bool              `json:"fallback_to_stdout" yaml:"fallback_to_stdout" toml:"fallback_to_stdout"`
+	ErrorFingerprint bool              `json:"error_fingerprint" yaml:"error_fingerprint" toml:"error_fingerprint"`
+	Sequence         bool              `json:"sequence" yaml:"sequence" toml:"sequence"`
+	MaxMessageBytes  int               `json:"max_message_bytes" yaml:"max_message_bytes" toml:"max_message_bytes"`
+	Files            fileNames         `json:"files" yaml:"files" toml:"files"`
+	Rotation         fileRotation      `json:"rotation" yaml:"rotation" toml:"rotation"`
+	Sinks            map[string]string `json:"sinks" yaml:"sinks" toml:"sinks"`          //同Options.SinkLevels
+	Resource         map[string]string `json:"resource" yaml:"resource" toml:"resource"` //按语义约定命名的资源属性，如 service.name
+	Sentry           *fileSentry       `json:"sentry" yaml:"sentry" toml:"sentry"`
+	LevelAuth        *LevelAuthOptions `json:"level_auth" yaml:"level_auth" toml:"level_auth"`
+}
+
+type fileNames struct {
+	Error string `json:"error" yaml:"error" toml:"error"`
+	Warn  string `json:"warn" yaml:"warn" toml:"warn"`
+	Info  string `json:"info" yaml:"info" toml:"info"`
+	Debug string `json:"debug" yaml:"debug" toml:"debug"`
+}
+
+type fileRotation struct {
+	Type         string `json:"type" yaml:"type" toml:"type"` //size|time，默认size
+	MaxSize      int    `json:"max_size" yaml:"max_size" toml:"max_size"`
+	MaxBackups   int    `json:"max_backups" yaml:"max_backups" toml:"max_backups"`
+	MaxAge       int    `json:"max_age" yaml:"max_age" toml:"max_age"`
+	Compress     *bool  `json:"compress" yaml:"compress" toml:"compress"`
+	Compression  string `json:"compression" yaml:"compression" toml:"compression"` //gzip|zstd|none
+	Layout       string `json:"layout" yaml:"layout" toml:"layout"`                //flat|daily
+	WindowsMode  bool   `json:"windows_mode" yaml:"windows_mode" toml:"windows_mode"`
+	MultiProcess string `json:"multi_process" yaml:"multi_process" toml:"multi_process"` //pid|flock
+}
+
+type fileSentry struct {
+	DSN          string  `json:"dsn" yaml:"dsn" toml:"dsn"`
+	Environment  string  `json:"environment" yaml:"environment" toml:"environment"`
+	Release      string  `json:"release" yaml:"release" toml:"release"`
+	SampleRate   float64 `json:"sample_rate" yaml:"sample_rate" toml:"sample_rate"`
+	RateLimit    int     `json:"rate_limit" yaml:"rate_limit" toml:"rate_limit"`
+	FlushTimeout string  `json:"flush_timeout" yaml:"flush_timeout" toml:"flush_timeout"` //如 "2s"
+}
+
+// LoadOptions 按扩展名读取yaml/json/toml配置文件生成Options，文件内容中的${VAR}替换为环境变量。
+// 未知的配置项及无效的取值返回错误，未配置的项在初始化时使用与InitLogger相同的默认值。示例(yaml)：
+//
+//	level: info
+//	dir: /var/log/order
+//	app_name: order
+//	format: json
+//	rotation:
+//	  type: size
+//	  max_size: 100
+//	  compression: zstd
+//	sinks:
+//	  console: debug
+//	sentry:
+//	  dsn: ${SENTRY_DSN}
+//	  flush_timeout: 2s
+func LoadOptions(path string) (*Options, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("zaplog: read options: %w", err)
+	}
+	data = []byte(ExpandEnv(string(data)))
+	var fo fileOptions
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&fo)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(&fo); errors.Is(err, io.EOF) {
+			err = nil //空文件
+		}
+	case ".toml":
+		var md toml.MetaData
+		md, err = toml.Decode(string(data), &fo)
+		if keys := md.Undecoded(); err == nil && len(keys) > 0 {
+			err = fmt.Errorf("unknown key %s", keys[0])
+		}
+	default:
+		return nil, fmt.Errorf("zaplog: unsupported options format %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("zaplog: parse options %s: %w", path, err)
+	}
+	opts, err := fo.options()
+	if err == nil {
+		err = opts.Validate()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("zaplog: options %s: %w", path, err)
+	}
+	return opts, nil
+}
+
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandEnv 将s中的${VAR}替换为环境变量，未设置时为空。与os.ExpandEnv不同，不展开$VAR形式，
+// 密码等取值中的$原样保留
+func ExpandEnv(s string) string {
+	return envRef.ReplaceAllStringFunc(s, func(ref string) string {
+		return os.Getenv(ref[2 : len(ref)-1])
+	})
+}
+
+func (fo *fileOptions) options() (*Options, error) {
+	r := fo.Rotation
+	opts := &Options{
+		LogLevel:         fo.Level,
+		LogFileDir:       fo.Dir,
+		AppName:          fo.AppName,
+		ErrorFileName:    fo.Files.Error,
+		WarnFileName:     fo.Files.Warn,
+		InfoFileName:     fo.Files.Info,
+		DebugFileName:    fo.Files.Debug,
+		MaxSize:          r.MaxSize,
+		MaxBackups:       r.MaxBackups,
+		MaxAge:           r.MaxAge,
+		JSONCodec:        fo.JSONCodec,
+		WindowsMode:      r.WindowsMode,
+		FallbackToStdout: fo.FallbackToStdout,
+		Compress:         r.Compress,
+		CompressionAlgo:  r.Compression,
+		SinkLevels:       fo.Sinks,
+		ErrorFingerprint: fo.ErrorFingerprint,
+		DirLayout:        r.Layout,
+		Sequence:         fo.Sequence,
+		MaxMessageBytes:  fo.MaxMessageBytes,
+		MultiProcess:     r.MultiProcess,
+		LevelAuth:        fo.LevelAuth,
+	}
+	if fo.Format != "" {
+		if err := (formatFlag{opts}).Set(fo.Format); err != nil {
+			return nil, fmt.Errorf("format: %w", err)
+		}
+	}
+	if r.Type != "" {
+		if err := (cutFlag{&opts.CutType}).Set(r.Type); err != nil {
+			return nil, fmt.Errorf("rotation.type: %w", err)
+		}
+	}
+	if fo.DirPerm != "" {
+		perm, err := strconv.ParseUint(fo.DirPerm, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("dir_perm %q: want octal like 0755", fo.DirPerm)
+		}
+		opts.DirPerm = os.FileMode(perm)
+	}
+	if len(fo.Resource) > 0 {
+		opts.Resource = &Resource{}
+		for k, v := range fo.Resource {
+			opts.Resource.set(k, v)
+		}
+	}
+	if s := fo.Sentry; s != nil {
+		opts.Sentry = &SentryOptions{
+			DSN:         s.DSN,
+			Environment: s.Environment,
+			Release:     s.Release,
+			SampleRate:  s.SampleRate,
+			RateLimit:   s.RateLimit,
+		}
+		if s.FlushTimeout != "" {
+			d, err := time.ParseDuration(s.FlushTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("sentry.flush_timeout: %w", err)
+			}
+			opts.Sentry.FlushTimeout = d
+		}
+	}
+	return opts, nil
+}
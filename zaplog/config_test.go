@@ -0,0 +1,177 @@
+package zaplog
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const yamlOptions = `
+level: warn
+dir: ${LOG_TEST_DIR}
+app_name: order
+format: ecs
+dir_perm: "0750"
+sequence: true
+files:
+  error: err.log
+rotation:
+  type: time
+  max_age: 7
+  compress: false
+  compression: zstd
+  layout: daily
+sinks:
+  file: error
+resource:
+  service.name: order
+  team: pay
+sentry:
+  dsn: https://key@sentry.example.com/1
+  flush_timeout: 3s
+level_auth:
+  token: "se$cret$$"
+`
+
+const jsonOptions = `{
+	"level": "warn", "dir": "${LOG_TEST_DIR}", "app_name": "order", "format": "ecs", "dir_perm": "0750", "sequence": true,
+	"files": {"error": "err.log"},
+	"rotation": {"type": "time", "max_age": 7, "compress": false, "compression": "zstd", "layout": "daily"},
+	"sinks": {"file": "error"},
+	"resource": {"service.name": "order", "team": "pay"},
+	"sentry": {"dsn": "https://key@sentry.example.com/1", "flush_timeout": "3s"},
+	"level_auth": {"token": "se$cret$$"}
+}`
+
+const tomlOptions = `
+level = "warn"
+dir = "${LOG_TEST_DIR}"
+app_name = "order"
+format = "ecs"
+dir_perm = "0750"
+sequence = true
+
+[files]
+error = "err.log"
+
+[rotation]
+type = "time"
+max_age = 7
+compress = false
+compression = "zstd"
+layout = "daily"
+
+[sinks]
+file = "error"
+
+[resource]
+"service.name" = "order"
+team = "pay"
+
+[sentry]
+dsn = "https://key@sentry.example.com/1"
+flush_timeout = "3s"
+
+[level_auth]
+token = "se$cret$$"
+`
+
+func writeOptions(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadOptions(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("LOG_TEST_DIR", dir)
+	no := false
+	want := &Options{
+		LogLevel:        "warn",
+		LogFileDir:      dir,
+		AppName:         "order",
+		ErrorFileName:   "err.log",
+		MaxAge:          7,
+		CutType:         1,
+		DirPerm:         0750,
+		Compress:        &no,
+		CompressionAlgo: CompressZstd,
+		SinkLevels:      map[string]string{SinkFile: "error"},
+		Schema:          SchemaECS,
+		Resource:        &Resource{ServiceName: "order", Attributes: map[string]string{"team": "pay"}},
+		Sentry:          &SentryOptions{DSN: "https://key@sentry.example.com/1", FlushTimeout: 3 * time.Second},
+		DirLayout:       DirLayoutDaily,
+		Sequence:        true,
+		LevelAuth:       &LevelAuthOptions{Token: "se$cret$$"}, //$不展开
+	}
+	for name, content := range map[string]string{"log.yaml": yamlOptions, "log.json": jsonOptions, "log.toml": tomlOptions} {
+		opts, err := LoadOptions(writeOptions(t, name, content))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(opts, want) {
+			t.Errorf("%s:\n%+v\nwant:\n%+v", name, opts, want)
+		}
+	}
+
+	opts, err := LoadOptions(writeOptions(t, "empty.yml", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.LogFileDir = dir
+	lg, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lg.Close()
+	if lg.Opts.AppName != "app" || lg.Opts.MaxSize != 100 {
+		t.Errorf("defaults not applied: %+v", lg.Opts)
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("ZAPLOG_TEST_ENV", "v")
+	cases := map[string]string{
+		"${ZAPLOG_TEST_ENV}":    "v",
+		"a${ZAPLOG_TEST_ENV}b":  "avb",
+		"ab$cd":                 "ab$cd",
+		"$ZAPLOG_TEST_ENV":      "$ZAPLOG_TEST_ENV",
+		"${ZAPLOG_TEST_UNSET}x": "x",
+		"${not valid} $$ ${":    "${not valid} $$ ${",
+		"$${ZAPLOG_TEST_ENV}":   "$v",
+	}
+	for in, want := range cases {
+		if got := ExpandEnv(in); got != want {
+			t.Errorf("ExpandEnv(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestLoadOptionsErrors(t *testing.T) {
+	cases := map[string]string{
+		"typo.yaml":   "levle: debug\n",
+		"typo.json":   `{"rotation": {"maxsize": 10}}`,
+		"typo.toml":   "[rotation]\nmaxsize = 10\n",
+		"format.yaml": "format: xml\n",
+		"level.yaml":  "level: loud\n",
+		"perm.yaml":   "dir_perm: rwx\n",
+		"flush.yaml":  "sentry:\n  flush_timeout: soon\n",
+		"log.ini":     "level=info\n",
+	}
+	for name, content := range cases {
+		_, err := LoadOptions(writeOptions(t, name, content))
+		if err == nil || !strings.HasPrefix(err.Error(), "zaplog: ") {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+	if _, err := LoadOptions(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("missing file should fail")
+	}
+}
@@ -0,0 +1,22 @@
+package zaplog
+
+import (
+	"context"
+)
+
+type ctxKey struct{}
+
+// NewContext 将logger放入context，FromContext取出
+func NewContext(ctx context.Context, lg *Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, lg)
+}
+
+// FromContext 返回context中的logger，没有时返回全局logger
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if lg, ok := ctx.Value(ctxKey{}).(*Logger); ok && lg != nil {
+			return lg
+		}
+	}
+	return logger
+}
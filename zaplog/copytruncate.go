@@ -0,0 +1,223 @@
+package zaplog
+
+import (
+	"fmt"
+	"github.com/liuxy92/golib/buffer/bytespool"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sizeBackupLayout = "2006-01-02T15-04-05.000" //按大小切割的备份文件时间格式，与lumberjack一致
+	timeBackupLayout = "2006_0102_15"            //按时间切割的备份文件时间格式，与rotatelogs的%Y_%m%d_%H一致
+)
+
+// copyTruncateWriter 文件名固定不变，切割时将内容复制到备份文件后截断原文件。
+// Windows下打开中的文件无法重命名且不支持软链接，使用该方式代替lumberjack/rotatelogs
+type copyTruncateWriter struct {
+	mu         sync.Mutex
+	filename   string
+	maxSize    int64         //按大小切割的阈值，0表示不按大小切割
+	period     time.Duration //按时间切割的周期，0表示不按时间切割
+	maxBackups int
+	maxAge     time.Duration
+	file       *os.File
+	size       int64
+	openedAt   time.Time
+	now        func() time.Time
+}
+
+func newCopyTruncateWriter(filename string, maxSizeMB int, period time.Duration, maxBackups, maxAgeDays int) *copyTruncateWriter {
+	return &copyTruncateWriter{
+		filename:   filename,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		period:     period,
+		maxBackups: maxBackups,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+		now:        time.Now,
+	}
+}
+
+func (w *copyTruncateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.needRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *copyTruncateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	return w.rotate()
+}
+
+func (w *copyTruncateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *copyTruncateWriter) open() error {
+	f, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	w.openedAt = w.now()
+	if w.size > 0 {
+		w.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+func (w *copyTruncateWriter) needRotate(writeLen int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.maxSize > 0 && w.size+writeLen > w.maxSize {
+		return true
+	}
+	if w.period > 0 && !w.now().Truncate(w.period).Equal(w.openedAt.Truncate(w.period)) {
+		return true
+	}
+	return false
+}
+
+// backupName 备份文件名，同一周期内多次切割(如手动Rotate)或同一毫秒内按大小切割时追加序号，
+// 不写入已存在的备份及其压缩文件
+func (w *copyTruncateWriter) backupName() string {
+	name, ext := w.filename+"."+w.openedAt.Format(timeBackupLayout), ""
+	if w.period <= 0 {
+		name, ext = splitExt(w.filename)
+		name += "-" + w.now().Format(sizeBackupLayout)
+	}
+	backup := name + ext
+	for n := 1; backupExists(backup); n++ {
+		backup = name + "." + strconv.Itoa(n) + ext
+	}
+	return backup
+}
+
+// backupExists 备份文件或其压缩后的文件已存在
+func backupExists(path string) bool {
+	if fileExists(path) {
+		return true
+	}
+	for _, ext := range compressExt {
+		if fileExists(path + ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// rotate 复制当前内容到备份文件并截断，调用方需持有锁
+func (w *copyTruncateWriter) rotate() error {
+	if w.size > 0 {
+		if err := w.copyTo(w.backupName()); err != nil {
+			return err
+		}
+		if err := w.file.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	w.size = 0
+	w.openedAt = w.now()
+	w.cleanup()
+	return nil
+}
+
+func (w *copyTruncateWriter) copyTo(dst string) error {
+	src, err := os.Open(w.filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := bytespool.Copy(out, src); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("zaplog: copy %s to %s: %w", w.filename, dst, err)
+	}
+	return out.Close()
+}
+
+// cleanup 按保留个数与保留天数删除旧的备份文件
+func (w *copyTruncateWriter) cleanup() {
+	cleanupBackups(w.filename, w.maxBackups, w.maxAge, w.now())
+}
+
+// cleanupBackups 按保留个数与保留天数删除filename的备份文件(含压缩后的文件)
+func cleanupBackups(filename string, maxBackups int, maxAge time.Duration, now time.Time) {
+	if maxBackups <= 0 && maxAge <= 0 {
+		return
+	}
+	name, _ := splitExt(filename)
+	prefix := filepath.Base(name)
+	base := filepath.Base(filename)
+	entries, err := os.ReadDir(filepath.Dir(filename))
+	if err != nil {
+		return
+	}
+	type backup struct {
+		path    string
+		modTime time.Time
+	}
+	var backups []backup
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() || n == base || !(strings.HasPrefix(n, prefix+"-") || strings.HasPrefix(n, base+".")) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{filepath.Join(filepath.Dir(filename), n), info.ModTime()})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].modTime.After(backups[j].modTime) })
+	cutoff := now.Add(-maxAge)
+	for i, b := range backups {
+		if (maxBackups > 0 && i >= maxBackups) || (maxAge > 0 && b.modTime.Before(cutoff)) {
+			_ = os.Remove(b.path)
+		}
+	}
+}
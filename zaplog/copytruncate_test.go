@@ -0,0 +1,105 @@
+package zaplog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogFilePath(t *testing.T) {
+	got := logFilePath("logs//sub/", "app", "info.log")
+	want := filepath.Join("logs", "sub", "app-info.log")
+	if got != want {
+		t.Errorf("logFilePath = %q, want %q", got, want)
+	}
+}
+
+func TestCopyTruncateBySize(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app-info.log")
+	w := newCopyTruncateWriter(filename, 0, 0, 2, 0)
+	w.maxSize = 10
+	defer w.Close()
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write([]byte("12345678\n")); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Millisecond) //保证备份文件名不重复
+	}
+	data, _ := os.ReadFile(filename)
+	if string(data) != "12345678\n" {
+		t.Errorf("current file = %q", data)
+	}
+	backups, _ := filepath.Glob(filepath.Join(filepath.Dir(filename), "app-info-*.log"))
+	if len(backups) != 2 {
+		t.Errorf("expected 2 backups kept, got %v", backups)
+	}
+}
+
+func TestCopyTruncateByTime(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app-error.log")
+	now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+	w := newCopyTruncateWriter(filename, 0, time.Hour, 0, 0)
+	w.now = func() time.Time { return now }
+	defer w.Close()
+	if _, err := w.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Hour)
+	if _, err := w.Write([]byte("second\n")); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := os.ReadFile(filename + ".2024_0501_10")
+	if err != nil || string(backup) != "first\n" {
+		t.Errorf("backup = %q, %v", backup, err)
+	}
+	data, _ := os.ReadFile(filename)
+	if strings.TrimSpace(string(data)) != "second" {
+		t.Errorf("current file = %q", data)
+	}
+}
+
+func TestCopyTruncateSamePeriod(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app-error.log")
+	now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+	w := newCopyTruncateWriter(filename, 0, time.Hour, 0, 0)
+	w.now = func() time.Time { return now }
+	defer w.Close()
+	backup := filename + ".2024_0501_10"
+
+	//同一周期内手动切割两次，第一个备份压缩后不被覆盖
+	_, _ = w.Write([]byte("first\n"))
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := compressFile(CompressGzip, backup); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte("second\n"))
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(backup + ".1"); err != nil || string(data) != "second\n" {
+		t.Errorf("second backup = %q, %v", data, err)
+	}
+	if err := compressFile(CompressGzip, backup+".1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{backup + ".gz", backup + ".1.gz"} {
+		if _, err := os.Stat(f); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestWindowsModeLogger(t *testing.T) {
+	lg := newTestLogger(t, &Options{AppName: "win", WindowsMode: true, CutType: 1})
+	lg.Info("windows mode")
+	if err := lg.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Lstat(filepath.Join(lg.Opts.LogFileDir, "win-info.log")); err != nil || fi.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("expected regular file, got %v %v", fi, err)
+	}
+}
@@ -0,0 +1,39 @@
+package zaplog
+
+import (
+	"fmt"
+	"os"
+)
+
+const defaultDirPerm os.FileMode = 0755
+
+// mkdirLogDir 创建日志目录（包括上级目录）
+func (lg *Logger) mkdirLogDir() error {
+	if err := os.MkdirAll(lg.Opts.LogFileDir, lg.dirPerm()); err != nil {
+		return fmt.Errorf("zaplog: create log dir %s: %w", lg.Opts.LogFileDir, err)
+	}
+	return nil
+}
+
+func (lg *Logger) dirPerm() os.FileMode {
+	if lg.Opts.DirPerm == 0 {
+		return defaultDirPerm
+	}
+	return lg.Opts.DirPerm
+}
+
+// openFiles 创建日志目录并打开日志文件
+func (lg *Logger) openFiles() error {
+	if err := lg.mkdirLogDir(); err != nil {
+		return err
+	}
+	return lg.setSyncers()
+}
+
+// FileError 返回日志文件初始化失败的原因，nil表示正常写文件；
+// 仅在开启FallbackToStdout时可能非nil，此时日志只输出到控制台
+func (lg *Logger) FileError() error {
+	lg.RLock()
+	defer lg.RUnlock()
+	return lg.fileErr
+}
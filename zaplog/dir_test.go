@@ -0,0 +1,69 @@
+package zaplog
+
+import (
+	"bytes"
+	"go.uber.org/zap/zapcore"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateLogDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "a", "b", "logs")
+	lg := newTestLogger(t, &Options{LogFileDir: dir, AppName: "mkdir", DirPerm: 0700})
+	lg.Info("created")
+	fi, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0700 {
+		t.Errorf("perm = %v", fi.Mode().Perm())
+	}
+	if lg.FileError() != nil {
+		t.Errorf("unexpected file error: %v", lg.FileError())
+	}
+}
+
+func TestCreateLogDirFallback(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(blocker, "logs")
+
+	lg := newTestLogger(t, &Options{LogFileDir: dir, FallbackToStdout: true})
+	if lg.FileError() == nil {
+		t.Fatal("expected file error")
+	}
+	lg.Info("fallback to stdout")
+
+	if _, err := New(&Options{LogFileDir: dir}); err == nil {
+		t.Error("expected error without FallbackToStdout")
+	}
+}
+
+func TestFallbackWritesOnce(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	oldOut, oldErr := debugConsoleWS, errorConsoleWS
+	debugConsoleWS, errorConsoleWS = zapcore.AddSync(&stdout), zapcore.AddSync(&stderr)
+	defer func() { debugConsoleWS, errorConsoleWS = oldOut, oldErr }()
+
+	lg := newTestLogger(t, &Options{LogFileDir: filepath.Join(blocker, "logs"), LogLevel: "info", FallbackToStdout: true})
+	lg.Debug("debug entry")
+	lg.Info("info entry")
+	lg.Error("error entry")
+	_ = lg.Sync()
+
+	out, errOut := stdout.String(), stderr.String()
+	if strings.Count(out, "info entry") != 1 || strings.Contains(out, "error entry") || strings.Contains(out, "debug entry") {
+		t.Errorf("stdout: %s", out)
+	}
+	if strings.Count(errOut, "error entry") != 1 || strings.Contains(errOut, "info entry") {
+		t.Errorf("stderr: %s", errOut)
+	}
+}
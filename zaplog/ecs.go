@@ -0,0 +1,112 @@
+package zaplog
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"time"
+)
+
+// Options.Schema 可选值
+const (
+	SchemaDefault = ""    //zap默认字段名
+	SchemaECS     = "ecs" //Elastic Common Schema
+)
+
+// ECSVersion 输出的ecs.version
+const ECSVersion = "8.11.0"
+
+// ecsFieldNames 常见业务字段名到ECS字段名的映射
+var ecsFieldNames = map[string]string{
+	"trace_id":       "trace.id",
+	"traceId":        "trace.id",
+	"traceID":        "trace.id",
+	"span_id":        "span.id",
+	"spanId":         "span.id",
+	"spanID":         "span.id",
+	"transaction_id": "transaction.id",
+	"service":        "service.name",
+	sequenceKey:      "event.sequence",
+}
+
+// ecsEncoderConfig 按ECS命名字段：@timestamp、log.level、message、log.logger、error.stack_trace，
+// 调用位置由ecsCore以log.origin对象输出
+func ecsEncoderConfig(base zapcore.EncoderConfig) zapcore.EncoderConfig {
+	cfg := base
+	cfg.TimeKey = "@timestamp"
+	cfg.LevelKey = "log.level"
+	cfg.MessageKey = "message"
+	cfg.NameKey = "log.logger"
+	cfg.CallerKey = zapcore.OmitKey
+	cfg.FunctionKey = zapcore.OmitKey
+	cfg.StacktraceKey = "error.stack_trace"
+	cfg.EncodeTime = ecsTimeEncoder
+	cfg.EncodeLevel = zapcore.LowercaseLevelEncoder
+	cfg.EncodeDuration = zapcore.NanosDurationEncoder
+	return cfg
+}
+
+// ecsTimeEncoder ISO8601 UTC毫秒精度
+func ecsTimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(t.UTC().Format("2006-01-02T15:04:05.000Z"))
+}
+
+// ecsCore 写入前将字段转换为ECS命名，并补充log.origin与ecs.version
+type ecsCore struct {
+	zapcore.Core
+}
+
+func newECSCore(core zapcore.Core) zapcore.Core {
+	return ecsCore{core.With([]zapcore.Field{zap.String("ecs.version", ECSVersion)})}
+}
+
+func (c ecsCore) With(fields []zapcore.Field) zapcore.Core {
+	return ecsCore{c.Core.With(ecsFields(fields, false))}
+}
+
+func (c ecsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c ecsCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	fields = ecsFields(fields, ent.Stack != "")
+	if ent.Caller.Defined {
+		fields = append(fields, zap.Object("log.origin", ecsOrigin(ent.Caller)))
+	}
+	return c.Core.Write(ent, fields)
+}
+
+type ecsOrigin zapcore.EntryCaller
+
+func (o ecsOrigin) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("file.name", zapcore.EntryCaller(o).TrimmedPath())
+	enc.AddInt("file.line", o.Line)
+	if o.Function != "" {
+		enc.AddString("function", o.Function)
+	}
+	return nil
+}
+
+// ecsFields 返回转换后的新切片，不修改传入的fields；hasStack为true时entry已带调用栈，不再输出错误的详细信息
+func ecsFields(fields []zapcore.Field, hasStack bool) []zapcore.Field {
+	out := make([]zapcore.Field, 0, len(fields)+2)
+	for _, f := range fields {
+		if f.Type == zapcore.ErrorType && f.Key == "error" {
+			if err, ok := f.Interface.(error); ok && err != nil {
+				out = append(out, zap.String("error.message", err.Error()), zap.String("error.type", fmt.Sprintf("%T", err)))
+				if verbose := fmt.Sprintf("%+v", err); !hasStack && verbose != err.Error() {
+					out = append(out, zap.String("error.stack_trace", verbose))
+				}
+				continue
+			}
+		}
+		if name, ok := ecsFieldNames[f.Key]; ok {
+			f.Key = name
+		}
+		out = append(out, f)
+	}
+	return out
+}
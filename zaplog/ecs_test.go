@@ -0,0 +1,42 @@
+package zaplog
+
+import (
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestECSSchema(t *testing.T) {
+	lg := newTestLogger(t, &Options{AppName: "ecs", Schema: SchemaECS})
+	lg.Desugar().With(zap.String("trace_id", "abc123")).Error("payment failed", zap.Error(errors.New("timeout")))
+	lg.Sync()
+
+	data, err := os.ReadFile(filepath.Join(lg.Opts.LogFileDir, "ecs-error.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(data))), &entry); err != nil {
+		t.Fatalf("invalid json %s: %v", data, err)
+	}
+	for key, want := range map[string]interface{}{
+		"log.level":     "error",
+		"message":       "payment failed",
+		"trace.id":      "abc123",
+		"error.message": "timeout",
+		"ecs.version":   ECSVersion,
+	} {
+		if entry[key] != want {
+			t.Errorf("%s = %v, want %v", key, entry[key], want)
+		}
+	}
+	for _, key := range []string{"@timestamp", "error.stack_trace", "log.origin"} {
+		if _, ok := entry[key]; !ok {
+			t.Errorf("missing %s in %s", key, data)
+		}
+	}
+}
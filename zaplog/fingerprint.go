@@ -0,0 +1,173 @@
+package zaplog
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+const (
+	fingerprintKey    = "fingerprint"
+	fingerprintFrames = 3 //参与计算的栈帧数
+)
+
+var templateReplacer = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`"[^"]*"|'[^']*'`), "<str>"},
+	{regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b`), "<ip>"},
+	{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]{16,}\b`), "<hex>"},
+	{regexp.MustCompile(`\d+(\.\d+)?`), "<n>"},
+}
+
+// MessageTemplate 将消息中的uuid、引号字符串、IP、十六进制串、数字替换为占位符，
+// 使同类错误得到相同的模板，如 "user 123 not found" -> "user <n> not found"
+func MessageTemplate(msg string) string {
+	for _, r := range templateReplacer {
+		msg = r.re.ReplaceAllString(msg, r.repl)
+	}
+	return msg
+}
+
+// Fingerprint 计算错误的分组指纹：根因错误类型 + 消息模板 + 错误自带调用栈(如pkg/errors)的前几帧函数名
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+	return fingerprint(rootCause(err), MessageTemplate(err.Error()), errorFrames(err))
+}
+
+// FingerprintEntry 计算日志条目的指纹，err为nil时使用日志消息模板与调用栈
+func FingerprintEntry(ent zapcore.Entry, err error) string {
+	if err != nil {
+		if frames := errorFrames(err); len(frames) > 0 {
+			return fingerprint(rootCause(err), MessageTemplate(err.Error()), frames)
+		}
+		return fingerprint(rootCause(err), MessageTemplate(err.Error()), entryFrames(ent))
+	}
+	return fingerprint(nil, MessageTemplate(ent.Message), entryFrames(ent))
+}
+
+func fingerprint(cause error, template string, frames []string) string {
+	h := sha1.New()
+	if cause != nil {
+		fmt.Fprintf(h, "%T\n", cause)
+	}
+	h.Write([]byte(template))
+	for _, f := range frames {
+		h.Write([]byte{'\n'})
+		h.Write([]byte(f))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func rootCause(err error) error {
+	for i := 0; i < maxErrorChainDepth; i++ {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+	return err
+}
+
+// errorFrames 取错误链中最内层带StackTrace()方法(pkg/errors)的调用栈函数名
+func errorFrames(err error) []string {
+	var pcs []uintptr
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		m := reflect.ValueOf(e).MethodByName("StackTrace")
+		if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+			continue
+		}
+		st := m.Call(nil)[0]
+		if st.Kind() != reflect.Slice || st.Type().Elem().Kind() != reflect.Uintptr {
+			continue
+		}
+		pcs = pcs[:0]
+		for i := 0; i < st.Len(); i++ {
+			pcs = append(pcs, uintptr(st.Index(i).Uint()))
+		}
+	}
+	if len(pcs) == 0 {
+		return nil
+	}
+	frames := runtime.CallersFrames(pcs)
+	var names []string
+	for len(names) < fingerprintFrames {
+		f, more := frames.Next()
+		if f.Function != "" {
+			names = append(names, f.Function)
+		}
+		if !more {
+			break
+		}
+	}
+	return names
+}
+
+// entryFrames 从日志条目的调用栈取函数名，没有调用栈时使用调用位置
+func entryFrames(ent zapcore.Entry) []string {
+	var names []string
+	for _, line := range strings.Split(ent.Stack, "\n") {
+		if line == "" || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		names = append(names, line)
+		if len(names) == fingerprintFrames {
+			break
+		}
+	}
+	if len(names) == 0 && ent.Caller.Defined {
+		if ent.Caller.Function != "" {
+			names = append(names, ent.Caller.Function)
+		} else {
+			names = append(names, ent.Caller.File)
+		}
+	}
+	return names
+}
+
+// fingerprintCore 为error及以上级别的日志追加fingerprint字段
+type fingerprintCore struct {
+	zapcore.Core
+}
+
+func (c fingerprintCore) With(fields []zapcore.Field) zapcore.Core {
+	return fingerprintCore{c.Core.With(fields)}
+}
+
+func (c fingerprintCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c fingerprintCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Level < zapcore.ErrorLevel {
+		return c.Core.Write(ent, fields)
+	}
+	var err error
+	for _, f := range fields {
+		if f.Type == zapcore.ErrorType {
+			if e, ok := f.Interface.(error); ok {
+				err = e
+				break
+			}
+		}
+	}
+	all := make([]zapcore.Field, 0, len(fields)+1)
+	all = append(all, fields...)
+	all = append(all, zap.String(fingerprintKey, FingerprintEntry(ent, err)))
+	return c.Core.Write(ent, all)
+}
@@ -0,0 +1,60 @@
+package zaplog
+
+import (
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+)
+
+type notFoundError struct {
+	id int
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("user %d not found", e.id)
+}
+
+func TestMessageTemplate(t *testing.T) {
+	cases := map[string]string{
+		"user 123 not found":                              "user <n> not found",
+		`dial 10.0.0.1:3306 failed for "orders"`:          "dial <ip> failed for <str>",
+		"order 6ba7b810-9dad-11d1-80b4-00c04fd430c8 lost": "order <uuid> lost",
+	}
+	for in, want := range cases {
+		if got := MessageTemplate(in); got != want {
+			t.Errorf("MessageTemplate(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := fmt.Errorf("load profile: %w", &notFoundError{1})
+	b := fmt.Errorf("load profile: %w", &notFoundError{2})
+	c := fmt.Errorf("load profile: %w", errors.New("user 1 not found"))
+	if Fingerprint(a) != Fingerprint(b) {
+		t.Error("same error class should share fingerprint")
+	}
+	if Fingerprint(a) == Fingerprint(c) {
+		t.Error("different root cause types should differ")
+	}
+	if Fingerprint(nil) != "" {
+		t.Error("nil error fingerprint should be empty")
+	}
+}
+
+func TestFingerprintCore(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := zap.New(fingerprintCore{core})
+	l.Error("save failed", zap.Error(&notFoundError{7}))
+	l.Warn("not fingerprinted")
+	entries := logs.All()
+	if got := entries[0].ContextMap()[fingerprintKey]; got != FingerprintEntry(entries[0].Entry, &notFoundError{8}) {
+		t.Errorf("fingerprint = %v", got)
+	}
+	if _, ok := entries[1].ContextMap()[fingerprintKey]; ok {
+		t.Error("warn entry should not carry fingerprint")
+	}
+}
@@ -0,0 +1,196 @@
+package zaplog
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// --log-format 可选值
+const (
+	FormatJSON    = "json"    //文件输出zap默认字段的JSON
+	FormatECS     = "ecs"     //文件输出Elastic Common Schema字段的JSON
+	FormatConsole = "console" //开发模式，同时输出彩色文本到控制台
+)
+
+// flagValue 同时满足flag.Value与pflag.Value
+type flagValue interface {
+	String() string
+	Set(string) error
+	Type() string
+}
+
+type stringFlag struct {
+	p       *string
+	allowed []string //为空时不校验
+}
+
+func (f stringFlag) String() string {
+	if f.p == nil {
+		return ""
+	}
+	return *f.p
+}
+
+func (f stringFlag) Set(s string) error {
+	if len(f.allowed) > 0 && !containsString(f.allowed, s) {
+		return fmt.Errorf("must be one of %s", strings.Join(f.allowed, "|"))
+	}
+	*f.p = s
+	return nil
+}
+
+func (f stringFlag) Type() string { return "string" }
+
+type intFlag struct{ p *int }
+
+func (f intFlag) String() string {
+	if f.p == nil {
+		return "0"
+	}
+	return strconv.Itoa(*f.p)
+}
+
+func (f intFlag) Set(s string) error {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*f.p = v
+	return nil
+}
+
+func (f intFlag) Type() string { return "int" }
+
+type boolFlag struct{ p *bool }
+
+func (f boolFlag) String() string {
+	if f.p == nil {
+		return "false"
+	}
+	return strconv.FormatBool(*f.p)
+}
+
+func (f boolFlag) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*f.p = v
+	return nil
+}
+
+func (f boolFlag) Type() string { return "bool" }
+
+// IsBoolFlag 允许 --log-fallback-stdout 不带值
+func (f boolFlag) IsBoolFlag() bool { return true }
+
+// formatFlag --log-format，设置Schema与Development
+type formatFlag struct{ o *Options }
+
+func (f formatFlag) String() string {
+	switch {
+	case f.o == nil:
+		return ""
+	case f.o.Development:
+		return FormatConsole
+	case f.o.Schema == SchemaECS:
+		return FormatECS
+	}
+	return FormatJSON
+}
+
+func (f formatFlag) Set(s string) error {
+	switch s {
+	case FormatJSON:
+		f.o.Schema, f.o.Development = SchemaDefault, false
+	case FormatECS:
+		f.o.Schema, f.o.Development = SchemaECS, false
+	case FormatConsole:
+		f.o.Schema, f.o.Development = SchemaDefault, true
+	default:
+		return fmt.Errorf("must be one of %s|%s|%s", FormatJSON, FormatECS, FormatConsole)
+	}
+	return nil
+}
+
+func (f formatFlag) Type() string { return "string" }
+
+// levelFlag --log-level，与Validate、LoadOptions相同按parseLevel校验，大小写不敏感
+type levelFlag struct{ p *string }
+
+func (f levelFlag) String() string {
+	if f.p == nil {
+		return ""
+	}
+	return *f.p
+}
+
+func (f levelFlag) Set(s string) error {
+	if s != "" {
+		if _, err := parseLevel(s); err != nil {
+			return fmt.Errorf("unknown level %q, want debug|info|warn|error|dpanic|panic|fatal", s)
+		}
+	}
+	*f.p = s
+	return nil
+}
+
+func (f levelFlag) Type() string { return "string" }
+
+// cutFlag --log-rotate，size按大小切割，time按时间切割
+type cutFlag struct{ p *int }
+
+func (f cutFlag) String() string {
+	if f.p != nil && *f.p != 0 {
+		return "time"
+	}
+	return "size"
+}
+
+func (f cutFlag) Set(s string) error {
+	switch s {
+	case "size":
+		*f.p = 0
+	case "time":
+		*f.p = 1
+	default:
+		return fmt.Errorf("must be one of size|time")
+	}
+	return nil
+}
+
+func (f cutFlag) Type() string { return "string" }
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// bindFlags 注册日志相关参数，默认值取opts当前值
+func bindFlags(opts *Options, register func(v flagValue, name, usage string)) *Options {
+	register(levelFlag{&opts.LogLevel}, "log-level", "日志级别: debug|info|warn|error|dpanic|panic|fatal")
+	register(stringFlag{p: &opts.LogFileDir}, "log-dir", "日志目录，默认为运行目录下的logs")
+	register(stringFlag{p: &opts.AppName}, "log-name", "日志文件名前缀，默认app")
+	register(formatFlag{opts}, "log-format", "日志格式: json|ecs|console")
+	register(cutFlag{&opts.CutType}, "log-rotate", "切割方式: size|time")
+	register(intFlag{&opts.MaxSize}, "log-max-size", "按大小切割时单个文件的大小(MB)")
+	register(intFlag{&opts.MaxBackups}, "log-max-backups", "保留旧文件的最大个数")
+	register(intFlag{&opts.MaxAge}, "log-max-age", "保留旧文件的最大天数")
+	register(stringFlag{&opts.CompressionAlgo, []string{"", CompressGzip, CompressZstd, CompressNone}}, "log-compress", "旧文件压缩算法: gzip|zstd|none")
+	register(boolFlag{&opts.FallbackToStdout}, "log-fallback-stdout", "日志文件不可用时仅输出到控制台")
+	return opts
+}
+
+// BindFlags 在fs上注册 --log-level、--log-dir、--log-format 等日志参数，
+// fs.Parse之后返回的Options即为命令行配置，可直接传给InitLogger
+func BindFlags(fs *flag.FlagSet) *Options {
+	return bindFlags(&Options{}, func(v flagValue, name, usage string) {
+		fs.Var(v, name, usage)
+	})
+}
@@ -0,0 +1,13 @@
+package zaplog
+
+import "github.com/spf13/pflag"
+
+// BindPFlags BindFlags的pflag版本，用于cobra等基于pflag的命令行
+func BindPFlags(fs *pflag.FlagSet) *Options {
+	return bindFlags(&Options{}, func(v flagValue, name, usage string) {
+		fs.Var(v, name, usage)
+		if v.Type() == "bool" {
+			fs.Lookup(name).NoOptDefVal = "true" //允许不带值
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package zaplog
+
+import (
+	"flag"
+	"github.com/spf13/pflag"
+	"io"
+	"testing"
+)
+
+func TestBindFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	opts := BindFlags(fs)
+	err := fs.Parse([]string{"--log-level=warn", "--log-dir", "/var/log/app", "--log-format=ecs", "--log-rotate=time", "--log-max-size=50", "--log-compress=zstd", "--log-fallback-stdout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.LogLevel != "warn" || opts.LogFileDir != "/var/log/app" || opts.Schema != SchemaECS || opts.CutType != 1 ||
+		opts.MaxSize != 50 || opts.CompressionAlgo != CompressZstd || !opts.FallbackToStdout {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if err := fs.Parse([]string{"--log-level=verbose"}); err == nil {
+		t.Error("invalid level should fail")
+	}
+	//与Validate一致，大小写不敏感且支持dpanic等级别
+	for _, lvl := range []string{"INFO", "dpanic", "Fatal"} {
+		if err := fs.Parse([]string{"--log-level=" + lvl}); err != nil || opts.LogLevel != lvl || opts.Validate() != nil {
+			t.Errorf("--log-level=%s: %v", lvl, err)
+		}
+	}
+}
+
+func TestBindPFlags(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	opts := BindPFlags(fs)
+	if err := fs.Parse([]string{"--log-format", "console", "--log-name=svc", "--log-fallback-stdout"}); err != nil {
+		t.Fatal(err)
+	}
+	if !opts.Development || opts.AppName != "svc" || !opts.FallbackToStdout {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if f := fs.Lookup("log-fallback-stdout"); f == nil || f.Value.Type() != "bool" {
+		t.Error("log-fallback-stdout should be a bool flag")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package zaplog
+
+import "errors"
+
+// lockFile Windows下不支持flock，MultiProcessFlock会改用pid模式
+func lockFile(string) (func(), error) {
+	return nil, errors.New("zaplog: flock is not supported on windows")
+}
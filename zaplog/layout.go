@@ -0,0 +1,85 @@
+package zaplog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	DirLayoutFlat  = "flat"  //日志文件直接写在LogFileDir下
+	DirLayoutDaily = "daily" //按日期写入LogFileDir/2006-01-02/子目录
+
+	dailyDirFormat = "2006-01-02"
+)
+
+// dailyDirWriter 将日志写入当天的日期子目录，跨天时关闭旧文件并在新目录下重新打开。
+// 切割与清理仍由原有方式在当天目录内完成，历史日期目录不会自动删除
+type dailyDirWriter struct {
+	root string //LogFileDir
+	name string //文件名，如 app-info.log
+	perm os.FileMode
+	mill *millConfig
+	open func(filename string, mill *millConfig) (rotateWriter, error)
+	now  func() time.Time
+	day  string
+	w    rotateWriter
+}
+
+func newDailyDirWriter(root, name string, perm os.FileMode, mill *millConfig, open func(string, *millConfig) (rotateWriter, error)) *dailyDirWriter {
+	w := &dailyDirWriter{root: root, name: name, perm: perm, mill: mill, open: open, now: time.Now}
+	if mill != nil {
+		mill.filename = w.filename(w.now().Format(dailyDirFormat))
+	}
+	return w
+}
+
+func (w *dailyDirWriter) filename(day string) string {
+	return filepath.Join(w.root, day, w.name)
+}
+
+// switchDay 日期变化时切换到新的日期目录，调用方需持有fileSink的锁
+func (w *dailyDirWriter) switchDay() error {
+	day := w.now().Format(dailyDirFormat)
+	if w.w != nil && day == w.day {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Join(w.root, day), w.perm); err != nil {
+		return fmt.Errorf("zaplog: create log dir %s: %w", filepath.Join(w.root, day), err)
+	}
+	filename := w.filename(day)
+	next, err := w.open(filename, w.mill)
+	if err != nil {
+		return err
+	}
+	if w.w != nil {
+		_ = w.w.Close()
+	}
+	w.w, w.day = next, day
+	if w.mill != nil {
+		w.mill.filename = filename
+	}
+	return nil
+}
+
+func (w *dailyDirWriter) Write(p []byte) (int, error) {
+	if err := w.switchDay(); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
+
+func (w *dailyDirWriter) Rotate() error {
+	if err := w.switchDay(); err != nil {
+		return err
+	}
+	return w.w.Rotate()
+}
+
+func (w *dailyDirWriter) Close() error {
+	if w.w == nil {
+		return nil
+	}
+	return w.w.Close()
+}
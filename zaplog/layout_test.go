@@ -0,0 +1,49 @@
+package zaplog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDailyDirLayout(t *testing.T) {
+	no := false
+	lg := newTestLogger(t, &Options{DirLayout: DirLayoutDaily, Compress: &no})
+	lg.Info("hello")
+	day := time.Now().Format(dailyDirFormat)
+	if _, err := os.Stat(filepath.Join(lg.Opts.LogFileDir, day, "app-info.log")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(lg.Opts.LogFileDir, "app-info.log")); !os.IsNotExist(err) {
+		t.Errorf("flat file should not exist, stat err = %v", err)
+	}
+}
+
+func TestDailyDirSwitch(t *testing.T) {
+	root := t.TempDir()
+	now := time.Date(2024, 5, 1, 23, 59, 0, 0, time.Local)
+	mill := &millConfig{algo: CompressZstd}
+	open := func(filename string, _ *millConfig) (rotateWriter, error) {
+		return newCopyTruncateWriter(filename, 0, 0, 0, 0), nil
+	}
+	w := newDailyDirWriter(root, "app-info.log", defaultDirPerm, mill, open)
+	w.now = func() time.Time { return now }
+	defer w.Close()
+	if _, err := w.Write([]byte("a\n")); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := w.Write([]byte("b\n")); err != nil {
+		t.Fatal(err)
+	}
+	for day, want := range map[string]string{"2024-05-01": "a\n", "2024-05-02": "b\n"} {
+		data, err := os.ReadFile(filepath.Join(root, day, "app-info.log"))
+		if err != nil || string(data) != want {
+			t.Errorf("%s: got %q, %v; want %q", day, data, err, want)
+		}
+	}
+	if want := filepath.Join(root, "2024-05-02", "app-info.log"); mill.filename != want {
+		t.Errorf("mill filename = %q, want %q", mill.filename, want)
+	}
+}
@@ -0,0 +1,79 @@
+package zaplog
+
+import (
+	"crypto/subtle"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+const defaultLevelPath = "/log/level"
+
+// LevelAuthOptions LevelHandler的访问控制，设置了Token时校验 Authorization: Bearer <token>，
+// 设置了Username时校验Basic认证，两者都设置时满足其一即可
+type LevelAuthOptions struct {
+	Token    string
+	Username string
+	Password string
+}
+
+func (o *LevelAuthOptions) allow(r *http.Request) bool {
+	if o.Token != "" {
+		auth := r.Header.Get("Authorization")
+		if strings.HasPrefix(auth, "Bearer ") && secureEqual(strings.TrimPrefix(auth, "Bearer "), o.Token) {
+			return true
+		}
+	}
+	if o.Username != "" {
+		user, pass, ok := r.BasicAuth()
+		//两项都比较，避免按耗时猜测用户名
+		userOK, passOK := secureEqual(user, o.Username), secureEqual(pass, o.Password)
+		if ok && userOK && passOK {
+			return true
+		}
+	}
+	return o.Token == "" && o.Username == ""
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// LevelHandler 查看及修改全局日志级别的HTTP接口，即zap.AtomicLevel的ServeHTTP：
+// GET返回 {"level":"info"}，PUT提交 {"level":"debug"} 或表单 level=debug 修改级别。
+// Options.LevelAuth非nil时先校验访问权限，修改成功后输出一条warn日志
+func (lg *Logger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lg.RLock()
+		level := lg.zapConfig.Level
+		var auth *LevelAuthOptions
+		if lg.Opts != nil {
+			auth = lg.Opts.LevelAuth
+		}
+		lg.RUnlock()
+		if auth != nil && !auth.allow(r) {
+			if auth.Username != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="zaplog"`)
+			}
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		if level == (zap.AtomicLevel{}) {
+			http.Error(w, "zaplog: logger not initialized", http.StatusServiceUnavailable)
+			return
+		}
+		old := level.Level()
+		level.ServeHTTP(w, r)
+		if now := level.Level(); now != old {
+			lg.Warnf("[zaplog] log level changed from %s to %s by %s", old, now, r.RemoteAddr)
+		}
+	})
+}
+
+// HandleLevel 将LevelHandler挂载到mux的pattern上，pattern为空时使用 /log/level
+func (lg *Logger) HandleLevel(mux *http.ServeMux, pattern string) {
+	if pattern == "" {
+		pattern = defaultLevelPath
+	}
+	mux.Handle(pattern, lg.LevelHandler())
+}
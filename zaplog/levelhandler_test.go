@@ -0,0 +1,63 @@
+package zaplog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLevelHandler(t *testing.T) {
+	lg := newTestLogger(t, &Options{
+		LogLevel:  "info",
+		AppName:   "levelhttp",
+		LevelAuth: &LevelAuthOptions{Token: "secret", Username: "ops", Password: "pw"},
+	})
+	mux := http.NewServeMux()
+	lg.HandleLevel(mux, "")
+
+	do := func(method, body string, auth func(r *http.Request)) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/log/level", strings.NewReader(body))
+		if auth != nil {
+			auth(r)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	bearer := func(token string) func(r *http.Request) {
+		return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+	}
+
+	if w := do("GET", "", nil); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("no auth: %d", w.Code)
+	}
+	if w := do("PUT", `{"level":"debug"}`, bearer("wrong")); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: %d", w.Code)
+	}
+	if w := do("GET", "", bearer("secret")); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"level":"info"`) {
+		t.Fatalf("get: %d %s", w.Code, w.Body)
+	}
+	w := do("PUT", `{"level":"debug"}`, func(r *http.Request) { r.SetBasicAuth("ops", "pw") })
+	if w.Code != http.StatusOK || lg.Level() != zapcore.DebugLevel {
+		t.Fatalf("put: %d %s, level %v", w.Code, w.Body, lg.Level())
+	}
+	if w := do("PUT", `{"level":"loud"}`, bearer("secret")); w.Code != http.StatusBadRequest || lg.Level() != zapcore.DebugLevel {
+		t.Fatalf("invalid level: %d", w.Code)
+	}
+
+	warn, _ := os.ReadFile(filepath.Join(lg.Opts.LogFileDir, "levelhttp-warn.log"))
+	if !strings.Contains(string(warn), "log level changed from info to debug") {
+		t.Errorf("warn file: %s", warn)
+	}
+
+	var uninit Logger
+	w = httptest.NewRecorder()
+	uninit.LevelHandler().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("uninitialized: %d", w.Code)
+	}
+}
@@ -0,0 +1,112 @@
+package zaplog
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+)
+
+// SinkLevels 支持的输出目标名称
+const (
+	SinkFile    = "file"    //日志文件，FallbackToStdout时的控制台JSON输出同样使用该级别
+	SinkConsole = "console" //开发模式下的控制台输出
+)
+
+var knownSinks = map[string]bool{
+	SinkFile:    true,
+	SinkConsole: true,
+}
+
+// levelGetter zap.AtomicLevel等可读取当前级别的类型
+type levelGetter interface {
+	Level() zapcore.Level
+}
+
+// parseLevel 解析级别字符串，支持debug/info/warn/error/dpanic/panic/fatal，大小写不敏感
+func parseLevel(s string) (zapcore.Level, error) {
+	var l zapcore.Level
+	err := l.UnmarshalText([]byte(strings.ToLower(strings.TrimSpace(s))))
+	return l, err
+}
+
+// loadSinkLevels 解析Options.SinkLevels，无法识别的名称或级别忽略，初始化完成后输出警告
+func (lg *Logger) loadSinkLevels() {
+	lg.sinkLevels = make(map[string]zap.AtomicLevel, len(lg.Opts.SinkLevels))
+	for name, s := range lg.Opts.SinkLevels {
+		l, err := parseLevel(s)
+		if err != nil || !knownSinks[name] {
+			continue
+		}
+		lg.sinkLevels[name] = zap.NewAtomicLevelAt(l)
+	}
+}
+
+// sinkLevel 返回输出目标的级别，未单独配置时使用全局级别
+func (lg *Logger) sinkLevel(name string) levelGetter {
+	if l, ok := lg.sinkLevels[name]; ok {
+		return l
+	}
+	return lg.zapConfig.Level
+}
+
+// Level 当前的全局日志级别，未初始化时为info
+func (lg *Logger) Level() zapcore.Level {
+	lg.RLock()
+	defer lg.RUnlock()
+	if lg.zapConfig.Level == (zap.AtomicLevel{}) {
+		return zapcore.InfoLevel
+	}
+	return lg.zapConfig.Level.Level()
+}
+
+// SetLevel 运行中修改全局日志级别，立即对各级别文件及控制台输出生效，无需重启或Reconfigure。
+// SinkLevels中单独配置了级别的输出目标不受影响，Reconfigure后以新配置的LogLevel为准
+func (lg *Logger) SetLevel(level string) error {
+	l, err := parseLevel(level)
+	if err != nil {
+		return fmt.Errorf("zaplog: invalid level %q", level)
+	}
+	lg.SetZapLevel(l)
+	return nil
+}
+
+// SetZapLevel 同SetLevel
+func (lg *Logger) SetZapLevel(level zapcore.Level) {
+	lg.RLock()
+	defer lg.RUnlock()
+	if lg.zapConfig.Level == (zap.AtomicLevel{}) {
+		return //未初始化
+	}
+	lg.zapConfig.Level.SetLevel(level)
+}
+
+// sinkLevelWarnings 返回SinkLevels中无法识别的配置
+func (o *Options) sinkLevelWarnings() []string {
+	var warns []string
+	for name, s := range o.SinkLevels {
+		if !knownSinks[name] {
+			warns = append(warns, "unknown sink "+name)
+		} else if _, err := parseLevel(s); err != nil {
+			warns = append(warns, "invalid level "+s+" for sink "+name)
+		}
+	}
+	return warns
+}
+
+// levelPriorities 各级别文件的过滤条件：文件接收不低于自身级别的日志，且自身级别不低于当前配置级别
+func levelPriorities(level levelGetter) (errPriority, warnPriority, infoPriority, debugPriority zapcore.LevelEnabler) {
+	errPriority = zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl >= zapcore.ErrorLevel && zapcore.ErrorLevel-level.Level() > -1
+	})
+	warnPriority = zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl >= zapcore.WarnLevel && zapcore.WarnLevel-level.Level() > -1
+	})
+	infoPriority = zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl >= zapcore.InfoLevel && zapcore.InfoLevel-level.Level() > -1
+	})
+	debugPriority = zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl >= zapcore.DebugLevel && zapcore.DebugLevel-level.Level() > -1
+	})
+	return
+}
@@ -0,0 +1,70 @@
+package zaplog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSinkLevels(t *testing.T) {
+	lg := newTestLogger(t, &Options{
+		LogLevel:   "debug",
+		AppName:    "sinklevel",
+		SinkLevels: map[string]string{SinkFile: "WARN", SinkConsole: "debug", "kafka": "warn"},
+	})
+	lg.Info("info dropped by file level")
+	lg.Warn("warn kept")
+	lg.Sync()
+
+	warn, _ := os.ReadFile(filepath.Join(lg.Opts.LogFileDir, "sinklevel-warn.log"))
+	info, _ := os.ReadFile(filepath.Join(lg.Opts.LogFileDir, "sinklevel-info.log"))
+	if !strings.Contains(string(warn), "warn kept") || strings.Contains(string(info), "info dropped") {
+		t.Errorf("warn file: %s\ninfo file: %s", warn, info)
+	}
+	if got := lg.sinkLevel(SinkConsole).Level().String(); got != "debug" {
+		t.Errorf("console level = %s", got)
+	}
+	if warns := lg.Opts.sinkLevelWarnings(); len(warns) != 1 || !strings.Contains(warns[0], "kafka") {
+		t.Errorf("warnings = %v", warns)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	lg := newTestLogger(t, &Options{LogLevel: "warn", AppName: "setlevel"})
+	child := lg.With("component", "child")
+	lg.Info("info before")
+	if err := lg.SetLevel("DEBUG"); err != nil {
+		t.Fatal(err)
+	}
+	if lg.Level() != zapcore.DebugLevel {
+		t.Errorf("level = %v", lg.Level())
+	}
+	lg.Info("info after")
+	child.Debugw("debug from child")
+	if err := lg.SetLevel("verbose"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+	lg.SetZapLevel(zapcore.ErrorLevel)
+	lg.Warn("warn dropped")
+
+	info, _ := os.ReadFile(filepath.Join(lg.Opts.LogFileDir, "setlevel-info.log"))
+	debug, _ := os.ReadFile(filepath.Join(lg.Opts.LogFileDir, "setlevel-debug.log"))
+	warn, _ := os.ReadFile(filepath.Join(lg.Opts.LogFileDir, "setlevel-warn.log"))
+	if strings.Contains(string(info), "info before") || !strings.Contains(string(info), "info after") {
+		t.Errorf("info file: %s", info)
+	}
+	if !strings.Contains(string(debug), "debug from child") {
+		t.Errorf("debug file: %s", debug)
+	}
+	if strings.Contains(string(warn), "warn dropped") {
+		t.Errorf("warn file: %s", warn)
+	}
+
+	var uninit Logger
+	uninit.SetZapLevel(zapcore.DebugLevel)
+	if uninit.Level() != zapcore.InfoLevel {
+		t.Errorf("uninitialized level = %v", uninit.Level())
+	}
+}
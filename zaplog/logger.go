@@ -1,46 +1,77 @@
 package zaplog
 
 import (
+	"fmt"
+	"github.com/getsentry/sentry-go"
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
+	"github.com/liuxy92/golib/clockx"
 	"github.com/natefinch/lumberjack"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Options struct {
-	LogLevel      string //日志级别
-	LogFileDir    string //日志路径
-	AppName       string //Filename是要写入日志的文件前缀
-	ErrorFileName string //Error输出日志文件前缀
-	WarnFileName  string //Warn输出日志文件前缀
-	InfoFileName  string //Info输出日志文件前缀
-	DebugFileName string //Debug输出日志文件前缀
-	MaxSize       int    //一个文件多少M大于该数字开始切分文件
-	MaxBackups    int    //要保留的最大旧日志文件数
-	MaxAge        int    //根据日期保留旧日志文件的最大天数
-	CutType       int    //日志分割方式
-	Development   bool   //日志模式
+	LogLevel         string            //日志级别
+	LogFileDir       string            //日志路径
+	AppName          string            //Filename是要写入日志的文件前缀
+	ErrorFileName    string            //Error输出日志文件前缀
+	WarnFileName     string            //Warn输出日志文件前缀
+	InfoFileName     string            //Info输出日志文件前缀
+	DebugFileName    string            //Debug输出日志文件前缀
+	MaxSize          int               //一个文件多少M大于该数字开始切分文件
+	MaxBackups       int               //要保留的最大旧日志文件数
+	MaxAge           int               //根据日期保留旧日志文件的最大天数
+	CutType          int               //日志分割方式
+	Development      bool              //日志模式
+	JSONCodec        string            //文件输出反射字段的JSON编码器，默认std，见RegisterJSONCodec
+	WindowsMode      bool              //Windows兼容模式：不创建软链接，使用copy-truncate方式切割，Windows下自动开启
+	DirPerm          os.FileMode       //自动创建日志目录时使用的权限，默认0755
+	FallbackToStdout bool              //日志目录或文件创建失败时退化为仅输出到控制台，默认直接panic
+	Compress         *bool             //是否压缩切割后的旧文件，nil时按大小切割使用gzip压缩，按时间切割不压缩
+	CompressionAlgo  string            //旧文件压缩算法：gzip|zstd|none，设置后对所有切割方式生效
+	SinkLevels       map[string]string //按输出目标单独设置级别，如 {"console": "debug", "file": "info"}，未设置的使用LogLevel
+	Schema           string            //文件输出的字段布局，ecs输出Elastic Common Schema字段名，默认zap字段名
+	Resource         *Resource         //OpenTelemetry资源属性，非nil时文件日志附带resource对象
+	ErrorFingerprint bool              //error及以上级别的文件日志附带fingerprint字段，用于同类错误分组
+	Sentry           *SentryOptions    //非nil且DSN不为空时error及以上级别的日志上报到Sentry
+	DirLayout        string            //日志文件目录布局：flat直接写在LogFileDir下(默认)，daily按日期写入LogFileDir/2006-01-02/子目录
+	Sequence         bool              //文件日志附带单调递增的seq字段，用于合并各级别文件后还原输出顺序
+	MaxMessageBytes  int               //日志消息及字符串字段的最大字节数，超出部分截断，0不限制
+	MultiProcess     string            //多进程共用日志目录：pid文件名追加进程号，flock共用文件并加锁切割(仅按大小切割，Windows下改用pid)
+	Clock            clockx.Clock      //日志时间、切割及耗时统计使用的时间来源，默认系统时间，测试时可使用clockx.Mock
+	LevelAuth        *LevelAuthOptions //LevelHandler的访问控制，nil时不校验
 	zap.Config
 }
 
 type Logger struct {
 	*zap.SugaredLogger
 	sync.RWMutex
-	Opts      *Options `json:"opts"`
-	zapConfig zap.Config
-	inited    bool
+	Opts       *Options    `json:"opts"`
+	base       *zap.Logger //Infow等键值对方法使用的底层logger，跳过两层调用栈
+	zapConfig  zap.Config
+	sinks      *sinkRegistry //已打开的日志文件句柄
+	fileErr    error         //日志文件初始化失败原因，非nil时仅输出到控制台
+	usedSinks  map[string]bool
+	core       *swapCore //Reconfigure时原子替换的core
+	sinkLevels map[string]zap.AtomicLevel
+	sentry     *sentry.Client
+	sentryErr  error         //Sentry客户端创建失败原因，非nil时不上报
+	seq        atomic.Uint64 //Sequence开启时的日志序号，Reconfigure后继续递增
+	clk        atomic.Value  //clockHolder
+	inited     bool
+
+	errWS, warnWS, infoWS, debugWS zapcore.WriteSyncer //各级别日志文件输出
 }
 
 var (
-	logger                         *Logger
-	sp                             = string(filepath.Separator) //路径分隔符'/'
-	errWS, warnWS, infoWS, debugWS zapcore.WriteSyncer          //IO输出
-	debugConsoleWS                 = zapcore.Lock(os.Stdout)    //控制台调试标准输出
-	errorConsoleWS                 = zapcore.Lock(os.Stderr)    //控制台异常标准输出
+	logger         *Logger
+	debugConsoleWS = zapcore.Lock(os.Stdout) //控制台调试标准输出
+	errorConsoleWS = zapcore.Lock(os.Stderr) //控制台异常标准输出
 )
 
 func init() {
@@ -49,21 +80,75 @@ func init() {
 	}
 }
 
+// InitLogger 初始化全局logger，无法识别的配置项输出警告后忽略，日志文件创建失败时panic
 func InitLogger(cfg ...*Options) {
+	if err := initGlobal(false, cfg); err != nil {
+		panic(err)
+	}
+}
+
+// InitLoggerE 初始化全局logger，配置无效或日志目录、文件、Sentry创建失败时返回错误，由调用方决定如何处理。
+// 返回错误时全局logger保持未初始化，可修正配置后再次调用
+func InitLoggerE(cfg ...*Options) error {
+	return initGlobal(true, cfg)
+}
+
+func initGlobal(strict bool, cfg []*Options) error {
 	logger.Lock()
 	defer logger.Unlock()
 	if logger.inited {
-		logger.Info("[initLogger] zaplog already initialized")
-		return
+		logger.Info("[initLogger] zaplog already initialized, use Reconfigure to change options")
+		return nil
 	}
 
-	if len(cfg) > 0 {
-		logger.Opts = cfg[0]
+	opts := logger.Opts
+	if len(cfg) > 0 && cfg[0] != nil {
+		opts = cfg[0]
+	}
+	if strict {
+		if err := opts.Validate(); err != nil {
+			return err
+		}
 	}
+	logger.Opts = opts
 	logger.loadCfg()
-	logger.init()
+	if err := logger.apply(); err != nil {
+		if logger.sinks != nil {
+			_ = logger.sinks.close()
+		}
+		return err
+	}
+	logger.logInitWarnings()
 	logger.Info("[initLogger] zap plugin initializing completed")
 	logger.inited = true
+	return nil
+}
+
+// New 创建独立于全局logger的Logger实例，各实例使用各自的日志文件、级别及输出core，
+// 可用于同一进程内不同组件写入不同目录。配置校验规则同InitLoggerE，opts会被填充默认值，不再使用时需调用Close关闭文件
+func New(opts *Options) (*Logger, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	lg := &Logger{Opts: opts}
+	lg.loadCfg()
+	if err := lg.apply(); err != nil {
+		if lg.sinks != nil {
+			_ = lg.sinks.close()
+		}
+		return nil, err
+	}
+	lg.logInitWarnings()
+	lg.inited = true
+	return lg, nil
+}
+
+// Reconfigure 使用新配置重新初始化全局logger，未初始化时等同于InitLogger
+func Reconfigure(opts *Options) error {
+	return logger.Reconfigure(opts)
 }
 
 // GetLogger return logger
@@ -71,15 +156,101 @@ func GetLogger() *Logger {
 	return logger
 }
 
-func (lg *Logger) init() {
-	lg.setSyncers()
-	var err error
-	myLogger, err := lg.zapConfig.Build(lg.cores())
-	if err != nil {
-		panic(err)
+// Reconfigure 在运行中替换日志文件、输出core及日志级别，已取得的logger及其With派生的子logger立即生效。
+// 替换前先刷新缓冲，新配置无法生效时返回错误并保持原配置。
+// Development对应的调用栈、DPanic等logger级别选项以首次初始化为准
+func (lg *Logger) Reconfigure(opts *Options) error {
+	lg.Lock()
+	defer lg.Unlock()
+	if opts == nil {
+		opts = &Options{}
+	}
+	oldOpts, oldCfg, oldUsed := lg.Opts, lg.zapConfig, lg.usedSinks
+	lg.Opts = opts
+	lg.loadCfg()
+	if lg.SugaredLogger != nil {
+		_ = lg.SugaredLogger.Sync()
+	}
+	if err := lg.apply(); err != nil {
+		lg.Opts, lg.zapConfig, lg.usedSinks = oldOpts, oldCfg, oldUsed
+		lg.clk.Store(clockHolder{clockx.Or(oldOpts.Clock)})
+		if lg.sinks != nil {
+			_ = lg.sinks.retain(oldUsed)
+		}
+		return err
+	}
+	lg.logInitWarnings()
+	lg.Info("[reconfigure] zaplog reconfigured")
+	lg.inited = true
+	return nil
+}
+
+func (lg *Logger) logInitWarnings() {
+	if lg.fileErr != nil {
+		lg.Warnf("[initLogger] log files unavailable, fallback to stdout: %v", lg.fileErr)
+	}
+	if lg.sentryErr != nil {
+		lg.Warnf("[initLogger] sentry disabled: %v", lg.sentryErr)
+	}
+	if _, ok := lookupJSONCodec(lg.Opts.JSONCodec); !ok {
+		lg.Warnf("[initLogger] unknown JSONCodec %q, fallback to %s", lg.Opts.JSONCodec, JSONCodecStd)
+	}
+	if lg.Opts.Schema != SchemaDefault && lg.Opts.Schema != SchemaECS {
+		lg.Warnf("[initLogger] unknown Schema %q, use default field layout", lg.Opts.Schema)
+	}
+	for _, w := range lg.Opts.sinkLevelWarnings() {
+		lg.Warnf("[initLogger] SinkLevels: %s, ignored", w)
+	}
+	switch mp := lg.Opts.MultiProcess; {
+	case mp != "" && mp != MultiProcessPID && mp != MultiProcessFlock:
+		lg.Warnf("[initLogger] unknown MultiProcess %q, ignored", mp)
+	case mp == MultiProcessFlock && lg.Opts.WindowsMode:
+		lg.Warnf("[initLogger] MultiProcess %q is not supported in WindowsMode, use %s", mp, MultiProcessPID)
+	}
+	if l := lg.Opts.DirLayout; l != "" && l != DirLayoutFlat && l != DirLayoutDaily {
+		lg.Warnf("[initLogger] unknown DirLayout %q, use %s", l, DirLayoutFlat)
+	}
+	if a := lg.Opts.CompressionAlgo; a != "" && a != CompressNone && compressExt[a] == "" {
+		lg.Warnf("[initLogger] unknown CompressionAlgo %q, use default compression", a)
+	}
+}
+
+// apply 按当前配置打开日志文件并生效，首次调用时创建logger，之后原子替换core
+func (lg *Logger) apply() error {
+	lg.fileErr = nil
+	lg.usedSinks = nil
+	lg.clk.Store(clockHolder{clockx.Or(lg.Opts.Clock)})
+	if err := lg.openFiles(); err != nil {
+		if !lg.Opts.FallbackToStdout {
+			return err
+		}
+		lg.fileErr = err
+	}
+	oldSentry := lg.sentry
+	lg.sentry, lg.sentryErr = lg.openSentry()
+	cores := lg.cores()
+	if lg.core == nil {
+		core := newSwapCore(cores)
+		myLogger, err := lg.zapConfig.Build(zap.WrapCore(func(zapcore.Core) zapcore.Core {
+			return core
+		}), zap.WithClock(zapClock{lg}))
+		if err != nil {
+			return err
+		}
+		lg.core = core
+		lg.SugaredLogger = myLogger.Sugar()
+		lg.base = myLogger.WithOptions(zap.AddCallerSkip(2))
+	} else {
+		lg.core.swap(cores)
+	}
+	if oldSentry != nil {
+		oldSentry.Flush(defaultSentryFlushTimeout)
+	}
+	//新core生效后再关闭不再使用的旧文件
+	if lg.sinks != nil {
+		return lg.sinks.retain(lg.usedSinks)
 	}
-	lg.SugaredLogger = myLogger.Sugar()
-	defer lg.SugaredLogger.Sync()
+	return nil
 }
 
 func (lg *Logger) loadCfg() {
@@ -90,6 +261,12 @@ func (lg *Logger) loadCfg() {
 		lg.zapConfig = zap.NewProductionConfig()
 		lg.zapConfig.EncoderConfig.EncodeTime = timeUnixNano
 	}
+	lg.zapConfig.EncoderConfig.EncodeCaller = shortCallerEncoder
+	if codec, ok := lookupJSONCodec(lg.Opts.JSONCodec); ok {
+		lg.zapConfig.EncoderConfig.NewReflectedEncoder = codec
+	} else {
+		lg.zapConfig.EncoderConfig.NewReflectedEncoder = stdReflectedEncoder
+	}
 	if lg.Opts.OutputPaths == nil || len(lg.Opts.OutputPaths) == 0 {
 		lg.zapConfig.OutputPaths = []string{"stdout"}
 	}
@@ -97,22 +274,20 @@ func (lg *Logger) loadCfg() {
 		lg.zapConfig.ErrorOutputPaths = []string{"stderr"}
 	}
 
-	// 设置日志级别
-	switch lg.Opts.LogLevel {
-	case "debug":
-		lg.zapConfig.Level.SetLevel(zap.DebugLevel)
-	case "info":
-		lg.zapConfig.Level.SetLevel(zap.InfoLevel)
-	case "warn":
-		lg.zapConfig.Level.SetLevel(zap.WarnLevel)
-	case "error":
-		lg.zapConfig.Level.SetLevel(zap.ErrorLevel)
+	// 设置日志级别，无法识别时使用zap默认的info
+	if l, err := parseLevel(lg.Opts.LogLevel); err == nil && lg.Opts.LogLevel != "" {
+		lg.zapConfig.Level.SetLevel(l)
 	}
+	lg.loadSinkLevels()
 
 	// 默认输出到程序运行目录的logs子目录
 	if lg.Opts.LogFileDir == "" {
 		lg.Opts.LogFileDir, _ = filepath.Abs(filepath.Dir(filepath.Join(".")))
-		lg.Opts.LogFileDir += sp + "logs" + sp
+		lg.Opts.LogFileDir = filepath.Join(lg.Opts.LogFileDir, "logs")
+	}
+	lg.Opts.LogFileDir = filepath.Clean(filepath.FromSlash(lg.Opts.LogFileDir))
+	if isWindows {
+		lg.Opts.WindowsMode = true
 	}
 	if lg.Opts.AppName == "" {
 		lg.Opts.AppName = "app"
@@ -140,72 +315,195 @@ func (lg *Logger) loadCfg() {
 	}
 }
 
-func (lg *Logger) setSyncers() {
-	f := func(fName string) zapcore.WriteSyncer {
+func (lg *Logger) setSyncers() error {
+	if lg.sinks == nil {
+		lg.sinks = newSinkRegistry()
+	}
+	used := make(map[string]bool, 4)
+	lg.usedSinks = used
+	algo := lg.Opts.compressionAlgo()
+	daily := lg.Opts.DirLayout == DirLayoutDaily
+	mp := lg.Opts.multiProcess()
+	f := func(fName string) (zapcore.WriteSyncer, error) {
+		filename := logFilePath(lg.Opts.LogFileDir, lg.Opts.AppName, fName)
+		if mp == MultiProcessPID {
+			filename = pidFileName(filename, os.Getpid())
+		}
+		key := fmt.Sprintf("%d|%t|%t|%s|%s|%d|%d|%d|%s", lg.Opts.CutType, lg.Opts.WindowsMode, daily, mp, filename, lg.Opts.MaxSize, lg.Opts.MaxBackups, lg.Opts.MaxAge, algo)
+		used[key] = true
+		mill := lg.millConfig(filename)
+		sink, err := lg.sinks.get(key, mill, func() (rotateWriter, error) {
+			if daily {
+				w := newDailyDirWriter(lg.Opts.LogFileDir, filepath.Base(filename), lg.dirPerm(), mill, lg.openWriter)
+				w.now = lg.clock().Now
+				return w, nil
+			}
+			return lg.openWriter(filename, mill)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	}
+	var err error
+	if lg.errWS, err = f(lg.Opts.ErrorFileName); err != nil {
+		return err
+	}
+	if lg.warnWS, err = f(lg.Opts.WarnFileName); err != nil {
+		return err
+	}
+	if lg.infoWS, err = f(lg.Opts.InfoFileName); err != nil {
+		return err
+	}
+	lg.debugWS, err = f(lg.Opts.DebugFileName)
+	return err
+}
+
+// openWriter 按切割方式打开日志文件，rotatelogs需要额外压缩时记录当前写入的文件
+func (lg *Logger) openWriter(filename string, mill *millConfig) (rotateWriter, error) {
+	algo := lg.Opts.compressionAlgo()
+	if lg.Opts.WindowsMode {
+		//Windows下无法重命名打开中的文件，也不支持软链接
+		w := newCopyTruncateWriter(filename, 0, time.Hour, 0, lg.Opts.MaxAge)
 		if lg.Opts.CutType == 0 {
-			//lumberjack根据文件大小进行切割文件
-			return zapcore.AddSync(&lumberjack.Logger{
-				Filename:   lg.Opts.LogFileDir + sp + lg.Opts.AppName + "-" + fName, //日志文件的位置
-				MaxSize:    lg.Opts.MaxSize,                                         //在进行切割之前，日志文件的最大大小(以MB为单位)
-				MaxBackups: lg.Opts.MaxBackups,                                      //保留旧文件的最大个数
-				MaxAge:     lg.Opts.MaxAge,                                          //保留旧文件的最大天数
-				Compress:   true,                                                    //是否压缩/归档旧文件
-				LocalTime:  true,
-			})
-		} else {
-			//每一小时一个文件
-			logf, _ := rotatelogs.New(
-				lg.Opts.LogFileDir+sp+lg.Opts.AppName+"-"+fName+".%Y_%m%d_%H",
-				rotatelogs.WithLinkName(lg.Opts.LogFileDir+sp+lg.Opts.AppName+"-"+fName),
-				rotatelogs.WithMaxAge(time.Duration(lg.Opts.MaxAge)*24*time.Hour),
-				rotatelogs.WithRotationTime(time.Minute),
-			)
-			return zapcore.AddSync(logf)
-		}
-	}
-	errWS = f(lg.Opts.ErrorFileName)
-	warnWS = f(lg.Opts.WarnFileName)
-	infoWS = f(lg.Opts.InfoFileName)
-	debugWS = f(lg.Opts.DebugFileName)
-	return
-}
-
-func (lg *Logger) cores() zap.Option {
-	fileEncoder := zapcore.NewJSONEncoder(lg.zapConfig.EncoderConfig)
+			w = newCopyTruncateWriter(filename, lg.Opts.MaxSize, 0, lg.Opts.MaxBackups, lg.Opts.MaxAge)
+		}
+		w.now = lg.clock().Now
+		return w, nil
+	}
+	if lg.Opts.CutType == 0 && lg.Opts.multiProcess() == MultiProcessFlock {
+		//lumberjack切割时重命名文件，多进程共用时会互相覆盖
+		sharedAlgo := algo
+		if algo == "" {
+			sharedAlgo = CompressGzip
+		} else if algo == CompressNone {
+			sharedAlgo = ""
+		}
+		w := newSharedFileWriter(filename, lg.Opts.MaxSize, lg.Opts.MaxBackups, lg.Opts.MaxAge, sharedAlgo)
+		w.now = lg.clock().Now
+		return w, nil
+	}
+	if lg.Opts.CutType == 0 {
+		//lumberjack根据文件大小进行切割文件
+		return &lumberjack.Logger{
+			Filename:   filename,                           //日志文件的位置
+			MaxSize:    lg.Opts.MaxSize,                    //在进行切割之前，日志文件的最大大小(以MB为单位)
+			MaxBackups: lg.Opts.MaxBackups,                 //保留旧文件的最大个数
+			MaxAge:     lg.Opts.MaxAge,                     //保留旧文件的最大天数
+			Compress:   algo == "" || algo == CompressGzip, //是否压缩/归档旧文件
+			LocalTime:  true,
+		}, nil
+	}
+	//每一小时一个文件
+	rl, err := rotatelogs.New(
+		filename+".%Y_%m%d_%H",
+		rotatelogs.WithLinkName(filename),
+		rotatelogs.WithMaxAge(time.Duration(lg.Opts.MaxAge)*24*time.Hour),
+		rotatelogs.WithRotationTime(time.Minute),
+	)
+	if err == nil && mill != nil {
+		mill.current = rl.CurrentFileName
+	}
+	return rl, err
+}
+
+// millConfig 返回需要额外压缩时的配置，lumberjack自带的gzip压缩及不压缩时返回nil
+func (lg *Logger) millConfig(filename string) *millConfig {
+	algo := lg.Opts.compressionAlgo()
+	if algo == "" || algo == CompressNone {
+		return nil
+	}
+	if algo == CompressGzip && lg.Opts.CutType == 0 && !lg.Opts.WindowsMode {
+		return nil
+	}
+	if lg.Opts.CutType == 0 && lg.Opts.multiProcess() == MultiProcessFlock {
+		return nil //切割后由持有文件锁的进程压缩
+	}
+	m := &millConfig{algo: algo, filename: filename}
+	if lg.Opts.CutType == 0 && !lg.Opts.WindowsMode {
+		m.maxBackups = lg.Opts.MaxBackups
+		m.maxAge = time.Duration(lg.Opts.MaxAge) * 24 * time.Hour
+	}
+	return m
+}
+
+func (lg *Logger) cores() []zapcore.Core {
+	fileEncoderConfig := lg.zapConfig.EncoderConfig
+	if lg.Opts.Schema == SchemaECS {
+		fileEncoderConfig = ecsEncoderConfig(fileEncoderConfig)
+	}
+	fileEncoder := zapcore.NewJSONEncoder(fileEncoderConfig)
 	//consoleEncoder := zapcore.NewConsoleEncoder(lg.zapConfig.EncoderConfig)
 	encoderConfig := zap.NewDevelopmentConfig().EncoderConfig
 	encoderConfig.EncodeTime = timeEncoder
+	encoderConfig.EncodeCaller = shortCallerEncoder
 	consoleEncoder := zapcore.NewConsoleEncoder(encoderConfig)
-
-	errPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= zapcore.ErrorLevel && zapcore.ErrorLevel-lg.zapConfig.Level.Level() > -1
-	})
-	warnPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= zapcore.WarnLevel && zapcore.WarnLevel-lg.zapConfig.Level.Level() > -1
-	})
-	infoPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= zapcore.InfoLevel && zapcore.InfoLevel-lg.zapConfig.Level.Level() > -1
-	})
-	debugPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= zapcore.DebugLevel && zapcore.DebugLevel-lg.zapConfig.Level.Level() > -1
-	})
-	cores := []zapcore.Core{
-		zapcore.NewCore(fileEncoder, errWS, errPriority),
-		zapcore.NewCore(fileEncoder, warnWS, warnPriority),
-		zapcore.NewCore(fileEncoder, infoWS, infoPriority),
-		zapcore.NewCore(fileEncoder, debugWS, debugPriority),
+	errPriority, warnPriority, infoPriority, debugPriority := levelPriorities(lg.sinkLevel(SinkFile))
+	var cores []zapcore.Core
+	switch {
+	case lg.fileErr == nil:
+		cores = []zapcore.Core{
+			zapcore.NewCore(fileEncoder, lg.errWS, errPriority),
+			zapcore.NewCore(fileEncoder, lg.warnWS, warnPriority),
+			zapcore.NewCore(fileEncoder, lg.infoWS, infoPriority),
+			zapcore.NewCore(fileEncoder, lg.debugWS, debugPriority),
+		}
+	case !lg.Opts.Development:
+		//文件不可用时改为输出JSON到控制台，开发模式下已有控制台输出。
+		//error及以上写stderr，其余写stdout，每条日志只输出一次
+		level := lg.sinkLevel(SinkFile)
+		cores = []zapcore.Core{
+			zapcore.NewCore(fileEncoder, errorConsoleWS, zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+				return lvl >= zapcore.ErrorLevel && lvl >= level.Level()
+			})),
+			zapcore.NewCore(fileEncoder, debugConsoleWS, zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+				return lvl < zapcore.ErrorLevel && lvl >= level.Level()
+			})),
+		}
+	}
+	if lg.Opts.Resource != nil {
+		resource := []zapcore.Field{lg.Opts.Resource.Field()}
+		for i, c := range cores {
+			cores[i] = c.With(resource)
+		}
+	}
+	if lg.Opts.ErrorFingerprint {
+		for i, c := range cores {
+			cores[i] = fingerprintCore{c}
+		}
+	}
+	if lg.Opts.Schema == SchemaECS {
+		for i, c := range cores {
+			cores[i] = newECSCore(c)
+		}
+	}
+	if lg.Opts.Sequence && len(cores) > 0 {
+		cores = []zapcore.Core{newSeqCore(cores, &lg.seq)}
+	}
+	if lg.sentry != nil {
+		var c zapcore.Core = newSentryCore(lg.sentry, lg.Opts.Sentry, lg.clock())
+		if lg.Opts.ErrorFingerprint {
+			c = fingerprintCore{c}
+		}
+		cores = append(cores, c)
+	}
+	if lg.Opts.MaxMessageBytes > 0 {
+		for i, c := range cores {
+			cores[i] = sanitizeCore{c, lg.Opts.MaxMessageBytes, false}
+		}
 	}
 	if lg.Opts.Development {
-		cores = append(cores, []zapcore.Core{
+		errPriority, warnPriority, infoPriority, debugPriority := levelPriorities(lg.sinkLevel(SinkConsole))
+		for _, c := range []zapcore.Core{
 			zapcore.NewCore(consoleEncoder, errorConsoleWS, errPriority),
 			zapcore.NewCore(consoleEncoder, debugConsoleWS, warnPriority),
 			zapcore.NewCore(consoleEncoder, debugConsoleWS, infoPriority),
 			zapcore.NewCore(consoleEncoder, debugConsoleWS, debugPriority),
-		}...)
+		} {
+			cores = append(cores, sanitizeCore{c, lg.Opts.MaxMessageBytes, true})
+		}
 	}
-	return zap.WrapCore(func(c zapcore.Core) zapcore.Core {
-		return zapcore.NewTee(cores...)
-	})
+	return cores
 }
 
 func timeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
@@ -3,6 +3,10 @@ package zaplog
 import (
 	"fmt"
 	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -28,3 +32,64 @@ func TestZaplog(t *testing.T) {
 		logger.Error(fmt.Sprint("err log ", i), zap.String("level", `{"a":"7","b":"8"}`))
 	}
 }
+
+func TestNewIndependent(t *testing.T) {
+	var loggers [2]*Logger
+	var dirs [2]string
+	var wg sync.WaitGroup
+	for i := range loggers {
+		dirs[i] = t.TempDir()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			lg, err := New(&Options{LogFileDir: dirs[i], AppName: fmt.Sprint("c", i), LogLevel: "info"})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			loggers[i] = lg
+		}(i)
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+	for i, lg := range loggers {
+		defer lg.Close()
+		lg.Infow("component log", "n", i)
+	}
+	//重新配置一个实例不影响另一个
+	if err := loggers[0].Reconfigure(&Options{LogFileDir: dirs[0], AppName: "c0", LogLevel: "error"}); err != nil {
+		t.Fatal(err)
+	}
+	loggers[0].Info("dropped")
+	loggers[1].Info("kept")
+
+	for i, dir := range dirs {
+		b, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("c%d-info.log", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := string(b)
+		if !strings.Contains(s, fmt.Sprintf(`"n":%d`, i)) || strings.Contains(s, fmt.Sprintf(`"n":%d`, 1-i)) {
+			t.Errorf("logger %d wrote:\n%s", i, s)
+		}
+		if strings.Contains(s, "dropped") || (i == 1) != strings.Contains(s, "kept") {
+			t.Errorf("logger %d level:\n%s", i, s)
+		}
+	}
+	if GetLogger() == loggers[0] || GetLogger() == loggers[1] {
+		t.Error("New must not replace the global logger")
+	}
+}
+
+func TestNewError(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	lg, err := New(&Options{LogFileDir: filepath.Join(blocker, "logs")})
+	if err == nil || lg != nil {
+		t.Fatalf("New = %v, %v", lg, err)
+	}
+}
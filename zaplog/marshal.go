@@ -0,0 +1,245 @@
+package zaplog
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"net/url"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// SensitiveHeaders 输出时脱敏的请求/响应头，需在初始化阶段修改
+var SensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+}
+
+func isSensitiveHeader(name string) bool {
+	for _, h := range SensitiveHeaders {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// SensitiveQueryParams 输出URL时脱敏的查询参数，大小写不敏感，与SensitiveHeaders同名的参数(如 X-Api-Key)同样脱敏，需在初始化阶段修改
+var SensitiveQueryParams = []string{
+	"access_token",
+	"refresh_token",
+	"id_token",
+	"token",
+	"api_key",
+	"apikey",
+	"password",
+	"secret",
+	"client_secret",
+	"signature",
+	"sig",
+	"X-Amz-Signature",
+	"X-Amz-Credential",
+	"X-Amz-Security-Token",
+}
+
+func isSensitiveQuery(name string) bool {
+	for _, q := range SensitiveQueryParams {
+		if strings.EqualFold(q, name) {
+			return true
+		}
+	}
+	return isSensitiveHeader(name)
+}
+
+// redactURL 去掉userinfo，敏感查询参数的值替换为[REDACTED]，其余参数保持原有顺序及编码
+func redactURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	if c.RawQuery == "" {
+		return c.String()
+	}
+	parts := strings.Split(c.RawQuery, "&")
+	for i, part := range parts {
+		key, _, hasValue := strings.Cut(part, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if hasValue && isSensitiveQuery(name) {
+			parts[i] = key + "=" + redacted
+		}
+	}
+	c.RawQuery = strings.Join(parts, "&")
+	return c.String()
+}
+
+// headers 按key排序输出，敏感头替换为[REDACTED]
+type headers http.Header
+
+func (h headers) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if isSensitiveHeader(k) {
+			enc.AddString(k, redacted)
+			continue
+		}
+		enc.AddString(k, strings.Join(h[k], ", "))
+	}
+	return nil
+}
+
+type httpRequest struct {
+	r *http.Request
+}
+
+// HTTPRequest 请求的结构化输出：method、url(查询参数脱敏)、proto、host、remote_addr、content_length、headers(脱敏)
+func HTTPRequest(r *http.Request) zapcore.ObjectMarshaler {
+	return httpRequest{r}
+}
+
+func (m httpRequest) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	r := m.r
+	if r == nil {
+		return nil
+	}
+	enc.AddString("method", r.Method)
+	if r.URL != nil {
+		enc.AddString("url", redactURL(r.URL))
+	}
+	enc.AddString("proto", r.Proto)
+	enc.AddString("host", r.Host)
+	enc.AddString("remote_addr", r.RemoteAddr)
+	if r.ContentLength > 0 {
+		enc.AddInt64("content_length", r.ContentLength)
+	}
+	return enc.AddObject("headers", headers(r.Header))
+}
+
+type httpResponse struct {
+	resp *http.Response
+}
+
+// HTTPResponse 响应的结构化输出：status、proto、content_length、headers(脱敏)，不读取body
+func HTTPResponse(resp *http.Response) zapcore.ObjectMarshaler {
+	return httpResponse{resp}
+}
+
+func (m httpResponse) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	resp := m.resp
+	if resp == nil {
+		return nil
+	}
+	enc.AddInt("status", resp.StatusCode)
+	enc.AddString("proto", resp.Proto)
+	if resp.ContentLength >= 0 {
+		enc.AddInt64("content_length", resp.ContentLength)
+	}
+	if resp.Request != nil {
+		enc.AddString("method", resp.Request.Method)
+		if resp.Request.URL != nil {
+			enc.AddString("url", redactURL(resp.Request.URL))
+		}
+	}
+	return enc.AddObject("headers", headers(resp.Header))
+}
+
+type dbStats sql.DBStats
+
+// DBStats 连接池统计
+func DBStats(s sql.DBStats) zapcore.ObjectMarshaler {
+	return dbStats(s)
+}
+
+func (s dbStats) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("max_open", s.MaxOpenConnections)
+	enc.AddInt("open", s.OpenConnections)
+	enc.AddInt("in_use", s.InUse)
+	enc.AddInt("idle", s.Idle)
+	enc.AddInt64("wait_count", s.WaitCount)
+	enc.AddDuration("wait_duration", s.WaitDuration)
+	enc.AddInt64("max_idle_closed", s.MaxIdleClosed)
+	enc.AddInt64("max_idle_time_closed", s.MaxIdleTimeClosed)
+	enc.AddInt64("max_lifetime_closed", s.MaxLifetimeClosed)
+	return nil
+}
+
+type memStats struct {
+	m *runtime.MemStats
+}
+
+// MemStats 内存统计的常用字段，m为nil时即时读取
+func MemStats(m *runtime.MemStats) zapcore.ObjectMarshaler {
+	if m == nil {
+		m = &runtime.MemStats{}
+		runtime.ReadMemStats(m)
+	}
+	return memStats{m}
+}
+
+func (s memStats) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	m := s.m
+	enc.AddUint64("alloc", m.Alloc)
+	enc.AddUint64("total_alloc", m.TotalAlloc)
+	enc.AddUint64("sys", m.Sys)
+	enc.AddUint64("heap_alloc", m.HeapAlloc)
+	enc.AddUint64("heap_inuse", m.HeapInuse)
+	enc.AddUint64("heap_objects", m.HeapObjects)
+	enc.AddUint64("stack_inuse", m.StackInuse)
+	enc.AddUint32("num_gc", m.NumGC)
+	enc.AddUint64("pause_total_ns", m.PauseTotalNs)
+	enc.AddFloat64("gc_cpu_fraction", m.GCCPUFraction)
+	enc.AddInt("goroutines", runtime.NumGoroutine())
+	return nil
+}
+
+type errorChain struct {
+	err error
+}
+
+// ErrorChain 逐层展开errors.Unwrap链，输出每一层的类型与信息；支持errors.Join的多个错误
+func ErrorChain(err error) zapcore.ArrayMarshaler {
+	return errorChain{err}
+}
+
+func (c errorChain) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return appendErrorChain(enc, c.err, 0)
+}
+
+const maxErrorChainDepth = 32 //防止循环引用
+
+func appendErrorChain(enc zapcore.ArrayEncoder, err error, depth int) error {
+	for err != nil && depth < maxErrorChainDepth {
+		e := err
+		if err := enc.AppendObject(zapcore.ObjectMarshalerFunc(func(oe zapcore.ObjectEncoder) error {
+			oe.AddString("type", fmt.Sprintf("%T", e))
+			oe.AddString("message", e.Error())
+			return nil
+		})); err != nil {
+			return err
+		}
+		depth++
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, sub := range joined.Unwrap() {
+				if err := appendErrorChain(enc, sub, depth); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		err = errors.Unwrap(err)
+	}
+	return nil
+}
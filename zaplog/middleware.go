@@ -0,0 +1,255 @@
+package zaplog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/liuxy92/golib/buffer/bytespool"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	defaultMaxBodyBytes = 4096
+	maskedValue         = "***"
+)
+
+// HTTPLogOptions HTTP访问日志中间件配置
+type HTTPLogOptions struct {
+	CaptureRequestBody  bool                     //记录请求body
+	CaptureResponseBody bool                     //记录响应body
+	MaxBodyBytes        int                      //body最多记录的字节数，默认4096，超出部分截断
+	ContentTypes        []string                 //允许记录body的Content-Type，默认json、form、text、xml
+	MaskFields          []string                 //需要掩码的JSON/表单字段，大小写不敏感，默认password、token等
+	Capture             func(*http.Request) bool //按路由决定是否记录body，nil表示全部记录
+	Skip                func(*http.Request) bool //不记录访问日志的请求，如健康检查
+}
+
+var (
+	defaultCaptureTypes = []string{"application/json", "application/x-www-form-urlencoded", "text/plain", "application/xml", "text/xml"}
+	defaultMaskFields   = []string{"password", "passwd", "token", "access_token", "refresh_token", "secret", "id_card"}
+)
+
+func (o *HTTPLogOptions) withDefaults() *HTTPLogOptions {
+	opts := HTTPLogOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	if opts.ContentTypes == nil {
+		opts.ContentTypes = defaultCaptureTypes
+	}
+	if opts.MaskFields == nil {
+		opts.MaskFields = defaultMaskFields
+	}
+	return &opts
+}
+
+// HTTPMiddleware 记录访问日志：5xx为error、4xx为warn，其余为info，可按配置记录请求与响应body
+func (lg *Logger) HTTPMiddleware(o *HTTPLogOptions) func(http.Handler) http.Handler {
+	opts := o.withDefaults()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.Skip != nil && opts.Skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			clock := lg.clock()
+			start := clock.Now()
+			capture := opts.Capture == nil || opts.Capture(r)
+
+			var reqBody *bodyCapture
+			if capture && opts.CaptureRequestBody && r.Body != nil && r.Body != http.NoBody && opts.allowed(r.Header.Get("Content-Type")) {
+				//handler读取body时同步记录，不提前读取，避免阻塞流式请求
+				reqBody = newBodyCapture(opts.MaxBodyBytes)
+				defer reqBody.release()
+				r.Body = readCloser{io.TeeReader(r.Body, reqBody), r.Body}
+			}
+			rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			if capture && opts.CaptureResponseBody {
+				rw.body = newBodyCapture(opts.MaxBodyBytes)
+				defer rw.body.release()
+			}
+			next.ServeHTTP(rw, r)
+
+			kv := []interface{}{
+				"http", HTTPRequest(r),
+				"status", rw.status,
+				"bytes", rw.size,
+				"duration", clock.Since(start),
+			}
+			if reqBody != nil {
+				kv = append(kv, "req_body", opts.mask(reqBody, r.Header.Get("Content-Type")))
+			}
+			if rw.body != nil && opts.allowed(rw.Header().Get("Content-Type")) {
+				kv = append(kv, "resp_body", opts.mask(rw.body, rw.Header().Get("Content-Type")))
+			}
+			switch {
+			case rw.status >= http.StatusInternalServerError:
+				lg.Errorw("http request", kv...)
+			case rw.status >= http.StatusBadRequest:
+				lg.Warnw("http request", kv...)
+			default:
+				lg.Infow("http request", kv...)
+			}
+		})
+	}
+}
+
+func (o *HTTPLogOptions) allowed(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range o.ContentTypes {
+		if strings.EqualFold(t, mt) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *HTTPLogOptions) isMasked(key string) bool {
+	for _, f := range o.MaskFields {
+		if strings.EqualFold(f, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// mask 对JSON和表单中的敏感字段掩码。截断的JSON、表单无法可靠掩码，配置了MaskFields时只输出占位及长度
+func (o *HTTPLogOptions) mask(c *bodyCapture, contentType string) string {
+	body := c.buf.Bytes()
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch mt {
+	case "application/json", "application/x-www-form-urlencoded":
+		if c.truncated {
+			if len(o.MaskFields) > 0 {
+				return fmt.Sprintf("<truncated, %d bytes>", c.size)
+			}
+			break
+		}
+		if mt == "application/json" {
+			var v interface{}
+			if json.Unmarshal(body, &v) == nil {
+				if b, err := json.Marshal(o.maskJSON(v)); err == nil {
+					return string(b)
+				}
+			}
+		} else if values, err := url.ParseQuery(string(body)); err == nil {
+			for k := range values {
+				if o.isMasked(k) {
+					values[k] = []string{maskedValue}
+				}
+			}
+			return values.Encode()
+		}
+	}
+	if c.truncated {
+		return string(body) + "...(truncated)"
+	}
+	return string(body)
+}
+
+func (o *HTTPLogOptions) maskJSON(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, val := range x {
+			if o.isMasked(k) {
+				x[k] = maskedValue
+			} else {
+				x[k] = o.maskJSON(val)
+			}
+		}
+	case []interface{}:
+		for i := range x {
+			x[i] = o.maskJSON(x[i])
+		}
+	}
+	return v
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyCapture 记录写入内容的前limit字节及总字节数
+type bodyCapture struct {
+	buf       *bytespool.Buffer
+	limit     int
+	size      int
+	truncated bool
+}
+
+func newBodyCapture(limit int) *bodyCapture {
+	return &bodyCapture{buf: bytespool.Get(limit), limit: limit}
+}
+
+func (c *bodyCapture) Write(p []byte) (int, error) {
+	c.size += len(p)
+	if remain := c.limit - c.buf.Len(); len(p) > remain {
+		c.buf.Write(p[:remain])
+		c.truncated = true
+	} else {
+		c.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func (c *bodyCapture) release() {
+	bytespool.Put(c.buf)
+}
+
+// responseRecorder 记录状态码、字节数，body非nil时记录响应body
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	body        *bodyCapture
+	wroteHeader bool
+}
+
+func (w *responseRecorder) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	if w.body != nil {
+		w.body.Write(p)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += n
+	return n, err
+}
+
+func (w *responseRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("zaplog: response writer does not support hijacking")
+}
+
+// Unwrap 供http.ResponseController使用
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
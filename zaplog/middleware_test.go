@@ -0,0 +1,129 @@
+package zaplog
+
+import (
+	"bytes"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func observedLogger() (*Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := zap.New(core)
+	return &Logger{SugaredLogger: l.Sugar(), base: l, Opts: &Options{}}, logs
+}
+
+func TestHTTPMiddlewareCapture(t *testing.T) {
+	lg, logs := observedLogger()
+	h := lg.HTTPMiddleware(&HTTPLogOptions{
+		CaptureRequestBody:  true,
+		CaptureResponseBody: true,
+		MaxBodyBytes:        64,
+		Capture:             func(r *http.Request) bool { return strings.HasPrefix(r.URL.Path, "/api") },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"echo":` + string(body) + `,"token":"t"}`))
+	}))
+
+	req := httptest.NewRequest("POST", "/api/login", strings.NewReader(`{"user":"a","password":"p"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if !strings.Contains(rec.Body.String(), `"password":"p"`) {
+		t.Fatalf("handler did not receive full body: %s", rec.Body.String())
+	}
+	entries := logs.All()
+	if len(entries) != 1 || entries[0].Level != zapcore.WarnLevel {
+		t.Fatalf("entries = %v", entries)
+	}
+	ctx := entries[0].ContextMap()
+	if !strings.Contains(ctx["req_body"].(string), `"password":"***"`) {
+		t.Errorf("req_body = %v", ctx["req_body"])
+	}
+	if !strings.Contains(ctx["resp_body"].(string), `"token":"***"`) {
+		t.Errorf("resp_body = %v", ctx["resp_body"])
+	}
+}
+
+func TestHTTPMiddlewareTruncateAndSkip(t *testing.T) {
+	lg, logs := observedLogger()
+	h := lg.HTTPMiddleware(&HTTPLogOptions{
+		CaptureRequestBody: true,
+		MaxBodyBytes:       4,
+		Skip:               func(r *http.Request) bool { return r.URL.Path == "/health" },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	}))
+
+	req := httptest.NewRequest("POST", "/upload", bytes.NewBufferString("0123456789"))
+	req.Header.Set("Content-Type", "text/plain")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("entries = %d", len(entries))
+	}
+	if got := entries[0].ContextMap()["req_body"]; got != "0123...(truncated)" {
+		t.Errorf("req_body = %v", got)
+	}
+}
+
+func TestHTTPMiddlewareTruncatedMasked(t *testing.T) {
+	lg, logs := observedLogger()
+	h := lg.HTTPMiddleware(&HTTPLogOptions{
+		CaptureRequestBody: true,
+		MaxBodyBytes:       40,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	}))
+
+	body := `{"password":"hunter2-supersecret","user":"alice","note":"0123456789"}`
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := logs.All()[0].ContextMap()["req_body"].(string)
+	if strings.Contains(got, "hunter2") || got != fmt.Sprintf("<truncated, %d bytes>", len(body)) {
+		t.Errorf("req_body = %s", got)
+	}
+}
+
+func TestHTTPMiddlewareStreamingBody(t *testing.T) {
+	lg, logs := observedLogger()
+	h := lg.HTTPMiddleware(&HTTPLogOptions{CaptureRequestBody: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := make([]byte, 5)
+		_, _ = io.ReadFull(r.Body, buf)
+		_, _ = w.Write(buf)
+	}))
+
+	//body未结束时handler即可读取并返回，中间件不能提前读满MaxBodyBytes
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go func() { _, _ = pw.Write([]byte("hello")) }()
+	req := httptest.NewRequest("POST", "/stream", pr)
+	req.Header.Set("Content-Type", "text/plain")
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("middleware blocked on streaming body")
+	}
+	if got := logs.All()[0].ContextMap()["req_body"]; got != "hello" {
+		t.Errorf("req_body = %v", got)
+	}
+}
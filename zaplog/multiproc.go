@@ -0,0 +1,215 @@
+package zaplog
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Options.MultiProcess 可选值
+const (
+	MultiProcessPID   = "pid"   //文件名追加进程号，如 app-info.1234.log，各进程独立写入与切割
+	MultiProcessFlock = "flock" //多个进程共用日志文件，O_APPEND写入，按大小切割时通过文件锁协调
+
+	reopenCheckInterval = time.Second //flock模式下检查文件是否已被其他进程切割的间隔
+)
+
+// multiProcess 解析多进程模式，Windows不支持flock时改用pid模式
+func (o *Options) multiProcess() string {
+	switch o.MultiProcess {
+	case MultiProcessPID:
+		return MultiProcessPID
+	case MultiProcessFlock:
+		if o.WindowsMode {
+			return MultiProcessPID
+		}
+		return MultiProcessFlock
+	}
+	return ""
+}
+
+// pidFileName 在扩展名前插入进程号，如 app-info.log -> app-info.1234.log
+func pidFileName(filename string, pid int) string {
+	name, ext := splitExt(filename)
+	return name + "." + strconv.Itoa(pid) + ext
+}
+
+// sharedFileWriter 多进程共用的按大小切割的日志文件。
+// 写入使用O_APPEND保证各进程的整行不会互相覆盖；切割时持有文件锁，重命名当前文件后重新打开，
+// 其他进程定期发现文件已被替换后重新打开，不会再写入已切割的旧文件
+type sharedFileWriter struct {
+	filename   string
+	lockname   string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	algo       string //切割后的压缩算法，空表示不压缩
+	file       *os.File
+	checkedAt  time.Time
+	now        func() time.Time
+}
+
+func newSharedFileWriter(filename string, maxSizeMB, maxBackups, maxAgeDays int, algo string) *sharedFileWriter {
+	dir, base := filepath.Split(filename)
+	return &sharedFileWriter{
+		filename:   filename,
+		lockname:   filepath.Join(dir, "."+base+".lock"), //以.开头，不会被当作备份文件清理或压缩
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+		algo:       algo,
+		now:        time.Now,
+	}
+}
+
+func (w *sharedFileWriter) Write(p []byte) (int, error) {
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	} else if w.now().Sub(w.checkedAt) >= reopenCheckInterval {
+		if err := w.reopenIfMoved(); err != nil {
+			return 0, err
+		}
+	}
+	if w.maxSize > 0 {
+		info, err := w.file.Stat()
+		if err != nil {
+			return 0, err
+		}
+		if info.Size() > 0 && info.Size()+int64(len(p)) > w.maxSize {
+			if err := w.rotate(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return w.file.Write(p)
+}
+
+// Rotate 立即切割，其他进程在下次检查时切换到新文件
+func (w *sharedFileWriter) Rotate() error {
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	return w.rotate()
+}
+
+func (w *sharedFileWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *sharedFileWriter) open() error {
+	f, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if w.file != nil {
+		_ = w.file.Close()
+	}
+	w.file = f
+	w.checkedAt = w.now()
+	return nil
+}
+
+// moved 当前打开的文件是否已被其他进程切割(重命名)
+func (w *sharedFileWriter) moved() (bool, error) {
+	cur, err := os.Stat(w.filename)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	opened, err := w.file.Stat()
+	if err != nil {
+		return false, err
+	}
+	return !os.SameFile(cur, opened), nil
+}
+
+func (w *sharedFileWriter) reopenIfMoved() error {
+	w.checkedAt = w.now()
+	moved, err := w.moved()
+	if err != nil || !moved {
+		return err
+	}
+	return w.open()
+}
+
+// rotate 持有文件锁重命名当前文件并重新打开，再压缩与清理旧的备份；
+// 获得锁时文件已被其他进程切割则只重新打开
+func (w *sharedFileWriter) rotate() error {
+	unlock, err := lockFile(w.lockname)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	moved, err := w.moved()
+	if err != nil {
+		return err
+	}
+	if moved {
+		return w.open()
+	}
+	info, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+	backup := w.backupName()
+	if err := os.Rename(w.filename, backup); err != nil {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	err = w.compress()
+	cleanupBackups(w.filename, w.maxBackups, w.maxAge, w.now())
+	return err
+}
+
+// backupName 与lumberjack相同的备份文件名，多个进程在同一毫秒内切割时顺延时间避免覆盖
+func (w *sharedFileWriter) backupName() string {
+	name, ext := splitExt(w.filename)
+	t := w.now()
+	for {
+		backup := name + "-" + t.Format(sizeBackupLayout) + ext
+		if _, err := os.Lstat(backup); os.IsNotExist(err) {
+			if _, err := os.Lstat(backup + compressExt[w.algo]); w.algo == "" || os.IsNotExist(err) {
+				return backup
+			}
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// compress 压缩已切割的备份文件。其他进程最多在reopenCheckInterval后才切换到新文件，
+// 期间仍会写入刚切割的文件，因此只压缩最后修改时间超过两个检查周期的备份，其余留到下次切割时处理
+func (w *sharedFileWriter) compress() error {
+	if w.algo == "" {
+		return nil
+	}
+	m := &millConfig{algo: w.algo, filename: w.filename}
+	cutoff := w.now().Add(-2 * reopenCheckInterval)
+	var firstErr error
+	for _, f := range m.backups() {
+		info, err := os.Stat(f)
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := compressFile(w.algo, f); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
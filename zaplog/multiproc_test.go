@@ -0,0 +1,147 @@
+package zaplog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPIDFileName(t *testing.T) {
+	if got := pidFileName(filepath.Join("logs", "app-info.log"), 42); got != filepath.Join("logs", "app-info.42.log") {
+		t.Errorf("pidFileName = %q", got)
+	}
+}
+
+func TestMultiProcessPID(t *testing.T) {
+	lg := newTestLogger(t, &Options{MultiProcess: MultiProcessPID})
+	lg.Info("hello")
+	name := fmt.Sprintf("app-info.%d.log", os.Getpid())
+	if _, err := os.Stat(filepath.Join(lg.Opts.LogFileDir, name)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const (
+	sharedFileEnv   = "ZAPLOG_TEST_SHARED_FILE"
+	sharedWriterEnv = "ZAPLOG_TEST_SHARED_WRITER"
+	sharedLines     = 2000
+)
+
+// TestSharedFileHelper 子进程入口，由TestSharedFileMultiProcess启动
+func TestSharedFileHelper(t *testing.T) {
+	filename := os.Getenv(sharedFileEnv)
+	if filename == "" {
+		t.Skip("helper process")
+	}
+	w := newSharedFileWriter(filename, 0, 0, 0, CompressGzip)
+	w.maxSize = 16 * 1024
+	defer w.Close()
+	id := os.Getenv(sharedWriterEnv)
+	for i := 0; i < sharedLines; i++ {
+		if _, err := fmt.Fprintf(w, "writer=%s line=%d %s\n", id, i, strings.Repeat("x", 40)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSharedFileMultiProcess(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app-info.log")
+	const writers = 4
+	cmds := make([]*exec.Cmd, writers)
+	for i := range cmds {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestSharedFileHelper$")
+		cmd.Env = append(os.Environ(), sharedFileEnv+"="+filename, sharedWriterEnv+"="+strconv.Itoa(i))
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cmds[i] = cmd
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "app-info*"))
+	if len(files) < 2 {
+		t.Fatalf("expected rotated backups, got %v", files)
+	}
+	seen := make(map[string]bool)
+	for _, f := range files {
+		file, err := os.Open(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r io.Reader = file
+		if strings.HasSuffix(f, ".gz") {
+			if r, err = gzip.NewReader(file); err != nil {
+				t.Fatal(err)
+			}
+		}
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			line := sc.Text()
+			if !strings.HasPrefix(line, "writer=") || !strings.HasSuffix(line, strings.Repeat("x", 40)) {
+				t.Fatalf("corrupted line in %s: %q", f, line)
+			}
+			if seen[line] {
+				t.Fatalf("duplicated line %q", line)
+			}
+			seen[line] = true
+		}
+		file.Close()
+	}
+	if len(seen) != writers*sharedLines {
+		t.Errorf("got %d lines, want %d", len(seen), writers*sharedLines)
+	}
+}
+
+func TestSharedFileCompress(t *testing.T) {
+	dir := t.TempDir()
+	w := newSharedFileWriter(filepath.Join(dir, "app-info.log"), 0, 0, 0, CompressGzip)
+	defer w.Close()
+	rotate := func() {
+		if _, err := w.Write([]byte("line\n")); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Millisecond) //保证备份文件名不重复
+	}
+	rotate()
+	rotate()
+	if gz := mustGlob(t, filepath.Join(dir, "app-info-*.log.gz")); len(gz) != 0 {
+		t.Fatalf("recent backups should not be compressed yet: %v", gz)
+	}
+	old := time.Now().Add(-time.Minute)
+	for _, f := range mustGlob(t, filepath.Join(dir, "app-info-*.log")) {
+		_ = os.Chtimes(f, old, old)
+	}
+	rotate()
+	if gz := mustGlob(t, filepath.Join(dir, "app-info-*.log.gz")); len(gz) != 2 {
+		t.Errorf("expected 2 compressed backups, got %v", gz)
+	}
+	if plain := mustGlob(t, filepath.Join(dir, "app-info-*.log")); len(plain) != 1 {
+		t.Errorf("expected latest backup left uncompressed, got %v", plain)
+	}
+}
+
+func mustGlob(t *testing.T, pattern string) []string {
+	t.Helper()
+	m, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
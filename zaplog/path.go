@@ -0,0 +1,21 @@
+package zaplog
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// isWindows 当前平台是否为Windows
+var isWindows = runtime.GOOS == "windows"
+
+// logFilePath 拼接日志文件路径，按平台统一分隔符并清理多余的分隔符
+func logFilePath(dir, appName, fileName string) string {
+	return filepath.Join(filepath.FromSlash(dir), appName+"-"+fileName)
+}
+
+// splitExt 拆分文件名与扩展名，如 "app-info.log" -> "app-info", ".log"
+func splitExt(path string) (string, string) {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext), ext
+}
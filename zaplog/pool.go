@@ -0,0 +1,204 @@
+package zaplog
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"sync"
+)
+
+const maxPooledFields = 64 //超过该容量的切片不放回池中，避免偶发的大切片长期占用内存
+
+var fieldPool = sync.Pool{
+	New: func() interface{} {
+		fs := make([]zap.Field, 0, 16)
+		return &fs
+	},
+}
+
+func getFields() *[]zap.Field {
+	return fieldPool.Get().(*[]zap.Field)
+}
+
+func putFields(fs *[]zap.Field) {
+	if cap(*fs) > maxPooledFields {
+		return
+	}
+	for i := range *fs {
+		(*fs)[i] = zap.Field{}
+	}
+	*fs = (*fs)[:0]
+	fieldPool.Put(fs)
+}
+
+// appendKeysAndValues 与SugaredLogger的规则一致：zap.Field直接使用，其余按key、value成对转换
+func appendKeysAndValues(fs []zap.Field, kv []interface{}) []zap.Field {
+	for i := 0; i < len(kv); {
+		if f, ok := kv[i].(zap.Field); ok {
+			fs = append(fs, f)
+			i++
+			continue
+		}
+		if i == len(kv)-1 {
+			fs = append(fs, zap.Any("ignored", kv[i]))
+			break
+		}
+		if key, ok := kv[i].(string); ok {
+			fs = append(fs, zap.Any(key, kv[i+1]))
+		} else {
+			fs = append(fs, zap.Any("invalid", kv[i:i+2]))
+		}
+		i += 2
+	}
+	return fs
+}
+
+// logw 先做级别判断再组装字段，字段切片取自对象池，写入后立即归还；
+// core在Write中同步完成编码，不得持有fields
+func (lg *Logger) logw(lvl zapcore.Level, msg string, kv []interface{}) {
+	if lg.base == nil {
+		return
+	}
+	ce := lg.base.Check(lvl, msg)
+	if ce == nil {
+		return
+	}
+	fs := getFields()
+	*fs = appendKeysAndValues(*fs, kv)
+	ce.Write(*fs...)
+	putFields(fs)
+}
+
+// Debugw 带键值对的Debug日志，如 Debugw("msg", "key", value)
+func (lg *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	lg.logw(zapcore.DebugLevel, msg, keysAndValues)
+}
+
+// Infow 带键值对的Info日志
+func (lg *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	lg.logw(zapcore.InfoLevel, msg, keysAndValues)
+}
+
+// Warnw 带键值对的Warn日志
+func (lg *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	lg.logw(zapcore.WarnLevel, msg, keysAndValues)
+}
+
+// Errorw 带键值对的Error日志
+func (lg *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	lg.logw(zapcore.ErrorLevel, msg, keysAndValues)
+}
+
+// formatMessage 与SugaredLogger规则一致：有模板时Sprintf，否则Sprint。
+// 单个string、error或fmt.Stringer参数直接取其字符串，不经过fmt复制一次
+func formatMessage(template string, args []interface{}) string {
+	if len(args) == 0 {
+		return template
+	}
+	if template != "" {
+		return fmt.Sprintf(template, args...)
+	}
+	if len(args) == 1 {
+		if s, ok := stringOf(args[0]); ok {
+			return s
+		}
+	}
+	return fmt.Sprint(args...)
+}
+
+// stringOf Error、String方法panic(如nil指针接收者)时返回false，交由fmt按其规则输出
+func stringOf(v interface{}) (s string, ok bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case error:
+		defer func() {
+			if recover() != nil {
+				ok = false
+			}
+		}()
+		return v.Error(), true
+	case fmt.Stringer:
+		defer func() {
+			if recover() != nil {
+				ok = false
+			}
+		}()
+		return v.String(), true
+	}
+	return "", false
+}
+
+var callerPool = buffer.NewPool()
+
+// shortCallerEncoder 输出同zapcore.ShortCallerEncoder，在池化的缓冲区中拼接路径与行号，不为每条日志分配字符串
+func shortCallerEncoder(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+	if !caller.Defined {
+		enc.AppendString("undefined")
+		return
+	}
+	file := caller.File
+	if i := strings.LastIndexByte(file, '/'); i >= 0 {
+		if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+			file = file[j+1:]
+		}
+	}
+	b := callerPool.Get()
+	b.AppendString(file)
+	b.AppendByte(':')
+	b.AppendInt(int64(caller.Line))
+	enc.AppendByteString(b.Bytes())
+	b.Free()
+}
+
+// logf 级别未开启时不格式化消息，写入时不经过SugaredLogger的字段转换
+func (lg *Logger) logf(lvl zapcore.Level, template string, args []interface{}) {
+	if lg.base == nil || !lg.base.Core().Enabled(lvl) {
+		return
+	}
+	if ce := lg.base.Check(lvl, formatMessage(template, args)); ce != nil {
+		ce.Write()
+	}
+}
+
+// Debug 同fmt.Sprint拼接消息
+func (lg *Logger) Debug(args ...interface{}) {
+	lg.logf(zapcore.DebugLevel, "", args)
+}
+
+// Info 同fmt.Sprint拼接消息
+func (lg *Logger) Info(args ...interface{}) {
+	lg.logf(zapcore.InfoLevel, "", args)
+}
+
+// Warn 同fmt.Sprint拼接消息
+func (lg *Logger) Warn(args ...interface{}) {
+	lg.logf(zapcore.WarnLevel, "", args)
+}
+
+// Error 同fmt.Sprint拼接消息
+func (lg *Logger) Error(args ...interface{}) {
+	lg.logf(zapcore.ErrorLevel, "", args)
+}
+
+// Debugf 同fmt.Sprintf格式化消息
+func (lg *Logger) Debugf(template string, args ...interface{}) {
+	lg.logf(zapcore.DebugLevel, template, args)
+}
+
+// Infof 同fmt.Sprintf格式化消息
+func (lg *Logger) Infof(template string, args ...interface{}) {
+	lg.logf(zapcore.InfoLevel, template, args)
+}
+
+// Warnf 同fmt.Sprintf格式化消息
+func (lg *Logger) Warnf(template string, args ...interface{}) {
+	lg.logf(zapcore.WarnLevel, template, args)
+}
+
+// Errorf 同fmt.Sprintf格式化消息
+func (lg *Logger) Errorf(template string, args ...interface{}) {
+	lg.logf(zapcore.ErrorLevel, template, args)
+}
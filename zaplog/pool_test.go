@@ -0,0 +1,187 @@
+package zaplog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"strings"
+	"testing"
+)
+
+// discardLogger 使用与InitLogger相同的编码配置，输出丢弃
+func discardLogger() *Logger {
+	cfgLogger := &Logger{Opts: &Options{}}
+	cfgLogger.loadCfg()
+	cfg := cfgLogger.zapConfig.EncoderConfig
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(cfg), zapcore.AddSync(io.Discard), zapcore.InfoLevel)
+	l := zap.New(core, zap.AddCaller())
+	return &Logger{
+		SugaredLogger: l.Sugar(),
+		base:          l.WithOptions(zap.AddCallerSkip(2)),
+		Opts:          &Options{},
+	}
+}
+
+func TestAppendKeysAndValues(t *testing.T) {
+	fs := appendKeysAndValues(nil, []interface{}{"a", 1, zap.String("b", "x"), 3, 4, "dangling"})
+	if len(fs) != 4 {
+		t.Fatalf("got %d fields: %v", len(fs), fs)
+	}
+	if fs[0].Key != "a" || fs[1].Key != "b" || fs[2].Key != "invalid" || fs[3].Key != "ignored" {
+		t.Errorf("unexpected keys: %v", fs)
+	}
+}
+
+func BenchmarkInfowSugared(b *testing.B) {
+	lg := discardLogger()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lg.SugaredLogger.Infow("order created", "order_id", "202405010001", "user_id", 10086, "amount", 99.5)
+	}
+}
+
+func BenchmarkInfowPooled(b *testing.B) {
+	lg := discardLogger()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lg.Infow("order created", "order_id", "202405010001", "user_id", 10086, "amount", 99.5)
+	}
+}
+
+func BenchmarkDebugwDisabled(b *testing.B) {
+	lg := discardLogger()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lg.Debugw("skipped", "order_id", "202405010001", "user_id", 10086)
+	}
+}
+
+func TestInfowCaller(t *testing.T) {
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zapcore.InfoLevel)
+	l := zap.New(core, zap.AddCaller())
+	lg := &Logger{SugaredLogger: l.Sugar(), base: l.WithOptions(zap.AddCallerSkip(2))}
+	lg.Infow("caller", "k", "v")
+	if !strings.Contains(buf.String(), "pool_test.go") {
+		t.Errorf("caller not pointing at test: %s", buf.String())
+	}
+}
+
+var benchErr = errors.New("connection refused")
+
+func BenchmarkInfo(b *testing.B) {
+	lg := discardLogger()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lg.Info("order created")
+	}
+}
+
+func BenchmarkInfoArgs(b *testing.B) {
+	lg := discardLogger()
+	id := "202405010001"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lg.Info("order ", id, " created")
+	}
+}
+
+func BenchmarkInfof(b *testing.B) {
+	lg := discardLogger()
+	id, uid := "202405010001", 10086
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lg.Infof("order %s created by %d", id, uid)
+	}
+}
+
+func BenchmarkErrorValue(b *testing.B) {
+	lg := discardLogger()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lg.Error(benchErr)
+	}
+}
+
+func BenchmarkDebugfDisabled(b *testing.B) {
+	lg := discardLogger()
+	id := "202405010001"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		lg.Debugf("order %s skipped", id)
+	}
+}
+
+type nilErr struct{ msg string }
+
+func (e *nilErr) Error() string { return e.msg }
+
+func TestFormatMessage(t *testing.T) {
+	var typedNil *nilErr
+	cases := []struct {
+		template string
+		args     []interface{}
+		want     string
+	}{
+		{"plain", nil, "plain"},
+		{"", []interface{}{"only"}, "only"},
+		{"", []interface{}{benchErr}, "connection refused"},
+		{"", []interface{}{typedNil}, "<nil>"},
+		{"", []interface{}{"a", 1, 2, "b"}, "a1 2b"},
+		{"id=%d", []interface{}{7}, "id=7"},
+	}
+	for _, c := range cases {
+		if got := formatMessage(c.template, c.args); got != c.want {
+			t.Errorf("formatMessage(%q, %v) = %q, want %q", c.template, c.args, got, c.want)
+		}
+	}
+}
+
+func TestShortCallerEncoder(t *testing.T) {
+	for _, caller := range []zapcore.EntryCaller{
+		zapcore.NewEntryCaller(0, "/home/app/src/order/service.go", 42, true),
+		zapcore.NewEntryCaller(0, "main.go", 7, true),
+		{},
+	} {
+		want := zapcore.NewMapObjectEncoder()
+		_ = want.AddArray("c", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+			zapcore.ShortCallerEncoder(caller, enc)
+			return nil
+		}))
+		got := zapcore.NewMapObjectEncoder()
+		_ = got.AddArray("c", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+			shortCallerEncoder(caller, enc)
+			return nil
+		}))
+		if fmt.Sprint(got.Fields["c"]) != fmt.Sprint(want.Fields["c"]) {
+			t.Errorf("%+v: got %v, want %v", caller, got.Fields["c"], want.Fields["c"])
+		}
+	}
+}
+
+func TestHelperCaller(t *testing.T) {
+	var buf bytes.Buffer
+	cfgLogger := &Logger{Opts: &Options{}}
+	cfgLogger.loadCfg()
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(cfgLogger.zapConfig.EncoderConfig), zapcore.AddSync(&buf), zapcore.InfoLevel)
+	l := zap.New(core, zap.AddCaller())
+	lg := &Logger{SugaredLogger: l.Sugar(), base: l.WithOptions(zap.AddCallerSkip(2))}
+	lg.Infof("order %d", 1)
+	lg.Error(benchErr)
+	lg.Debug("dropped")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, `"caller":"zaplog/pool_test.go:`) {
+			t.Errorf("caller not pointing at test: %s", line)
+		}
+	}
+	if !strings.Contains(lines[0], `"msg":"order 1"`) || !strings.Contains(lines[1], `"msg":"connection refused"`) {
+		t.Errorf("messages: %s", buf.String())
+	}
+}
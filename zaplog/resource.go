@@ -0,0 +1,107 @@
+package zaplog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Resource OpenTelemetry资源属性，按语义约定命名，设置Options.Resource后以resource对象输出到每条文件日志
+type Resource struct {
+	ServiceName           string            `json:"service.name"`           //service.name
+	ServiceVersion        string            `json:"service.version"`        //service.version
+	ServiceNamespace      string            `json:"service.namespace"`      //service.namespace
+	ServiceInstanceID     string            `json:"service.instance.id"`    //service.instance.id
+	DeploymentEnvironment string            `json:"deployment.environment"` //deployment.environment
+	HostName              string            `json:"host.name"`              //host.name
+	Attributes            map[string]string `json:"attributes"`             //其他自定义属性，key按语义约定命名
+}
+
+// ResourceFromEnv 从OTEL_SERVICE_NAME、OTEL_RESOURCE_ATTRIBUTES环境变量读取资源属性，
+// 未指定host.name时使用本机主机名
+func ResourceFromEnv() *Resource {
+	r := &Resource{}
+	for _, kv := range strings.Split(os.Getenv("OTEL_RESOURCE_ATTRIBUTES"), ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		k = strings.TrimSpace(k)
+		if uv, err := url.QueryUnescape(strings.TrimSpace(v)); err == nil {
+			v = uv
+		}
+		r.set(k, v)
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		r.ServiceName = name
+	}
+	if r.HostName == "" {
+		r.HostName, _ = os.Hostname()
+	}
+	return r
+}
+
+func (r *Resource) set(k, v string) {
+	switch k {
+	case "service.name":
+		r.ServiceName = v
+	case "service.version":
+		r.ServiceVersion = v
+	case "service.namespace":
+		r.ServiceNamespace = v
+	case "service.instance.id":
+		r.ServiceInstanceID = v
+	case "deployment.environment", "deployment.environment.name":
+		r.DeploymentEnvironment = v
+	case "host.name":
+		r.HostName = v
+	default:
+		if r.Attributes == nil {
+			r.Attributes = make(map[string]string)
+		}
+		r.Attributes[k] = v
+	}
+}
+
+// Map 返回全部非空属性，供导出到OTel等外部系统时使用
+func (r *Resource) Map() map[string]string {
+	m := make(map[string]string, len(r.Attributes)+6)
+	for k, v := range r.Attributes {
+		m[k] = v
+	}
+	for k, v := range map[string]string{
+		"service.name":           r.ServiceName,
+		"service.version":        r.ServiceVersion,
+		"service.namespace":      r.ServiceNamespace,
+		"service.instance.id":    r.ServiceInstanceID,
+		"deployment.environment": r.DeploymentEnvironment,
+		"host.name":              r.HostName,
+	} {
+		if v != "" {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler，key按字母序输出
+func (r *Resource) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	m := r.Map()
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		enc.AddString(k, m[k])
+	}
+	return nil
+}
+
+// Field 返回resource字段
+func (r *Resource) Field() zap.Field {
+	return zap.Object("resource", r)
+}
@@ -0,0 +1,39 @@
+package zaplog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResourceFromEnv(t *testing.T) {
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "service.name=ignored,service.version=1.2.3,deployment.environment=prod,team=pay%20ops")
+	t.Setenv("OTEL_SERVICE_NAME", "order")
+	r := ResourceFromEnv()
+	if r.ServiceName != "order" || r.ServiceVersion != "1.2.3" || r.DeploymentEnvironment != "prod" {
+		t.Errorf("resource = %+v", r)
+	}
+	if r.Attributes["team"] != "pay ops" {
+		t.Errorf("attributes = %v", r.Attributes)
+	}
+}
+
+func TestResourceField(t *testing.T) {
+	res := &Resource{ServiceName: "order", ServiceVersion: "1.0.0", DeploymentEnvironment: "test"}
+	lg := newTestLogger(t, &Options{AppName: "res", Resource: res})
+	lg.Info("with resource")
+	lg.Sync()
+	data, _ := os.ReadFile(filepath.Join(lg.Opts.LogFileDir, "res-info.log"))
+	var entry struct {
+		Resource map[string]string `json:"resource"`
+	}
+	line := strings.Split(strings.TrimSpace(string(data)), "\n")[0]
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Resource["service.name"] != "order" || entry.Resource["deployment.environment"] != "test" {
+		t.Errorf("resource = %v", entry.Resource)
+	}
+}
@@ -0,0 +1,197 @@
+package zaplog
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+)
+
+// rotateWriter lumberjack.Logger与rotatelogs.RotateLogs的公共方法
+type rotateWriter interface {
+	io.WriteCloser
+	Rotate() error
+}
+
+// fileSink 日志文件句柄，写入、切割、关闭共用一把锁，避免切割过程中与写入并发
+type fileSink struct {
+	mu     sync.Mutex
+	key    string
+	w      rotateWriter
+	mill   *millConfig //切割后的压缩配置，nil表示不需要额外压缩
+	closed bool
+}
+
+func (s *fileSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, os.ErrClosed
+	}
+	return s.w.Write(p)
+}
+
+// Sync lumberjack与rotatelogs均直接写文件，无需刷盘
+func (s *fileSink) Sync() error {
+	return nil
+}
+
+// Rotate 立即切割当前文件
+func (s *fileSink) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	return s.w.Rotate()
+}
+
+// Close 关闭文件句柄，之后的写入返回os.ErrClosed
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.w.Close()
+}
+
+// sinkRegistry 管理logger打开的所有文件句柄，相同配置重复初始化时复用已有句柄
+type sinkRegistry struct {
+	mu       sync.Mutex
+	sinks    map[string]*fileSink
+	millMu   sync.Mutex
+	millStop chan struct{}
+	millNow  chan struct{}
+}
+
+func newSinkRegistry() *sinkRegistry {
+	return &sinkRegistry{sinks: make(map[string]*fileSink)}
+}
+
+// get key相同时返回已有句柄，否则调用open新建；mill非nil时由后台定期压缩切割后的旧文件
+func (r *sinkRegistry) get(key string, mill *millConfig, open func() (rotateWriter, error)) (*fileSink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sinks[key]; ok {
+		return s, nil
+	}
+	w, err := open()
+	if err != nil {
+		return nil, err
+	}
+	s := &fileSink{key: key, w: w, mill: mill}
+	r.sinks[key] = s
+	if mill != nil {
+		r.startMill()
+	}
+	return s, nil
+}
+
+// retain 关闭并移除keys以外的句柄，用于重新初始化后释放不再使用的文件
+func (r *sinkRegistry) retain(keys map[string]bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var firstErr error
+	for key, s := range r.sinks {
+		if keys[key] {
+			continue
+		}
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(r.sinks, key)
+	}
+	if len(r.sinks) == 0 {
+		r.stopMill()
+	}
+	return firstErr
+}
+
+// rotate 依次切割所有句柄，返回第一个错误
+func (r *sinkRegistry) rotate() error {
+	var firstErr error
+	for _, s := range r.list() {
+		if err := s.Rotate(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	r.triggerMill()
+	return firstErr
+}
+
+// close 关闭所有句柄
+func (r *sinkRegistry) close() error {
+	return r.retain(nil)
+}
+
+func (r *sinkRegistry) list() []*fileSink {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]*fileSink, 0, len(r.sinks))
+	for _, s := range r.sinks {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].key < list[j].key })
+	return list
+}
+
+// Rotate 立即切割所有日志文件
+func (lg *Logger) Rotate() error {
+	lg.RLock()
+	defer lg.RUnlock()
+	if lg.sinks == nil {
+		return nil
+	}
+	return lg.sinks.rotate()
+}
+
+// Close 刷新并关闭所有日志文件
+func (lg *Logger) Close() error {
+	lg.Lock()
+	defer lg.Unlock()
+	if lg.SugaredLogger != nil {
+		_ = lg.SugaredLogger.Sync()
+	}
+	if lg.sentry != nil {
+		lg.sentry.Flush(lg.Opts.Sentry.flushTimeout())
+	}
+	if lg.sinks == nil {
+		return nil
+	}
+	return lg.sinks.close()
+}
+
+// RotateOnSignal 收到信号时切割日志文件，默认监听SIGHUP，返回的函数用于停止监听
+func (lg *Logger) RotateOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				if err := lg.Rotate(); err != nil {
+					lg.Errorf("[zaplog] rotate on %v failed: %v", sig, err)
+				} else {
+					lg.Infof("[zaplog] log files rotated on %v", sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
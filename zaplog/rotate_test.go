@@ -0,0 +1,76 @@
+package zaplog
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func newTestLogger(t *testing.T, opts *Options) *Logger {
+	t.Helper()
+	if opts.LogFileDir == "" {
+		opts.LogFileDir = t.TempDir()
+	}
+	//不经过Validate，以便测试无效配置的降级处理
+	lg := &Logger{Opts: opts}
+	lg.loadCfg()
+	if err := lg.apply(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = lg.Close() })
+	return lg
+}
+
+// TestRotateConcurrent 写入与切割并发执行，配合 go test -race 使用
+func TestRotateConcurrent(t *testing.T) {
+	//关闭压缩，避免lumberjack后台gzip与TempDir清理并发
+	no := false
+	lg := newTestLogger(t, &Options{LogLevel: "debug", AppName: "rotate", MaxSize: 1, Compress: &no})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				lg.Infow("concurrent write", "worker", n, "seq", j)
+				lg.Errorf("worker %d seq %d", n, j)
+			}
+		}(i)
+	}
+	for i := 0; i < 20; i++ {
+		if err := lg.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	matches, _ := filepath.Glob(filepath.Join(lg.Opts.LogFileDir, "rotate-info*"))
+	if len(matches) < 2 {
+		t.Errorf("expected rotated backups, got %v", matches)
+	}
+}
+
+func TestSinkReuse(t *testing.T) {
+	lg := newTestLogger(t, &Options{AppName: "reuse"})
+	before := lg.sinks.list()
+	if err := lg.setSyncers(); err != nil {
+		t.Fatal(err)
+	}
+	after := lg.sinks.list()
+	if len(before) != 4 || len(after) != 4 {
+		t.Fatalf("expected 4 sinks, got %d/%d", len(before), len(after))
+	}
+	for i := range before {
+		if before[i] != after[i] {
+			t.Errorf("sink %s was reopened", before[i].key)
+		}
+	}
+
+	if err := lg.Reconfigure(&Options{LogFileDir: lg.Opts.LogFileDir, AppName: "renamed"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := before[0].Write([]byte("x")); err != os.ErrClosed {
+		t.Errorf("old sink not closed, err=%v", err)
+	}
+}
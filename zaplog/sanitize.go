@@ -0,0 +1,115 @@
+package zaplog
+
+import (
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"unicode/utf8"
+)
+
+const truncatedSuffix = "...(truncated)"
+
+// Sanitize 清理不可信的日志内容：非法UTF-8替换为U+FFFD；escapeControl为true时将控制字符转义为\n、\x1b等可见形式；
+// maxBytes大于0时按字节截断(不截断多字节字符)并追加"...(truncated)"
+func Sanitize(s string, maxBytes int, escapeControl bool) string {
+	if !needSanitize(s, maxBytes, escapeControl) {
+		return s
+	}
+	s = strings.ToValidUTF8(s, string(utf8.RuneError))
+	if escapeControl {
+		var b strings.Builder
+		b.Grow(len(s))
+		for _, r := range s {
+			switch {
+			case r == '\n':
+				b.WriteString(`\n`)
+			case r == '\r':
+				b.WriteString(`\r`)
+			case isControl(r):
+				fmt.Fprintf(&b, `\x%02x`, r)
+			default:
+				b.WriteRune(r)
+			}
+		}
+		s = b.String()
+	}
+	if maxBytes > 0 && len(s) > maxBytes {
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = s[:cut] + truncatedSuffix
+	}
+	return s
+}
+
+// needSanitize 快速判断是否需要处理，大部分日志无需分配内存
+func needSanitize(s string, maxBytes int, escapeControl bool) bool {
+	if maxBytes > 0 && len(s) > maxBytes {
+		return true
+	}
+	if !escapeControl {
+		return !utf8.ValidString(s)
+	}
+	for i, r := range s {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				return true
+			}
+		}
+		if isControl(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// isControl 需要转义的控制字符，保留\t
+func isControl(r rune) bool {
+	return r != '\t' && (r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0))
+}
+
+// sanitizeCore 写入前清理日志消息与字符串字段
+type sanitizeCore struct {
+	zapcore.Core
+	maxBytes      int
+	escapeControl bool //控制台输出的消息不经过JSON转义，需转义控制字符防止伪造日志行及终端控制序列
+}
+
+func (c sanitizeCore) With(fields []zapcore.Field) zapcore.Core {
+	return sanitizeCore{c.Core.With(c.fields(fields)), c.maxBytes, c.escapeControl}
+}
+
+func (c sanitizeCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c sanitizeCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = Sanitize(ent.Message, c.maxBytes, c.escapeControl)
+	return c.Core.Write(ent, c.fields(fields))
+}
+
+// fields 截断字符串字段，字段值按JSON编码输出，无需转义控制字符
+func (c sanitizeCore) fields(fields []zapcore.Field) []zapcore.Field {
+	if c.maxBytes <= 0 {
+		return fields
+	}
+	var out []zapcore.Field
+	for i, f := range fields {
+		if f.Type != zapcore.StringType || len(f.String) <= c.maxBytes {
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+		out[i].String = Sanitize(f.String, c.maxBytes, false)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
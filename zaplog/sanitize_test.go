@@ -0,0 +1,104 @@
+package zaplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitize(t *testing.T) {
+	cases := []struct {
+		in       string
+		max      int
+		escape   bool
+		expected string
+	}{
+		{"plain", 0, true, "plain"},
+		{"a\nb\x1b[31m\tc", 0, true, `a\nb\x1b[31m` + "\tc"},
+		{"a\nb", 0, false, "a\nb"},
+		{"bad\xffutf8", 0, false, "bad�utf8"},
+		{"中文字符", 4, false, "中" + truncatedSuffix},
+		{"abcdef", 3, false, "abc" + truncatedSuffix},
+	}
+	for _, c := range cases {
+		if got := Sanitize(c.in, c.max, c.escape); got != c.expected {
+			t.Errorf("Sanitize(%q, %d, %t) = %q, want %q", c.in, c.max, c.escape, got, c.expected)
+		}
+	}
+}
+
+func TestSanitizeCoreTruncatesFields(t *testing.T) {
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zapcore.DebugLevel)
+	l := zap.New(sanitizeCore{core, 8, false})
+	l.With(zap.String("w", strings.Repeat("w", 20))).Info(strings.Repeat("m", 20), zap.String("f", strings.Repeat("f", 20)))
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"msg": "mmmmmmmm", "w": "wwwwwwww", "f": "ffffffff"} {
+		if entry[key] != want+truncatedSuffix {
+			t.Errorf("%s = %v", key, entry[key])
+		}
+	}
+}
+
+func FuzzSanitize(f *testing.F) {
+	for _, s := range []string{"", "hello", "a\nb\r\x00\x1b[0m", "\xff\xfe", "中文\u0085", strings.Repeat("x", 300)} {
+		f.Add(s, 16, true)
+	}
+	f.Fuzz(func(t *testing.T, s string, max int, escape bool) {
+		got := Sanitize(s, max, escape)
+		if !utf8.ValidString(got) {
+			t.Fatalf("invalid utf8: %q", got)
+		}
+		if max > 0 && len(got) > max+len(truncatedSuffix) {
+			t.Fatalf("len %d exceeds %d", len(got), max)
+		}
+		if escape && strings.ContainsFunc(got, isControl) {
+			t.Fatalf("control character left in %q", got)
+		}
+	})
+}
+
+// FuzzJSONLine 任意消息和字段内容写入文件编码器后，每条日志都是独立可解析的一行JSON
+func FuzzJSONLine(f *testing.F) {
+	for _, s := range []string{"", "msg", "line1\nline2", "\x00\x1f  ", "\xff\xc3(", `"},{"inject":true`, strings.Repeat("长", 200)} {
+		f.Add(s, s)
+	}
+	lg := &Logger{Opts: &Options{}}
+	lg.loadCfg()
+	var buf bytes.Buffer
+	enc := zapcore.NewJSONEncoder(lg.zapConfig.EncoderConfig)
+	core := zapcore.NewCore(enc, zapcore.AddSync(&buf), zapcore.DebugLevel)
+	l := zap.New(sanitizeCore{core, 256, false})
+	f.Fuzz(func(t *testing.T, msg, val string) {
+		buf.Reset()
+		l.Info(msg,
+			zap.String("s", val),
+			zap.ByteString("b", []byte(val)),
+			zap.Strings("arr", []string{val, msg}),
+			zap.Any("m", map[string]string{val: msg}),
+			zap.Error(errors.New(val)),
+		)
+		l.Warn(val)
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+		}
+		for _, line := range lines {
+			var entry map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("invalid json line %q: %v", line, err)
+			}
+			if m, _ := entry["msg"].(string); len(m) > 256+len(truncatedSuffix) {
+				t.Fatalf("msg not truncated: %d bytes", len(m))
+			}
+		}
+	})
+}
@@ -0,0 +1,227 @@
+package zaplog
+
+import (
+	"errors"
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"github.com/liuxy92/golib/clockx"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSentryRateLimit    = 10 //每秒最多上报的事件数
+	defaultSentryFlushTimeout = 2 * time.Second
+)
+
+// SentryOptions Sentry上报配置，error及以上级别的日志异步上报，字段转为tags
+type SentryOptions struct {
+	DSN          string
+	Environment  string
+	Release      string
+	SampleRate   float64          //采样率，0时为1即全部上报
+	RateLimit    int              //每秒最多上报的事件数，默认10，小于0不限制
+	FlushTimeout time.Duration    //Sync及关闭时等待上报完成的时间，默认2s
+	Transport    sentry.Transport //自定义发送方式，默认使用SDK的异步HTTP发送
+}
+
+func (o *SentryOptions) flushTimeout() time.Duration {
+	if o.FlushTimeout > 0 {
+		return o.FlushTimeout
+	}
+	return defaultSentryFlushTimeout
+}
+
+// openSentry 按配置创建Sentry客户端，未配置时返回nil
+func (lg *Logger) openSentry() (*sentry.Client, error) {
+	o := lg.Opts.Sentry
+	if o == nil || o.DSN == "" {
+		return nil, nil
+	}
+	return sentry.NewClient(sentry.ClientOptions{
+		Dsn:         o.DSN,
+		Environment: o.Environment,
+		Release:     o.Release,
+		SampleRate:  o.SampleRate,
+		Transport:   o.Transport,
+	})
+}
+
+// rateLimiter 令牌桶，桶容量与每秒速率相同
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newRateLimiter(perSecond int) *rateLimiter {
+	if perSecond == 0 {
+		perSecond = defaultSentryRateLimit
+	}
+	if perSecond < 0 {
+		return nil
+	}
+	return &rateLimiter{rate: float64(perSecond), tokens: float64(perSecond), now: time.Now}
+}
+
+func (r *rateLimiter) allow() bool {
+	if r == nil {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if !r.last.IsZero() {
+		r.tokens += now.Sub(r.last).Seconds() * r.rate
+		if r.tokens > r.rate {
+			r.tokens = r.rate
+		}
+	}
+	r.last = now
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+// sentryCore 将error及以上级别的日志转为Sentry事件
+type sentryCore struct {
+	client  *sentry.Client
+	limiter *rateLimiter
+	timeout time.Duration
+	fields  []zapcore.Field
+}
+
+func newSentryCore(client *sentry.Client, o *SentryOptions, clock clockx.Clock) zapcore.Core {
+	limiter := newRateLimiter(o.RateLimit)
+	if limiter != nil {
+		limiter.now = clock.Now
+	}
+	return &sentryCore{
+		client:  client,
+		limiter: limiter,
+		timeout: o.flushTimeout(),
+	}
+}
+
+func (c *sentryCore) Enabled(l zapcore.Level) bool {
+	return l >= zapcore.ErrorLevel
+}
+
+func (c *sentryCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+	return &clone
+}
+
+func (c *sentryCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *sentryCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.limiter.allow() {
+		return nil
+	}
+	c.client.CaptureEvent(c.event(ent, fields), nil, nil)
+	//Fatal、Panic之后进程退出，等待事件发送完成
+	if ent.Level > zapcore.ErrorLevel {
+		c.client.Flush(c.timeout)
+	}
+	return nil
+}
+
+func (c *sentryCore) Sync() error {
+	if !c.client.Flush(c.timeout) {
+		return errors.New("zaplog: sentry flush timeout")
+	}
+	return nil
+}
+
+func (c *sentryCore) event(ent zapcore.Entry, fields []zapcore.Field) *sentry.Event {
+	event := sentry.NewEvent()
+	event.Level = sentryLevel(ent.Level)
+	event.Message = ent.Message
+	event.Logger = ent.LoggerName
+	event.Timestamp = ent.Time
+
+	var err error
+	enc := zapcore.NewMapObjectEncoder()
+	for _, fs := range [][]zapcore.Field{c.fields, fields} {
+		for _, f := range fs {
+			if f.Type == zapcore.ErrorType && err == nil {
+				if e, ok := f.Interface.(error); ok {
+					err = e
+					continue
+				}
+			}
+			if f.Key == fingerprintKey && f.Type == zapcore.StringType {
+				event.Fingerprint = []string{f.String}
+				continue
+			}
+			f.AddTo(enc)
+		}
+	}
+	for k, v := range enc.Fields {
+		switch v.(type) {
+		case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, time.Duration:
+			event.Tags[k] = fmt.Sprint(v)
+		default:
+			event.Extra[k] = v
+		}
+	}
+	if ent.Caller.Defined {
+		event.Extra["caller"] = ent.Caller.TrimmedPath()
+	}
+
+	if err != nil {
+		event.SetException(err, maxErrorChainDepth)
+		trimLoggerFrames(event.Exception[len(event.Exception)-1].Stacktrace)
+		return event
+	}
+	st := sentry.NewStacktrace()
+	trimLoggerFrames(st)
+	event.Threads = []sentry.Thread{{Stacktrace: st, Current: true}}
+	return event
+}
+
+// trimLoggerFrames 去掉调用栈末尾zap及本包的栈帧，使最后一帧为业务调用处
+func trimLoggerFrames(st *sentry.Stacktrace) {
+	if st == nil {
+		return
+	}
+	n := len(st.Frames)
+	for n > 0 {
+		m := st.Frames[n-1].Module
+		if !strings.HasPrefix(m, "go.uber.org/zap") && !strings.HasPrefix(m, "github.com/liuxy92/golib/zaplog") {
+			break
+		}
+		n--
+	}
+	if n > 0 {
+		st.Frames = st.Frames[:n]
+	}
+}
+
+func sentryLevel(l zapcore.Level) sentry.Level {
+	switch {
+	case l > zapcore.ErrorLevel:
+		return sentry.LevelFatal
+	case l == zapcore.ErrorLevel:
+		return sentry.LevelError
+	case l == zapcore.WarnLevel:
+		return sentry.LevelWarning
+	case l == zapcore.InfoLevel:
+		return sentry.LevelInfo
+	}
+	return sentry.LevelDebug
+}
@@ -0,0 +1,91 @@
+package zaplog
+
+import (
+	"context"
+	"github.com/getsentry/sentry-go"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *mockTransport) Flush(time.Duration) bool              { return true }
+func (t *mockTransport) FlushWithContext(context.Context) bool { return true }
+func (t *mockTransport) Configure(sentry.ClientOptions)        {}
+func (t *mockTransport) Close()                                {}
+func (t *mockTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func (t *mockTransport) all() []*sentry.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*sentry.Event(nil), t.events...)
+}
+
+func TestSentryCore(t *testing.T) {
+	tr := &mockTransport{}
+	no := false
+	lg := newTestLogger(t, &Options{
+		Compress:         &no,
+		ErrorFingerprint: true,
+		Sentry: &SentryOptions{
+			DSN:         "https://key@sentry.example.com/1",
+			Environment: "test",
+			Release:     "v1.0.0",
+			Transport:   tr,
+		},
+	})
+	lg.With("order_id", 42).Errorw("pay failed", "user", "alice", "err", errors.New("timeout"))
+	lg.Warn("not reported")
+
+	events := tr.all()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	e := events[0]
+	if e.Level != sentry.LevelError || e.Message != "pay failed" || e.Environment != "test" || e.Release != "v1.0.0" {
+		t.Errorf("unexpected event: level=%s msg=%q env=%q release=%q", e.Level, e.Message, e.Environment, e.Release)
+	}
+	if e.Tags["user"] != "alice" || e.Tags["order_id"] != "42" {
+		t.Errorf("tags = %v", e.Tags)
+	}
+	if len(e.Exception) == 0 || e.Exception[len(e.Exception)-1].Stacktrace == nil {
+		t.Fatalf("exception without stacktrace: %+v", e.Exception)
+	}
+	if len(e.Fingerprint) != 1 {
+		t.Errorf("fingerprint = %v", e.Fingerprint)
+	}
+}
+
+func TestSentryRateLimit(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := newRateLimiter(2)
+	r.now = func() time.Time { return now }
+	if !r.allow() || !r.allow() || r.allow() {
+		t.Fatal("burst should be limited to 2")
+	}
+	now = now.Add(500 * time.Millisecond)
+	if !r.allow() || r.allow() {
+		t.Fatal("should refill 1 token after 500ms")
+	}
+	if newRateLimiter(-1) != nil || !(*rateLimiter)(nil).allow() {
+		t.Fatal("negative rate should disable limiting")
+	}
+}
+
+func TestSentryInvalidDSN(t *testing.T) {
+	lg := newTestLogger(t, &Options{Sentry: &SentryOptions{DSN: "::bad"}})
+	if lg.sentry != nil || lg.sentryErr == nil {
+		t.Fatal("invalid DSN should disable sentry with error")
+	}
+	lg.Error("still logged", zap.String("k", "v"))
+}
@@ -0,0 +1,66 @@
+package zaplog
+
+import (
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sync/atomic"
+)
+
+const sequenceKey = "seq"
+
+// seqCore 为每条日志追加单调递增的seq字段，同一条日志写入多个级别文件时序号相同，
+// 离线合并error/warn/info/debug文件时可按seq去重并还原输出顺序
+type seqCore struct {
+	cores []zapcore.Core
+	seq   *atomic.Uint64
+}
+
+func newSeqCore(cores []zapcore.Core, seq *atomic.Uint64) zapcore.Core {
+	return seqCore{cores, seq}
+}
+
+func (c seqCore) Enabled(l zapcore.Level) bool {
+	for _, core := range c.cores {
+		if core.Enabled(l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c seqCore) With(fields []zapcore.Field) zapcore.Core {
+	cores := make([]zapcore.Core, len(c.cores))
+	for i, core := range c.cores {
+		cores[i] = core.With(fields)
+	}
+	return seqCore{cores, c.seq}
+}
+
+func (c seqCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c seqCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := make([]zapcore.Field, 0, len(fields)+1)
+	all = append(all, zap.Uint64(sequenceKey, c.seq.Add(1)))
+	all = append(all, fields...)
+	var err error
+	for _, core := range c.cores {
+		if core.Enabled(ent.Level) {
+			err = multierr.Append(err, core.Write(ent, all))
+		}
+	}
+	return err
+}
+
+func (c seqCore) Sync() error {
+	var err error
+	for _, core := range c.cores {
+		err = multierr.Append(err, core.Sync())
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package zaplog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSequence(t *testing.T) {
+	lg := newTestLogger(t, &Options{AppName: "seq", LogLevel: "debug", Sequence: true})
+	msgs := []string{"d1", "i1", "w1", "e1", "i2", "d2"}
+	for _, m := range msgs {
+		switch m[0] {
+		case 'd':
+			lg.Debug(m)
+		case 'i':
+			lg.Info(m)
+		case 'w':
+			lg.Warn(m)
+		case 'e':
+			lg.Error(m)
+		}
+	}
+
+	type entry struct {
+		Msg string `json:"msg"`
+		Seq uint64 `json:"seq"`
+	}
+	var merged []entry
+	for _, name := range []string{"error", "warn", "info", "debug"} {
+		data, err := os.ReadFile(filepath.Join(lg.Opts.LogFileDir, "seq-"+name+".log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var e entry
+			if err := json.Unmarshal([]byte(line), &e); err != nil {
+				t.Fatalf("invalid json %s: %v", line, err)
+			}
+			merged = append(merged, e)
+		}
+	}
+	//同一条日志写入多个级别文件，按seq去重后排序
+	seen := make(map[uint64]bool)
+	uniq := merged[:0]
+	for _, e := range merged {
+		if !seen[e.Seq] {
+			seen[e.Seq] = true
+			uniq = append(uniq, e)
+		}
+	}
+	merged = uniq
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Seq < merged[j].Seq })
+	if len(merged) != len(msgs) {
+		t.Fatalf("got %d entries, want %d", len(merged), len(msgs))
+	}
+	for i, e := range merged {
+		if e.Msg != msgs[i] || e.Seq != uint64(i+1) {
+			t.Errorf("entry %d = %+v, want msg %s seq %d", i, e, msgs[i], i+1)
+		}
+	}
+}
+
+func TestSequenceECS(t *testing.T) {
+	lg := newTestLogger(t, &Options{AppName: "seq", Schema: SchemaECS, Sequence: true})
+	lg.Error("boom")
+	data, _ := os.ReadFile(filepath.Join(lg.Opts.LogFileDir, "seq-error.log"))
+	var entry map[string]interface{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("invalid json %s: %v", data, err)
+	}
+	if entry["event.sequence"] != float64(1) {
+		t.Errorf("event.sequence = %v in %s", entry["event.sequence"], data)
+	}
+}
@@ -0,0 +1,99 @@
+package zaplog
+
+import (
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+	"sync/atomic"
+)
+
+// coreSet 一次初始化产生的全部core
+type coreSet struct {
+	cores []zapcore.Core
+}
+
+// derivedSet With派生的core，from用于判断是否已被Reconfigure替换
+type derivedSet struct {
+	from  *coreSet
+	cores []zapcore.Core
+}
+
+// swapCore 可原子替换内部core的zapcore.Core，Reconfigure时logger及其With派生的子logger无需重建
+type swapCore struct {
+	root    *atomic.Value //*coreSet
+	fields  []zapcore.Field
+	derived atomic.Value //*derivedSet
+}
+
+func newSwapCore(cores []zapcore.Core) *swapCore {
+	root := &atomic.Value{}
+	root.Store(&coreSet{cores: cores})
+	return &swapCore{root: root}
+}
+
+// swap 替换内部core，返回旧的core
+func (c *swapCore) swap(cores []zapcore.Core) []zapcore.Core {
+	old := c.root.Swap(&coreSet{cores: cores})
+	return old.(*coreSet).cores
+}
+
+// current 当前生效的core，带fields的子core在替换后首次使用时重新派生
+func (c *swapCore) current() []zapcore.Core {
+	set := c.root.Load().(*coreSet)
+	if len(c.fields) == 0 {
+		return set.cores
+	}
+	if d, ok := c.derived.Load().(*derivedSet); ok && d.from == set {
+		return d.cores
+	}
+	cores := make([]zapcore.Core, len(set.cores))
+	for i, core := range set.cores {
+		cores[i] = core.With(c.fields)
+	}
+	c.derived.Store(&derivedSet{from: set, cores: cores})
+	return cores
+}
+
+func (c *swapCore) Enabled(lvl zapcore.Level) bool {
+	for _, core := range c.root.Load().(*coreSet).cores {
+		if core.Enabled(lvl) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *swapCore) With(fields []zapcore.Field) zapcore.Core {
+	if len(fields) == 0 {
+		return c
+	}
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	return &swapCore{root: c.root, fields: all}
+}
+
+func (c *swapCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 写入级别匹配的core，各core的级别在这里判断而不是Check中
+func (c *swapCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var err error
+	for _, core := range c.current() {
+		if core.Enabled(ent.Level) {
+			err = multierr.Append(err, core.Write(ent, fields))
+		}
+	}
+	return err
+}
+
+func (c *swapCore) Sync() error {
+	var err error
+	for _, core := range c.root.Load().(*coreSet).cores {
+		err = multierr.Append(err, core.Sync())
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package zaplog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReconfigure(t *testing.T) {
+	lg := newTestLogger(t, &Options{LogLevel: "info", AppName: "before"})
+	child := lg.With("component", "child")
+	lg.Debug("dropped before reconfigure")
+
+	dir := t.TempDir()
+	if err := lg.Reconfigure(&Options{LogLevel: "debug", LogFileDir: dir, AppName: "after"}); err != nil {
+		t.Fatal(err)
+	}
+	child.Debug("child after reconfigure")
+	lg.Sync()
+
+	data, err := os.ReadFile(filepath.Join(dir, "after-debug.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "child after reconfigure") || !strings.Contains(string(data), `"component":"child"`) {
+		t.Errorf("child logger not switched to new core: %s", data)
+	}
+}
+
+func TestReconfigureFailureKeepsOld(t *testing.T) {
+	lg := newTestLogger(t, &Options{AppName: "keep"})
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldDir := lg.Opts.LogFileDir
+	if err := lg.Reconfigure(&Options{LogFileDir: filepath.Join(blocker, "logs")}); err == nil {
+		t.Fatal("expected error")
+	}
+	if lg.Opts.LogFileDir != oldDir {
+		t.Errorf("options not restored: %s", lg.Opts.LogFileDir)
+	}
+	lg.Info("still writable")
+	data, _ := os.ReadFile(filepath.Join(oldDir, "keep-info.log"))
+	if !strings.Contains(string(data), "still writable") {
+		t.Errorf("old sinks closed after failed reconfigure: %s", data)
+	}
+}
@@ -0,0 +1,29 @@
+package zaplog
+
+import (
+	"context"
+	"go.uber.org/zap/zapcore"
+	"time"
+)
+
+// TimeOp 记录操作耗时，超过threshold时输出warn，否则输出debug，用法：
+//
+//	defer zaplog.TimeOp(ctx, "query order", 200*time.Millisecond)()
+func TimeOp(ctx context.Context, name string, threshold time.Duration) func() {
+	return FromContext(ctx).TimeOp(name, threshold)
+}
+
+// TimeOp 同包级TimeOp，使用当前logger输出
+func (lg *Logger) TimeOp(name string, threshold time.Duration) func() {
+	clock := lg.clock()
+	start := clock.Now()
+	return func() {
+		elapsed := clock.Since(start)
+		//直接调用logw，base跳过的两层为logw及本闭包，caller为调用返回函数的位置；未初始化时不输出
+		if elapsed > threshold {
+			lg.logw(zapcore.WarnLevel, "slow operation", []interface{}{"op", name, "duration", elapsed, "threshold", threshold})
+			return
+		}
+		lg.logw(zapcore.DebugLevel, "operation finished", []interface{}{"op", name, "duration", elapsed})
+	}
+}
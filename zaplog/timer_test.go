@@ -0,0 +1,53 @@
+package zaplog
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeOp(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := zap.New(core, zap.AddCaller())
+	lg := &Logger{SugaredLogger: l.Sugar(), base: l.WithOptions(zap.AddCallerSkip(2)), Opts: &Options{}}
+	ctx := NewContext(context.Background(), lg)
+
+	func() {
+		defer TimeOp(ctx, "fast", time.Hour)()
+	}()
+	func() {
+		defer TimeOp(ctx, "slow", time.Millisecond)()
+		time.Sleep(3 * time.Millisecond)
+	}()
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("entries = %v", entries)
+	}
+	if entries[0].Level != zapcore.DebugLevel || entries[0].ContextMap()["op"] != "fast" {
+		t.Errorf("fast entry = %+v", entries[0])
+	}
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Caller.File, "zaplog/timer_test.go") {
+			t.Errorf("caller = %s", e.Caller)
+		}
+	}
+	if entries[1].Level != zapcore.WarnLevel || entries[1].Message != "slow operation" {
+		t.Errorf("slow entry = %+v", entries[1])
+	}
+	if FromContext(context.Background()) != GetLogger() {
+		t.Error("FromContext should fall back to global logger")
+	}
+}
+
+func TestTimeOpBeforeInit(t *testing.T) {
+	old := logger
+	logger = &Logger{Opts: &Options{}}
+	defer func() { logger = old }()
+
+	TimeOp(context.Background(), "uninitialized", 0)()
+}
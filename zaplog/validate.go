@@ -0,0 +1,47 @@
+package zaplog
+
+import (
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/multierr"
+)
+
+// Validate 检查配置，返回全部无效项。InitLogger及Reconfigure对无效项仅输出警告，New及InitLoggerE初始化前调用该方法
+func (o *Options) Validate() error {
+	var err error
+	if o.LogLevel != "" {
+		if _, e := parseLevel(o.LogLevel); e != nil {
+			err = multierr.Append(err, fmt.Errorf("zaplog: invalid LogLevel %q", o.LogLevel))
+		}
+	}
+	if o.CutType != 0 && o.CutType != 1 {
+		err = multierr.Append(err, fmt.Errorf("zaplog: invalid CutType %d, want 0 (size) or 1 (time)", o.CutType))
+	}
+	if o.MaxSize < 0 || o.MaxBackups < 0 || o.MaxAge < 0 {
+		err = multierr.Append(err, fmt.Errorf("zaplog: negative MaxSize/MaxBackups/MaxAge %d/%d/%d", o.MaxSize, o.MaxBackups, o.MaxAge))
+	}
+	if _, ok := lookupJSONCodec(o.JSONCodec); !ok {
+		err = multierr.Append(err, fmt.Errorf("zaplog: unknown JSONCodec %q", o.JSONCodec))
+	}
+	if o.Schema != SchemaDefault && o.Schema != SchemaECS {
+		err = multierr.Append(err, fmt.Errorf("zaplog: unknown Schema %q", o.Schema))
+	}
+	if l := o.DirLayout; l != "" && l != DirLayoutFlat && l != DirLayoutDaily {
+		err = multierr.Append(err, fmt.Errorf("zaplog: unknown DirLayout %q", l))
+	}
+	if a := o.CompressionAlgo; a != "" && a != CompressNone && compressExt[a] == "" {
+		err = multierr.Append(err, fmt.Errorf("zaplog: unknown CompressionAlgo %q", a))
+	}
+	if mp := o.MultiProcess; mp != "" && mp != MultiProcessPID && mp != MultiProcessFlock {
+		err = multierr.Append(err, fmt.Errorf("zaplog: unknown MultiProcess %q", mp))
+	}
+	for _, w := range o.sinkLevelWarnings() {
+		err = multierr.Append(err, fmt.Errorf("zaplog: SinkLevels: %s", w))
+	}
+	if o.Sentry != nil && o.Sentry.DSN != "" {
+		if _, e := sentry.NewDsn(o.Sentry.DSN); e != nil {
+			err = multierr.Append(err, fmt.Errorf("zaplog: invalid Sentry DSN: %w", e))
+		}
+	}
+	return err
+}
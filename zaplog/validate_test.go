@@ -0,0 +1,64 @@
+package zaplog
+
+import (
+	"go.uber.org/multierr"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	if err := (&Options{LogLevel: "WARN", CutType: 1, Schema: SchemaECS}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+	bad := &Options{
+		LogLevel:   "verbose",
+		CutType:    2,
+		MaxAge:     -1,
+		JSONCodec:  "nope",
+		Schema:     "otel",
+		SinkLevels: map[string]string{"file": "loud"},
+		Sentry:     &SentryOptions{DSN: "not a dsn"},
+	}
+	err := bad.Validate()
+	if n := len(multierr.Errors(err)); n != 7 {
+		t.Fatalf("got %d errors: %v", n, err)
+	}
+	if _, err := New(bad); err == nil {
+		t.Error("New should reject invalid options")
+	}
+}
+
+func TestInitLoggerE(t *testing.T) {
+	old := logger
+	logger = &Logger{Opts: &Options{}}
+	defer func() {
+		_ = logger.Close()
+		logger = old
+	}()
+
+	if err := InitLoggerE(&Options{LogLevel: "verbose"}); err == nil {
+		t.Fatal("expected validation error")
+	}
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := InitLoggerE(&Options{LogFileDir: filepath.Join(blocker, "logs")}); err == nil {
+		t.Fatal("expected log dir error")
+	}
+	if logger.inited {
+		t.Fatal("logger should stay uninitialized after errors")
+	}
+
+	dir := t.TempDir()
+	if err := InitLoggerE(&Options{LogFileDir: dir, AppName: "inite"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "inite-info.log")); err != nil {
+		t.Error(err)
+	}
+	if err := InitLoggerE(&Options{LogLevel: "verbose"}); err != nil {
+		t.Errorf("already initialized: %v", err)
+	}
+}